@@ -112,6 +112,20 @@ type SessionAgentCall struct {
 	// (in-process / local callers like AppWorkspace), behavior is
 	// unchanged and no accept tracking applies.
 	Accepted *AcceptedRun
+	// Command is the ID of the custom command the turn runs, if any.
+	Command string
+	// Model, when non-nil, runs the turn with this model instead of the
+	// agent's large model, as custom commands may ask.
+	Model *Model
+	// Tools, when non-nil, replaces the agent's tools for the turn, as
+	// when a custom command runs with the tools of another agent.
+	Tools []fantasy.AgentTool
+	// DeferredTools are the deferred tools among Tools, which replace
+	// those of the agent along with them.
+	DeferredTools *tools.DeferredTools
+	// AllowedTools are the tools the turn may use without asking for
+	// permission, granted by the custom command it runs.
+	AllowedTools []string
 	// acceptSeq carries the accept sequence of the handle that produced
 	// this call after it has been enqueued and its Accepted handle
 	// stripped. The queue-drain paths compare it against a session's
@@ -153,6 +167,9 @@ type sessionAgent struct {
 	systemPromptPrefix *csync.Value[string]
 	systemPrompt       *csync.Value[string]
 	tools              *csync.Slice[fantasy.AgentTool]
+	// deferredTools holds the tools only sent to the model once the
+	// session activated them with the tool_search tool.
+	deferredTools *tools.DeferredTools
 
 	isSubAgent           bool
 	sessions             session.Service
@@ -214,6 +231,7 @@ type SessionAgentOptions struct {
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	DeferredTools        *tools.DeferredTools
 	Notify               pubsub.Publisher[notify.Notification]
 	RunComplete          pubsub.Publisher[notify.RunComplete]
 }
@@ -231,6 +249,7 @@ func NewSessionAgent(
 		messages:             opts.Messages,
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                csync.NewSliceFrom(opts.Tools),
+		deferredTools:        opts.DeferredTools,
 		isYolo:               opts.IsYolo,
 		notify:               opts.Notify,
 		runComplete:          opts.RunComplete,
@@ -548,6 +567,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 	if err := ValidateCall(call); err != nil {
 		return nil, err
 	}
+	if len(call.AllowedTools) > 0 {
+		ctx = withCommandGrants(ctx, call.Command, call.AllowedTools)
+	}
 
 	// genCtx/cancel are the run context and its cancel func. For the
 	// accepted (fire-and-forget) dispatch path they are created under
@@ -637,8 +659,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 	}
 
 	// Copy mutable fields under lock to avoid races with SetTools/SetModels.
-	agentTools := a.tools.Copy()
+	agentTools := a.deferredTools.Filter(call.SessionID, a.tools.Copy())
+	if call.Tools != nil {
+		agentTools = call.DeferredTools.Filter(call.SessionID, call.Tools)
+	}
 	largeModel := a.largeModel.Get()
+	if call.Model != nil {
+		largeModel = *call.Model
+	}
 	systemPrompt := a.systemPrompt.Get()
 	promptPrefix := a.systemPromptPrefix.Get()
 	var instructions strings.Builder
@@ -800,8 +828,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 				prepared.Messages[i].ProviderOptions = nil
 			}
 
-			// Use latest tools (updated by SetTools when MCP tools change).
-			prepared.Tools = a.tools.Copy()
+			// Use latest tools (updated by SetTools when MCP tools change),
+			// including those activated by tool_search in the last step,
+			// unless the call brings its own, as a custom command's agent
+			// does.
+			prepared.Tools = a.deferredTools.Filter(call.SessionID, a.tools.Copy())
+			if call.Tools != nil {
+				prepared.Tools = call.DeferredTools.Filter(call.SessionID, call.Tools)
+			}
 
 			// Drain queued follow-up prompts for this step. Calls covered
 			// by a cancel recorded while they sat in the queue are dropped:
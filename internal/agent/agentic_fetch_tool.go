@@ -109,7 +109,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 
 			if params.URL != "" {
 				// URL mode: fetch the URL content first.
-				content, err := tools.FetchURLAndConvert(ctx, client, params.URL)
+				content, err := tools.FetchURLAndConvert(ctx, client, c.fetchCache, params.URL, params.NoCache)
 				if err != nil {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("Failed to fetch URL: %s", err)), nil
 				}
@@ -162,8 +162,14 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, errors.New("small model provider not configured")
 			}
 
-			webFetchTool := tools.NewWebFetchTool(tmpDir, client)
-			webSearchTool := tools.NewWebSearchTool(client)
+			// Bypassing the cache applies to everything the sub-agent
+			// fetches, too.
+			fetchCache := c.fetchCache
+			if params.NoCache {
+				fetchCache = nil
+			}
+			webFetchTool := tools.NewWebFetchTool(tmpDir, client, fetchCache)
+			webSearchTool := tools.NewWebSearchTool(client, c.cfg.Config().Tools.WebSearch, c.cfg.Resolver())
 			fetchTools := []fantasy.AgentTool{
 				webFetchTool,
 				webSearchTool,
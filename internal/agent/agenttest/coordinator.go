@@ -28,9 +28,9 @@ import (
 // so no request is ever issued. The coder agent's allowed-tools list is
 // cleared to keep tool construction cheap and free of sub-agent wiring.
 //
-// The optional coordinator dependencies (history, filetracker, LSP,
-// notify, runComplete, skills) are nil: run guards the publisher fields
-// and the cancel-on-entry path never touches the others.
+// The optional coordinator dependencies (history, filetracker, shell
+// state, LSP, notify, runComplete, skills) are nil: run guards the
+// publisher fields and the cancel-on-entry path never touches the others.
 func NewCoordinator(
 	ctx context.Context,
 	workingDir string,
@@ -76,5 +76,7 @@ func NewCoordinator(
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 	)
 }
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/commands"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// CommandRun describes the custom command a prompt was expanded from. It
+// travels from the workspace boundary into coordinator.Run on the
+// context, like the RunID, so that Run keeps its signature.
+type CommandRun struct {
+	// Name is the ID of the command, as in "project:review-staged".
+	Name string
+	// Model overrides the model of the turn: large, small, a model ID or
+	// a provider/model pair.
+	Model string
+	// AllowedTools are the tools the turn may use without asking for
+	// permission, in the allowed-tools syntax of skills.
+	AllowedTools []string
+	// Agent runs the turn with the tools and model of another agent.
+	Agent string
+}
+
+type commandRunContextKey struct{}
+
+// WithCommandRun returns ctx tagged with the custom command its prompt
+// was expanded from. The coordinator then expands the !`command` and
+// @path references of the prompt and applies the command's overrides.
+func WithCommandRun(ctx context.Context, run CommandRun) context.Context {
+	return context.WithValue(ctx, commandRunContextKey{}, run)
+}
+
+// CommandRunFromContext returns the command set by [WithCommandRun], and
+// whether one was set.
+func CommandRunFromContext(ctx context.Context) (CommandRun, bool) {
+	run, ok := ctx.Value(commandRunContextKey{}).(CommandRun)
+	return run, ok
+}
+
+// expandCommand expands the !`command` and @path references of the
+// prompt of a custom command. Shell commands run through a bash tool of
+// their own, so they follow its permission prompts, policy and sandbox.
+func (c *coordinator) expandCommand(ctx context.Context, sessionID string, command CommandRun, prompt string, attachments []message.Attachment) (string, []message.Attachment, error) {
+	if !commands.HasExpansions(prompt) {
+		return prompt, attachments, nil
+	}
+
+	cfg := c.cfg.Config()
+	bash := tools.NewBashTool(c.permissions, c.shellState, nil, c.cfg.WorkingDir(), cfg.Options.Attribution, "", cfg.Tools.Bash)
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+	runs := 0
+	expanded, included, err := commands.Expand(ctx, prompt, commands.ExpandOptions{
+		WorkingDir: c.cfg.WorkingDir(),
+		// Files outside the working directory need the same permission
+		// as reading them with the view tool.
+		Allow: func(ctx context.Context, path string) (bool, error) {
+			return c.permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        path,
+				ToolCallID:  fmt.Sprintf("command-%s-include", sessionID),
+				ToolName:    tools.ViewToolName,
+				Action:      "read",
+				Description: fmt.Sprintf("Read file outside working directory: %s", path),
+				Params:      tools.ViewPermissionsParams{FilePath: path},
+			})
+		},
+		Run: func(ctx context.Context, cmd string) (string, error) {
+			runs++
+			input, err := json.Marshal(tools.BashParams{
+				Description: "Expand /" + command.Name,
+				Command:     cmd,
+			})
+			if err != nil {
+				return "", err
+			}
+			resp, err := bash.Run(ctx, fantasy.ToolCall{
+				ID:    fmt.Sprintf("command-%s-%d", sessionID, runs),
+				Name:  tools.BashToolName,
+				Input: string(input),
+			})
+			if err != nil {
+				return "", err
+			}
+			if resp.IsError {
+				return "", errors.New(resp.Content)
+			}
+			var meta tools.BashResponseMetadata
+			if err := json.Unmarshal([]byte(resp.Metadata), &meta); err != nil {
+				return "", fmt.Errorf("reading command output: %w", err)
+			}
+			if meta.Background {
+				return "", fmt.Errorf("command did not finish within %d seconds", tools.DefaultAutoBackgroundAfter)
+			}
+			return meta.Output, nil
+		},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("expanding command %s: %w", command.Name, err)
+	}
+	return expanded, append(attachments, included...), nil
+}
+
+// commandOverrides returns the model and the tools a custom command runs
+// its turn with, or nil for those of the coder agent. The tools come with
+// the deferred tools of their own, so that the turn neither changes nor
+// sees the tools the coder agent's sessions activated.
+func (c *coordinator) commandOverrides(ctx context.Context, command CommandRun) (*Model, []fantasy.AgentTool, *tools.DeferredTools, error) {
+	modelSpec := command.Model
+	var (
+		agentTools []fantasy.AgentTool
+		deferred   *tools.DeferredTools
+	)
+	if command.Agent != "" && command.Agent != config.AgentCoder {
+		agentCfg, ok := c.cfg.Config().Agents[command.Agent]
+		if !ok || agentCfg.Disabled {
+			return nil, nil, nil, fmt.Errorf("command %s: unknown agent %q", command.Name, command.Agent)
+		}
+		deferred = tools.NewDeferredTools()
+		var err error
+		if agentTools, err = c.buildTools(ctx, agentCfg, false, deferred); err != nil {
+			return nil, nil, nil, err
+		}
+		modelSpec = cmp.Or(modelSpec, string(agentCfg.Model))
+	}
+	if modelSpec == "" {
+		return nil, agentTools, deferred, nil
+	}
+	model, err := c.commandModel(ctx, modelSpec)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("command %s: %w", command.Name, err)
+	}
+	return &model, agentTools, deferred, nil
+}
+
+// commandModel builds the model a custom command asks for: the large or
+// small model, a model ID of a configured provider, or a provider/model
+// pair.
+func (c *coordinator) commandModel(ctx context.Context, spec string) (Model, error) {
+	switch config.SelectedModelType(spec) {
+	case config.SelectedModelTypeLarge, config.SelectedModelTypeSmall:
+		large, small, err := c.buildAgentModels(ctx, false)
+		if config.SelectedModelType(spec) == config.SelectedModelTypeSmall {
+			return small, err
+		}
+		return large, err
+	}
+
+	var (
+		selected    config.SelectedModel
+		providerCfg config.ProviderConfig
+		catwalkCfg  *catwalk.Model
+	)
+	for id, p := range c.cfg.Config().Providers.Seq2() {
+		if p.Disable {
+			continue
+		}
+		modelID := spec
+		if rest, ok := strings.CutPrefix(spec, id+"/"); ok {
+			modelID = rest
+		}
+		for _, m := range p.Models {
+			if m.ID == modelID && (catwalkCfg == nil || modelID != spec) {
+				selected = config.SelectedModel{Provider: id, Model: m.ID}
+				providerCfg = p
+				catwalkCfg = &m
+			}
+		}
+	}
+	if catwalkCfg == nil {
+		return Model{}, fmt.Errorf("model %q not found in the configured providers", spec)
+	}
+
+	provider, err := c.buildProvider(providerCfg, selected, false)
+	if err != nil {
+		return Model{}, err
+	}
+	languageModel, err := provider.LanguageModel(ctx, selected.Model)
+	if err != nil {
+		return Model{}, err
+	}
+	return Model{
+		Model:      languageModel,
+		CatwalkCfg: *catwalkCfg,
+		ModelCfg:   selected,
+		FlatRate:   providerCfg.FlatRate,
+	}, nil
+}
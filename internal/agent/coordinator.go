@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/fantasy"
@@ -22,19 +23,23 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/discover"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/charmbracelet/crush/internal/filetracker"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/httpcache"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
+	"github.com/charmbracelet/crush/internal/outputstore"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/charmbracelet/crush/internal/skills"
 	"golang.org/x/sync/errgroup"
 
@@ -110,6 +115,8 @@ type coordinator struct {
 	permissions permission.Service
 	history     history.Service
 	filetracker filetracker.Service
+	shellState  shellstate.Service
+	outputs     *outputstore.Store
 	lspManager  *lsp.Manager
 	notify      pubsub.Publisher[notify.Notification]
 	runComplete pubsub.Publisher[notify.RunComplete]
@@ -117,11 +124,25 @@ type coordinator struct {
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
 
-	// Skills discovery results (session-start snapshot).
+	// Skills discovery results, synced from skillsMgr before each run.
+	skillsMgr    *skills.Manager
+	skillsMu     sync.RWMutex
 	allSkills    []*skills.Skill // Pre-filter: all discovered after dedup.
 	activeSkills []*skills.Skill // Post-filter: active skills only.
 	skillTracker *skills.Tracker
 
+	// deferredTools holds the tools of the coder agent that are only sent
+	// to the model once activated with the tool_search tool.
+	deferredTools *tools.DeferredTools
+
+	// prompt builds the system prompt of the coder agent, rebuilt when
+	// the skills it lists change.
+	prompt *prompt.Prompt
+
+	// fetchCache is shared by the fetch tools; nil when caching is
+	// disabled.
+	fetchCache *httpcache.Cache
+
 	readyWg errgroup.Group
 }
 
@@ -133,6 +154,8 @@ func NewCoordinator(
 	permissions permission.Service,
 	history history.Service,
 	filetracker filetracker.Service,
+	shellState shellstate.Service,
+	outputs *outputstore.Store,
 	lspManager *lsp.Manager,
 	notify pubsub.Publisher[notify.Notification],
 	runComplete pubsub.Publisher[notify.RunComplete],
@@ -151,6 +174,11 @@ func NewCoordinator(
 	}
 	skillTracker := skills.NewTracker(activeSkills)
 
+	var fetchCache *httpcache.Cache
+	if fetchCfg := cfg.Config().Tools.Fetch; !fetchCfg.DisableCache {
+		fetchCache = httpcache.New(config.GlobalFetchCacheDir(), fetchCfg.GetMaxCacheSize())
+	}
+
 	c := &coordinator{
 		cfg:          cfg,
 		sessions:     sessions,
@@ -158,13 +186,19 @@ func NewCoordinator(
 		permissions:  permissions,
 		history:      history,
 		filetracker:  filetracker,
+		shellState:   shellState,
+		outputs:      outputs,
 		lspManager:   lspManager,
 		notify:       notify,
 		runComplete:  runComplete,
 		agents:       make(map[string]SessionAgent),
+		skillsMgr:    skillsMgr,
 		allSkills:    allSkills,
 		activeSkills: activeSkills,
 		skillTracker: skillTracker,
+		fetchCache:   fetchCache,
+
+		deferredTools: tools.NewDeferredTools(),
 	}
 
 	agentCfg, ok := cfg.Config().Agents[config.AgentCoder]
@@ -178,12 +212,16 @@ func NewCoordinator(
 		return nil, err
 	}
 
+	c.prompt = prompt
+
 	agent, err := c.buildAgent(ctx, prompt, agentCfg, false)
 	if err != nil {
 		return nil, err
 	}
 	c.currentAgent = agent
 	c.agents[config.AgentCoder] = agent
+	// MCP servers sample with the models of the coordinator.
+	mcp.SetSampler(c.sample)
 	return c, nil
 }
 
@@ -212,7 +250,28 @@ func (c *coordinator) run(ctx context.Context, accept *AcceptedRun, sessionID st
 		return nil, fmt.Errorf("failed to update models: %w", err)
 	}
 
+	command, isCommand := CommandRunFromContext(ctx)
+	var (
+		commandModel    *Model
+		commandTools    []fantasy.AgentTool
+		commandDeferred *tools.DeferredTools
+	)
+	if isCommand {
+		var err error
+		if prompt, attachments, err = c.expandCommand(ctx, sessionID, command, prompt, attachments); err != nil {
+			return nil, err
+		}
+		if commandModel, commandTools, commandDeferred, err = c.commandOverrides(ctx, command); err != nil {
+			return nil, err
+		}
+	}
+
+	attachments = c.mcpResourceAttachments(ctx, sessionID, attachments)
+
 	model := c.currentAgent.Model()
+	if commandModel != nil {
+		model = *commandModel
+	}
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
 	if model.ModelCfg.MaxTokens != 0 {
 		maxTokens = model.ModelCfg.MaxTokens
@@ -281,6 +340,11 @@ func (c *coordinator) run(ctx context.Context, accept *AcceptedRun, sessionID st
 			PresencePenalty:  presPenalty,
 			OnComplete:       onComplete,
 			Accepted:         accept,
+			Command:          command.Name,
+			Model:            commandModel,
+			Tools:            commandTools,
+			DeferredTools:    commandDeferred,
+			AllowedTools:     command.AllowedTools,
 		})
 	}
 	beforeLoaded := c.skillTracker.LoadedNames()
@@ -290,7 +354,8 @@ func (c *coordinator) run(ctx context.Context, accept *AcceptedRun, sessionID st
 		result, err = run()
 		return err
 	})
-	logTurnSkillUsage(sessionID, prompt, c.activeSkills, c.skillTracker, beforeLoaded)
+	_, activeSkills := c.skills()
+	logTurnSkillUsage(sessionID, prompt, activeSkills, c.skillTracker, beforeLoaded)
 
 	// Notify only if still unauthorized after retry — a successful
 	// retry means the user doesn't need to re-authenticate.
@@ -543,6 +608,10 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	}
 
 	largeProviderCfg, _ := c.cfg.Config().Providers.Get(large.ModelCfg.Provider)
+	var deferredTools *tools.DeferredTools
+	if !isSubAgent {
+		deferredTools = c.deferredTools
+	}
 	result := NewSessionAgent(SessionAgentOptions{
 		LargeModel:           large,
 		SmallModel:           small,
@@ -554,6 +623,7 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		Sessions:             c.sessions,
 		Messages:             c.messages,
 		Tools:                nil,
+		DeferredTools:        deferredTools,
 		Notify:               c.notify,
 		RunComplete:          c.runComplete,
 	})
@@ -568,7 +638,7 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	})
 
 	c.readyWg.Go(func() error {
-		tools, err := c.buildTools(ctx, agent, isSubAgent)
+		tools, err := c.buildTools(ctx, agent, isSubAgent, deferredTools)
 		if err != nil {
 			return err
 		}
@@ -579,7 +649,9 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	return result, nil
 }
 
-func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubAgent bool) ([]fantasy.AgentTool, error) {
+// buildTools returns the tools of agent. The tools the config defers are
+// recorded in deferred, if not nil; see [coordinator.deferTools].
+func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubAgent bool, deferred *tools.DeferredTools) ([]fantasy.AgentTool, error) {
 	var allTools []fantasy.AgentTool
 	if slices.Contains(agent.AllowedTools, AgentToolName) {
 		agentTool, err := c.agentTool(ctx)
@@ -606,6 +678,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 	}
 
 	logFile := filepath.Join(c.cfg.Config().Options.DataDirectory, "logs", "crush.log")
+	allSkills, activeSkills := c.skills()
 
 	// Build hook runner if PreToolUse hooks are configured.
 	var hookRunner *hooks.Runner
@@ -615,18 +688,25 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 
 	allTools = append(
 		allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Options.Attribution, modelID),
-		tools.NewCrushInfoTool(c.cfg, c.lspManager, c.allSkills, c.activeSkills, c.skillTracker),
+		tools.NewBashTool(c.permissions, c.shellState, c.outputs, c.cfg.WorkingDir(), c.cfg.Config().Options.Attribution, modelID, c.cfg.Config().Tools.Bash),
+		tools.NewCrushInfoTool(c.cfg, c.lspManager, allSkills, activeSkills, c.skillTracker),
 		tools.NewCrushLogsTool(logFile),
-		tools.NewJobOutputTool(),
+		tools.NewJobOutputTool(c.outputs),
+		tools.NewJobInputTool(c.permissions),
 		tools.NewJobKillTool(),
+		tools.NewReadOutputTool(c.outputs),
 		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
 		tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
 		tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
+		tools.NewApplyPatchTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewNotebookEditTool(c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil, c.fetchCache),
+		tools.NewGitTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Options.Attribution, modelID),
 		tools.NewGlobTool(c.cfg.WorkingDir()),
+		tools.NewHTTPRequestTool(c.permissions, c.cfg.Config().Tools.HTTPRequest, nil),
 		tools.NewGrepTool(c.cfg.WorkingDir(), c.cfg.Config().Tools.Grep),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Tools.Ls),
+		tools.NewRunTestsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Tools.RunTests, c.cfg.Config().Tools.Bash),
 		tools.NewSourcegraphTool(nil),
 		tools.NewTodosTool(c.sessions),
 		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.skillTracker, c.cfg.WorkingDir(), c.cfg.Config().Options.SkillsPaths...),
@@ -676,15 +756,19 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 			slog.Debug("MCP not allowed", "tool", tool.Name(), "agent", agent.Name)
 		}
 	}
+	filteredTools = c.deferTools(filteredTools, deferred)
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
 
-	// Wrap tools with hook interception for the top-level agent only.
+	// Apply the allowed-tools of loaded skills beneath the hooks, so that
+	// hooks see and may deny every call, and wrap tools with hook
+	// interception for the top-level agent only.
 	// Sub-agents (the `agent` task tool, `agentic_fetch`, etc.) run
 	// without hook interception to avoid firing the user's hook N times
 	// per delegated turn. The top-level invocation of the sub-agent tool
 	// itself is still wrapped from the coder's side.
+	filteredTools = wrapToolsWithSkills(filteredTools, c.skillTracker, c.cfg.WorkingDir(), isSubAgent)
 	filteredTools = wrapToolsWithHooks(filteredTools, hookRunner, isSubAgent)
 
 	return filteredTools, nil
@@ -1101,12 +1185,16 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 	}
 	c.currentAgent.SetModels(large, small)
 
+	if err := c.syncSkills(ctx, large); err != nil {
+		return err
+	}
+
 	agentCfg, ok := c.cfg.Config().Agents[config.AgentCoder]
 	if !ok {
 		return errCoderAgentNotConfigured
 	}
 
-	tools, err := c.buildTools(ctx, agentCfg, false)
+	tools, err := c.buildTools(ctx, agentCfg, false, c.deferredTools)
 	if err != nil {
 		return err
 	}
@@ -1114,6 +1202,40 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 	return nil
 }
 
+// skills returns the latest skills synced from the skills manager.
+func (c *coordinator) skills() (allSkills, activeSkills []*skills.Skill) {
+	c.skillsMu.RLock()
+	defer c.skillsMu.RUnlock()
+	return c.allSkills, c.activeSkills
+}
+
+// syncSkills picks up the skills installed, updated or removed since the
+// last run, rebuilding the system prompt that lists them.
+func (c *coordinator) syncSkills(ctx context.Context, large Model) error {
+	if c.skillsMgr == nil {
+		return nil
+	}
+	allSkills, activeSkills := c.skillsMgr.AllSkills(), c.skillsMgr.ActiveSkills()
+	c.skillsMu.Lock()
+	changed := !slices.Equal(allSkills, c.allSkills) || !slices.Equal(activeSkills, c.activeSkills)
+	if changed {
+		c.allSkills, c.activeSkills = allSkills, activeSkills
+		c.skillTracker.SetActive(activeSkills)
+	}
+	c.skillsMu.Unlock()
+	if !changed {
+		return nil
+	}
+
+	slog.Info("Skills changed, rebuilding system prompt", "component", "skills", "active", len(activeSkills))
+	systemPrompt, err := c.prompt.Build(ctx, large.Model.Provider(), large.Model.Model(), c.cfg)
+	if err != nil {
+		return err
+	}
+	c.currentAgent.SetSystemPrompt(systemPrompt)
+	return nil
+}
+
 func (c *coordinator) QueuedPrompts(sessionID string) int {
 	return c.currentAgent.QueuedPrompts(sessionID)
 }
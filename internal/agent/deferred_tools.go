@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// deferTools records the tools of allTools the config defers in deferred,
+// and adds the tool_search tool activating them when any is. Deferred
+// tools stay in the tools of the agent, which only sends them to the model
+// once a session activated them. Without deferred, as for sub-agents, all
+// tools are sent upfront.
+func (c *coordinator) deferTools(allTools []fantasy.AgentTool, deferred *tools.DeferredTools) []fantasy.AgentTool {
+	if deferred == nil {
+		return allTools
+	}
+
+	cfg := c.cfg.Config()
+	mcpTools := make(map[string]int)
+	for _, tool := range allTools {
+		if t, ok := tool.(*tools.Tool); ok {
+			mcpTools[t.MCP()]++
+		}
+	}
+	var deferrable []fantasy.AgentTool
+	for _, tool := range allTools {
+		if t, ok := tool.(*tools.Tool); ok {
+			if cfg.DefersMCPTools(t.MCP(), mcpTools[t.MCP()]) {
+				deferrable = append(deferrable, tool)
+			}
+		} else if cfg.DefersTool(tool.Info().Name) {
+			deferrable = append(deferrable, tool)
+		}
+	}
+
+	deferred.Set(deferrable)
+	if len(deferrable) == 0 {
+		return allTools
+	}
+	return append(allTools, tools.NewToolSearchTool(deferred))
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferTools(t *testing.T) {
+	t.Parallel()
+
+	newCoordinator := func(t *testing.T) *coordinator {
+		cfg, err := config.Init(t.TempDir(), "", false)
+		require.NoError(t, err)
+		cfg.Config().Options.ToolSearch = &config.ToolSearch{
+			Enabled:       true,
+			DeferredTools: []string{tools.SourcegraphToolName},
+		}
+		return &coordinator{cfg: cfg, deferredTools: tools.NewDeferredTools()}
+	}
+	allTools := func() []fantasy.AgentTool {
+		return []fantasy.AgentTool{
+			&fakeTool{name: tools.ViewToolName},
+			&fakeTool{name: tools.SourcegraphToolName},
+		}
+	}
+
+	t.Run("defers configured tools", func(t *testing.T) {
+		t.Parallel()
+		c := newCoordinator(t)
+		result := c.deferTools(allTools(), c.deferredTools)
+		require.Len(t, result, 3)
+		require.Equal(t, tools.ToolSearchToolName, result[2].Info().Name)
+		require.Equal(t, 1, c.deferredTools.Len())
+		require.Len(t, c.deferredTools.Filter("session", result), 2)
+	})
+
+	t.Run("sub-agents get all tools", func(t *testing.T) {
+		t.Parallel()
+		c := newCoordinator(t)
+		require.Len(t, c.deferTools(allTools(), nil), 2)
+		require.Zero(t, c.deferredTools.Len())
+	})
+
+	t.Run("separate sets", func(t *testing.T) {
+		t.Parallel()
+		c := newCoordinator(t)
+		c.deferTools(allTools(), c.deferredTools)
+		c.deferredTools.Activate("session", tools.SourcegraphToolName)
+
+		other := tools.NewDeferredTools()
+		result := c.deferTools(allTools(), other)
+		require.Equal(t, 1, other.Len())
+		require.Empty(t, other.Active("session"))
+		require.Len(t, other.Filter("session", result), 2)
+		require.Equal(t, []string{tools.SourcegraphToolName}, c.deferredTools.Active("session"))
+	})
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// mcpResourceAttachments subscribes to the updates of the MCP resources
+// attached to a run, and attaches again the resources of the session that
+// were updated since it last read them.
+func (c *coordinator) mcpResourceAttachments(ctx context.Context, sessionID string, attachments []message.Attachment) []message.Attachment {
+	updated := mcp.TakeUpdatedResources(sessionID)
+	for _, att := range attachments {
+		if att.MCPName == "" {
+			continue
+		}
+		if err := mcp.AttachResource(ctx, c.cfg, sessionID, att.MCPName, att.FilePath); err != nil {
+			slog.Warn("Failed to subscribe to MCP resource", "name", att.MCPName, "uri", att.FilePath, "error", err)
+		}
+	}
+	for _, r := range updated {
+		// Resources attached to this run are already up to date.
+		if slices.ContainsFunc(attachments, func(att message.Attachment) bool {
+			return att.MCPName == r.Name && att.FilePath == r.URI
+		}) {
+			continue
+		}
+		att, err := c.readMCPResource(ctx, r)
+		if err != nil {
+			slog.Warn("Failed to read updated MCP resource", "name", r.Name, "uri", r.URI, "error", err)
+			continue
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments
+}
+
+// readMCPResource reads the current contents of an attached MCP resource.
+func (c *coordinator) readMCPResource(ctx context.Context, r mcp.AttachedResource) (message.Attachment, error) {
+	contents, err := mcp.ReadResource(ctx, c.cfg, r.Name, r.URI)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	if len(contents) == 0 {
+		return message.Attachment{}, fmt.Errorf("resource %s has no contents", r.URI)
+	}
+	content := contents[0]
+	data := content.Blob
+	if content.Text != "" {
+		data = []byte(content.Text)
+	}
+	return message.Attachment{
+		FilePath: r.URI,
+		FileName: r.URI + " (updated)",
+		MimeType: cmp.Or(content.MIMEType, "text/plain"),
+		Content:  data,
+		MCPName:  r.Name,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+)
+
+// sample generates the completion requested by an MCP server through
+// sampling with the small or large model, capped at the default output
+// tokens of the model.
+func (c *coordinator) sample(ctx context.Context, req mcp.SamplingRequest) (mcp.SamplingResult, error) {
+	if len(req.Messages) == 0 {
+		return mcp.SamplingResult{}, errors.New("no messages to sample")
+	}
+
+	large, small, err := c.buildAgentModels(ctx, true)
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+	model := small
+	if req.Large {
+		model = large
+	}
+	providerCfg, ok := c.cfg.Config().Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return mcp.SamplingResult{}, errModelProviderNotConfigured
+	}
+	maxTokens := req.MaxTokens
+	if limit := model.CatwalkCfg.DefaultMaxTokens; limit > 0 {
+		maxTokens = min(maxTokens, limit)
+	}
+
+	// The system prompts are sent as messages so that the prefix some
+	// providers require comes first.
+	var history []fantasy.Message
+	if prefix := providerCfg.SystemPromptPrefix; prefix != "" {
+		history = append(history, fantasy.NewSystemMessage(prefix))
+	}
+	if req.SystemPrompt != "" {
+		history = append(history, fantasy.NewSystemMessage(req.SystemPrompt))
+	}
+	for _, m := range req.Messages[:len(req.Messages)-1] {
+		history = append(history, samplingMessage(m))
+	}
+	last := req.Messages[len(req.Messages)-1]
+	var files []fantasy.FilePart
+	if len(last.Data) > 0 {
+		files = append(files, fantasy.FilePart{Data: last.Data, MediaType: last.MediaType})
+	}
+
+	agent := fantasy.NewAgent(
+		model.Model,
+		fantasy.WithMaxOutputTokens(maxTokens),
+		fantasy.WithUserAgent(userAgent),
+	)
+	resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
+		Prompt:          last.Text,
+		Files:           files,
+		Messages:        history,
+		ProviderOptions: getProviderOptions(model, providerCfg),
+		Temperature:     req.Temperature,
+	})
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+	return mcp.SamplingResult{
+		Text:      resp.Response.Content.Text(),
+		Model:     model.ModelCfg.Model,
+		Truncated: resp.Response.FinishReason == fantasy.FinishReasonLength,
+	}, nil
+}
+
+func samplingMessage(m mcp.SamplingMessage) fantasy.Message {
+	role := fantasy.MessageRoleUser
+	if m.Role == "assistant" {
+		role = fantasy.MessageRoleAssistant
+	}
+	var parts []fantasy.MessagePart
+	if m.Text != "" {
+		parts = append(parts, fantasy.TextPart{Text: m.Text})
+	}
+	if len(m.Data) > 0 {
+		parts = append(parts, fantasy.FilePart{Data: m.Data, MediaType: m.MediaType})
+	}
+	return fantasy.Message{Role: role, Content: parts}
+}
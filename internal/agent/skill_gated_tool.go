@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/tidwall/sjson"
+)
+
+// skillGatedTool wraps a fantasy.AgentTool to apply the allowed-tools of
+// the skills loaded in the session: calls they grant skip the permission
+// prompt, and calls a strict skill does not list are refused. Reading or
+// editing a file also activates the skills whose paths match it, once per
+// session, by appending their instructions to the result.
+type skillGatedTool struct {
+	inner      fantasy.AgentTool
+	tracker    *skills.Tracker
+	workingDir string
+}
+
+// wrapToolsWithSkills returns a tool slice with each entry wrapped in a
+// skillGatedTool. Returns the original slice unchanged when tracker is nil
+// or when isSubAgent is true; sub-agents run in sessions of their own, in
+// which no skill is loaded.
+func wrapToolsWithSkills(tools []fantasy.AgentTool, tracker *skills.Tracker, workingDir string, isSubAgent bool) []fantasy.AgentTool {
+	if tracker == nil || isSubAgent {
+		return tools
+	}
+	out := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		out[i] = &skillGatedTool{inner: tool, tracker: tracker, workingDir: workingDir}
+	}
+	return out
+}
+
+type commandGrantsContextKey struct{}
+
+// withCommandGrants returns ctx granting the allowed tools of the custom
+// command a turn runs, which apply like those of a loaded skill.
+func withCommandGrants(ctx context.Context, command string, allowedTools []string) context.Context {
+	return context.WithValue(ctx, commandGrantsContextKey{}, &skills.Skill{
+		Name:         command,
+		AllowedTools: allowedTools,
+	})
+}
+
+func commandGrantsFromContext(ctx context.Context) *skills.Skill {
+	grants, _ := ctx.Value(commandGrantsContextKey{}).(*skills.Skill)
+	return grants
+}
+
+func (s *skillGatedTool) Info() fantasy.ToolInfo {
+	return s.inner.Info()
+}
+
+func (s *skillGatedTool) ProviderOptions() fantasy.ProviderOptions {
+	return s.inner.ProviderOptions()
+}
+
+func (s *skillGatedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	s.inner.SetProviderOptions(opts)
+}
+
+func (s *skillGatedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	loaded := s.tracker.SessionSkills(tools.GetSessionFromContext(ctx))
+	if grants := commandGrantsFromContext(ctx); grants != nil {
+		loaded = append(loaded, grants)
+	}
+	decision, skill := skills.CheckTool(loaded, skills.ToolCall{
+		Name:       call.Name,
+		Input:      call.Input,
+		WorkingDir: s.workingDir,
+	})
+	switch decision {
+	case skills.ToolRefused:
+		slog.Info("Tool call refused by strict skill", "component", "skills", "tool", call.Name, "skill", skill.Name)
+		return fantasy.NewTextErrorResponse(skill.RefusalMessage(call.Name)), nil
+	case skills.ToolGranted:
+		slog.Debug("Tool call pre-approved by skill", "component", "skills", "tool", call.Name, "skill", skill.Name)
+		ctx = permission.WithSkillApproval(ctx, call.ID)
+	}
+
+	resp, err := s.inner.Run(ctx, call)
+	if err != nil || resp.IsError || len(resp.Data) > 0 {
+		return resp, err
+	}
+	s.activateForPath(tools.GetSessionFromContext(ctx), call, &resp)
+	return resp, nil
+}
+
+// activateForPath activates the skills whose paths match the file read or
+// edited by the call, and injects their instructions into its result.
+func (s *skillGatedTool) activateForPath(sessionID string, call fantasy.ToolCall, resp *fantasy.ToolResponse) {
+	switch call.Name {
+	case tools.ViewToolName, tools.EditToolName, tools.MultiEditToolName, tools.WriteToolName, tools.NotebookEditToolName:
+	default:
+		return
+	}
+	var input struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &input); err != nil {
+		return
+	}
+	path := cmp.Or(input.FilePath, input.NotebookPath)
+	activated := s.tracker.ActivateForPath(sessionID, path, s.workingDir)
+	if len(activated) == 0 {
+		return
+	}
+
+	activations := make([]skills.Activation, 0, len(activated))
+	for _, skill := range activated {
+		slog.Info("Skill activated by path", "component", "skills", "skill", skill.Name, "path", path)
+		if resp.Content != "" {
+			resp.Content += "\n\n"
+		}
+		resp.Content += skill.FormatInvocation()
+		activations = append(activations, skills.NewActivation(skill, path))
+	}
+	resp.Metadata = mergeSkillActivations(resp.Metadata, activations)
+}
+
+// mergeSkillActivations injects the activated skills into existing tool
+// metadata.
+func mergeSkillActivations(existing string, activations []skills.Activation) string {
+	data, err := json.Marshal(activations)
+	if err != nil {
+		return existing
+	}
+	if existing == "" {
+		existing = "{}"
+	}
+	merged, err := sjson.SetRaw(existing, "activated_skills", string(data))
+	if err != nil {
+		return existing
+	}
+	return merged
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkillGatedTool(t *testing.T) {
+	t.Parallel()
+
+	release := &skills.Skill{Name: "release", Path: t.TempDir(), AllowedTools: skills.AllowedTools{"Bash(git tag:*)"}, Strict: true}
+	tracker := skills.NewTracker([]*skills.Skill{release})
+	tracker.MarkLoadedInSession("s1", "release")
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "s1")
+
+	t.Run("granted call skips the permission prompt", func(t *testing.T) {
+		t.Parallel()
+		inner := &fakeTool{name: "bash", resp: fantasy.NewTextResponse("ok")}
+		tool := wrapToolsWithSkills([]fantasy.AgentTool{inner}, tracker, t.TempDir(), false)[0]
+
+		_, err := tool.Run(ctx, fantasy.ToolCall{ID: "call-1", Name: "bash", Input: `{"command":"git tag v1.0.0"}`})
+		require.NoError(t, err)
+		require.True(t, inner.called)
+
+		svc := permission.NewPermissionService(t.TempDir(), false, nil)
+		granted, err := svc.Request(inner.gotCtx, permission.CreatePermissionRequest{
+			SessionID:  "s1",
+			ToolCallID: "call-1",
+			ToolName:   "bash",
+			Action:     "execute",
+			Path:       t.TempDir(),
+		})
+		require.NoError(t, err)
+		require.True(t, granted)
+	})
+
+	t.Run("strict skill refuses unlisted tools", func(t *testing.T) {
+		t.Parallel()
+		inner := &fakeTool{name: "edit", resp: fantasy.NewTextResponse("ok")}
+		tool := wrapToolsWithSkills([]fantasy.AgentTool{inner}, tracker, t.TempDir(), false)[0]
+
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call-2", Name: "edit", Input: `{"file_path":"main.go"}`})
+		require.NoError(t, err)
+		require.False(t, inner.called)
+		require.True(t, resp.IsError)
+		require.Contains(t, resp.Content, `strict skill "release"`)
+	})
+
+	t.Run("custom command grants apply to its turn", func(t *testing.T) {
+		t.Parallel()
+		inner := &fakeTool{name: "bash", resp: fantasy.NewTextResponse("ok")}
+		tool := wrapToolsWithSkills([]fantasy.AgentTool{inner}, tracker, t.TempDir(), false)[0]
+
+		cmdCtx := withCommandGrants(context.WithValue(t.Context(), tools.SessionIDContextKey, "s3"), "project:review", []string{"Bash(git diff:*)"})
+		_, err := tool.Run(cmdCtx, fantasy.ToolCall{ID: "call-4", Name: "bash", Input: `{"command":"git diff --staged"}`})
+		require.NoError(t, err)
+		require.True(t, inner.called)
+
+		svc := permission.NewPermissionService(t.TempDir(), false, nil)
+		granted, err := svc.Request(inner.gotCtx, permission.CreatePermissionRequest{
+			SessionID:  "s3",
+			ToolCallID: "call-4",
+			ToolName:   "bash",
+			Action:     "execute",
+			Path:       t.TempDir(),
+		})
+		require.NoError(t, err)
+		require.True(t, granted)
+	})
+
+	t.Run("other sessions are unaffected", func(t *testing.T) {
+		t.Parallel()
+		inner := &fakeTool{name: "edit", resp: fantasy.NewTextResponse("ok")}
+		tool := wrapToolsWithSkills([]fantasy.AgentTool{inner}, tracker, t.TempDir(), false)[0]
+
+		otherCtx := context.WithValue(t.Context(), tools.SessionIDContextKey, "s2")
+		_, err := tool.Run(otherCtx, fantasy.ToolCall{ID: "call-3", Name: "edit", Input: `{"file_path":"main.go"}`})
+		require.NoError(t, err)
+		require.True(t, inner.called)
+	})
+}
+
+func TestSkillGatedTool_ActivateForPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	protobuf := &skills.Skill{
+		Name:         "protobuf",
+		Description:  "Protobuf conventions",
+		Instructions: "Run buf lint after editing.",
+		Paths:        skills.PathGlobs{"**/*.proto"},
+		AllowedTools: skills.AllowedTools{"Bash(buf:*)"},
+	}
+	tracker := skills.NewTracker([]*skills.Skill{protobuf})
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "s1")
+
+	inner := &fakeTool{name: "edit", resp: fantasy.WithResponseMetadata(fantasy.NewTextResponse("edited"), map[string]string{"file_path": "x"})}
+	tool := wrapToolsWithSkills([]fantasy.AgentTool{inner}, tracker, dir, false)[0]
+
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call-1", Name: "edit", Input: `{"file_path":"api/user.proto"}`})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(resp.Content, "edited\n\n<loaded_skill>"))
+	require.Contains(t, resp.Content, "Run buf lint after editing.")
+	require.JSONEq(t, `{"file_path":"x","activated_skills":[{"name":"protobuf","path":"api/user.proto"}]}`, resp.Metadata)
+	require.Empty(t, tracker.SessionSkills("s1"))
+
+	resp, err = tool.Run(ctx, fantasy.ToolCall{ID: "call-2", Name: "edit", Input: `{"file_path":"api/order.proto"}`})
+	require.NoError(t, err)
+	require.Equal(t, "edited", resp.Content)
+
+	other := &fakeTool{name: "ls", resp: fantasy.NewTextResponse("api/")}
+	tool = wrapToolsWithSkills([]fantasy.AgentTool{other}, tracker, dir, false)[0]
+	otherCtx := context.WithValue(t.Context(), tools.SessionIDContextKey, "s2")
+	resp, err = tool.Run(otherCtx, fantasy.ToolCall{ID: "call-3", Name: "ls", Input: `{"path":"api/user.proto"}`})
+	require.NoError(t, err)
+	require.Equal(t, "api/", resp.Content)
+	require.Empty(t, tracker.SessionSkills("s2"))
+}
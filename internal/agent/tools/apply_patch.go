@@ -0,0 +1,419 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type ApplyPatchParams struct {
+	Patch string `json:"patch" description:"A unified or git-style diff; may touch several files and create, delete or rename them"`
+}
+
+type ApplyPatchPermissionsParams struct {
+	FilePath   string `json:"file_path"`
+	OldPath    string `json:"old_path,omitempty"`
+	Operation  string `json:"operation"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+}
+
+// ApplyPatchFile describes the change a patch made (or would make) to a
+// single file.
+type ApplyPatchFile struct {
+	FilePath   string            `json:"file_path"`
+	OldPath    string            `json:"old_path,omitempty"`
+	Operation  string            `json:"operation"`
+	OldContent string            `json:"old_content,omitempty"`
+	NewContent string            `json:"new_content,omitempty"`
+	Additions  int               `json:"additions"`
+	Removals   int               `json:"removals"`
+	Hunks      []diff.HunkResult `json:"hunks,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type ApplyPatchResponseMetadata struct {
+	Files     []ApplyPatchFile `json:"files"`
+	Additions int              `json:"additions"`
+	Removals  int              `json:"removals"`
+}
+
+const ApplyPatchToolName = "apply_patch"
+
+// Operations reported for each file touched by a patch.
+const (
+	PatchOperationCreate = "create"
+	PatchOperationModify = "modify"
+	PatchOperationDelete = "delete"
+	PatchOperationRename = "rename"
+)
+
+//go:embed apply_patch.md
+var applyPatchDescription string
+
+func NewApplyPatchTool(
+	lspManager *lsp.Manager,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ApplyPatchToolName,
+		applyPatchDescription,
+		func(ctx context.Context, params ApplyPatchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Patch) == "" {
+				return fantasy.NewTextErrorResponse("patch is required"), nil
+			}
+
+			patches, err := diff.ParsePatch(params.Patch)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid patch: %s", err)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for applying a patch")
+			}
+
+			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			response, changed, err := applyPatch(editCtx, sessionID, patches, call)
+			if err != nil || response.IsError {
+				return response, err
+			}
+
+			for _, path := range changed {
+				notifyLSPs(ctx, lspManager, path)
+			}
+
+			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+			if len(changed) > 0 {
+				text += getDiagnostics(changed[0], lspManager)
+			}
+			response.Content = text
+			return response, nil
+		},
+	)
+}
+
+// applyPatch computes the result of every file patch, asks for permission
+// for each file and only then writes the changes, so a failed hunk or a
+// denied file leaves the working tree untouched. It returns the paths that
+// were written.
+func applyPatch(edit editContext, sessionID string, patches []diff.FilePatch, call fantasy.ToolCall) (fantasy.ToolResponse, []string, error) {
+	var (
+		meta   ApplyPatchResponseMetadata
+		failed int
+	)
+	for _, fp := range patches {
+		file, err := preparePatchedFile(edit, sessionID, fp)
+		if err != nil {
+			return fantasy.ToolResponse{}, nil, err
+		}
+		if file.Error != "" {
+			failed++
+		}
+		meta.Files = append(meta.Files, file)
+		meta.Additions += file.Additions
+		meta.Removals += file.Removals
+	}
+
+	if failed > 0 {
+		return fantasy.WithResponseMetadata(
+			fantasy.NewTextErrorResponse(formatPatchFailures(meta.Files, failed)),
+			meta,
+		), nil, nil
+	}
+
+	for _, file := range meta.Files {
+		paths := []string{fsext.PathOrPrefix(file.FilePath, edit.workingDir)}
+		// A rename also removes its old path, which may lie elsewhere.
+		if file.Operation == PatchOperationRename {
+			if oldPath := fsext.PathOrPrefix(file.OldPath, edit.workingDir); oldPath != paths[0] {
+				paths = append(paths, oldPath)
+			}
+		}
+		for _, path := range paths {
+			p, err := edit.permissions.Request(edit.ctx, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        path,
+				ToolCallID:  call.ID,
+				ToolName:    ApplyPatchToolName,
+				Action:      "write",
+				Description: describePatchedFile(file),
+				Params: ApplyPatchPermissionsParams{
+					FilePath:   file.FilePath,
+					OldPath:    file.OldPath,
+					Operation:  file.Operation,
+					OldContent: file.OldContent,
+					NewContent: file.NewContent,
+				},
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, nil, err
+			}
+			if !p {
+				return fantasy.WithResponseMetadata(NewPermissionDeniedResponse(), meta), nil, nil
+			}
+		}
+	}
+
+	var changed []string
+	for _, file := range meta.Files {
+		if err := writePatchedFile(edit, sessionID, file); err != nil {
+			return fantasy.ToolResponse{}, nil, err
+		}
+		if file.Operation != PatchOperationDelete {
+			changed = append(changed, file.FilePath)
+		}
+	}
+
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(formatPatchSummary(meta.Files)),
+		meta,
+	), changed, nil
+}
+
+// preparePatchedFile validates a single file patch against the file system
+// and computes its new content. Problems the model can fix are reported via
+// ApplyPatchFile.Error; only unexpected I/O failures are returned as errors.
+func preparePatchedFile(edit editContext, sessionID string, fp diff.FilePatch) (ApplyPatchFile, error) {
+	file := ApplyPatchFile{FilePath: filepathext.SmartJoin(edit.workingDir, fp.Path())}
+	switch {
+	case fp.IsCreate():
+		file.Operation = PatchOperationCreate
+	case fp.IsDelete():
+		file.Operation = PatchOperationDelete
+	case fp.IsRename():
+		file.Operation = PatchOperationRename
+		file.OldPath = filepathext.SmartJoin(edit.workingDir, fp.OldPath)
+	default:
+		file.Operation = PatchOperationModify
+	}
+
+	if file.Operation == PatchOperationCreate {
+		if _, err := os.Stat(file.FilePath); err == nil {
+			file.Error = "file already exists"
+			return file, nil
+		} else if !os.IsNotExist(err) {
+			return file, fmt.Errorf("failed to access file: %w", err)
+		}
+	} else {
+		source := cmp.Or(file.OldPath, file.FilePath)
+		oldContent, errMsg, err := readFileForPatch(edit, sessionID, source)
+		if err != nil || errMsg != "" {
+			file.Error = errMsg
+			return file, err
+		}
+		file.OldContent = oldContent
+		if file.Operation == PatchOperationRename {
+			if _, err := os.Stat(file.FilePath); err == nil {
+				file.Error = "rename target already exists"
+				return file, nil
+			}
+		}
+	}
+
+	if file.Operation == PatchOperationDelete {
+		file.NewContent = ""
+	} else {
+		newContent, hunks, err := diff.Apply(file.OldContent, fp)
+		file.Hunks = hunks
+		if errors.Is(err, diff.ErrHunksFailed) {
+			file.Error = err.Error()
+			return file, nil
+		}
+		file.NewContent = newContent
+		if file.Operation == PatchOperationModify && file.NewContent == file.OldContent {
+			file.Error = "patch does not change the file"
+			return file, nil
+		}
+	}
+
+	_, file.Additions, file.Removals = diff.GenerateDiff(
+		file.OldContent,
+		file.NewContent,
+		strings.TrimPrefix(file.FilePath, edit.workingDir),
+	)
+	return file, nil
+}
+
+// readFileForPatch reads an existing file, enforcing the same read-before-edit
+// rules as the edit tools. The returned message describes problems the model
+// should fix.
+func readFileForPatch(edit editContext, sessionID, path string) (string, string, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "file not found", nil
+		}
+		return "", "", fmt.Errorf("failed to access file: %w", err)
+	}
+	if fileInfo.IsDir() {
+		return "", "path is a directory, not a file", nil
+	}
+
+	lastRead := edit.filetracker.LastReadTime(edit.ctx, sessionID, path)
+	if lastRead.IsZero() {
+		return "", "you must read the file before patching it. Use the View tool first", nil
+	}
+	modTime := fileInfo.ModTime().Truncate(time.Second)
+	if modTime.After(lastRead) {
+		return "", fmt.Sprintf(
+			"file has been modified since it was last read (mod time: %s, last read: %s)",
+			modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
+		), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file: %w", err)
+	}
+	oldContent, _ := fsext.ToUnixLineEndings(string(content))
+	return oldContent, "", nil
+}
+
+func writePatchedFile(edit editContext, sessionID string, file ApplyPatchFile) error {
+	source := cmp.Or(file.OldPath, file.FilePath)
+	isCrlf := false
+	if file.Operation != PatchOperationCreate {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		_, isCrlf = fsext.ToUnixLineEndings(string(content))
+	}
+
+	switch file.Operation {
+	case PatchOperationDelete:
+		if err := os.Remove(file.FilePath); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+	default:
+		if err := os.MkdirAll(filepath.Dir(file.FilePath), 0o755); err != nil {
+			return fmt.Errorf("failed to create parent directories: %w", err)
+		}
+		content := file.NewContent
+		if isCrlf {
+			content, _ = fsext.ToWindowsLineEndings(content)
+		}
+		if err := os.WriteFile(file.FilePath, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if file.Operation == PatchOperationRename {
+			if err := os.Remove(file.OldPath); err != nil {
+				return fmt.Errorf("failed to remove renamed file: %w", err)
+			}
+		}
+	}
+
+	if file.Operation == PatchOperationRename {
+		recordPatchHistory(edit, sessionID, file.OldPath, file.OldContent, "")
+		recordPatchHistory(edit, sessionID, file.FilePath, "", file.NewContent)
+	} else {
+		recordPatchHistory(edit, sessionID, file.FilePath, file.OldContent, file.NewContent)
+	}
+
+	if file.Operation != PatchOperationDelete {
+		edit.filetracker.RecordRead(edit.ctx, sessionID, file.FilePath)
+	}
+	return nil
+}
+
+// recordPatchHistory stores the old and new versions of a file the same way
+// the edit tools do.
+func recordPatchHistory(edit editContext, sessionID, path, oldContent, newContent string) {
+	file, err := edit.files.GetByPathAndSession(edit.ctx, path, sessionID)
+	if err != nil {
+		if _, err = edit.files.Create(edit.ctx, sessionID, path, oldContent); err != nil {
+			slog.Error("Error creating file history", "error", err)
+			return
+		}
+	} else if file.Content != oldContent {
+		// User manually changed the content; store an intermediate version
+		if _, err = edit.files.CreateVersion(edit.ctx, sessionID, path, oldContent); err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	if _, err = edit.files.CreateVersion(edit.ctx, sessionID, path, newContent); err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+}
+
+func describePatchedFile(file ApplyPatchFile) string {
+	switch file.Operation {
+	case PatchOperationCreate:
+		return fmt.Sprintf("Create file %s", file.FilePath)
+	case PatchOperationDelete:
+		return fmt.Sprintf("Delete file %s", file.FilePath)
+	case PatchOperationRename:
+		return fmt.Sprintf("Rename file %s to %s", file.OldPath, file.FilePath)
+	default:
+		return fmt.Sprintf("Apply %d hunk(s) to file %s", len(file.Hunks), file.FilePath)
+	}
+}
+
+func formatPatchSummary(files []ApplyPatchFile) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Patch applied to %d file(s):\n", len(files))
+	for _, file := range files {
+		switch file.Operation {
+		case PatchOperationCreate:
+			fmt.Fprintf(&sb, "A %s\n", file.FilePath)
+		case PatchOperationDelete:
+			fmt.Fprintf(&sb, "D %s\n", file.FilePath)
+		case PatchOperationRename:
+			fmt.Fprintf(&sb, "R %s -> %s\n", file.OldPath, file.FilePath)
+		default:
+			fmt.Fprintf(&sb, "M %s\n", file.FilePath)
+		}
+		for _, hunk := range file.Hunks {
+			if hunk.Fuzz == "" && hunk.Offset == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "  hunk %d applied at line %d", hunk.Index, hunk.Line)
+			if hunk.Offset != 0 {
+				fmt.Fprintf(&sb, " (offset %+d)", hunk.Offset)
+			}
+			if hunk.Fuzz != "" {
+				fmt.Fprintf(&sb, " (%s)", hunk.Fuzz)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func formatPatchFailures(files []ApplyPatchFile, failed int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Patch not applied: %d of %d file(s) failed. No files were changed.\n", failed, len(files))
+	for _, file := range files {
+		if file.Error == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", file.FilePath, file.Error)
+		for _, hunk := range file.Hunks {
+			if hunk.Applied {
+				continue
+			}
+			fmt.Fprintf(&sb, "  hunk %d %s: %s\n", hunk.Index, hunk.Header, hunk.Error)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func runApplyPatch(t *testing.T, workingDir, patch string) fantasy.ToolResponse {
+	t.Helper()
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	tool := NewApplyPatchTool(nil, &mockPermissionService{}, &mockHistoryService{}, mockFileTrackerService{}, workingDir)
+
+	input, err := json.Marshal(ApplyPatchParams{Patch: patch})
+	require.NoError(t, err)
+
+	resp, err := tool.Run(ctx, fantasy.ToolCall{
+		ID:    "test-call",
+		Name:  ApplyPatchToolName,
+		Input: string(input),
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestApplyPatchMultipleFiles(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "old.txt"), []byte("bye\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "a.txt"), []byte("same\n"), 0o644))
+
+	patch := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,5 @@
+ package main
+
++import "fmt"
++
+ func main() {}
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/a.txt b/b.txt
+similarity index 100%
+rename from a.txt
+rename to b.txt
+`
+	resp := runApplyPatch(t, workingDir, patch)
+	require.False(t, resp.IsError, resp.Content)
+
+	b, err := os.ReadFile(filepath.Join(workingDir, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nimport \"fmt\"\n\nfunc main() {}\n", string(b))
+
+	b, err = os.ReadFile(filepath.Join(workingDir, "new.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(b))
+
+	_, err = os.Stat(filepath.Join(workingDir, "old.txt"))
+	require.True(t, os.IsNotExist(err))
+
+	_, err = os.Stat(filepath.Join(workingDir, "a.txt"))
+	require.True(t, os.IsNotExist(err))
+	b, err = os.ReadFile(filepath.Join(workingDir, "b.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "same\n", string(b))
+
+	var meta ApplyPatchResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Len(t, meta.Files, 4)
+	require.Equal(t, PatchOperationModify, meta.Files[0].Operation)
+	require.Equal(t, PatchOperationCreate, meta.Files[1].Operation)
+	require.Equal(t, PatchOperationDelete, meta.Files[2].Operation)
+	require.Equal(t, PatchOperationRename, meta.Files[3].Operation)
+}
+
+func TestApplyPatchRenameRequestsOldPath(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("same\n"), 0o644))
+
+	perms := &recordingPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest](), allow: true}
+	tool := NewApplyPatchTool(nil, perms, &mockHistoryService{}, mockFileTrackerService{}, workingDir)
+	input, err := json.Marshal(ApplyPatchParams{Patch: "diff --git a/secret.txt b/moved.txt\nsimilarity index 100%\nrename from " + outside + "\nrename to moved.txt\n"})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "test-call", Name: ApplyPatchToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, 2, perms.requestCount)
+	require.NoFileExists(t, outside)
+	require.FileExists(t, filepath.Join(workingDir, "moved.txt"))
+}
+
+func TestApplyPatchFailedHunkChangesNothing(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "one.txt"), []byte("a\nb\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "two.txt"), []byte("c\nd\n"), 0o644))
+
+	patch := `--- a/one.txt
++++ b/one.txt
+@@ -1,2 +1,2 @@
+-a
++A
+ b
+--- a/two.txt
++++ b/two.txt
+@@ -1,2 +1,2 @@
+-x
++X
+ d
+`
+	resp := runApplyPatch(t, workingDir, patch)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "No files were changed")
+	require.Contains(t, resp.Content, "hunk 1")
+	require.Contains(t, resp.Content, `"x"`)
+
+	b, err := os.ReadFile(filepath.Join(workingDir, "one.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "a\nb\n", string(b))
+}
+
+func TestApplyPatchPreservesCRLF(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "win.txt"), []byte("a\r\nb\r\n"), 0o644))
+
+	resp := runApplyPatch(t, workingDir, "--- a/win.txt\n+++ b/win.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n")
+	require.False(t, resp.IsError, resp.Content)
+
+	b, err := os.ReadFile(filepath.Join(workingDir, "win.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "a\r\nc\r\n", string(b))
+}
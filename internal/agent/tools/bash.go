@@ -5,18 +5,26 @@ import (
 	"cmp"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"html/template"
+	"log/slog"
+	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/outputstore"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/sandbox"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/shellstate"
 )
 
 type BashParams struct {
@@ -25,6 +33,7 @@ type BashParams struct {
 	WorkingDir          string `json:"working_dir,omitempty" description:"The working directory to execute the command in (defaults to current directory)"`
 	RunInBackground     bool   `json:"run_in_background,omitempty" description:"Set to true (boolean) to run this command in the background. Use job_output to read the output later."`
 	AutoBackgroundAfter int    `json:"auto_background_after,omitempty" description:"Seconds to wait before automatically moving the command to a background job (default: 60)"`
+	Interactive         bool   `json:"interactive,omitempty" description:"Set to true to run a program that reads input (REPL, installer, database shell) as a background job whose stdin stays open for job_input"`
 }
 
 type BashPermissionsParams struct {
@@ -33,6 +42,7 @@ type BashPermissionsParams struct {
 	WorkingDir          string `json:"working_dir"`
 	RunInBackground     bool   `json:"run_in_background"`
 	AutoBackgroundAfter int    `json:"auto_background_after"`
+	Interactive         bool   `json:"interactive,omitempty"`
 }
 
 type BashResponseMetadata struct {
@@ -68,6 +78,13 @@ type bashDescriptionData struct {
 	ModelID         string
 	RgAvailable     bool
 	GhAvailable     bool
+	Sandbox         string
+	PersistState    bool
+	Shell           string
+	StoresOutput    bool
+	// Policy holds one line per policy rule. The lines are plain text for
+	// the model, so they are not HTML-escaped.
+	Policy []template.HTML
 }
 
 var bannedCommands = []string{
@@ -143,7 +160,7 @@ var bannedCommands = []string{
 	"ufw",
 }
 
-func bashDescription(attribution *config.Attribution, modelID string) string {
+func bashDescription(attribution *config.Attribution, modelID string, policy *sandbox.Policy, rules []config.ToolBashPolicyRule, persistState bool, external *shell.ExternalShell, storesOutput bool) string {
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
 	var out bytes.Buffer
 	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
@@ -153,6 +170,11 @@ func bashDescription(attribution *config.Attribution, modelID string) string {
 		ModelID:         modelID,
 		RgAvailable:     getRg() != "",
 		GhAvailable:     ghAvailable,
+		Sandbox:         sandboxDescription(policy),
+		PersistState:    persistState,
+		Shell:           externalShellDescription(external),
+		StoresOutput:    storesOutput,
+		Policy:          policyDescription(rules),
 	}); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
@@ -192,17 +214,187 @@ func blockFuncs() []shell.BlockFunc {
 	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelID string) fantasy.AgentTool {
+// bashBlockFuncs returns the block funcs enforcing the command policy in
+// rules in front of the built-in ones.
+func bashBlockFuncs(rules []config.ToolBashPolicyRule) ([]shell.BlockFunc, error) {
+	if len(rules) == 0 {
+		return blockFuncs(), nil
+	}
+	policy := make([]shell.PolicyRule, 0, len(rules))
+	for _, rule := range rules {
+		policy = append(policy, shell.PolicyRule{
+			Action:      shell.PolicyAction(rule.Action),
+			Command:     rule.Command,
+			Subcommands: rule.Subcommands,
+			Flags:       rule.Flags,
+			Args:        rule.Args,
+			Reason:      rule.Reason,
+		})
+	}
+	block, err := shell.PolicyBlocker(policy, blockFuncs())
+	if err != nil {
+		return nil, err
+	}
+	return []shell.BlockFunc{block}, nil
+}
+
+func policyDescription(rules []config.ToolBashPolicyRule) []template.HTML {
+	lines := make([]template.HTML, 0, len(rules))
+	for _, rule := range rules {
+		var b strings.Builder
+		b.WriteString(rule.Action + " " + strings.Join(append([]string{rule.Command}, rule.Subcommands...), " "))
+		if len(rule.Flags) > 0 {
+			b.WriteString(" with any of the flags " + strings.Join(rule.Flags, ", "))
+		}
+		if len(rule.Args) > 0 {
+			if rule.Action == string(shell.PolicyAllow) {
+				b.WriteString(" when every argument matches one of " + strings.Join(rule.Args, ", "))
+			} else {
+				b.WriteString(" when an argument matches one of " + strings.Join(rule.Args, ", "))
+			}
+		}
+		if rule.Reason != "" {
+			b.WriteString(": " + rule.Reason)
+		}
+		lines = append(lines, template.HTML(b.String()))
+	}
+	return lines
+}
+
+// blockedNote appends the reason a command was blocked to its output when
+// the output does not already include it, as happens when the command
+// wrote to stderr before it was blocked.
+func blockedNote(output string, execErr error) string {
+	blocked, ok := errors.AsType[*shell.BlockedError](execErr)
+	if !ok || strings.Contains(output, blocked.Error()) {
+		return output
+	}
+	return output + "\n" + blocked.Error()
+}
+
+// newBashSandbox returns the policy confining bash commands, or nil when
+// the sandbox is disabled. The working directory is always writable.
+func newBashSandbox(cfg config.ToolBashSandbox, workingDir string) *sandbox.Policy {
+	if !cfg.Enabled {
+		return nil
+	}
+	resolve := func(paths []string) []string {
+		resolved := make([]string, 0, len(paths))
+		for _, p := range paths {
+			p = home.Long(os.ExpandEnv(p))
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(workingDir, p)
+			}
+			resolved = append(resolved, p)
+		}
+		return resolved
+	}
+	return sandbox.New(sandbox.Options{
+		WritablePaths:  append([]string{workingDir}, resolve(cfg.WritablePaths)...),
+		HiddenPaths:    resolve(cfg.HiddenPaths),
+		DisableNetwork: cfg.DisableNetwork,
+		AllowedHosts:   cfg.AllowedHosts,
+	})
+}
+
+// newExternalShell returns the shell running bash commands, or nil when
+// they run in the built-in interpreter.
+func newExternalShell(cfg config.ToolBashShell) *shell.ExternalShell {
+	if cfg.Path == "" {
+		return nil
+	}
+	external := &shell.ExternalShell{Path: cfg.Path, Args: cfg.Args}
+	if cfg.RCFile != "" {
+		external.RCFile = home.Long(os.ExpandEnv(cfg.RCFile))
+	}
+	return external
+}
+
+func externalShellDescription(external *shell.ExternalShell) string {
+	if external == nil {
+		return ""
+	}
+	return external.Path
+}
+
+func sandboxDescription(policy *sandbox.Policy) string {
+	if policy == nil {
+		return ""
+	}
+	return policy.Describe()
+}
+
+// sandboxNote appends a note on the sandbox to the output of a failed
+// command whose errors suggest the sandbox denied it access.
+func sandboxNote(output string, policy *sandbox.Policy, execErr error) string {
+	if policy == nil || shell.ExitCode(execErr) == 0 || !sandbox.LooksBlocked(output) {
+		return output
+	}
+	return output + "\n\nThis command ran in a sandbox (" + policy.Describe() + ") which may have blocked it. Do not try to work around the sandbox; tell the user, who can change tools.bash.sandbox in the configuration."
+}
+
+// restoredWorkingDir returns the working directory saved in state, unless
+// it no longer exists.
+func restoredWorkingDir(state shellstate.State) string {
+	if state.WorkingDir == "" {
+		return ""
+	}
+	if info, err := os.Stat(state.WorkingDir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return state.WorkingDir
+}
+
+// restoredEnv returns the environment of a command started with the
+// variables saved in state, or nil for the environment of Crush.
+func restoredEnv(state shellstate.State) []string {
+	if len(state.Env) == 0 {
+		return nil
+	}
+	return slices.Concat(os.Environ(), state.Env)
+}
+
+// saveShellState saves the working directory and exported variables the
+// finished command of sh left behind, leaving out variables matching
+// exclude. The working directory is only saved when the command changed
+// it, so that an explicit working_dir does not stick.
+func saveShellState(ctx context.Context, shellState shellstate.Service, state shellstate.State, sh *shell.Shell, startDir string, exclude []string) {
+	next := shellstate.State{
+		SessionID:  state.SessionID,
+		WorkingDir: state.WorkingDir,
+		Env:        shellstate.FilterEnv(shell.EnvChanges(os.Environ(), sh.GetEnv()), exclude),
+	}
+	if dir := sh.GetWorkingDir(); dir != startDir {
+		next.WorkingDir = dir
+	}
+	if next.WorkingDir != state.WorkingDir || !slices.Equal(next.Env, state.Env) {
+		if err := shellState.Save(ctx, next); err != nil {
+			slog.Warn("Failed to save shell state", "error", err, "session_id", state.SessionID)
+		}
+	}
+}
+
+func NewBashTool(permissions permission.Service, shellState shellstate.Service, outputs *outputstore.Store, workingDir string, attribution *config.Attribution, modelID string, cfg config.ToolBash) fantasy.AgentTool {
+	policy := newBashSandbox(cfg.Sandbox, workingDir)
+	external := newExternalShell(cfg.Shell)
+	blocks, policyErr := bashBlockFuncs(cfg.Policy)
+	persist := shellState != nil && !cfg.State.Disabled
+	exclude := slices.Concat(shellstate.DefaultExcludeEnv, cfg.State.ExcludeEnv)
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelID)),
+		string(bashDescription(attribution, modelID, policy, cfg.Policy, persist, external, outputs != nil)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
 			}
-
-			// Determine working directory
-			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
+			if policyErr != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("The bash command policy in the configuration is invalid: %s", policyErr)), nil
+			}
+			if policy != nil {
+				if err := policy.Check(); err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("The bash sandbox is enabled in the configuration but cannot run on this system: %s", err)), nil
+				}
+			}
 
 			isSafeReadOnly := false
 			cmdLower := strings.ToLower(params.Command)
@@ -222,6 +414,20 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for executing shell command")
 			}
+			spill := &outputSpill{outputs: outputs, sessionID: sessionID, name: call.ID}
+
+			state := shellstate.State{SessionID: sessionID}
+			if persist {
+				var err error
+				if state, err = shellState.Get(ctx, sessionID); err != nil {
+					slog.Warn("Failed to load shell state", "error", err, "session_id", sessionID)
+					state = shellstate.State{SessionID: sessionID}
+				}
+			}
+
+			// Determine working directory
+			execWorkingDir := cmp.Or(params.WorkingDir, restoredWorkingDir(state), workingDir)
+
 			if !isSafeReadOnly {
 				p, err := permissions.Request(
 					ctx,
@@ -244,12 +450,20 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			}
 
 			// If explicitly requested as background, start immediately with detached context
-			if params.RunInBackground {
+			if params.RunInBackground || params.Interactive {
 				startTime := time.Now()
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				bgShell, err := bgManager.StartJob(shell.WithSandbox(context.Background(), policy), shell.JobOptions{
+					WorkingDir:  execWorkingDir,
+					Env:         restoredEnv(state),
+					BlockFuncs:  blocks,
+					Command:     params.Command,
+					Description: params.Description,
+					Interactive: params.Interactive,
+					External:    external,
+				})
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -257,6 +471,9 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				// Wait a short time to detect fast failures (blocked commands, syntax errors, etc.)
 				time.Sleep(1 * time.Second)
 				stdout, stderr, done, execErr := bgShell.GetOutput()
+				if bgShell.Terminal() {
+					stdout = cleanTerminalOutput(stdout)
+				}
 
 				if done {
 					// Command failed or completed very quickly
@@ -268,7 +485,9 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 						return fantasy.ToolResponse{}, fmt.Errorf("[Job %s] error executing command: %w", bgShell.ID, execErr)
 					}
 
-					stdout = formatOutput(stdout, stderr, execErr)
+					stdout = formatOutput(stdout, stderr, execErr, spill)
+					stdout = sandboxNote(stdout, policy, execErr)
+					stdout = blockedNote(stdout, execErr)
 
 					metadata := BashResponseMetadata{
 						StartTime:        startTime.UnixMilli(),
@@ -295,6 +514,9 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					ShellID:          bgShell.ID,
 				}
 				response := fmt.Sprintf("Background shell started with ID: %s\n\nUse job_output tool to view output or job_kill to terminate.", bgShell.ID)
+				if params.Interactive {
+					response = fmt.Sprintf("Interactive background shell started with ID: %s\n\nUse job_input to send input, job_output with wait_for to wait for a prompt, and job_kill to terminate.", bgShell.ID)
+				}
 				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), metadata), nil
 			}
 
@@ -304,7 +526,14 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			bgShell, err := bgManager.StartJob(shell.WithSandbox(context.Background(), policy), shell.JobOptions{
+				WorkingDir:  execWorkingDir,
+				Env:         restoredEnv(state),
+				BlockFuncs:  blocks,
+				Command:     params.Command,
+				Description: params.Description,
+				External:    external,
+			})
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
@@ -352,7 +581,15 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					return fantasy.ToolResponse{}, fmt.Errorf("[Job %s] error executing command: %w", bgShell.ID, execErr)
 				}
 
-				stdout = formatOutput(stdout, stderr, execErr)
+				stdout = formatOutput(stdout, stderr, execErr, spill)
+				stdout = sandboxNote(stdout, policy, execErr)
+				stdout = blockedNote(stdout, execErr)
+
+				cwd := bgShell.WorkingDir
+				if persist {
+					saveShellState(ctx, shellState, state, bgShell.Shell, execWorkingDir, exclude)
+					cwd = bgShell.Shell.GetWorkingDir()
+				}
 
 				metadata := BashResponseMetadata{
 					StartTime:        startTime.UnixMilli(),
@@ -365,7 +602,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				if stdout == "" {
 					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
 				}
-				stdout += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(bgShell.WorkingDir))
+				stdout += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(cwd))
 				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(stdout), metadata), nil
 			}
 
@@ -384,13 +621,14 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 	)
 }
 
-// formatOutput formats the output of a completed command with error handling
-func formatOutput(stdout, stderr string, execErr error) string {
+// formatOutput formats the output of a completed command with error
+// handling. Output too long to return whole is saved by spill, if not nil.
+func formatOutput(stdout, stderr string, execErr error, spill *outputSpill) string {
 	interrupted := shell.IsInterrupt(execErr)
 	exitCode := shell.ExitCode(execErr)
 
-	stdout = truncateOutput(stdout)
-	stderr = truncateOutput(stderr)
+	stdout = spill.truncate("stdout", stdout)
+	stderr = spill.truncate("stderr", stderr)
 
 	errorMessage := stderr
 	if errorMessage == "" && execErr != nil {
@@ -427,12 +665,44 @@ func TruncateOutput(content string) string {
 		return content
 	}
 
+	start, end, truncatedLinesCount := splitOutput(content)
+	return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, truncatedLinesCount, end)
+}
+
+// splitOutput returns the head and tail of content kept by
+// [TruncateOutput], and the number of lines between them.
+func splitOutput(content string) (start, end string, truncatedLines int) {
 	halfLength := MaxOutputLength / 2
-	start := content[:halfLength]
-	end := content[len(content)-halfLength:]
+	start = content[:halfLength]
+	end = content[len(content)-halfLength:]
+	return start, end, countLines(content[halfLength : len(content)-halfLength])
+}
 
-	truncatedLinesCount := countLines(content[halfLength : len(content)-halfLength])
-	return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, truncatedLinesCount, end)
+// outputSpill saves output too long to return whole to the output store,
+// so that the lines left out can be read with the read_output tool.
+type outputSpill struct {
+	outputs   *outputstore.Store
+	sessionID string
+	// name identifies the output in the store, such as the ID of the
+	// tool call that produced it.
+	name string
+}
+
+// truncate returns content truncated like [TruncateOutput]. When it is
+// truncated and there is a store, the full content is saved as stream
+// first, and the note in place of the missing lines tells where.
+func (o *outputSpill) truncate(stream, content string) string {
+	if o == nil || o.outputs == nil || o.sessionID == "" || len(content) <= MaxOutputLength {
+		return TruncateOutput(content)
+	}
+	path, err := o.outputs.Save(o.sessionID, o.name+"."+stream, content)
+	if err != nil {
+		slog.Warn("Failed to save command output", "error", err, "session_id", o.sessionID)
+		return TruncateOutput(content)
+	}
+	start, end, truncatedLinesCount := splitOutput(content)
+	return fmt.Sprintf("%s\n\n... [%d lines truncated; the full %s (%d lines) is saved to %s, use %s to page through or search it] ...\n\n%s",
+		start, truncatedLinesCount, stream, countLines(strings.TrimSuffix(content, "\n")), path, ReadOutputToolName, end)
 }
 
 func truncateOutput(content string) string {
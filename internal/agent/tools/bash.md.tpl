@@ -1,17 +1,18 @@
 Execute shell commands; long-running commands automatically move to background and return a shell ID.
 
 <cross_platform>
-Uses mvdan/sh interpreter (Bash-compatible on all platforms including Windows).
+{{ if .Shell }}Commands run in the user's shell, {{ .Shell }}, with the shell functions of its rc file available. Use its syntax and features, such as arrays or process substitution, where needed.
+{{ else }}Uses mvdan/sh interpreter (Bash-compatible on all platforms including Windows).
 Use forward slashes for paths: "ls C:/foo/bar" not "ls C:\foo\bar".
 Common shell builtins and core utils available on Windows.
-</cross_platform>
+{{ end }}</cross_platform>
 
 <execution_steps>
 1. Directory Verification: If creating directories/files, use LS tool to verify parent exists
 2. Security Check: Banned commands ({{ .BannedCommands }}) return error - explain to user. Safe read-only commands execute without prompts
 3. Command Execution: Execute with proper quoting, capture output
 4. Auto-Background: Commands exceeding 1 minute (default, configurable via `auto_background_after`) automatically move to background and return shell ID
-5. Output Processing: Truncate if exceeds {{ .MaxOutputLength }} characters
+5. Output Processing: Truncate if exceeds {{ .MaxOutputLength }} characters{{ if .StoresOutput }}; the full output is saved to a file whose path is given in the truncation note, read it with read_output instead of rerunning the command{{ end }}
 6. Return Result: Include errors, metadata with <cwd></cwd> tags
 </execution_steps>
 
@@ -19,8 +20,9 @@ Common shell builtins and core utils available on Windows.
 - Command required, working_dir optional (defaults to current directory)
 - IMPORTANT: Use Grep/Glob/Agent tools instead of 'find'/'grep'. Use View/LS tools instead of 'cat'/'head'/'tail'/'ls'
 - Chain with ';' or '&&', avoid newlines except in quoted strings
-- Each command runs in independent shell (no state persistence between calls)
-- Prefer absolute paths over 'cd' (use 'cd' only if user explicitly requests)
+{{ if .PersistState }}- The working directory and exported variables carry over to later calls in the session, even after a restart; secrets are not kept
+{{ else }}- Each command runs in independent shell (no state persistence between calls)
+{{ end }}- Prefer absolute paths over 'cd' (use 'cd' only if user explicitly requests)
 {{- if .RgAvailable }}
 - Ripgrep (`rg`) is available; prefer it over `grep` for faster, more intuitive searching
 {{- end }}
@@ -31,6 +33,7 @@ Common shell builtins and core utils available on Windows.
 - Returns a shell ID for managing the background process
 - Use job_output tool to view current output from background shell
 - Use job_kill tool to terminate a background shell
+- Set interactive=true for programs that read input (REPLs, installers, prompts); send input with job_input and use job_output with wait_for to wait for a prompt
 - IMPORTANT: NEVER use `&` at the end of commands to run in background - use run_in_background parameter instead
 - Commands that should run in background:
   * Long-running servers (e.g., `npm start`, `python -m http.server`, `node server.js`)
@@ -44,7 +47,18 @@ Common shell builtins and core utils available on Windows.
   * File operations
   * Short-lived scripts
 </background_execution>
-
+{{ if .Sandbox }}
+<sandbox>
+Commands run in a sandbox: {{ .Sandbox }}.
+- Failures such as permission denied, read-only file system or unreachable network may come from the sandbox; tell the user instead of working around it
+</sandbox>
+{{ end }}{{ if .Policy }}
+<command_policy>
+The user's command policy applies to every command, including those in pipelines, subshells and sh -c scripts. The first matching rule decides:
+{{ range .Policy }}- {{ . }}
+{{ end }}- Commands the policy denies fail with its reason; explain it to the user instead of working around the policy
+</command_policy>
+{{ end }}
 <git_message_quality>
 These rules apply whenever creating or updating commit messages, PR titles, or PR bodies:
 
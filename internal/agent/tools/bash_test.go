@@ -3,13 +3,19 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/sandbox"
+	"github.com/charmbracelet/crush/internal/sandbox/sandboxtest"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/stretchr/testify/require"
 )
 
@@ -115,7 +121,7 @@ func (m *recordingPermissionService) SubscribeNotifications(ctx context.Context)
 func newBashToolForTest(workingDir string) fantasy.AgentTool {
 	permissions := &mockBashPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
 	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
-	return NewBashTool(permissions, workingDir, attribution, "test-model")
+	return NewBashTool(permissions, nil, nil, workingDir, attribution, "test-model", config.ToolBash{})
 }
 
 func newBashToolWithRecordingPerms(workingDir string, allow bool) (fantasy.AgentTool, *recordingPermissionService) {
@@ -124,7 +130,7 @@ func newBashToolWithRecordingPerms(workingDir string, allow bool) (fantasy.Agent
 		allow:  allow,
 	}
 	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
-	return NewBashTool(perms, workingDir, attribution, "test-model"), perms
+	return NewBashTool(perms, nil, nil, workingDir, attribution, "test-model", config.ToolBash{}), perms
 }
 
 func TestBashTool_ChainedCommandsRequirePermission(t *testing.T) {
@@ -182,3 +188,168 @@ func runBashTool(t *testing.T, tool fantasy.AgentTool, ctx context.Context, para
 	require.NoError(t, err)
 	return resp
 }
+
+func TestBashTool_Sandbox(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the sandbox is only supported on Linux")
+	}
+	if err := sandbox.New(sandbox.Options{}).Check(); err != nil {
+		t.Skipf("sandbox not available: %v", err)
+	}
+
+	readOnly := sandboxtest.ReadOnlyDir(t)
+
+	workingDir := t.TempDir()
+	permissions := &mockBashPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
+	tool := NewBashTool(permissions, nil, nil, workingDir, attribution, "test-model", config.ToolBash{
+		Sandbox: config.ToolBashSandbox{Enabled: true},
+	})
+	require.Contains(t, tool.Info().Description, "Commands run in a sandbox: writes are limited to")
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp := runBashTool(t, tool, ctx, BashParams{
+		Description: "write inside",
+		Command:     "touch inside",
+	})
+	require.False(t, resp.IsError)
+	require.FileExists(t, filepath.Join(workingDir, "inside"))
+
+	resp = runBashTool(t, tool, ctx, BashParams{
+		Description: "write outside",
+		Command:     "touch " + filepath.Join(readOnly, "outside"),
+	})
+	require.Contains(t, resp.Content, "This command ran in a sandbox")
+	require.NoFileExists(t, filepath.Join(readOnly, "outside"))
+}
+
+func TestBashTool_Policy(t *testing.T) {
+	t.Parallel()
+
+	permissions := &mockBashPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
+	tool := NewBashTool(permissions, nil, nil, t.TempDir(), attribution, "test-model", config.ToolBash{
+		Policy: []config.ToolBashPolicyRule{
+			{Action: "deny", Command: "git", Subcommands: []string{"push"}, Flags: []string{"--force"}, Reason: "force pushes are not allowed"},
+		},
+	})
+	require.Contains(t, tool.Info().Description, "- deny git push with any of the flags --force: force pushes are not allowed")
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp := runBashTool(t, tool, ctx, BashParams{
+		Description: "force push",
+		Command:     "echo pushing >&2; git status | sh -c 'git push --force'",
+	})
+	require.Contains(t, resp.Content, "pushing")
+	require.Contains(t, resp.Content, `command is not allowed: "git": force pushes are not allowed`)
+
+	resp = runBashTool(t, tool, ctx, BashParams{
+		Description: "banned command",
+		Command:     "wget https://example.com",
+	})
+	require.Contains(t, resp.Content, `command is not allowed for security reasons: "wget"`)
+
+	tool = NewBashTool(permissions, nil, nil, t.TempDir(), attribution, "test-model", config.ToolBash{
+		Policy: []config.ToolBashPolicyRule{{Action: "block", Command: "git"}},
+	})
+	resp = runBashTool(t, tool, ctx, BashParams{Description: "echo", Command: "echo hi"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, `rule 1: unknown action "block"`)
+}
+
+type memoryShellState struct {
+	states map[string]shellstate.State
+}
+
+func (m *memoryShellState) Get(ctx context.Context, sessionID string) (shellstate.State, error) {
+	if state, ok := m.states[sessionID]; ok {
+		return state, nil
+	}
+	return shellstate.State{SessionID: sessionID}, nil
+}
+
+func (m *memoryShellState) Save(ctx context.Context, state shellstate.State) error {
+	m.states[state.SessionID] = state
+	return nil
+}
+
+func (m *memoryShellState) Reset(ctx context.Context, sessionID string) error {
+	delete(m.states, sessionID)
+	return nil
+}
+
+func TestBashTool_PersistState(t *testing.T) {
+	workingDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(workingDir, "sub"), 0o755))
+
+	states := &memoryShellState{states: map[string]shellstate.State{}}
+	permissions := &mockBashPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
+	cfg := config.ToolBash{State: config.ToolBashState{ExcludeEnv: []string{"SKIPPED_*"}}}
+	tool := NewBashTool(permissions, states, nil, workingDir, attribution, "test-model", cfg)
+	require.Contains(t, tool.Info().Description, "exported variables carry over")
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp := runBashTool(t, tool, ctx, BashParams{
+		Description: "set up state",
+		Command:     "cd sub && export GREETING=hello MY_TOKEN=secret SKIPPED_VAR=1 && echo ok",
+	})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "<cwd>"+normalizeWorkingDir(filepath.Join(workingDir, "sub"))+"</cwd>")
+	require.Equal(t, shellstate.State{
+		SessionID:  "test-session",
+		WorkingDir: filepath.Join(workingDir, "sub"),
+		Env:        []string{"GREETING=hello"},
+	}, states.states["test-session"])
+
+	// A new tool, as after a restart, starts where the last command left off.
+	tool = NewBashTool(permissions, states, nil, workingDir, attribution, "test-model", cfg)
+	resp = runBashTool(t, tool, ctx, BashParams{
+		Description: "use state",
+		Command:     `echo "$GREETING from $(basename "$PWD") $MY_TOKEN"`,
+	})
+	require.Contains(t, resp.Content, "hello from sub \n")
+
+	// An explicit working directory does not replace the saved one.
+	resp = runBashTool(t, tool, ctx, BashParams{
+		Description: "explicit dir",
+		Command:     "unset GREETING && pwd",
+		WorkingDir:  workingDir,
+	})
+	require.Contains(t, resp.Content, normalizeWorkingDir(workingDir)+"\n")
+	require.Equal(t, filepath.Join(workingDir, "sub"), states.states["test-session"].WorkingDir)
+	require.Empty(t, states.states["test-session"].Env)
+
+	// Disabled persistence leaves every command in the working directory.
+	tool = NewBashTool(permissions, states, nil, workingDir, attribution, "test-model", config.ToolBash{State: config.ToolBashState{Disabled: true}})
+	require.Contains(t, tool.Info().Description, "no state persistence between calls")
+	resp = runBashTool(t, tool, ctx, BashParams{Description: "pwd", Command: "pwd"})
+	require.Contains(t, resp.Content, normalizeWorkingDir(workingDir)+"\n")
+}
+
+func TestBashTool_ExternalShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test shell is POSIX sh")
+	}
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	rc := filepath.Join(workingDir, "rc")
+	require.NoError(t, os.WriteFile(rc, []byte("greet() { echo \"hello $1\"; }\n"), 0o644))
+
+	permissions := &mockBashPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
+	tool := NewBashTool(permissions, nil, nil, workingDir, attribution, "test-model", config.ToolBash{
+		Shell: config.ToolBashShell{Path: "sh", RCFile: rc},
+	})
+	require.Contains(t, tool.Info().Description, "Commands run in the user's shell, sh,")
+	require.NotContains(t, tool.Info().Description, "mvdan/sh")
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp := runBashTool(t, tool, ctx, BashParams{Description: "greet", Command: "greet world"})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "hello world\n")
+
+	resp = runBashTool(t, tool, ctx, BashParams{Description: "banned", Command: "greet $(wget example.com)"})
+	require.Contains(t, resp.Content, `command is not allowed for security reasons: "wget"`)
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -39,6 +40,9 @@ type EditResponseMetadata struct {
 	Removals   int    `json:"removals"`
 	OldContent string `json:"old_content,omitempty"`
 	NewContent string `json:"new_content,omitempty"`
+	// MatchTier is the matching strategy that located old_string; see the
+	// MatchTier constants.
+	MatchTier string `json:"match_tier,omitempty"`
 }
 
 const EditToolName = "edit"
@@ -236,25 +240,12 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
-
-	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, "")
-		if newContent == oldContent {
-			return oldStringNotFoundErr, nil
-		}
-	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return oldStringNotFoundErr, nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return fantasy.NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match, or set replace_all to true"), nil
-		}
-
-		newContent = oldContent[:index] + oldContent[index+len(oldString):]
+	newContent, matchTier, err := replaceMatched(oldContent, oldString, "", replaceAll)
+	if errors.Is(err, errOldStringNotFound) {
+		return oldStringNotFoundErr, nil
+	}
+	if errors.Is(err, errOldStringMultipleMatches) {
+		return oldStringMultipleMatchesErr, nil
 	}
 
 	_, additions, removals := diff.GenerateDiff(
@@ -327,12 +318,13 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
+		fantasy.NewTextResponse(withMatchTierNote("Content deleted from file: "+filePath, matchTier)),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
 			Additions:  additions,
 			Removals:   removals,
+			MatchTier:  matchTier,
 		},
 	), nil
 }
@@ -377,22 +369,12 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
-
-	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, newString)
-	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return oldStringNotFoundErr, nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return oldStringMultipleMatchesErr, nil
-		}
-
-		newContent = oldContent[:index] + newString + oldContent[index+len(oldString):]
+	newContent, matchTier, err := replaceMatched(oldContent, oldString, newString, replaceAll)
+	if errors.Is(err, errOldStringNotFound) {
+		return oldStringNotFoundErr, nil
+	}
+	if errors.Is(err, errOldStringMultipleMatches) {
+		return oldStringMultipleMatchesErr, nil
 	}
 
 	if oldContent == newContent {
@@ -468,12 +450,13 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse(withMatchTierNote("Content replaced in file: "+filePath, matchTier)),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
 			Additions:  additions,
 			Removals:   removals,
+			MatchTier:  matchTier,
 		},
 	), nil
 }
@@ -0,0 +1,278 @@
+package tools
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Match tiers reported in the edit tools' response metadata, from the
+// strictest to the loosest. Looser tiers are only tried when the stricter
+// ones find nothing, and are only accepted when they match exactly one
+// location.
+const (
+	MatchTierExact              = "exact"
+	MatchTierLineEndings        = "line_endings"
+	MatchTierTrailingWhitespace = "trailing_whitespace"
+	MatchTierIndentation        = "indentation"
+)
+
+// indentTabWidth is the width assumed for a tab when comparing indentation.
+const indentTabWidth = 4
+
+var (
+	errOldStringNotFound        = errors.New("old_string not found")
+	errOldStringMultipleMatches = errors.New("old_string appears multiple times")
+)
+
+// matchTierDescriptions are appended to tool responses so the model knows
+// its old_string was not an exact match.
+var matchTierDescriptions = map[string]string{
+	MatchTierLineEndings:        "matched after normalizing line endings",
+	MatchTierTrailingWhitespace: "matched ignoring trailing whitespace",
+	MatchTierIndentation:        "matched ignoring indentation; new_string was re-indented to the matched block",
+}
+
+// replaceMatched replaces oldString with newString in content, trying the
+// match tiers in order. It returns the new content and the tier used.
+func replaceMatched(content, oldString, newString string, replaceAll bool) (string, string, error) {
+	if out, ok, err := replaceExact(content, oldString, newString, replaceAll); ok || err != nil {
+		return out, MatchTierExact, err
+	}
+
+	if strings.Contains(oldString, "\r") || strings.Contains(newString, "\r") {
+		oldString = strings.ReplaceAll(oldString, "\r\n", "\n")
+		newString = strings.ReplaceAll(newString, "\r\n", "\n")
+		if out, ok, err := replaceExact(content, oldString, newString, replaceAll); ok || err != nil {
+			return out, MatchTierLineEndings, err
+		}
+	}
+
+	if out, ok, err := replaceLines(content, oldString, newString, trailingWhitespaceMatch); ok || err != nil {
+		return out, MatchTierTrailingWhitespace, err
+	}
+	if out, ok, err := replaceLines(content, oldString, newString, indentationMatch); ok || err != nil {
+		return out, MatchTierIndentation, err
+	}
+	return "", "", errOldStringNotFound
+}
+
+// replaceExact performs a plain string replacement. ok is false when
+// oldString does not occur in content.
+func replaceExact(content, oldString, newString string, replaceAll bool) (string, bool, error) {
+	count := strings.Count(content, oldString)
+	switch {
+	case count == 0:
+		return "", false, nil
+	case replaceAll:
+		return strings.ReplaceAll(content, oldString, newString), true, nil
+	case count > 1:
+		return "", true, errOldStringMultipleMatches
+	}
+	index := strings.Index(content, oldString)
+	return content[:index] + newString + content[index+len(oldString):], true, nil
+}
+
+// lineMatcher reports whether the candidate lines of the file match the
+// lines of old_string, returning the new_string lines to splice in.
+type lineMatcher func(candidate, oldLines, newLines []string) ([]string, bool)
+
+// replaceLines matches old_string against whole lines of content using
+// match. Unlike exact matching it always requires a unique match.
+func replaceLines(content, oldString, newString string, match lineMatcher) (string, bool, error) {
+	oldLines := splitEditLines(oldString)
+	if len(oldLines) == 0 || strings.TrimSpace(oldString) == "" {
+		return "", false, nil
+	}
+	newLines := splitEditLines(newString)
+	lines := strings.Split(content, "\n")
+
+	var (
+		found       int
+		start       int
+		replacement []string
+	)
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		if out, ok := match(lines[i:i+len(oldLines)], oldLines, newLines); ok {
+			found++
+			start, replacement = i, out
+		}
+	}
+	switch {
+	case found == 0:
+		return "", false, nil
+	case found > 1:
+		return "", true, errOldStringMultipleMatches
+	}
+
+	out := slices.Concat(lines[:start], replacement, lines[start+len(oldLines):])
+	return strings.Join(out, "\n"), true, nil
+}
+
+// splitEditLines splits an old_string or new_string into lines, ignoring a
+// single trailing newline.
+func splitEditLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func trailingWhitespaceMatch(candidate, oldLines, newLines []string) ([]string, bool) {
+	for i, line := range candidate {
+		if strings.TrimRight(line, " \t") != strings.TrimRight(oldLines[i], " \t") {
+			return nil, false
+		}
+	}
+	return newLines, true
+}
+
+// indentationMatch matches lines whose content is equal and whose
+// indentation has the same relative structure, e.g. a block indented with
+// tabs in the file and with four spaces in old_string. On success the
+// new_string lines are re-indented to the file's indentation.
+func indentationMatch(candidate, oldLines, newLines []string) ([]string, bool) {
+	// Map each indentation width used in old_string to the indentation used
+	// at the same place in the file.
+	indents := make(map[int]string)
+	for i, line := range candidate {
+		if strings.TrimSpace(line) != strings.TrimSpace(oldLines[i]) {
+			return nil, false
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := indentWidth(oldLines[i])
+		fileIndent := leadingWhitespace(line)
+		if existing, ok := indents[width]; ok && existing != fileIndent {
+			return nil, false
+		}
+		indents[width] = fileIndent
+	}
+	if len(indents) == 0 {
+		return nil, false
+	}
+
+	// The relative order of indentation levels must be preserved.
+	widths := make([]int, 0, len(indents))
+	for w := range indents {
+		widths = append(widths, w)
+	}
+	slices.Sort(widths)
+	for i := 1; i < len(widths); i++ {
+		if indentWidth(indents[widths[i-1]]) >= indentWidth(indents[widths[i]]) {
+			return nil, false
+		}
+	}
+
+	return reindentLines(newLines, indents, widths, oldLines), true
+}
+
+// reindentLines rewrites the indentation of new_string lines using the
+// mapping from old_string indentation widths to file indentation.
+func reindentLines(newLines []string, indents map[int]string, widths []int, oldLines []string) []string {
+	oldUnit := indentUnitWidth(oldLines)
+	fileUnit := fileIndentUnit(indents, widths)
+
+	out := make([]string, len(newLines))
+	for i, line := range newLines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			out[i] = ""
+			continue
+		}
+		width := indentWidth(line)
+		if indent, ok := indents[width]; ok {
+			out[i] = indent + trimmed
+			continue
+		}
+
+		// Not a level seen in old_string: derive it from the closest known
+		// level plus or minus whole indentation units.
+		base := widths[0]
+		for _, w := range widths {
+			if w <= width {
+				base = w
+			}
+		}
+		levels := (width - base) / oldUnit
+		indent := indents[base]
+		if levels >= 0 {
+			indent += strings.Repeat(fileUnit, levels)
+		} else {
+			for range -levels {
+				indent = strings.TrimSuffix(indent, fileUnit)
+			}
+		}
+		out[i] = indent + trimmed
+	}
+	return out
+}
+
+// indentUnitWidth estimates the width of one indentation level in lines.
+func indentUnitWidth(lines []string) int {
+	var widths []int
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			widths = append(widths, indentWidth(line))
+		}
+	}
+	slices.Sort(widths)
+	widths = slices.Compact(widths)
+	unit := 0
+	for i := 1; i < len(widths); i++ {
+		if d := widths[i] - widths[i-1]; unit == 0 || d < unit {
+			unit = d
+		}
+	}
+	if unit == 0 {
+		return indentTabWidth
+	}
+	return unit
+}
+
+// fileIndentUnit returns the string used for one indentation level in the
+// matched file block.
+func fileIndentUnit(indents map[int]string, widths []int) string {
+	unit := ""
+	for i := 1; i < len(widths); i++ {
+		prev, next := indents[widths[i-1]], indents[widths[i]]
+		if d, ok := strings.CutPrefix(next, prev); ok && d != "" && (unit == "" || len(d) < len(unit)) {
+			unit = d
+		}
+	}
+	if unit != "" {
+		return unit
+	}
+	for _, indent := range indents {
+		if strings.Contains(indent, "\t") {
+			return "\t"
+		}
+	}
+	return strings.Repeat(" ", indentTabWidth)
+}
+
+func leadingWhitespace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+func indentWidth(s string) int {
+	width := 0
+	for _, r := range leadingWhitespace(s) {
+		if r == '\t' {
+			width += indentTabWidth
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// withMatchTierNote appends a note to a tool response message when old_string
+// was located by one of the fallback tiers.
+func withMatchTierNote(message, tier string) string {
+	if desc, ok := matchTierDescriptions[tier]; ok {
+		return message + " (" + desc + ")"
+	}
+	return message
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceMatched(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		content    string
+		oldString  string
+		newString  string
+		replaceAll bool
+		want       string
+		wantTier   string
+		wantErr    error
+	}{
+		{
+			name:      "exact",
+			content:   "a\nb\n",
+			oldString: "b",
+			newString: "c",
+			want:      "a\nc\n",
+			wantTier:  MatchTierExact,
+		},
+		{
+			name:      "exact multiple matches",
+			content:   "a\nb\nb\n",
+			oldString: "b",
+			newString: "c",
+			wantErr:   errOldStringMultipleMatches,
+		},
+		{
+			name:      "crlf old string",
+			content:   "a\nb\nc\n",
+			oldString: "a\r\nb",
+			newString: "x\r\ny",
+			want:      "x\ny\nc\n",
+			wantTier:  MatchTierLineEndings,
+		},
+		{
+			name:      "trailing whitespace",
+			content:   "a  \nb\nc\n",
+			oldString: "a\nb\n",
+			newString: "A\nB\n",
+			want:      "A\nB\nc\n",
+			wantTier:  MatchTierTrailingWhitespace,
+		},
+		{
+			name:      "spaces for tabs",
+			content:   "func f() {\n\tif x {\n\t\treturn 1\n\t}\n}\n",
+			oldString: "    if x {\n        return 1\n    }",
+			newString: "    if x {\n        y()\n        return 2\n    }\n    z()",
+			want:      "func f() {\n\tif x {\n\t\ty()\n\t\treturn 2\n\t}\n\tz()\n}\n",
+			wantTier:  MatchTierIndentation,
+		},
+		{
+			name:      "dedented old string",
+			content:   "class A:\n    def f(self):\n        pass\n",
+			oldString: "def f(self):\n    pass",
+			newString: "def f(self):\n    if x:\n        return 1\n    pass",
+			want:      "class A:\n    def f(self):\n        if x:\n            return 1\n        pass\n",
+			wantTier:  MatchTierIndentation,
+		},
+		{
+			name:      "delete with indentation fallback",
+			content:   "\tfoo\n\tbar\nx\n",
+			oldString: "foo\nbar",
+			want:      "x\n",
+			wantTier:  MatchTierIndentation,
+		},
+		{
+			name:      "ambiguous fallback",
+			content:   "\tfoo()\n\tbar()\n  foo()\n  bar()\n",
+			oldString: "foo()\nbar()",
+			newString: "baz()",
+			wantErr:   errOldStringMultipleMatches,
+		},
+		{
+			name:      "not found",
+			content:   "a\nb\n",
+			oldString: "c",
+			newString: "d",
+			wantErr:   errOldStringNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, tier, err := replaceMatched(tt.content, tt.oldString, tt.newString, tt.replaceAll)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantTier, tier)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"charm.land/fantasy"
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/crush/internal/httpcache"
 	"github.com/charmbracelet/crush/internal/permission"
 )
 
@@ -42,7 +43,9 @@ func fetchDescription() string {
 	})
 }
 
-func NewFetchTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
+// NewFetchTool creates the fetch tool. Responses are cached in cache, which
+// may be nil to disable caching.
+func NewFetchTool(permissions permission.Service, workingDir string, client *http.Client, cache *httpcache.Cache) fantasy.AgentTool {
 	if client == nil {
 		transport := http.DefaultTransport.(*http.Transport).Clone()
 		transport.MaxIdleConns = 100
@@ -96,6 +99,14 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 				return NewPermissionDeniedResponse(), nil
 			}
 
+			var cached *httpcache.Entry
+			if !params.NoCache {
+				cached = cache.Get(params.URL, format)
+			}
+			if cached.Fresh() {
+				return fantasy.NewTextResponse(truncateFetchContent(cached.Content)), nil
+			}
+
 			// maxFetchTimeoutSeconds is the maximum allowed timeout for fetch requests (2 minutes)
 			const maxFetchTimeoutSeconds = 120
 
@@ -116,6 +127,7 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			}
 
 			req.Header.Set("User-Agent", "crush/1.0")
+			cached.SetConditionalHeaders(req)
 
 			resp, err := client.Do(req)
 			if err != nil {
@@ -123,10 +135,27 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			}
 			defer resp.Body.Close()
 
+			if resp.StatusCode == http.StatusNotModified && cached != nil {
+				cache.Revalidated(cached, resp)
+				return fantasy.NewTextResponse(truncateFetchContent(cached.Content)), nil
+			}
+
 			if resp.StatusCode != http.StatusOK {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode)), nil
 			}
 
+			contentType := resp.Header.Get("Content-Type")
+
+			// PDFs are returned as extracted text whatever the format.
+			if isPDFContentType(contentType) {
+				content, err := extractPDFBody(resp.Body)
+				if err != nil {
+					return fantasy.NewTextErrorResponse("Failed to extract text from PDF: " + err.Error()), nil
+				}
+				cache.Store(params.URL, format, resp, content)
+				return fantasy.NewTextResponse(truncateFetchContent(content)), nil
+			}
+
 			body, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchSize))
 			if err != nil {
 				return fantasy.NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
@@ -138,7 +167,6 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			if !validUTF8 {
 				return fantasy.NewTextErrorResponse("Response content is not valid UTF-8"), nil
 			}
-			contentType := resp.Header.Get("Content-Type")
 
 			switch format {
 			case "text":
@@ -178,17 +206,22 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 					content = "<html>\n<body>\n" + body + "\n</body>\n</html>"
 				}
 			}
-			// truncate content if it exceeds max read size
-			if int64(len(content)) >= MaxFetchSize {
-				content = content[:MaxFetchSize]
-				content += fmt.Sprintf("\n\n[Content truncated to %d bytes]", MaxFetchSize)
-			}
+			cache.Store(params.URL, format, resp, content)
 
-			return fantasy.NewTextResponse(content), nil
+			return fantasy.NewTextResponse(truncateFetchContent(content)), nil
 		},
 	)
 }
 
+// truncateFetchContent truncates content if it exceeds the max read size.
+func truncateFetchContent(content string) string {
+	if int64(len(content)) >= MaxFetchSize {
+		content = content[:MaxFetchSize]
+		content += fmt.Sprintf("\n\n[Content truncated to %d bytes]", MaxFetchSize)
+	}
+	return content
+}
+
 func extractTextFromHTML(html string) (string, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
@@ -13,6 +13,8 @@ import (
 	"unicode/utf8"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/charmbracelet/crush/internal/extract"
+	"github.com/charmbracelet/crush/internal/httpcache"
 	"golang.org/x/net/html"
 )
 
@@ -21,8 +23,22 @@ const BrowserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/
 
 var multipleNewlinesRe = regexp.MustCompile(`\n{3,}`)
 
+// webFetchCacheFormat is the cache format of content converted by
+// FetchURLAndConvert.
+const webFetchCacheFormat = "web_fetch"
+
 // FetchURLAndConvert fetches a URL and converts HTML content to markdown.
-func FetchURLAndConvert(ctx context.Context, client *http.Client, url string) (string, error) {
+// Content is served from and stored in cache, which may be nil; noCache
+// skips the lookup but still stores the fresh content.
+func FetchURLAndConvert(ctx context.Context, client *http.Client, cache *httpcache.Cache, url string, noCache bool) (string, error) {
+	var cached *httpcache.Entry
+	if !noCache {
+		cached = cache.Get(url, webFetchCacheFormat)
+	}
+	if cached.Fresh() {
+		return cached.Content, nil
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -32,6 +48,7 @@ func FetchURLAndConvert(ctx context.Context, client *http.Client, url string) (s
 	req.Header.Set("User-Agent", BrowserUserAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	cached.SetConditionalHeaders(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -39,10 +56,26 @@ func FetchURLAndConvert(ctx context.Context, client *http.Client, url string) (s
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cache.Revalidated(cached, resp)
+		return cached.Content, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("request failed with status code: %d", resp.StatusCode)
 	}
 
+	contentType := resp.Header.Get("Content-Type")
+
+	if isPDFContentType(contentType) {
+		content, err := extractPDFBody(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract PDF text: %w", err)
+		}
+		cache.Store(url, webFetchCacheFormat, resp, content)
+		return content, nil
+	}
+
 	maxSize := int64(5 * 1024 * 1024) // 5MB
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
 	if err != nil {
@@ -55,8 +88,6 @@ func FetchURLAndConvert(ctx context.Context, client *http.Client, url string) (s
 		return "", errors.New("response content is not valid UTF-8")
 	}
 
-	contentType := resp.Header.Get("Content-Type")
-
 	// Convert HTML to markdown for better AI processing.
 	if strings.Contains(contentType, "text/html") {
 		// Remove noisy elements before conversion.
@@ -75,9 +106,29 @@ func FetchURLAndConvert(ctx context.Context, client *http.Client, url string) (s
 		// If formatting fails, keep original content.
 	}
 
+	cache.Store(url, webFetchCacheFormat, resp, content)
 	return content, nil
 }
 
+// isPDFContentType reports whether a Content-Type header value denotes a
+// PDF document.
+func isPDFContentType(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "application/pdf")
+}
+
+// extractPDFBody reads a PDF response body and returns its text, one
+// "--- Page N ---" section per page.
+func extractPDFBody(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, extract.MaxFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > extract.MaxFileSize {
+		return "", extract.ErrTooLarge
+	}
+	return extract.PDF(data)
+}
+
 // removeNoisyElements removes script, style, nav, header, footer, and other
 // noisy elements from HTML to improve content extraction.
 func removeNoisyElements(htmlContent string) string {
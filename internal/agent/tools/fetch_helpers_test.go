@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/httpcache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchURLAndConvertCache(t *testing.T) {
+	t.Parallel()
+
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<html><body><h1>Docs</h1></body></html>")
+	}))
+	t.Cleanup(server.Close)
+
+	cache := httpcache.New(t.TempDir(), 0)
+
+	content, err := FetchURLAndConvert(t.Context(), server.Client(), cache, server.URL, false)
+	require.NoError(t, err)
+	require.Equal(t, "# Docs", content)
+
+	content, err = FetchURLAndConvert(t.Context(), server.Client(), cache, server.URL, false)
+	require.NoError(t, err)
+	require.Equal(t, "# Docs", content)
+	require.Equal(t, int32(1), notModified.Load(), "second fetch should revalidate with the ETag")
+
+	content, err = FetchURLAndConvert(t.Context(), server.Client(), cache, server.URL, true)
+	require.NoError(t, err)
+	require.Equal(t, "# Docs", content)
+	require.Equal(t, int32(3), requests.Load())
+	require.Equal(t, int32(1), notModified.Load(), "no_cache should not send conditional headers")
+}
+
+func TestFetchURLAndConvertPDF(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = io.WriteString(w, "\xff\xfe not a pdf")
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := FetchURLAndConvert(t.Context(), server.Client(), nil, server.URL, false)
+	require.ErrorContains(t, err, "failed to extract PDF text")
+	require.NotContains(t, err.Error(), "UTF-8", "PDF bodies should not be checked as text")
+}
@@ -14,25 +14,28 @@ const LargeContentThreshold = 50000 // 50KB
 
 // AgenticFetchParams defines the parameters for the agentic fetch tool.
 type AgenticFetchParams struct {
-	URL    string `json:"url,omitempty" description:"The URL to fetch content from (optional - if not provided, the agent will search the web)"`
-	Prompt string `json:"prompt" description:"The prompt describing what information to find or extract"`
+	URL     string `json:"url,omitempty" description:"The URL to fetch content from (optional - if not provided, the agent will search the web)"`
+	Prompt  string `json:"prompt" description:"The prompt describing what information to find or extract"`
+	NoCache bool   `json:"no_cache,omitempty" description:"Bypass the fetch cache and always download fresh content"`
 }
 
 // AgenticFetchPermissionsParams defines the permission parameters for the agentic fetch tool.
 type AgenticFetchPermissionsParams struct {
-	URL    string `json:"url,omitempty"`
-	Prompt string `json:"prompt"`
+	URL     string `json:"url,omitempty"`
+	Prompt  string `json:"prompt"`
+	NoCache bool   `json:"no_cache,omitempty"`
 }
 
 // WebFetchParams defines the parameters for the web_fetch tool.
 type WebFetchParams struct {
-	URL string `json:"url" description:"The URL to fetch content from"`
+	URL     string `json:"url" description:"The URL to fetch content from"`
+	NoCache bool   `json:"no_cache,omitempty" description:"Bypass the fetch cache and always download fresh content"`
 }
 
 // WebSearchParams defines the parameters for the web_search tool.
 type WebSearchParams struct {
 	Query      string `json:"query" description:"The search query to find information on the web"`
-	MaxResults int    `json:"max_results,omitempty" description:"Maximum number of results to return (default: 10, max: 20)"`
+	MaxResults int    `json:"max_results,omitempty" description:"Maximum number of results to return (max: 20)"`
 }
 
 // FetchParams defines the parameters for the simple fetch tool.
@@ -40,6 +43,7 @@ type FetchParams struct {
 	URL     string `json:"url" description:"The URL to fetch content from"`
 	Format  string `json:"format" description:"The format to return the content in (text, markdown, or html)"`
 	Timeout int    `json:"timeout,omitempty" description:"Optional timeout in seconds (max 120)"`
+	NoCache bool   `json:"no_cache,omitempty" description:"Bypass the fetch cache and always download fresh content"`
 }
 
 // FetchPermissionsParams defines the permission parameters for the simple fetch tool.
@@ -47,4 +51,5 @@ type FetchPermissionsParams struct {
 	URL     string `json:"url"`
 	Format  string `json:"format"`
 	Timeout int    `json:"timeout,omitempty"`
+	NoCache bool   `json:"no_cache,omitempty"`
 }
@@ -0,0 +1,551 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type GitParams struct {
+	Operation string   `json:"operation" description:"One of: status, diff, log, show, blame, branch, commit"`
+	Paths     []string `json:"paths,omitempty" description:"Limit diff or log to these paths; for commit, stage these paths before committing"`
+	Staged    bool     `json:"staged,omitempty" description:"For diff: show staged changes instead of unstaged ones"`
+	Ref       string   `json:"ref,omitempty" description:"For diff: revision to compare against; for log: revision to start from; for show: revision to show (default HEAD); for blame: revision to blame at"`
+	Limit     int      `json:"limit,omitempty" description:"For log: maximum number of commits (default 20)"`
+	File      string   `json:"file,omitempty" description:"For blame: the file to blame"`
+	StartLine int      `json:"start_line,omitempty" description:"For blame: first line of the range (1-based)"`
+	EndLine   int      `json:"end_line,omitempty" description:"For blame: last line of the range (inclusive)"`
+	All       bool     `json:"all,omitempty" description:"For branch: include remote branches; for commit: stage all modified tracked files (git commit -a)"`
+	Message   string   `json:"message,omitempty" description:"For commit: the commit message; attribution trailers are added automatically"`
+}
+
+type GitPermissionsParams struct {
+	Operation string   `json:"operation"`
+	Message   string   `json:"message,omitempty"`
+	Paths     []string `json:"paths,omitempty"`
+	All       bool     `json:"all,omitempty"`
+}
+
+type GitResponseMetadata struct {
+	Operation string `json:"operation"`
+	// Truncated is set when the output exceeded MaxOutputLength.
+	Truncated bool `json:"truncated,omitempty"`
+	// Commit is the hash of the commit created by the commit operation.
+	Commit string `json:"commit,omitempty"`
+}
+
+const (
+	GitToolName = "git"
+
+	// GitCommitPermissionAction is the permission action requested before
+	// the git tool writes to the repository.
+	GitCommitPermissionAction = "commit"
+
+	defaultGitLogLimit = 20
+	maxGitLogLimit     = 200
+)
+
+// Git operations supported by the git tool.
+const (
+	GitOperationStatus = "status"
+	GitOperationDiff   = "diff"
+	GitOperationLog    = "log"
+	GitOperationShow   = "show"
+	GitOperationBlame  = "blame"
+	GitOperationBranch = "branch"
+	GitOperationCommit = "commit"
+)
+
+//go:embed git.md
+var gitDescription string
+
+func NewGitTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelID string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitToolName,
+		gitDescription,
+		func(ctx context.Context, params GitParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if _, err := exec.LookPath("git"); err != nil {
+				return fantasy.NewTextErrorResponse("git is not installed or not on PATH"), nil
+			}
+
+			var (
+				output string
+				meta   = GitResponseMetadata{Operation: params.Operation}
+				err    error
+			)
+			switch params.Operation {
+			case GitOperationStatus:
+				output, err = gitStatus(ctx, workingDir)
+			case GitOperationDiff:
+				output, err = gitDiff(ctx, workingDir, params)
+			case GitOperationLog:
+				output, err = gitLog(ctx, workingDir, params)
+			case GitOperationShow:
+				output, err = gitShow(ctx, workingDir, params)
+			case GitOperationBlame:
+				output, err = gitBlame(ctx, workingDir, params)
+			case GitOperationBranch:
+				output, err = gitBranches(ctx, workingDir, params.All)
+			case GitOperationCommit:
+				return gitCommit(ctx, permissions, workingDir, params, attribution, modelID, call)
+			case "":
+				return fantasy.NewTextErrorResponse("operation is required"), nil
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown operation %q; expected one of status, diff, log, show, blame, branch, commit", params.Operation)), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			if output == "" {
+				output = "no output"
+			}
+			truncated := truncateOutput(output)
+			meta.Truncated = truncated != output
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(truncated), meta), nil
+		},
+	)
+}
+
+// runGit runs git with args in dir and returns its stdout. A non-zero exit
+// is reported as an error carrying git's stderr.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_PAGER=cat", "LC_ALL=C")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// gitStatus summarizes `git status --porcelain=v2` as a branch line followed
+// by staged, unstaged, untracked and conflicted files.
+func gitStatus(ctx context.Context, dir string) (string, error) {
+	out, err := runGit(ctx, dir, "status", "--porcelain=v2", "--branch", "--untracked-files=all", "-z")
+	if err != nil {
+		return "", err
+	}
+	return formatGitStatus(parseGitStatus(out)), nil
+}
+
+type gitStatusEntry struct {
+	code string
+	path string
+	from string
+}
+
+type gitStatusSummary struct {
+	branch     string
+	upstream   string
+	ahead      int
+	behind     int
+	staged     []gitStatusEntry
+	unstaged   []gitStatusEntry
+	untracked  []string
+	conflicted []string
+}
+
+func parseGitStatus(out string) gitStatusSummary {
+	var s gitStatusSummary
+	records := strings.Split(out, "\x00")
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		switch {
+		case strings.HasPrefix(rec, "# branch.head "):
+			s.branch = strings.TrimPrefix(rec, "# branch.head ")
+		case strings.HasPrefix(rec, "# branch.upstream "):
+			s.upstream = strings.TrimPrefix(rec, "# branch.upstream ")
+		case strings.HasPrefix(rec, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(rec, "# branch.ab "), "+%d -%d", &s.ahead, &s.behind)
+		case strings.HasPrefix(rec, "1 "), strings.HasPrefix(rec, "2 "):
+			// 1 XY sub mH mI mW hH hI path
+			// 2 XY sub mH mI mW hH hI Xscore path, followed by the original path
+			fields := strings.SplitN(rec, " ", 9)
+			if rec[0] == '2' {
+				fields = strings.SplitN(rec, " ", 10)
+			}
+			if len(fields) < 9 {
+				continue
+			}
+			xy := fields[1]
+			entry := gitStatusEntry{path: fields[len(fields)-1]}
+			if rec[0] == '2' && i+1 < len(records) {
+				entry.from = records[i+1]
+				i++
+			}
+			if xy[0] != '.' {
+				e := entry
+				e.code = string(xy[0])
+				s.staged = append(s.staged, e)
+			}
+			if xy[1] != '.' {
+				e := entry
+				e.code = string(xy[1])
+				e.from = ""
+				s.unstaged = append(s.unstaged, e)
+			}
+		case strings.HasPrefix(rec, "u "):
+			fields := strings.SplitN(rec, " ", 11)
+			if len(fields) == 11 {
+				s.conflicted = append(s.conflicted, fields[10])
+			}
+		case strings.HasPrefix(rec, "? "):
+			s.untracked = append(s.untracked, strings.TrimPrefix(rec, "? "))
+		}
+	}
+	return s
+}
+
+func formatGitStatus(s gitStatusSummary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "branch: %s", s.branch)
+	if s.upstream != "" {
+		fmt.Fprintf(&sb, " (upstream %s, ahead %d, behind %d)", s.upstream, s.ahead, s.behind)
+	}
+	sb.WriteString("\n")
+
+	writeEntries := func(title string, entries []gitStatusEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "%s:\n", title)
+		for _, e := range entries {
+			if e.from != "" {
+				fmt.Fprintf(&sb, "  %s %s -> %s\n", e.code, e.from, e.path)
+			} else {
+				fmt.Fprintf(&sb, "  %s %s\n", e.code, e.path)
+			}
+		}
+	}
+	writeEntries("staged", s.staged)
+	writeEntries("unstaged", s.unstaged)
+	if len(s.conflicted) > 0 {
+		sb.WriteString("conflicted:\n")
+		for _, p := range s.conflicted {
+			fmt.Fprintf(&sb, "  %s\n", p)
+		}
+	}
+	if len(s.untracked) > 0 {
+		sb.WriteString("untracked:\n")
+		for _, p := range s.untracked {
+			fmt.Fprintf(&sb, "  %s\n", p)
+		}
+	}
+	if len(s.staged)+len(s.unstaged)+len(s.conflicted)+len(s.untracked) == 0 {
+		sb.WriteString("working tree clean\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// verifyGitRef checks that ref names a revision, or a range of revisions,
+// of the repository in dir. Read-only operations run without asking, so a
+// ref must never be taken for an option such as --output.
+func verifyGitRef(ctx context.Context, dir, ref string) error {
+	revs := []string{ref}
+	if from, to, ok := strings.Cut(ref, "..."); ok {
+		revs = []string{from, to}
+	} else if from, to, ok := strings.Cut(ref, ".."); ok {
+		revs = []string{from, to}
+	}
+	for _, rev := range revs {
+		if rev == "" {
+			// An empty side of a range stands for HEAD.
+			continue
+		}
+		if strings.HasPrefix(rev, "-") {
+			return fmt.Errorf("invalid ref %q", ref)
+		}
+		if _, err := runGit(ctx, dir, "rev-parse", "--verify", "--quiet", "--end-of-options", rev); err != nil {
+			return fmt.Errorf("unknown ref %q", rev)
+		}
+	}
+	return nil
+}
+
+func gitShow(ctx context.Context, dir string, params GitParams) (string, error) {
+	ref := cmp.Or(params.Ref, "HEAD")
+	if err := verifyGitRef(ctx, dir, ref); err != nil {
+		return "", err
+	}
+	return runGit(ctx, dir, "show", "--no-color", "--no-ext-diff", "--no-textconv", "--stat", "--patch", "--format=commit %H%nAuthor: %an <%ae>%nDate:   %ad%n%n%B", "--end-of-options", ref, "--")
+}
+
+func gitDiff(ctx context.Context, dir string, params GitParams) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-textconv"}
+	if params.Staged {
+		args = append(args, "--cached")
+	}
+	if params.Ref != "" {
+		if err := verifyGitRef(ctx, dir, params.Ref); err != nil {
+			return "", err
+		}
+		args = append(args, "--end-of-options", params.Ref)
+	}
+	args = append(args, "--")
+	args = append(args, params.Paths...)
+
+	stat, err := runGit(ctx, dir, append([]string{"diff", "--no-color", "--shortstat"}, args[2:]...)...)
+	if err != nil {
+		return "", err
+	}
+	patch, err := runGit(ctx, dir, args...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(patch) == "" {
+		return "no changes", nil
+	}
+	return strings.TrimSpace(stat) + "\n\n" + patch, nil
+}
+
+func gitLog(ctx context.Context, dir string, params GitParams) (string, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultGitLogLimit
+	}
+	limit = min(limit, maxGitLogLimit)
+
+	args := []string{"log", "--no-color", "-n", strconv.Itoa(limit), "--date=short", "--format=%h\x1f%ad\x1f%an\x1f%s"}
+	if params.Ref != "" {
+		if err := verifyGitRef(ctx, dir, params.Ref); err != nil {
+			return "", err
+		}
+		args = append(args, "--end-of-options", params.Ref)
+	}
+	args = append(args, "--")
+	args = append(args, params.Paths...)
+
+	out, err := runGit(ctx, dir, args...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %s %s: %s\n", fields[0], fields[1], fields[2], fields[3])
+	}
+	if sb.Len() == 0 {
+		return "no commits", nil
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func gitBlame(ctx context.Context, dir string, params GitParams) (string, error) {
+	if params.File == "" {
+		return "", errors.New("file is required for blame")
+	}
+	args := []string{"blame", "--line-porcelain"}
+	if params.StartLine > 0 {
+		end := ""
+		if params.EndLine >= params.StartLine {
+			end = strconv.Itoa(params.EndLine)
+		}
+		args = append(args, "-L", fmt.Sprintf("%d,%s", params.StartLine, end))
+	}
+	if params.Ref != "" {
+		// Unlike the other commands, blame doesn't accept --end-of-options
+		// in every git version; the verified ref can't start with a dash.
+		if err := verifyGitRef(ctx, dir, params.Ref); err != nil {
+			return "", err
+		}
+		args = append(args, params.Ref)
+	}
+	args = append(args, "--", filepathext.SmartJoin(dir, params.File))
+
+	out, err := runGit(ctx, dir, args...)
+	if err != nil {
+		return "", err
+	}
+	return formatGitBlame(out), nil
+}
+
+// formatGitBlame condenses `git blame --line-porcelain` output to one line
+// per source line: "<hash> <date> <author> <line>: <content>".
+func formatGitBlame(out string) string {
+	var (
+		sb                   strings.Builder
+		hash, author, date   string
+		lineNo               string
+		expectingHeaderStart = true
+	)
+	for line := range strings.SplitSeq(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			fmt.Fprintf(&sb, "%s %s %s %s: %s\n", hash, date, author, lineNo, line[1:])
+			expectingHeaderStart = true
+		case expectingHeaderStart && line != "":
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				hash = fields[0][:min(8, len(fields[0]))]
+				lineNo = fields[2]
+			}
+			expectingHeaderStart = false
+		case strings.HasPrefix(line, "author "):
+			author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if ts, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				date = time.Unix(ts, 0).UTC().Format(time.DateOnly)
+			}
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func gitBranches(ctx context.Context, dir string, all bool) (string, error) {
+	args := []string{"branch", "--no-color", "--format=%(HEAD)\x1f%(refname:short)\x1f%(objectname:short)\x1f%(upstream:short)\x1f%(upstream:track)\x1f%(contents:subject)"}
+	if all {
+		args = append(args, "--all")
+	}
+	out, err := runGit(ctx, dir, args...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+		marker := " "
+		if fields[0] == "*" {
+			marker = "*"
+		}
+		fmt.Fprintf(&sb, "%s %s %s", marker, fields[1], fields[2])
+		if fields[3] != "" {
+			fmt.Fprintf(&sb, " [%s", fields[3])
+			if fields[4] != "" {
+				fmt.Fprintf(&sb, " %s", strings.Trim(fields[4], "[]"))
+			}
+			sb.WriteString("]")
+		}
+		fmt.Fprintf(&sb, " %s\n", fields[5])
+	}
+	if sb.Len() == 0 {
+		return "no branches", nil
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func gitCommit(
+	ctx context.Context,
+	permissions permission.Service,
+	dir string,
+	params GitParams,
+	attribution *config.Attribution,
+	modelID string,
+	call fantasy.ToolCall,
+) (fantasy.ToolResponse, error) {
+	if strings.TrimSpace(params.Message) == "" {
+		return fantasy.NewTextErrorResponse("message is required for commit"), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for committing")
+	}
+
+	message := commitMessageWithAttribution(params.Message, attribution, modelID)
+	p, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        dir,
+		ToolCallID:  call.ID,
+		ToolName:    GitToolName,
+		Action:      GitCommitPermissionAction,
+		Description: fmt.Sprintf("Create git commit: %s", firstLine(params.Message)),
+		Params: GitPermissionsParams{
+			Operation: params.Operation,
+			Message:   message,
+			Paths:     params.Paths,
+			All:       params.All,
+		},
+	})
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return NewPermissionDeniedResponse(), nil
+	}
+
+	if len(params.Paths) > 0 {
+		if _, err := runGit(ctx, dir, append([]string{"add", "--"}, params.Paths...)...); err != nil {
+			return fantasy.NewTextErrorResponse(err.Error()), nil
+		}
+	}
+
+	args := []string{"commit", "--no-edit", "-F", "-"}
+	if params.All {
+		args = append(args, "--all")
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	cmd.Stdin = strings.NewReader(message)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("git commit failed:\n%s", strings.TrimSpace(string(out)))), nil
+	}
+
+	hash, _ := runGit(ctx, dir, "rev-parse", "--short", "HEAD")
+	hash = strings.TrimSpace(hash)
+	stat, _ := runGit(ctx, dir, "show", "--no-color", "--no-ext-diff", "--no-textconv", "--shortstat", "--format=", "HEAD")
+
+	result := fmt.Sprintf("Created commit %s: %s", hash, firstLine(params.Message))
+	if stat = strings.TrimSpace(stat); stat != "" {
+		result += "\n" + stat
+	}
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(result),
+		GitResponseMetadata{Operation: params.Operation, Commit: hash},
+	), nil
+}
+
+// commitMessageWithAttribution appends the configured attribution lines to
+// a commit message, matching the format the bash tool asks the model to
+// use.
+func commitMessageWithAttribution(message string, attribution *config.Attribution, modelID string) string {
+	message = strings.TrimRight(message, "\n")
+	if attribution == nil {
+		return message + "\n"
+	}
+	if attribution.GeneratedWith {
+		message += "\n\n💘 Generated with Crush"
+	}
+	switch attribution.TrailerStyle {
+	case config.TrailerStyleAssistedBy:
+		message += "\n\nAssisted-by: Crush:" + modelID
+	case config.TrailerStyleCoAuthoredBy:
+		message += "\n\nCo-Authored-By: Crush <crush@charm.land>"
+	}
+	return message + "\n"
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitStatus(t *testing.T) {
+	t.Parallel()
+
+	out := strings.Join([]string{
+		"# branch.oid 1234567890abcdef",
+		"# branch.head main",
+		"# branch.upstream origin/main",
+		"# branch.ab +2 -1",
+		"1 M. N... 100644 100644 100644 aaa bbb staged.go",
+		"1 .M N... 100644 100644 100644 aaa bbb unstaged.go",
+		"2 R. N... 100644 100644 100644 aaa bbb R100 new name.go",
+		"old name.go",
+		"u UU N... 100644 100644 100644 100644 aaa bbb ccc conflict.go",
+		"? untracked.txt",
+		"",
+	}, "\x00")
+
+	s := parseGitStatus(out)
+	require.Equal(t, "main", s.branch)
+	require.Equal(t, "origin/main", s.upstream)
+	require.Equal(t, 2, s.ahead)
+	require.Equal(t, 1, s.behind)
+	require.Equal(t, []gitStatusEntry{
+		{code: "M", path: "staged.go"},
+		{code: "R", path: "new name.go", from: "old name.go"},
+	}, s.staged)
+	require.Equal(t, []gitStatusEntry{{code: "M", path: "unstaged.go"}}, s.unstaged)
+	require.Equal(t, []string{"conflict.go"}, s.conflicted)
+	require.Equal(t, []string{"untracked.txt"}, s.untracked)
+
+	require.Equal(t, `branch: main (upstream origin/main, ahead 2, behind 1)
+staged:
+  M staged.go
+  R old name.go -> new name.go
+unstaged:
+  M unstaged.go
+conflicted:
+  conflict.go
+untracked:
+  untracked.txt`, formatGitStatus(s))
+}
+
+func TestFormatGitBlame(t *testing.T) {
+	t.Parallel()
+
+	out := `0123456789abcdef0123456789abcdef01234567 1 1 2
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1700000000
+author-tz +0000
+summary initial
+filename main.go
+	package main
+0123456789abcdef0123456789abcdef01234567 2 2
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1700000000
+author-tz +0000
+summary initial
+filename main.go
+	func main() {}
+`
+	require.Equal(t, "01234567 2023-11-14 Jane Doe 1: package main\n01234567 2023-11-14 Jane Doe 2: func main() {}", formatGitBlame(out))
+}
+
+func TestCommitMessageWithAttribution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		attribution *config.Attribution
+		want        string
+	}{
+		{
+			name: "none",
+			want: "fix bug\n",
+		},
+		{
+			name:        "assisted by",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy},
+			want:        "fix bug\n\nAssisted-by: Crush:model-1\n",
+		},
+		{
+			name:        "co-authored by with generated line",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleCoAuthoredBy, GeneratedWith: true},
+			want:        "fix bug\n\n💘 Generated with Crush\n\nCo-Authored-By: Crush <crush@charm.land>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, commitMessageWithAttribution("fix bug\n", tt.attribution, "model-1"))
+		})
+	}
+}
+
+func runGitTool(t *testing.T, workingDir string, params GitParams) fantasy.ToolResponse {
+	t.Helper()
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	tool := NewGitTool(&mockPermissionService{}, workingDir, &config.Attribution{TrailerStyle: config.TrailerStyleNone}, "model-1")
+
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	resp, err := tool.Run(ctx, fantasy.ToolCall{
+		ID:    "test-call",
+		Name:  GitToolName,
+		Input: string(input),
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// initGitRepo creates an empty repository on the main branch.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+
+	workingDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		_, err := runGit(t.Context(), workingDir, args...)
+		require.NoError(t, err)
+	}
+	return workingDir
+}
+
+func TestGitToolStatusAndCommit(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	workingDir := initGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "main.go"), []byte("package main\n"), 0o644))
+
+	resp := runGitTool(t, workingDir, GitParams{Operation: GitOperationStatus})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "untracked:\n  main.go")
+
+	resp = runGitTool(t, workingDir, GitParams{Operation: GitOperationCommit, Message: "add main", Paths: []string{"main.go"}})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "add main")
+
+	var meta GitResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.NotEmpty(t, meta.Commit)
+
+	resp = runGitTool(t, workingDir, GitParams{Operation: GitOperationLog})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, meta.Commit+" ")
+	require.Contains(t, resp.Content, "Test: add main")
+
+	resp = runGitTool(t, workingDir, GitParams{Operation: GitOperationStatus})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "working tree clean")
+}
+
+func TestGitToolRefs(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	workingDir := initGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "main.go"), []byte("package main\n"), 0o644))
+	resp := runGitTool(t, workingDir, GitParams{Operation: GitOperationCommit, Message: "add main", Paths: []string{"main.go"}})
+	require.False(t, resp.IsError, resp.Content)
+
+	pwned := filepath.Join(t.TempDir(), "pwned")
+	for _, op := range []string{GitOperationDiff, GitOperationLog, GitOperationShow, GitOperationBlame} {
+		for _, ref := range []string{"--output=" + pwned, "HEAD..--output=" + pwned, "no-such-ref"} {
+			resp := runGitTool(t, workingDir, GitParams{Operation: op, Ref: ref, File: "main.go"})
+			require.True(t, resp.IsError, "%s %s: %s", op, ref, resp.Content)
+		}
+	}
+	require.NoFileExists(t, pwned)
+
+	resp = runGitTool(t, workingDir, GitParams{Operation: GitOperationLog, Ref: "main..HEAD"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, "no commits", resp.Content)
+
+	resp = runGitTool(t, workingDir, GitParams{Operation: GitOperationBlame, Ref: "HEAD", File: "main.go"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "package main")
+}
@@ -0,0 +1,323 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/sandbox"
+)
+
+type HTTPRequestParams struct {
+	Method   string   `json:"method,omitempty" description:"HTTP method (default GET)"`
+	URL      string   `json:"url" description:"The URL to send the request to"`
+	Headers  []string `json:"headers,omitempty" description:"Request headers as 'Name: value' strings"`
+	Body     string   `json:"body,omitempty" description:"Request body"`
+	BodyType string   `json:"body_type,omitempty" description:"How to send body: json (validated, Content-Type application/json), form (a JSON object of strings or an already encoded string, sent as application/x-www-form-urlencoded) or text (sent as-is); defaults to json when body is valid JSON, text otherwise"`
+	Timeout  int      `json:"timeout,omitempty" description:"Optional timeout in seconds (default 30, max 120)"`
+}
+
+type HTTPRequestPermissionsParams struct {
+	Method  string   `json:"method"`
+	URL     string   `json:"url"`
+	Headers []string `json:"headers,omitempty"`
+	Body    string   `json:"body,omitempty"`
+}
+
+type HTTPRequestResponseMetadata struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Duration    int64  `json:"duration_ms"`
+}
+
+const (
+	HTTPRequestToolName = "http_request"
+
+	// MaxHTTPResponseSize is the largest response body returned to the
+	// model.
+	MaxHTTPResponseSize = 100 * 1024
+
+	defaultHTTPRequestTimeout = 30
+	maxHTTPRequestTimeout     = 120
+	maxHTTPRedirects          = 10
+)
+
+// errRedirectDenied stops a request whose redirect the user did not allow.
+var errRedirectDenied = errors.New("redirect denied")
+
+// HTTP request body types.
+const (
+	HTTPBodyJSON = "json"
+	HTTPBodyForm = "form"
+	HTTPBodyText = "text"
+)
+
+//go:embed http_request.md
+var httpRequestDescription string
+
+func NewHTTPRequestTool(permissions permission.Service, cfg config.ToolHTTPRequest, client *http.Client) fantasy.AgentTool {
+	if client == nil {
+		client = &http.Client{
+			Transport: http.DefaultTransport.(*http.Transport).Clone(),
+		}
+	}
+
+	return fantasy.NewParallelAgentTool(
+		HTTPRequestToolName,
+		httpRequestDescription,
+		func(ctx context.Context, params HTTPRequestParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			method := strings.ToUpper(cmp.Or(strings.TrimSpace(params.Method), http.MethodGet))
+			u, err := url.Parse(params.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fantasy.NewTextErrorResponse("url must be an absolute http:// or https:// URL"), nil
+			}
+
+			header, err := parseHTTPHeaders(params.Headers)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			body, contentType, err := encodeHTTPBody(params.Body, params.BodyType)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if contentType != "" && header.Get("Content-Type") == "" {
+				header.Set("Content-Type", contentType)
+			}
+
+			// authorize asks for permission to send a request to a host
+			// outside the allowlist, for the URL of the tool call as well as
+			// for every redirect the client follows.
+			authorize := func(ctx context.Context, method string, u *url.URL) (bool, error) {
+				host := hostWithPort(u)
+				if sandbox.HostAllowed(host, cfg.AllowedHosts) {
+					return true, nil
+				}
+				sessionID := GetSessionFromContext(ctx)
+				if sessionID == "" {
+					return false, fmt.Errorf("session ID is required for sending HTTP requests")
+				}
+				// Keying the request by host means "allow for session"
+				// covers every later request to the same host and port.
+				return permissions.Request(ctx, permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        host,
+					ToolCallID:  call.ID,
+					ToolName:    HTTPRequestToolName,
+					Action:      "request",
+					Description: fmt.Sprintf("Send %s request to %s", method, u),
+					Params: HTTPRequestPermissionsParams{
+						Method:  method,
+						URL:     u.String(),
+						Headers: params.Headers,
+						Body:    body,
+					},
+				})
+			}
+			p, err := authorize(ctx, method, u)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
+			}
+
+			timeout := params.Timeout
+			if timeout <= 0 {
+				timeout = defaultHTTPRequestTimeout
+			}
+			timeout = min(timeout, maxHTTPRequestTimeout)
+			requestCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			var bodyReader io.Reader
+			if body != "" {
+				bodyReader = strings.NewReader(body)
+			}
+			req, err := http.NewRequestWithContext(requestCtx, method, u.String(), bodyReader)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid request: %v", err)), nil
+			}
+			req.Header = header
+			if req.Header.Get("User-Agent") == "" {
+				req.Header.Set("User-Agent", "crush/1.0")
+			}
+
+			var redirectErr error
+			redirectClient := *client
+			redirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxHTTPRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxHTTPRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+				}
+				p, err := authorize(ctx, req.Method, req.URL)
+				if err != nil {
+					redirectErr = err
+					return err
+				}
+				if !p {
+					return errRedirectDenied
+				}
+				return nil
+			}
+
+			start := time.Now()
+			resp, err := redirectClient.Do(req)
+			if err != nil {
+				if redirectErr != nil {
+					return fantasy.ToolResponse{}, redirectErr
+				}
+				if errors.Is(err, errRedirectDenied) {
+					return NewPermissionDeniedResponse(), nil
+				}
+				if errors.Is(requestCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("request timed out after %ds", timeout)), nil
+				}
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("request failed: %v", err)), nil
+			}
+			defer resp.Body.Close()
+
+			data, err := io.ReadAll(io.LimitReader(resp.Body, MaxHTTPResponseSize+1))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read response body: %v", err)), nil
+			}
+			truncated := len(data) > MaxHTTPResponseSize
+			if truncated {
+				data = data[:MaxHTTPResponseSize]
+			}
+
+			metadata := HTTPRequestResponseMetadata{
+				Method:      method,
+				URL:         u.String(),
+				StatusCode:  resp.StatusCode,
+				ContentType: resp.Header.Get("Content-Type"),
+				Size:        len(data),
+				Truncated:   truncated,
+				Duration:    time.Since(start).Milliseconds(),
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(formatHTTPResponse(resp, data, truncated)), metadata), nil
+		},
+	)
+}
+
+func parseHTTPHeaders(lines []string) (http.Header, error) {
+	header := make(http.Header)
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q: expected 'Name: value'", line)
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	return header, nil
+}
+
+// encodeHTTPBody validates and encodes body according to bodyType and
+// returns the default Content-Type for it.
+func encodeHTTPBody(body, bodyType string) (string, string, error) {
+	if body == "" {
+		return "", "", nil
+	}
+	if bodyType == "" {
+		bodyType = HTTPBodyText
+		if json.Valid([]byte(body)) {
+			bodyType = HTTPBodyJSON
+		}
+	}
+
+	switch bodyType {
+	case HTTPBodyJSON:
+		if !json.Valid([]byte(body)) {
+			return "", "", errors.New("body is not valid JSON")
+		}
+		return body, "application/json", nil
+	case HTTPBodyForm:
+		var fields map[string]string
+		if err := json.Unmarshal([]byte(body), &fields); err == nil {
+			values := make(url.Values, len(fields))
+			for k, v := range fields {
+				values.Set(k, v)
+			}
+			return values.Encode(), "application/x-www-form-urlencoded", nil
+		}
+		if _, err := url.ParseQuery(body); err != nil {
+			return "", "", fmt.Errorf("form body must be a JSON object of strings or URL-encoded: %v", err)
+		}
+		return body, "application/x-www-form-urlencoded", nil
+	case HTTPBodyText:
+		return body, "", nil
+	default:
+		return "", "", fmt.Errorf("unknown body_type %q; expected json, form or text", bodyType)
+	}
+}
+
+// hostWithPort returns the host and port of u, filling in the scheme's
+// default port.
+func hostWithPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// formatHTTPResponse renders the status line, headers and body, with JSON
+// bodies pretty-printed.
+func formatHTTPResponse(resp *http.Response, body []byte, truncated bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", resp.Proto, resp.Status)
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(&sb, "%s: %s\n", name, value)
+		}
+	}
+	sb.WriteString("\n")
+
+	switch {
+	case len(body) == 0:
+		sb.WriteString("(empty body)")
+	case !utf8.Valid(body) && !truncated:
+		fmt.Fprintf(&sb, "(binary body, %d bytes)", len(body))
+	default:
+		var pretty bytes.Buffer
+		if !truncated && json.Indent(&pretty, body, "", "  ") == nil {
+			sb.Write(pretty.Bytes())
+		} else {
+			sb.WriteString(strings.ToValidUTF8(string(body), "�"))
+		}
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\n\n[Body truncated to %d bytes]", MaxHTTPResponseSize)
+	}
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeHTTPBody(t *testing.T) {
+	t.Parallel()
+
+	body, contentType, err := encodeHTTPBody(`{"a":1}`, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, body)
+	require.Equal(t, "application/json", contentType)
+
+	body, contentType, err = encodeHTTPBody(`{"b":"2","a":"1 x"}`, HTTPBodyForm)
+	require.NoError(t, err)
+	require.Equal(t, "a=1+x&b=2", body)
+	require.Equal(t, "application/x-www-form-urlencoded", contentType)
+
+	body, contentType, err = encodeHTTPBody("hello", "")
+	require.NoError(t, err)
+	require.Equal(t, "hello", body)
+	require.Empty(t, contentType)
+
+	_, _, err = encodeHTTPBody("{oops", HTTPBodyJSON)
+	require.Error(t, err)
+}
+
+func TestHTTPRequestTool(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Method", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"auth":         r.Header.Get("Authorization"),
+			"content_type": r.Header.Get("Content-Type"),
+			"body":         string(body),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.ToolHTTPRequest{AllowedHosts: []string{"127.0.0.1:*"}}
+	tool := NewHTTPRequestTool(&mockPermissionService{}, cfg, server.Client())
+
+	input, err := json.Marshal(HTTPRequestParams{
+		Method:  "post",
+		URL:     server.URL + "/items",
+		Headers: []string{"Authorization: Bearer token"},
+		Body:    `{"name":"x"}`,
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{
+		ID:    "test-call",
+		Name:  HTTPRequestToolName,
+		Input: string(input),
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "201 Created")
+	require.Contains(t, resp.Content, "X-Request-Method: POST")
+	require.Contains(t, resp.Content, `  "auth": "Bearer token",`)
+	require.Contains(t, resp.Content, `  "content_type": "application/json"`)
+
+	var meta HTTPRequestResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, http.StatusCreated, meta.StatusCode)
+	require.Equal(t, "POST", meta.Method)
+}
+
+// hostPermissionService denies every permission request and records the
+// hosts it was asked about.
+type hostPermissionService struct {
+	*mockPermissionService
+	hosts []string
+}
+
+func (m *hostPermissionService) Request(ctx context.Context, req permission.CreatePermissionRequest) (bool, error) {
+	m.hosts = append(m.hosts, req.Path)
+	return false, nil
+}
+
+func TestHTTPRequestToolRedirect(t *testing.T) {
+	t.Parallel()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "secret")
+	}))
+	t.Cleanup(target.Close)
+	targetURL, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// localhost resolves to the target server, but is another host as
+		// far as the allowlist is concerned.
+		http.Redirect(w, r, "http://localhost:"+targetURL.Port()+"/", http.StatusFound)
+	}))
+	t.Cleanup(server.Close)
+
+	permissions := &hostPermissionService{}
+	cfg := config.ToolHTTPRequest{AllowedHosts: []string{"127.0.0.1:*"}}
+	tool := NewHTTPRequestTool(permissions, cfg, server.Client())
+
+	input, err := json.Marshal(HTTPRequestParams{URL: server.URL, Timeout: -1})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{
+		ID:    "test-call",
+		Name:  HTTPRequestToolName,
+		Input: string(input),
+	})
+	require.NoError(t, err)
+	require.Equal(t, NewPermissionDeniedResponse().Content, resp.Content)
+	require.NotContains(t, resp.Content, "secret")
+	require.Equal(t, []string{"localhost:" + targetURL.Port()}, permissions.hosts)
+}
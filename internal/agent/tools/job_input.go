@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+const (
+	JobInputToolName = "job_input"
+
+	// jobInputSettle is how long job_input waits for output to stop
+	// changing after writing input, and jobInputMaxWait the longest it
+	// waits for output in total.
+	jobInputSettle  = 300 * time.Millisecond
+	jobInputMaxWait = 5 * time.Second
+)
+
+//go:embed job_input.md
+var jobInputDescription string
+
+type JobInputParams struct {
+	ShellID string `json:"shell_id" description:"The ID of the interactive background shell to write to"`
+	Input   string `json:"input,omitempty" description:"Text to write to the job's standard input; end it with a newline to submit a line"`
+	Key     string `json:"key,omitempty" description:"Key to send after input: enter, tab, escape, up, down, ctrl-c (interrupt) or ctrl-d (end of input)"`
+}
+
+type JobInputPermissionsParams struct {
+	ShellID string `json:"shell_id"`
+	Command string `json:"command"`
+	Input   string `json:"input,omitempty"`
+	Key     string `json:"key,omitempty"`
+}
+
+type JobInputResponseMetadata struct {
+	ShellID     string `json:"shell_id"`
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	Input       string `json:"input,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Done        bool   `json:"done"`
+}
+
+// jobInputKeys are the bytes a terminal sends for keys accepted by
+// job_input. ctrl-c and ctrl-d are handled separately.
+var jobInputKeys = map[string]string{
+	"enter":  "\n",
+	"tab":    "\t",
+	"escape": "\x1b",
+	"up":     "\x1b[A",
+	"down":   "\x1b[B",
+}
+
+// NewJobInputTool returns the tool writing to interactive background jobs.
+// Input can make a job run anything, such as a line typed into a shell, so
+// it needs the same permission as a bash command.
+func NewJobInputTool(permissions permission.Service) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		JobInputToolName,
+		jobInputDescription,
+		func(ctx context.Context, params JobInputParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.ShellID == "" {
+				return fantasy.NewTextErrorResponse("missing shell_id"), nil
+			}
+			if params.Input == "" && params.Key == "" {
+				return fantasy.NewTextErrorResponse("provide input, key or both"), nil
+			}
+
+			bgShell, ok := shell.GetBackgroundShellManager().Get(params.ShellID)
+			if !ok {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("background shell not found: %s", params.ShellID)), nil
+			}
+			if !bgShell.Interactive() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("background shell %s does not accept input; start the command with the bash tool's interactive=true", params.ShellID)), nil
+			}
+			if bgShell.IsDone() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("background shell %s has already exited; use job_output to read its output", params.ShellID)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for sending input to background shells")
+			}
+			p, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        bgShell.WorkingDir,
+				ToolCallID:  call.ID,
+				ToolName:    JobInputToolName,
+				Action:      "write",
+				Description: fmt.Sprintf("Send input to background shell %s: %s", params.ShellID, bgShell.Command),
+				Params: JobInputPermissionsParams{
+					ShellID: params.ShellID,
+					Command: bgShell.Command,
+					Input:   params.Input,
+					Key:     params.Key,
+				},
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
+			}
+
+			stdoutBefore, stderrBefore, _, _ := bgShell.GetOutput()
+
+			err = sendJobInput(bgShell, params)
+			if errors.Is(err, shell.ErrNoTerminal) {
+				return fantasy.NewTextErrorResponse("ctrl-c needs a terminal, which this platform does not provide; use job_kill to stop the job"), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to write to background shell %s: %s", params.ShellID, err)), nil
+			}
+
+			output, done := waitForJobOutputToSettle(ctx, bgShell, len(stdoutBefore), len(stderrBefore))
+			if bgShell.Terminal() {
+				output = cleanTerminalOutput(output)
+			}
+			output = TruncateOutput(output)
+			if output == "" {
+				output = BashNoOutput
+			}
+
+			status := "running"
+			if done {
+				status = "completed"
+			}
+			metadata := JobInputResponseMetadata{
+				ShellID:     params.ShellID,
+				Command:     bgShell.Command,
+				Description: bgShell.Description,
+				Input:       params.Input,
+				Key:         params.Key,
+				Done:        done,
+			}
+			result := fmt.Sprintf("Status: %s\n\nNew output:\n%s", status, output)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result), metadata), nil
+		},
+	)
+}
+
+func sendJobInput(bgShell *shell.BackgroundShell, params JobInputParams) error {
+	if params.Input != "" {
+		if err := bgShell.WriteInput(params.Input); err != nil {
+			return err
+		}
+	}
+	switch params.Key {
+	case "":
+		return nil
+	case "ctrl-c":
+		return bgShell.Interrupt()
+	case "ctrl-d":
+		return bgShell.CloseInput()
+	default:
+		seq, ok := jobInputKeys[params.Key]
+		if !ok {
+			return fmt.Errorf("unknown key %q", params.Key)
+		}
+		return bgShell.WriteInput(seq)
+	}
+}
+
+// waitForJobOutputToSettle waits until the job's output stops changing or
+// the job exits, and returns the output written after the given offsets.
+func waitForJobOutputToSettle(ctx context.Context, bgShell *shell.BackgroundShell, stdoutOffset, stderrOffset int) (string, bool) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(jobInputMaxWait)
+
+	lastLen := -1
+	lastChange := time.Now()
+	for {
+		stdout, stderr, done, _ := bgShell.GetOutput()
+		if n := len(stdout) + len(stderr); n != lastLen {
+			lastLen = n
+			lastChange = time.Now()
+		}
+
+		settled := done || time.Since(lastChange) >= jobInputSettle
+		if !settled {
+			select {
+			case <-ticker.C:
+				continue
+			case <-deadline:
+			case <-ctx.Done():
+			}
+		}
+
+		var parts []string
+		if s := stdout[min(stdoutOffset, len(stdout)):]; s != "" {
+			parts = append(parts, s)
+		}
+		if s := stderr[min(stderrOffset, len(stderr)):]; s != "" {
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, "\n"), done
+	}
+}
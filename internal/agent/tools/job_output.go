@@ -3,23 +3,36 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"net"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/outputstore"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
 	JobOutputToolName = "job_output"
+
+	defaultJobWaitTimeout = 60 * time.Second
+	maxJobWaitTimeout     = 600 * time.Second
 )
 
 //go:embed job_output.md
 var jobOutputDescription string
 
 type JobOutputParams struct {
-	ShellID string `json:"shell_id" description:"The ID of the background shell to retrieve output from"`
-	Wait    bool   `json:"wait" description:"If true, block until the background shell completes before returning output"`
+	ShellID     string `json:"shell_id" description:"The ID of the background shell to retrieve output from"`
+	Wait        bool   `json:"wait" description:"If true, block until the background shell completes before returning output"`
+	WaitFor     string `json:"wait_for,omitempty" description:"Regular expression to wait for in the output, such as a prompt or a ready message"`
+	WaitForPort int    `json:"wait_for_port,omitempty" description:"TCP port on localhost to wait for until it accepts connections"`
+	Timeout     int    `json:"timeout,omitempty" description:"Seconds to wait for wait, wait_for or wait_for_port (default 60 for wait_for and wait_for_port, max 600)"`
 }
 
 type JobOutputResponseMetadata struct {
@@ -30,7 +43,7 @@ type JobOutputResponseMetadata struct {
 	WorkingDirectory string `json:"working_directory"`
 }
 
-func NewJobOutputTool() fantasy.AgentTool {
+func NewJobOutputTool(outputs *outputstore.Store) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		JobOutputToolName,
 		jobOutputDescription,
@@ -45,11 +58,40 @@ func NewJobOutputTool() fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("background shell not found: %s", params.ShellID)), nil
 			}
 
-			if params.Wait {
-				bgShell.WaitContext(ctx)
+			var waitFor *regexp.Regexp
+			if params.WaitFor != "" {
+				re, err := regexp.Compile(params.WaitFor)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid wait_for pattern: %s", err)), nil
+				}
+				waitFor = re
+			}
+			if params.WaitForPort < 0 || params.WaitForPort > 65535 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid wait_for_port: %d", params.WaitForPort)), nil
+			}
+
+			var waitNote string
+			switch {
+			case waitFor != nil || params.WaitForPort != 0:
+				timeout := defaultJobWaitTimeout
+				if params.Timeout > 0 {
+					timeout = min(time.Duration(params.Timeout)*time.Second, maxJobWaitTimeout)
+				}
+				waitNote = waitForJob(ctx, bgShell, waitFor, params.WaitForPort, timeout)
+			case params.Wait:
+				waitCtx := ctx
+				if params.Timeout > 0 {
+					var cancel context.CancelFunc
+					waitCtx, cancel = context.WithTimeout(ctx, min(time.Duration(params.Timeout)*time.Second, maxJobWaitTimeout))
+					defer cancel()
+				}
+				bgShell.WaitContext(waitCtx)
 			}
 
 			stdout, stderr, done, err := bgShell.GetOutput()
+			if bgShell.Terminal() {
+				stdout = cleanTerminalOutput(stdout)
+			}
 
 			var outputParts []string
 			if stdout != "" {
@@ -71,7 +113,8 @@ func NewJobOutputTool() fantasy.AgentTool {
 			}
 
 			output := strings.Join(outputParts, "\n")
-			output = TruncateOutput(output)
+			spill := &outputSpill{outputs: outputs, sessionID: GetSessionFromContext(ctx), name: call.ID}
+			output = spill.truncate("output", output)
 
 			metadata := JobOutputResponseMetadata{
 				ShellID:          params.ShellID,
@@ -86,7 +129,70 @@ func NewJobOutputTool() fantasy.AgentTool {
 			}
 
 			result := fmt.Sprintf("Status: %s\n\n%s", status, output)
+			if waitNote != "" {
+				result = fmt.Sprintf("Status: %s\n%s\n\n%s", status, waitNote, output)
+			}
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result), metadata), nil
 		},
 	)
 }
+
+// waitForJob blocks until the job's output matches pattern, port accepts
+// connections, the job exits or timeout passes, and describes which of
+// these happened.
+func waitForJob(ctx context.Context, bgShell *shell.BackgroundShell, pattern *regexp.Regexp, port int, timeout time.Duration) string {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	var dialer net.Dialer
+	for {
+		stdout, stderr, done, _ := bgShell.GetOutput()
+		if pattern != nil {
+			if bgShell.Terminal() {
+				stdout = cleanTerminalOutput(stdout)
+			}
+			if pattern.MatchString(stdout) || pattern.MatchString(stderr) {
+				return fmt.Sprintf("Matched: %s", pattern)
+			}
+		}
+		if port != 0 {
+			dialCtx, cancelDial := context.WithTimeout(ctx, 100*time.Millisecond)
+			conn, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(port)))
+			cancelDial()
+			if err == nil {
+				conn.Close()
+				return fmt.Sprintf("Port %d is accepting connections", port)
+			}
+		}
+		if done {
+			return "The job exited before the condition was met"
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Sprintf("Timed out after %s waiting for the condition", timeout)
+			}
+			return "Stopped waiting: " + ctx.Err().Error()
+		}
+	}
+}
+
+// cleanTerminalOutput strips the escape sequences programs write to a
+// terminal and, like a terminal, keeps only the text after the last carriage
+// return of each line, so progress bars show their final state.
+func cleanTerminalOutput(s string) string {
+	lines := strings.Split(ansi.Strip(s), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
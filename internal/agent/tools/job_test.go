@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"regexp"
 	"runtime"
 	"testing"
 	"time"
@@ -332,3 +333,63 @@ func TestBackgroundShell_AutoBackground(t *testing.T) {
 		require.Equal(t, bgShell.ID, retrieved.ID)
 	})
 }
+
+func TestCleanTerminalOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "hello\nworld", "hello\nworld"},
+		{"colors", "\x1b[32mok\x1b[0m done", "ok done"},
+		{"carriage return line endings", "a\r\nb\r\n", "a\nb\n"},
+		{"progress bar", "10%\r50%\r100%\ndone", "100%\ndone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, cleanTerminalOutput(tt.input))
+		})
+	}
+}
+
+func TestWaitForJob(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	ctx := context.Background()
+	bgManager := shell.GetBackgroundShellManager()
+
+	t.Run("matches pattern", func(t *testing.T) {
+		t.Parallel()
+		bgShell, err := bgManager.Start(ctx, workingDir, nil, "sleep 0.2; echo 'Listening on 8080'; sleep 20", "")
+		require.NoError(t, err)
+		defer bgManager.Kill(bgShell.ID)
+
+		note := waitForJob(ctx, bgShell, regexp.MustCompile(`Listening on \d+`), 0, 10*time.Second)
+		require.Equal(t, `Matched: Listening on \d+`, note)
+		require.False(t, bgShell.IsDone())
+	})
+
+	t.Run("job exits first", func(t *testing.T) {
+		t.Parallel()
+		bgShell, err := bgManager.Start(ctx, workingDir, nil, "echo nothing", "")
+		require.NoError(t, err)
+		defer bgManager.Kill(bgShell.ID)
+
+		note := waitForJob(ctx, bgShell, regexp.MustCompile("never"), 0, 10*time.Second)
+		require.Equal(t, "The job exited before the condition was met", note)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		t.Parallel()
+		bgShell, err := bgManager.Start(ctx, workingDir, nil, "sleep 20", "")
+		require.NoError(t, err)
+		defer bgManager.Kill(bgShell.ID)
+
+		note := waitForJob(ctx, bgShell, regexp.MustCompile("never"), 0, 300*time.Millisecond)
+		require.Contains(t, note, "Timed out after 300ms")
+	})
+}
@@ -125,6 +125,7 @@ func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolRe
 		}
 	}
 
+	ctx = mcp.WithCaller(ctx, mcp.Caller{SessionID: sessionID, ToolCallID: params.ID})
 	result, err := mcp.RunTool(ctx, m.cfg, m.mcpName, m.tool.Name, params.Input)
 	if err != nil {
 		return fantasy.NewTextErrorResponse(err.Error()), nil
@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Actions answering an elicitation request.
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+// ElicitationRequest is a request of an MCP server for structured input
+// from the user. It is published when the server sends it and again, as a
+// deleted event, once it is answered or abandoned.
+type ElicitationRequest struct {
+	ID string
+	// Name is the name of the MCP server.
+	Name string
+	// SessionID is the session of the tool call the server is handling,
+	// if any.
+	SessionID string
+	Message   string
+	Fields    []ElicitationField
+}
+
+// ElicitationField is a property of the schema requested by an
+// [ElicitationRequest].
+type ElicitationField struct {
+	Name        string
+	Title       string
+	Description string
+	// Type is one of "string", "number", "integer" or "boolean".
+	Type     string
+	Enum     []string
+	Required bool
+	Default  any
+}
+
+// ElicitationResponse answers an [ElicitationRequest].
+type ElicitationResponse struct {
+	ID string
+	// Action is one of [ElicitationAccept], [ElicitationDecline] or
+	// [ElicitationCancel].
+	Action string
+	// Content holds the values of the fields when the request is
+	// accepted.
+	Content map[string]any
+}
+
+var (
+	elicitations        = pubsub.NewBroker[ElicitationRequest]()
+	pendingElicitations = csync.NewMap[string, chan ElicitationResponse]()
+)
+
+// SubscribeElicitations returns a channel for the elicitation requests of
+// MCP servers.
+func SubscribeElicitations(ctx context.Context) <-chan pubsub.Event[ElicitationRequest] {
+	return elicitations.Subscribe(ctx)
+}
+
+// RespondElicitation answers a pending elicitation request. It reports
+// false when the request is no longer pending, because another client
+// answered it or its server gave up.
+func RespondElicitation(resp ElicitationResponse) bool {
+	ch, ok := pendingElicitations.Take(resp.ID)
+	if !ok {
+		return false
+	}
+	ch <- resp
+	return true
+}
+
+// Label returns the name of f as shown to the user.
+func (f ElicitationField) Label() string {
+	return cmp.Or(f.Title, f.Name)
+}
+
+// Options returns the values f accepts when they are limited, in order.
+func (f ElicitationField) Options() []string {
+	if f.Type == "boolean" {
+		return []string{"true", "false"}
+	}
+	return f.Enum
+}
+
+// Parse converts the text entered for f into a value of its type. Empty
+// text yields nil, unless f is required.
+func (f ElicitationField) Parse(text string) (any, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		if f.Required {
+			return nil, fmt.Errorf("%s is required", f.Label())
+		}
+		return nil, nil
+	}
+	switch f.Type {
+	case "boolean":
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", f.Label())
+		}
+		return v, nil
+	case "integer":
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", f.Label())
+		}
+		return v, nil
+	case "number":
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.Label())
+		}
+		return v, nil
+	}
+	if len(f.Enum) > 0 && !slices.Contains(f.Enum, text) {
+		return nil, fmt.Errorf("%s must be one of %s", f.Label(), strings.Join(f.Enum, ", "))
+	}
+	return text, nil
+}
+
+// elicitationHandler returns the handler of the elicitation requests of
+// the named MCP server, which waits for a client to answer through
+// [RespondElicitation].
+func elicitationHandler(name string) func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+	return func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		if req.Params.Mode == "url" {
+			// Only form elicitation is advertised.
+			return &mcp.ElicitResult{Action: ElicitationDecline}, nil
+		}
+		fields, err := elicitationFields(req.Params.RequestedSchema)
+		if err != nil {
+			return nil, err
+		}
+
+		caller, _ := callers.Get(name)
+		er := ElicitationRequest{
+			ID:        uuid.New().String(),
+			Name:      name,
+			SessionID: caller.SessionID,
+			Message:   req.Params.Message,
+			Fields:    fields,
+		}
+		ch := make(chan ElicitationResponse, 1)
+		pendingElicitations.Set(er.ID, ch)
+		defer func() {
+			pendingElicitations.Del(er.ID)
+			elicitations.Publish(pubsub.DeletedEvent, er)
+		}()
+		elicitations.Publish(pubsub.CreatedEvent, er)
+
+		select {
+		case resp := <-ch:
+			switch resp.Action {
+			case ElicitationAccept:
+				return &mcp.ElicitResult{Action: ElicitationAccept, Content: resp.Content}, nil
+			case ElicitationDecline:
+				return &mcp.ElicitResult{Action: ElicitationDecline}, nil
+			default:
+				return &mcp.ElicitResult{Action: ElicitationCancel}, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// elicitationFields returns the fields of the flat object schema requested
+// by an elicitation request, sorted by name.
+func elicitationFields(schema any) ([]ElicitationField, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	var s struct {
+		Properties map[string]struct {
+			Type        string   `json:"type"`
+			Title       string   `json:"title"`
+			Description string   `json:"description"`
+			Enum        []string `json:"enum"`
+			Default     any      `json:"default"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+
+	fields := make([]ElicitationField, 0, len(s.Properties))
+	for name, p := range s.Properties {
+		fields = append(fields, ElicitationField{
+			Name:        name,
+			Title:       p.Title,
+			Description: p.Description,
+			Type:        cmp.Or(p.Type, "string"),
+			Enum:        p.Enum,
+			Required:    slices.Contains(s.Required, name),
+			Default:     p.Default,
+		})
+	}
+	slices.SortFunc(fields, func(a, b ElicitationField) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return fields, nil
+}
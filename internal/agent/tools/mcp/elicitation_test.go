@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElicitationFields(t *testing.T) {
+	t.Parallel()
+
+	fields, err := elicitationFields(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "title": "Name", "default": "crush"},
+			"age":   map[string]any{"type": "integer", "description": "Age in years"},
+			"color": map[string]any{"type": "string", "enum": []any{"red", "blue"}},
+		},
+		"required": []any{"name"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []ElicitationField{
+		{Name: "age", Description: "Age in years", Type: "integer"},
+		{Name: "color", Type: "string", Enum: []string{"red", "blue"}},
+		{Name: "name", Title: "Name", Type: "string", Required: true, Default: "crush"},
+	}, fields)
+
+	fields, err = elicitationFields(nil)
+	require.NoError(t, err)
+	require.Empty(t, fields)
+}
+
+func TestElicitationFieldParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		field   ElicitationField
+		text    string
+		want    any
+		wantErr string
+	}{
+		{name: "string", field: ElicitationField{Name: "s", Type: "string"}, text: " hi ", want: "hi"},
+		{name: "empty optional", field: ElicitationField{Name: "s", Type: "string"}, text: "", want: nil},
+		{name: "empty required", field: ElicitationField{Name: "s", Title: "Subject", Type: "string", Required: true}, wantErr: "Subject is required"},
+		{name: "integer", field: ElicitationField{Name: "n", Type: "integer"}, text: "42", want: int64(42)},
+		{name: "bad integer", field: ElicitationField{Name: "n", Type: "integer"}, text: "4.2", wantErr: "n must be an integer"},
+		{name: "number", field: ElicitationField{Name: "n", Type: "number"}, text: "4.2", want: 4.2},
+		{name: "boolean", field: ElicitationField{Name: "b", Type: "boolean"}, text: "true", want: true},
+		{name: "bad boolean", field: ElicitationField{Name: "b", Type: "boolean"}, text: "maybe", wantErr: "b must be true or false"},
+		{name: "enum", field: ElicitationField{Name: "c", Type: "string", Enum: []string{"red", "blue"}}, text: "blue", want: "blue"},
+		{name: "bad enum", field: ElicitationField{Name: "c", Type: "string", Enum: []string{"red", "blue"}}, text: "green", wantErr: "c must be one of red, blue"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.field.Parse(tt.text)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRespondElicitation(t *testing.T) {
+	t.Parallel()
+
+	events := SubscribeElicitations(t.Context())
+	type outcome struct {
+		result *mcp.ElicitResult
+		err    error
+	}
+	outcomes := make(chan outcome, 1)
+	go func() {
+		result, err := elicitationHandler("test")(t.Context(), &mcp.ElicitRequest{
+			Params: &mcp.ElicitParams{
+				Message: "Who are you?",
+				RequestedSchema: map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"name": map[string]any{"type": "string"}},
+				},
+			},
+		})
+		outcomes <- outcome{result, err}
+	}()
+
+	event := <-events
+	require.Equal(t, "test", event.Payload.Name)
+	require.Equal(t, "Who are you?", event.Payload.Message)
+	require.True(t, RespondElicitation(ElicitationResponse{
+		ID:      event.Payload.ID,
+		Action:  ElicitationAccept,
+		Content: map[string]any{"name": "crush"},
+	}))
+	require.False(t, RespondElicitation(ElicitationResponse{ID: event.Payload.ID, Action: ElicitationCancel}))
+
+	out := <-outcomes
+	require.NoError(t, out.err)
+	require.Equal(t, ElicitationAccept, out.result.Action)
+	require.Equal(t, map[string]any{"name": "crush"}, out.result.Content)
+}
@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/config"
@@ -25,6 +26,10 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// ErrAuthorizationRequired is reported when an HTTP or SSE MCP server
+// rejects Crush for lack of valid OAuth credentials.
+var ErrAuthorizationRequired = errors.New("authorization required")
+
 func parseLevel(level mcp.LoggingLevel) slog.Level {
 	switch level {
 	case "info":
@@ -119,6 +124,12 @@ type ClientInfo struct {
 	Client      *ClientSession
 	Counts      Counts
 	ConnectedAt time.Time
+	// Restarts counts the automatic restarts of the server after it
+	// crashed or stopped responding.
+	Restarts int
+	// Stderr is the last line the server wrote to stderr, if it is a
+	// stdio server.
+	Stderr string
 }
 
 // SubscribeEvents returns a channel for MCP events
@@ -140,6 +151,8 @@ func GetState(name string) (ClientInfo, bool) {
 func Close(ctx context.Context) error {
 	var wg sync.WaitGroup
 	for name, session := range sessions.Seq2() {
+		// Removed first so that its supervisor doesn't restart it.
+		sessions.Del(name)
 		wg.Go(func() {
 			done := make(chan error, 1)
 			go func() {
@@ -158,13 +171,18 @@ func Close(ctx context.Context) error {
 		})
 	}
 	wg.Wait()
+	closeStderrLogs()
 	broker.Shutdown()
+	elicitations.Shutdown()
 	return nil
 }
 
 // Initialize initializes MCP clients based on the provided configuration.
 func Initialize(ctx context.Context, permissions permission.Service, cfg *config.ConfigStore) {
 	slog.Info("Initializing MCP clients")
+	samplingPermissions.Set(permissions)
+	UpdateRoots(cfg)
+	setLogsDir(cfg)
 	var wg sync.WaitGroup
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.Config().MCP {
@@ -227,6 +245,9 @@ func InitializeSingle(ctx context.Context, name string, cfg *config.ConfigStore)
 		return nil
 	}
 
+	UpdateRoots(cfg)
+	setLogsDir(cfg)
+	restarts.Del(name)
 	return initClient(ctx, cfg, name, m, cfg.Resolver())
 }
 
@@ -235,6 +256,8 @@ func initClient(ctx context.Context, cfg *config.ConfigStore, name string, m con
 	// Set initial starting state.
 	updateState(name, StateStarting, nil, nil, Counts{})
 
+	m = refreshOAuthToken(ctx, cfg, name, m)
+
 	// createSession handles its own timeout internally.
 	session, err := createSession(ctx, name, m, resolver)
 	if err != nil {
@@ -265,6 +288,7 @@ func initClient(ctx context.Context, cfg *config.ConfigStore, name string, m con
 		Tools:   toolCount,
 		Prompts: len(prompts),
 	})
+	go supervise(ctx, cfg, name, session)
 
 	return nil
 }
@@ -273,14 +297,17 @@ func initClient(ctx context.Context, cfg *config.ConfigStore, name string, m con
 func DisableSingle(cfg *config.ConfigStore, name string) error {
 	session, ok := sessions.Get(name)
 	if ok {
+		// Removed first so that its supervisor doesn't restart it.
+		sessions.Del(name)
 		if err := session.Close(); err != nil &&
 			!errors.Is(err, io.EOF) &&
 			!errors.Is(err, context.Canceled) &&
 			err.Error() != "signal: killed" {
 			slog.Warn("Error closing MCP session", "name", name, "error", err)
 		}
-		sessions.Del(name)
 	}
+	clients.Del(name)
+	restarts.Del(name)
 
 	// Clear tools and prompts for this MCP.
 	updateTools(cfg, name, nil)
@@ -311,6 +338,9 @@ func getOrRenewClient(ctx context.Context, cfg *config.ConfigStore, name string)
 	}
 	updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, state.Counts)
 
+	m = refreshOAuthToken(ctx, cfg, name, m)
+	// The new session outlives the call renewing it.
+	ctx = context.WithoutCancel(ctx)
 	sess, err = createSession(ctx, name, m, cfg.Resolver())
 	if err != nil {
 		return nil, err
@@ -318,9 +348,24 @@ func getOrRenewClient(ctx context.Context, cfg *config.ConfigStore, name string)
 
 	updateState(name, StateConnected, nil, sess, state.Counts)
 	sessions.Set(name, sess)
+	go supervise(ctx, cfg, name, sess)
 	return sess, nil
 }
 
+// refreshOAuthToken refreshes the OAuth token of m when it has expired and
+// returns the updated configuration. Failures are logged: the server then
+// rejects the stale token and the client reports that it needs a new login.
+func refreshOAuthToken(ctx context.Context, cfg *config.ConfigStore, name string, m config.MCPConfig) config.MCPConfig {
+	if m.OAuth == nil || m.OAuth.Token == nil || !m.OAuth.Token.IsExpired() {
+		return m
+	}
+	if err := cfg.RefreshMCPOAuthToken(ctx, name); err != nil {
+		slog.Warn("Failed to refresh MCP OAuth token", "name", name, "error", err)
+		return m
+	}
+	return cfg.Config().MCP[name]
+}
+
 // updateState updates the state of an MCP client and publishes an event
 func updateState(name string, state State, err error, client *ClientSession, counts Counts) {
 	info := ClientInfo{
@@ -336,6 +381,8 @@ func updateState(name string, state State, err error, client *ClientSession, cou
 	case StateError:
 		sessions.Del(name)
 	}
+	info.Restarts, _ = restarts.Get(name)
+	info.Stderr = lastStderr(name)
 	states.Set(name, info)
 
 	// Publish state change event
@@ -361,6 +408,7 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 		cancelTimer.Stop()
 		return nil, err
 	}
+	captureStderr(name, transport)
 
 	client := mcp.NewClient(
 		&mcp.Implementation{
@@ -387,16 +435,23 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 					Name: name,
 				})
 			},
+			ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+				attachedResources.markUpdated(name, req.Params.URI)
+			},
 			LoggingMessageHandler: func(ctx context.Context, req *mcp.LoggingMessageRequest) {
 				level := parseLevel(req.Params.Level)
 				slog.Log(ctx, level, "MCP log", "name", name, "logger", req.Params.Logger, "data", req.Params.Data)
 			},
+			CreateMessageHandler: createMessageHandler(name, m),
+			ElicitationHandler:   elicitationHandler(name),
 		},
 	)
+	registerClient(name, client)
 
 	session, err := client.Connect(mcpCtx, transport, nil)
 	if err != nil {
 		err = maybeStdioErr(err, transport)
+		err = maybeAuthErr(err, name, transport)
 		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
 		slog.Error("MCP client failed to initialize", "error", err, "name", name)
 		cancel()
@@ -406,7 +461,9 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 
 	cancelTimer.Stop()
 	slog.Debug("MCP client initialized", "name", name)
-	return &ClientSession{session, cancel}, nil
+	sess := &ClientSession{session, cancel}
+	resubscribeResources(mcpCtx, name, sess)
+	return sess, nil
 }
 
 // maybeStdioErr if a stdio mcp prints an error in non-json format, it'll fail
@@ -430,6 +487,26 @@ func maybeStdioErr(err error, transport mcp.Transport) error {
 	return err
 }
 
+// maybeAuthErr explains how to authorize when an HTTP or SSE MCP server
+// rejected the connection with 401 Unauthorized.
+func maybeAuthErr(err error, name string, transport mcp.Transport) error {
+	var client *http.Client
+	switch t := transport.(type) {
+	case *mcp.StreamableClientTransport:
+		client = t.HTTPClient
+	case *mcp.SSEClientTransport:
+		client = t.HTTPClient
+	}
+	if client == nil {
+		return err
+	}
+	rt, ok := client.Transport.(*headerRoundTripper)
+	if !ok || !rt.unauthorized.Load() {
+		return err
+	}
+	return fmt.Errorf("%w: run `crush mcp login %s`", ErrAuthorizationRequired, name)
+}
+
 func maybeTimeoutErr(err error, timeout time.Duration) error {
 	if errors.Is(err, context.Canceled) {
 		return fmt.Errorf("timed out after %s", timeout)
@@ -468,7 +545,7 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(url) == "" {
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
-		headers, err := m.ResolvedHeaders(resolver)
+		headers, err := resolvedHeaders(m, url, resolver)
 		if err != nil {
 			return nil, err
 		}
@@ -489,7 +566,7 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(url) == "" {
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
-		headers, err := m.ResolvedHeaders(resolver)
+		headers, err := resolvedHeaders(m, url, resolver)
 		if err != nil {
 			return nil, err
 		}
@@ -507,15 +584,44 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 	}
 }
 
+// resolvedHeaders returns the resolved headers of m, authorizing requests
+// to url with its OAuth token unless an Authorization header is configured.
+// The token is only sent to the server it was issued for.
+func resolvedHeaders(m config.MCPConfig, url string, resolver config.VariableResolver) (map[string]string, error) {
+	headers, err := m.ResolvedHeaders(resolver)
+	if err != nil {
+		return nil, err
+	}
+	if !m.OAuth.Authorizes(url) {
+		return headers, nil
+	}
+	for k := range headers {
+		if strings.EqualFold(k, "Authorization") {
+			return headers, nil
+		}
+	}
+	if headers == nil {
+		headers = make(map[string]string, 1)
+	}
+	headers["Authorization"] = "Bearer " + m.OAuth.Token.AccessToken
+	return headers, nil
+}
+
 type headerRoundTripper struct {
 	headers map[string]string
+	// unauthorized records whether the server answered 401 Unauthorized.
+	unauthorized atomic.Bool
 }
 
-func (rt headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	for k, v := range rt.headers {
 		req.Header.Set(k, v)
 	}
-	return http.DefaultTransport.RoundTrip(req)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.unauthorized.Store(true)
+	}
+	return resp, err
 }
 
 func mcpTimeout(m config.MCPConfig) time.Duration {
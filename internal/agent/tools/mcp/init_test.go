@@ -8,6 +8,8 @@ import (
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/oauth"
+	mcpoauth "github.com/charmbracelet/crush/internal/oauth/mcp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
@@ -396,6 +398,51 @@ func TestCreateTransport_HeadersResolution(t *testing.T) {
 		require.True(t, ok)
 		require.NotContains(t, rt.headers, "Authorization")
 	})
+
+	t.Run("oauth token authorizes unless header is configured", func(t *testing.T) {
+		t.Parallel()
+		r := shellResolverWithPath(t, nil)
+		creds := &mcpoauth.Credentials{
+			Client: mcpoauth.Client{Resource: "https://mcp.example.com/api"},
+			Token:  &oauth.Token{AccessToken: "oauth-token"},
+		}
+
+		m := config.MCPConfig{
+			Type:  config.MCPHttp,
+			URL:   "https://mcp.example.com/api",
+			OAuth: creds,
+		}
+		tr, err := createTransport(t.Context(), m, r)
+		require.NoError(t, err)
+		rt, ok := tr.(*mcp.StreamableClientTransport).HTTPClient.Transport.(*headerRoundTripper)
+		require.True(t, ok)
+		require.Equal(t, map[string]string{"Authorization": "Bearer oauth-token"}, rt.headers)
+
+		m.Headers = map[string]string{"authorization": "Bearer static"}
+		tr, err = createTransport(t.Context(), m, r)
+		require.NoError(t, err)
+		rt, ok = tr.(*mcp.StreamableClientTransport).HTTPClient.Transport.(*headerRoundTripper)
+		require.True(t, ok)
+		require.Equal(t, map[string]string{"authorization": "Bearer static"}, rt.headers)
+	})
+
+	t.Run("oauth token is only sent to its resource", func(t *testing.T) {
+		t.Parallel()
+		r := shellResolverWithPath(t, nil)
+		m := config.MCPConfig{
+			Type: config.MCPHttp,
+			URL:  "https://evil.example.com/api",
+			OAuth: &mcpoauth.Credentials{
+				Client: mcpoauth.Client{Resource: "https://mcp.example.com/api"},
+				Token:  &oauth.Token{AccessToken: "oauth-token"},
+			},
+		}
+		tr, err := createTransport(t.Context(), m, r)
+		require.NoError(t, err)
+		rt, ok := tr.(*mcp.StreamableClientTransport).HTTPClient.Transport.(*headerRoundTripper)
+		require.True(t, ok)
+		require.NotContains(t, rt.headers, "Authorization")
+	})
 }
 
 // TestCreateSession_ResolutionFailureUpdatesState pins the user-visible
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"iter"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
@@ -18,6 +21,79 @@ type ResourceContents = mcp.ResourceContents
 
 var allResources = csync.NewMap[string, []*Resource]()
 
+// AttachedResource identifies a resource of an MCP server attached to a
+// session.
+type AttachedResource struct {
+	// Name is the name of the MCP server.
+	Name string
+	URI  string
+}
+
+// attachedResources tracks the resources attached to sessions, which
+// their servers are subscribed to, and which of them were updated since
+// the sessions last read them.
+var attachedResources = &resourceTracker{
+	sessions: make(map[AttachedResource][]string),
+	updated:  make(map[string][]AttachedResource),
+}
+
+type resourceTracker struct {
+	mu       sync.Mutex
+	sessions map[AttachedResource][]string
+	updated  map[string][]AttachedResource
+}
+
+// attach records r as attached to the session. It reports whether r was
+// not attached to any session before, and so needs a subscription.
+func (t *resourceTracker) attach(sessionID string, r AttachedResource) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sessions, ok := t.sessions[r]
+	if !slices.Contains(sessions, sessionID) {
+		t.sessions[r] = append(sessions, sessionID)
+	}
+	return !ok
+}
+
+// markUpdated marks the resources of the named server that the updated
+// uri belongs to as updated for the sessions they are attached to.
+func (t *resourceTracker) markUpdated(name, uri string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for r, sessions := range t.sessions {
+		if r.Name != name || (r.URI != uri && !strings.HasPrefix(uri, strings.TrimSuffix(r.URI, "/")+"/")) {
+			continue
+		}
+		for _, sessionID := range sessions {
+			if !slices.Contains(t.updated[sessionID], r) {
+				t.updated[sessionID] = append(t.updated[sessionID], r)
+			}
+		}
+	}
+}
+
+// take returns the resources updated for the session and forgets them.
+func (t *resourceTracker) take(sessionID string) []AttachedResource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	updated := t.updated[sessionID]
+	delete(t.updated, sessionID)
+	return updated
+}
+
+// uris returns the URIs of the attached resources of the named server.
+func (t *resourceTracker) uris(name string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var uris []string
+	for r := range t.sessions {
+		if r.Name == name {
+			uris = append(uris, r.URI)
+		}
+	}
+	return uris
+}
+
 // Resources returns all available MCP resources.
 func Resources() iter.Seq2[string, []*Resource] {
 	return allResources.Seq2()
@@ -55,6 +131,43 @@ func ReadResource(ctx context.Context, cfg *config.ConfigStore, name, uri string
 	return result.Contents, nil
 }
 
+// AttachResource records that a resource of the named MCP server was
+// attached to the session, and subscribes to its updates when the server
+// supports it, so that [TakeUpdatedResources] reports it once it changes.
+func AttachResource(ctx context.Context, cfg *config.ConfigStore, sessionID, name, uri string) error {
+	if !attachedResources.attach(sessionID, AttachedResource{Name: name, URI: uri}) {
+		return nil
+	}
+	session, err := getOrRenewClient(ctx, cfg, name)
+	if err != nil {
+		return err
+	}
+	return subscribeResource(ctx, session, uri)
+}
+
+// TakeUpdatedResources returns the resources attached to the session whose
+// servers notified updates since the last call.
+func TakeUpdatedResources(sessionID string) []AttachedResource {
+	return attachedResources.take(sessionID)
+}
+
+func subscribeResource(ctx context.Context, c *ClientSession, uri string) error {
+	if caps := c.InitializeResult().Capabilities.Resources; caps == nil || !caps.Subscribe {
+		return nil
+	}
+	return c.Subscribe(ctx, &mcp.SubscribeParams{URI: uri})
+}
+
+// resubscribeResources subscribes a new session of the named MCP server to
+// the resources attached before it reconnected.
+func resubscribeResources(ctx context.Context, name string, c *ClientSession) {
+	for _, uri := range attachedResources.uris(name) {
+		if err := subscribeResource(ctx, c, uri); err != nil {
+			slog.Warn("Failed to subscribe to MCP resource", "name", name, "uri", uri, "error", err)
+		}
+	}
+}
+
 // RefreshResources gets the updated list of resources from the MCP and updates the
 // global state.
 func RefreshResources(ctx context.Context, name string) {
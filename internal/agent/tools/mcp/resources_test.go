@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := &resourceTracker{
+		sessions: make(map[AttachedResource][]string),
+		updated:  make(map[string][]AttachedResource),
+	}
+	doc := AttachedResource{Name: "docs", URI: "file:///docs"}
+	note := AttachedResource{Name: "notes", URI: "note://today"}
+
+	require.True(t, tracker.attach("s1", doc))
+	require.False(t, tracker.attach("s2", doc))
+	require.False(t, tracker.attach("s1", doc))
+	require.True(t, tracker.attach("s1", note))
+	require.ElementsMatch(t, []string{"file:///docs"}, tracker.uris("docs"))
+
+	// Sub-resources update the resource they belong to, once per session.
+	tracker.markUpdated("docs", "file:///docs/readme.md")
+	tracker.markUpdated("docs", "file:///docs")
+	tracker.markUpdated("docs", "file:///docsite")
+	tracker.markUpdated("other", "note://today")
+
+	require.Equal(t, []AttachedResource{doc}, tracker.take("s1"))
+	require.Empty(t, tracker.take("s1"))
+	require.Equal(t, []AttachedResource{doc}, tracker.take("s2"))
+
+	tracker.markUpdated("notes", "note://today")
+	require.Equal(t, []AttachedResource{note}, tracker.take("s1"))
+	require.Empty(t, tracker.take("s2"))
+}
@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	// clients holds the client of each MCP server, which advertises the
+	// roots to its sessions.
+	clients = csync.NewMap[string, *mcp.Client]()
+	roots   = csync.NewSlice[*mcp.Root]()
+	// rootsMu serializes the updates of roots.
+	rootsMu sync.Mutex
+)
+
+// UpdateRoots advertises the working directory and the configured
+// mcp_roots to MCP servers. Connected servers are notified when the roots
+// changed since the last call.
+func UpdateRoots(cfg *config.ConfigStore) {
+	rootsMu.Lock()
+	defer rootsMu.Unlock()
+
+	next := rootsFor(cfg.WorkingDir(), cfg.Config().Options.MCPRoots)
+	prev := roots.Copy()
+	roots.SetSlice(next)
+
+	removed := rootsDiff(prev, next)
+	added := rootsDiff(next, prev)
+	if len(removed) == 0 && len(added) == 0 {
+		return
+	}
+	uris := make([]string, 0, len(removed))
+	for _, r := range removed {
+		uris = append(uris, r.URI)
+	}
+	for _, client := range clients.Seq2() {
+		client.RemoveRoots(uris...)
+		client.AddRoots(added...)
+	}
+}
+
+// registerClient advertises the roots to the client of the named MCP
+// server and keeps them up to date.
+func registerClient(name string, client *mcp.Client) {
+	rootsMu.Lock()
+	defer rootsMu.Unlock()
+	client.AddRoots(roots.Copy()...)
+	clients.Set(name, client)
+}
+
+// rootsFor returns the roots of the working directory and of the extra
+// directories, which may be relative to it.
+func rootsFor(workingDir string, extra []string) []*mcp.Root {
+	var result []*mcp.Root
+	for _, dir := range append([]string{workingDir}, extra...) {
+		if dir == "" {
+			continue
+		}
+		dir = home.Long(os.ExpandEnv(dir))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workingDir, dir)
+		}
+		dir = filepath.Clean(dir)
+		root := &mcp.Root{Name: filepath.Base(dir), URI: fileURI(dir)}
+		if !slices.ContainsFunc(result, func(r *mcp.Root) bool { return r.URI == root.URI }) {
+			result = append(result, root)
+		}
+	}
+	return result
+}
+
+// rootsDiff returns the roots of a that are not in b.
+func rootsDiff(a, b []*mcp.Root) []*mcp.Root {
+	var diff []*mcp.Root
+	for _, r := range a {
+		if !slices.ContainsFunc(b, func(o *mcp.Root) bool { return o.URI == r.URI && o.Name == r.Name }) {
+			diff = append(diff, r)
+		}
+	}
+	return diff
+}
+
+// fileURI returns the file:// URI of the absolute path p.
+func fileURI(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		// Windows drive letters.
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootsFor(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix paths")
+	}
+
+	roots := rootsFor("/work/app", []string{"../shared", "/opt/lib/", ".", ""})
+	require.Equal(t, []*mcp.Root{
+		{Name: "app", URI: "file:///work/app"},
+		{Name: "shared", URI: "file:///work/shared"},
+		{Name: "lib", URI: "file:///opt/lib"},
+	}, roots)
+}
+
+func TestRootsDiff(t *testing.T) {
+	t.Parallel()
+
+	a := []*mcp.Root{{Name: "app", URI: "file:///app"}, {Name: "lib", URI: "file:///lib"}}
+	b := []*mcp.Root{{Name: "app", URI: "file:///app"}, {Name: "library", URI: "file:///lib"}}
+	require.Equal(t, []*mcp.Root{{Name: "lib", URI: "file:///lib"}}, rootsDiff(a, b))
+	require.Empty(t, rootsDiff(a, a))
+}
+
+func TestFileURI(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "file:///work/my%20app", fileURI(filepath.FromSlash("/work/my app")))
+	require.Equal(t, "file:///C:/work", fileURI("C:/work"))
+}
@@ -0,0 +1,269 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultSamplingMaxTokens caps the completions generated for MCP servers
+// that don't configure sampling_max_tokens.
+const DefaultSamplingMaxTokens = 4096
+
+// errSamplingDenied is returned to MCP servers whose sampling request the
+// user denied.
+var errSamplingDenied = errors.New("sampling request denied by the user")
+
+// SamplingRequest is a request of an MCP server to generate a completion
+// with a model of the user.
+type SamplingRequest struct {
+	// Name is the name of the MCP server.
+	Name string
+	// SessionID is the session of the tool call the server is handling,
+	// if any.
+	SessionID    string
+	SystemPrompt string
+	Messages     []SamplingMessage
+	MaxTokens    int64
+	Temperature  *float64
+	// Large selects the large model rather than the small one.
+	Large bool
+}
+
+// SamplingMessage is a message of a [SamplingRequest].
+type SamplingMessage struct {
+	// Role is either "user" or "assistant".
+	Role      string
+	Text      string
+	Data      []byte
+	MediaType string
+}
+
+// SamplingResult is the completion generated for a [SamplingRequest].
+type SamplingResult struct {
+	Text  string
+	Model string
+	// Truncated reports whether the completion stopped at MaxTokens.
+	Truncated bool
+}
+
+// Sampler generates the completion of a sampling request.
+type Sampler func(ctx context.Context, req SamplingRequest) (SamplingResult, error)
+
+// Caller identifies the tool call on whose behalf an MCP server is called,
+// so that the requests the server sends back while handling it are
+// attributed to the right session.
+type Caller struct {
+	SessionID  string
+	ToolCallID string
+}
+
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx identifying the tool call that calls an
+// MCP server with it.
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+var (
+	sampler             = csync.NewValue[Sampler](nil)
+	samplingPermissions = csync.NewValue[permission.Service](nil)
+	callers             = &inFlightCalls{calls: make(map[string][]*Caller)}
+)
+
+// inFlightCalls holds the tool calls in flight for each MCP server.
+type inFlightCalls struct {
+	mu    sync.Mutex
+	calls map[string][]*Caller
+}
+
+// add records c as calling the named server and returns a function
+// forgetting that call alone.
+func (f *inFlightCalls) add(name string, c Caller) func() {
+	call := &c
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[name] = append(f.calls[name], call)
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls[name] = slices.DeleteFunc(f.calls[name], func(other *Caller) bool { return other == call })
+		if len(f.calls[name]) == 0 {
+			delete(f.calls, name)
+		}
+	}
+}
+
+// Get returns the latest tool call in flight for the named server. The
+// requests of a server don't say which call they belong to, so while
+// calls of several sessions are in flight it reports false rather than
+// guessing.
+func (f *inFlightCalls) Get(name string) (Caller, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls[name]
+	if len(calls) == 0 {
+		return Caller{}, false
+	}
+	latest := *calls[len(calls)-1]
+	for _, c := range calls {
+		if c.SessionID != latest.SessionID {
+			return Caller{}, false
+		}
+	}
+	return latest, true
+}
+
+// SetSampler sets the function generating the completions requested by MCP
+// servers. Sampling requests fail until it is set.
+func SetSampler(s Sampler) {
+	sampler.Set(s)
+}
+
+// trackCaller records the caller of ctx as calling the named MCP server and
+// returns a function forgetting it.
+func trackCaller(ctx context.Context, name string) func() {
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	if !ok {
+		return func() {}
+	}
+	return callers.add(name, c)
+}
+
+// createMessageHandler returns the handler of the sampling requests of the
+// named MCP server. Each request is confirmed by the user and capped at the
+// configured number of tokens.
+func createMessageHandler(name string, m config.MCPConfig) func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		sample := sampler.Get()
+		permissions := samplingPermissions.Get()
+		if sample == nil || permissions == nil {
+			return nil, errors.New("sampling is not available")
+		}
+
+		caller, _ := callers.Get(name)
+		sreq, err := samplingRequest(name, m, req.Params)
+		if err != nil {
+			return nil, err
+		}
+		sreq.SessionID = caller.SessionID
+
+		params, err := json.Marshal(samplingParams(sreq))
+		if err != nil {
+			return nil, err
+		}
+		granted, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+			SessionID:   caller.SessionID,
+			ToolCallID:  caller.ToolCallID,
+			ToolName:    "mcp_" + name,
+			Action:      "sample",
+			Description: fmt.Sprintf("%s wants to generate text with your model", name),
+			Params:      string(params),
+			Path:        ".",
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return nil, errSamplingDenied
+		}
+
+		result, err := sample(ctx, sreq)
+		if err != nil {
+			return nil, fmt.Errorf("sampling failed: %w", err)
+		}
+		stopReason := "endTurn"
+		if result.Truncated {
+			stopReason = "maxTokens"
+		}
+		return &mcp.CreateMessageResult{
+			Content:    &mcp.TextContent{Text: result.Text},
+			Model:      result.Model,
+			Role:       "assistant",
+			StopReason: stopReason,
+		}, nil
+	}
+}
+
+// samplingRequest converts the parameters of a sampling request of the
+// named MCP server.
+func samplingRequest(name string, m config.MCPConfig, params *mcp.CreateMessageParams) (SamplingRequest, error) {
+	if params == nil || len(params.Messages) == 0 {
+		return SamplingRequest{}, errors.New("sampling request has no messages")
+	}
+
+	req := SamplingRequest{
+		Name:         name,
+		SystemPrompt: params.SystemPrompt,
+		MaxTokens:    cmp.Or(m.SamplingMaxTokens, DefaultSamplingMaxTokens),
+		Large:        prefersLargeModel(params.ModelPreferences),
+	}
+	if params.MaxTokens > 0 {
+		req.MaxTokens = min(req.MaxTokens, params.MaxTokens)
+	}
+	if params.Temperature != 0 {
+		req.Temperature = &params.Temperature
+	}
+	for _, msg := range params.Messages {
+		sm := SamplingMessage{Role: string(msg.Role)}
+		switch content := msg.Content.(type) {
+		case *mcp.TextContent:
+			sm.Text = content.Text
+		case *mcp.ImageContent:
+			sm.Data, sm.MediaType = content.Data, content.MIMEType
+		case *mcp.AudioContent:
+			sm.Data, sm.MediaType = content.Data, content.MIMEType
+		default:
+			return SamplingRequest{}, fmt.Errorf("unsupported sampling content %T", msg.Content)
+		}
+		req.Messages = append(req.Messages, sm)
+	}
+	if last := req.Messages[len(req.Messages)-1]; last.Role != "user" {
+		return SamplingRequest{}, errors.New("the last sampling message must come from the user")
+	}
+	return req, nil
+}
+
+// prefersLargeModel reports whether the model preferences of a sampling
+// request favor intelligence over speed and cost.
+func prefersLargeModel(p *mcp.ModelPreferences) bool {
+	if p == nil {
+		return false
+	}
+	return p.IntelligencePriority > max(p.SpeedPriority, p.CostPriority)
+}
+
+// samplingParams summarizes a sampling request for the permission prompt.
+func samplingParams(req SamplingRequest) map[string]any {
+	model := "small"
+	if req.Large {
+		model = "large"
+	}
+	messages := make([]string, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		text := m.Text
+		if text == "" {
+			text = "[" + m.MediaType + "]"
+		}
+		messages = append(messages, m.Role+": "+text)
+	}
+	params := map[string]any{
+		"model":      model,
+		"max_tokens": req.MaxTokens,
+		"messages":   messages,
+	}
+	if req.SystemPrompt != "" {
+		params["system_prompt"] = req.SystemPrompt
+	}
+	return params
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingRequest(t *testing.T) {
+	t.Parallel()
+
+	params := &mcp.CreateMessageParams{
+		SystemPrompt: "Be brief.",
+		MaxTokens:    100000,
+		Temperature:  0.5,
+		Messages: []*mcp.SamplingMessage{
+			{Role: "user", Content: &mcp.TextContent{Text: "Hi"}},
+			{Role: "assistant", Content: &mcp.TextContent{Text: "Hello"}},
+			{Role: "user", Content: &mcp.ImageContent{Data: []byte("png"), MIMEType: "image/png"}},
+		},
+		ModelPreferences: &mcp.ModelPreferences{IntelligencePriority: 0.8, SpeedPriority: 0.2},
+	}
+
+	req, err := samplingRequest("test", config.MCPConfig{}, params)
+	require.NoError(t, err)
+	require.Equal(t, "test", req.Name)
+	require.Equal(t, "Be brief.", req.SystemPrompt)
+	require.Equal(t, int64(DefaultSamplingMaxTokens), req.MaxTokens, "requests are capped")
+	require.Equal(t, 0.5, *req.Temperature)
+	require.True(t, req.Large)
+	require.Equal(t, []SamplingMessage{
+		{Role: "user", Text: "Hi"},
+		{Role: "assistant", Text: "Hello"},
+		{Role: "user", Data: []byte("png"), MediaType: "image/png"},
+	}, req.Messages)
+
+	params.MaxTokens = 50
+	params.ModelPreferences = nil
+	req, err = samplingRequest("test", config.MCPConfig{SamplingMaxTokens: 100}, params)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), req.MaxTokens)
+	require.False(t, req.Large)
+
+	params.Messages = params.Messages[:2]
+	_, err = samplingRequest("test", config.MCPConfig{}, params)
+	require.EqualError(t, err, "the last sampling message must come from the user")
+}
+
+func TestInFlightCalls(t *testing.T) {
+	t.Parallel()
+
+	f := &inFlightCalls{calls: make(map[string][]*Caller)}
+	a := Caller{SessionID: "s1", ToolCallID: "a"}
+	b := Caller{SessionID: "s1", ToolCallID: "b"}
+	c := Caller{SessionID: "s2", ToolCallID: "c"}
+
+	doneA := f.add("srv", a)
+	doneB := f.add("srv", b)
+	got, ok := f.Get("srv")
+	require.True(t, ok)
+	require.Equal(t, b, got)
+
+	// Finishing one call keeps the others.
+	doneB()
+	got, ok = f.Get("srv")
+	require.True(t, ok)
+	require.Equal(t, a, got)
+
+	// Calls of several sessions can't be told apart.
+	doneC := f.add("srv", c)
+	_, ok = f.Get("srv")
+	require.False(t, ok)
+	doneC()
+	got, ok = f.Get("srv")
+	require.True(t, ok)
+	require.Equal(t, a, got)
+
+	doneA()
+	_, ok = f.Get("srv")
+	require.False(t, ok)
+	require.Empty(t, f.calls)
+}
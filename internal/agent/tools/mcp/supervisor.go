@@ -0,0 +1,247 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// healthCheckInterval is how often connected servers are pinged.
+	healthCheckInterval = 30 * time.Second
+	// restartBackoff is the delay before the first restart of a server,
+	// doubled for each further restart up to maxRestartBackoff.
+	restartBackoff    = time.Second
+	maxRestartBackoff = time.Minute
+	// maxRestarts is how many times in a row a server is restarted before
+	// it is left in the error state until restarted by hand.
+	maxRestarts = 5
+	// maxStderrLogSize is the size past which a stderr log is truncated.
+	maxStderrLogSize = 5 * 1024 * 1024
+)
+
+var (
+	// restarts counts the automatic restarts of each server since it was
+	// last initialized by hand or answered a health check.
+	restarts   = csync.NewMap[string, int]()
+	logsDir    = csync.NewValue("")
+	stderrLogs = csync.NewMap[string, *stderrLog]()
+)
+
+// LogFile returns the path of the log capturing the stderr output of the
+// named stdio MCP server.
+func LogFile(dataDir, name string) string {
+	return filepath.Join(dataDir, "logs", "mcp", name+".log")
+}
+
+// setLogsDir sets the directory of the stderr logs of MCP servers from the
+// data directory of cfg.
+func setLogsDir(cfg *config.ConfigStore) {
+	logsDir.Set(filepath.Dir(LogFile(cfg.Config().Options.DataDirectory, "")))
+}
+
+// supervise watches the session of the named MCP server and restarts the
+// server when its process exits or it stops answering pings. It returns
+// once the session is closed or replaced, or ctx is done.
+func supervise(ctx context.Context, cfg *config.ConfigStore, name string, sess *ClientSession) {
+	exited := make(chan error, 1)
+	go func() {
+		exited <- sess.Wait()
+	}()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case err = <-exited:
+			if err == nil {
+				err = errors.New("server exited")
+			}
+		case <-ticker.C:
+			if !isCurrent(name, sess) {
+				return
+			}
+			timeout := mcpTimeout(cfg.Config().MCP[name])
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			err = sess.Ping(pingCtx, nil)
+			cancel()
+			if err == nil {
+				// The server stayed up since it was started, so a later
+				// failure starts over with the shortest backoff rather
+				// than counting towards giving up.
+				restarts.Del(name)
+				continue
+			}
+			err = maybeTimeoutErr(err, timeout)
+		}
+		// Sessions closed on purpose were replaced or removed first.
+		if !isCurrent(name, sess) {
+			return
+		}
+		slog.Warn("MCP server is unhealthy, restarting", "name", name, "error", err)
+		_ = sess.Close()
+		restart(ctx, cfg, name, err)
+		return
+	}
+}
+
+// isCurrent reports whether sess is the session in use for the named
+// server.
+func isCurrent(name string, sess *ClientSession) bool {
+	current, ok := sessions.Get(name)
+	return ok && current == sess
+}
+
+// restart restarts the named MCP server after it failed with cause,
+// backing off exponentially, until it connects again or maxRestarts is
+// reached.
+func restart(ctx context.Context, cfg *config.ConfigStore, name string, cause error) {
+	for {
+		n, _ := restarts.Get(name)
+		if n >= maxRestarts {
+			updateState(name, StateError, fmt.Errorf("%w (gave up after %d restarts)", cause, n), nil, Counts{})
+			return
+		}
+		updateState(name, StateError, cause, nil, Counts{})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartDelay(n)):
+		}
+		// The server may have been restarted or disabled meanwhile.
+		if _, ok := sessions.Get(name); ok {
+			return
+		}
+		m, ok := cfg.Config().MCP[name]
+		if !ok || m.Disabled {
+			return
+		}
+		if state, _ := states.Get(name); state.State == StateDisabled {
+			return
+		}
+
+		restarts.Set(name, n+1)
+		slog.Info("Restarting MCP server", "name", name, "attempt", n+1)
+		err := initClient(ctx, cfg, name, m, cfg.Resolver())
+		if err == nil {
+			return
+		}
+		cause = err
+	}
+}
+
+// restartDelay returns the delay before the restart following n restarts.
+func restartDelay(n int) time.Duration {
+	if n >= 16 {
+		return maxRestartBackoff
+	}
+	return min(restartBackoff<<n, maxRestartBackoff)
+}
+
+// stderrLog appends the stderr output of a stdio MCP server to its log
+// file and remembers its last line. The file is truncated whenever it
+// would grow past maxStderrLogSize.
+type stderrLog struct {
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	last *csync.Value[string]
+}
+
+// openStderrLog returns the stderr log of the named server, or nil when it
+// can't be opened.
+func openStderrLog(name string) io.Writer {
+	dir := logsDir.Get()
+	if dir == "" {
+		return nil
+	}
+	if l, ok := stderrLogs.Get(name); ok {
+		return l
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		slog.Warn("Failed to create MCP logs directory", "error", err)
+		return nil
+	}
+	file, err := os.OpenFile(filepath.Join(dir, name+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		slog.Warn("Failed to open MCP stderr log", "name", name, "error", err)
+		return nil
+	}
+	l := &stderrLog{file: file, last: csync.NewValue("")}
+	if info, err := file.Stat(); err == nil {
+		l.size = info.Size()
+	}
+	if l.size > maxStderrLogSize && file.Truncate(0) == nil {
+		l.size = 0
+	}
+	stderrLogs.Set(name, l)
+	return l
+}
+
+// Write implements io.Writer.
+func (l *stderrLog) Write(p []byte) (int, error) {
+	if line := lastLine(p); line != "" {
+		l.last.Set(line)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size+int64(len(p)) > maxStderrLogSize && l.file.Truncate(0) == nil {
+		l.size = 0
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// lastStderr returns the last line the named server wrote to stderr.
+func lastStderr(name string) string {
+	l, ok := stderrLogs.Get(name)
+	if !ok {
+		return ""
+	}
+	return l.last.Get()
+}
+
+// lastLine returns the last non-blank line of p.
+func lastLine(p []byte) string {
+	p = bytes.TrimRight(p, " \t\r\n")
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		p = p[i+1:]
+	}
+	return string(bytes.TrimSpace(p))
+}
+
+// closeStderrLogs closes the stderr logs of all servers.
+func closeStderrLogs() {
+	for name, l := range stderrLogs.Seq2() {
+		_ = l.file.Close()
+		stderrLogs.Del(name)
+	}
+}
+
+// captureStderr sends the stderr output of a stdio transport to the log of
+// the named server.
+func captureStderr(name string, transport mcp.Transport) {
+	ct, ok := transport.(*mcp.CommandTransport)
+	if !ok || ct.Command.Stderr != nil {
+		return
+	}
+	if w := openStderrLog(name); w != nil {
+		ct.Command.Stderr = w
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartDelay(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Second, restartDelay(0))
+	require.Equal(t, 2*time.Second, restartDelay(1))
+	require.Equal(t, 32*time.Second, restartDelay(5))
+	require.Equal(t, time.Minute, restartDelay(6))
+	require.Equal(t, time.Minute, restartDelay(100))
+}
+
+func TestLastLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "single line", in: "listening\n", want: "listening"},
+		{name: "several lines", in: "starting\nError: EADDRINUSE\n", want: "Error: EADDRINUSE"},
+		{name: "trailing blank lines", in: "boom\n\n  \n", want: "boom"},
+		{name: "partial line", in: "  warn: slow", want: "warn: slow"},
+		{name: "blank", in: "\n\n", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, lastLine([]byte(tt.in)))
+		})
+	}
+}
+
+// TestStderrLog sets the package-level logs directory, so it doesn't run in
+// parallel.
+func TestStderrLog(t *testing.T) {
+	dir := t.TempDir()
+	logsDir.Set(dir)
+	t.Cleanup(func() {
+		logsDir.Set("")
+		if l, ok := stderrLogs.Take("test-stderr"); ok {
+			l.file.Close()
+		}
+	})
+
+	w := openStderrLog("test-stderr")
+	require.NotNil(t, w)
+	require.Same(t, w, openStderrLog("test-stderr"))
+
+	_, err := w.Write([]byte("starting\npanic: oops\n"))
+	require.NoError(t, err)
+	require.Equal(t, "panic: oops", lastStderr("test-stderr"))
+	require.Empty(t, lastStderr("test-other"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "test-stderr.log"))
+	require.NoError(t, err)
+	require.Equal(t, "starting\npanic: oops\n", string(data))
+
+	chunk := bytes.Repeat([]byte("x"), maxStderrLogSize/2+1)
+	for range 3 {
+		_, err = w.Write(chunk)
+		require.NoError(t, err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "test-stderr.log"))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(chunk)), info.Size())
+}
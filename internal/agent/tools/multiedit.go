@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -43,13 +44,21 @@ type FailedEdit struct {
 	Edit  MultiEditOperation `json:"edit"`
 }
 
+// FuzzyEditMatch records an edit whose old_string was located by one of the
+// fallback match tiers rather than an exact match.
+type FuzzyEditMatch struct {
+	Index     int    `json:"index"`
+	MatchTier string `json:"match_tier"`
+}
+
 type MultiEditResponseMetadata struct {
-	Additions    int          `json:"additions"`
-	Removals     int          `json:"removals"`
-	OldContent   string       `json:"old_content,omitempty"`
-	NewContent   string       `json:"new_content,omitempty"`
-	EditsApplied int          `json:"edits_applied"`
-	EditsFailed  []FailedEdit `json:"edits_failed,omitempty"`
+	Additions    int              `json:"additions"`
+	Removals     int              `json:"removals"`
+	OldContent   string           `json:"old_content,omitempty"`
+	NewContent   string           `json:"new_content,omitempty"`
+	EditsApplied int              `json:"edits_applied"`
+	EditsFailed  []FailedEdit     `json:"edits_failed,omitempty"`
+	FuzzyMatches []FuzzyEditMatch `json:"fuzzy_matches,omitempty"`
 }
 
 const MultiEditToolName = "multiedit"
@@ -149,9 +158,10 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 
 	// Apply remaining edits to the content, tracking failures
 	var failedEdits []FailedEdit
+	var fuzzyMatches []FuzzyEditMatch
 	for i := 1; i < len(params.Edits); i++ {
 		edit := params.Edits[i]
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, matchTier, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -160,6 +170,9 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 			})
 			continue
 		}
+		if _, fuzzy := matchTierDescriptions[matchTier]; fuzzy {
+			fuzzyMatches = append(fuzzyMatches, FuzzyEditMatch{Index: i + 1, MatchTier: matchTier})
+		}
 		currentContent = newContent
 	}
 
@@ -235,7 +248,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+formatFuzzyMatches(fuzzyMatches)),
 		MultiEditResponseMetadata{
 			OldContent:   "",
 			NewContent:   currentContent,
@@ -243,6 +256,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 			Removals:     removals,
 			EditsApplied: editsApplied,
 			EditsFailed:  failedEdits,
+			FuzzyMatches: fuzzyMatches,
 		},
 	), nil
 }
@@ -294,8 +308,9 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 
 	// Apply all edits sequentially, tracking failures
 	var failedEdits []FailedEdit
+	var fuzzyMatches []FuzzyEditMatch
 	for i, edit := range params.Edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, matchTier, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -304,6 +319,9 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 			})
 			continue
 		}
+		if _, fuzzy := matchTierDescriptions[matchTier]; fuzzy {
+			fuzzyMatches = append(fuzzyMatches, FuzzyEditMatch{Index: i + 1, MatchTier: matchTier})
+		}
 		currentContent = newContent
 	}
 
@@ -403,7 +421,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+formatFuzzyMatches(fuzzyMatches)),
 		MultiEditResponseMetadata{
 			OldContent:   oldContent,
 			NewContent:   currentContent,
@@ -411,42 +429,41 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 			Removals:     removals,
 			EditsApplied: editsApplied,
 			EditsFailed:  failedEdits,
+			FuzzyMatches: fuzzyMatches,
 		},
 	), nil
 }
 
-func applyEditToContent(content string, edit MultiEditOperation) (string, error) {
+// applyEditToContent applies a single edit to content and returns the new
+// content together with the match tier used to locate old_string.
+func applyEditToContent(content string, edit MultiEditOperation) (string, string, error) {
 	if edit.OldString == "" && edit.NewString == "" {
-		return content, nil
+		return content, "", nil
 	}
 
 	if edit.OldString == "" {
-		return "", fmt.Errorf("old_string cannot be empty for content replacement")
+		return "", "", fmt.Errorf("old_string cannot be empty for content replacement")
 	}
 
-	var newContent string
-	var replacementCount int
-
-	if edit.ReplaceAll {
-		newContent = strings.ReplaceAll(content, edit.OldString, edit.NewString)
-		replacementCount = strings.Count(content, edit.OldString)
-		if replacementCount == 0 {
-			return "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
-		}
-	} else {
-		index := strings.Index(content, edit.OldString)
-		if index == -1 {
-			return "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
-		}
-
-		lastIndex := strings.LastIndex(content, edit.OldString)
-		if index != lastIndex {
-			return "", fmt.Errorf("old_string appears multiple times in the content. Please provide more context to ensure a unique match, or set replace_all to true")
-		}
-
-		newContent = content[:index] + edit.NewString + content[index+len(edit.OldString):]
-		replacementCount = 1
+	newContent, matchTier, err := replaceMatched(content, edit.OldString, edit.NewString, edit.ReplaceAll)
+	switch {
+	case errors.Is(err, errOldStringNotFound):
+		return "", "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
+	case errors.Is(err, errOldStringMultipleMatches):
+		return "", "", fmt.Errorf("old_string appears multiple times in the content. Please provide more context to ensure a unique match, or set replace_all to true")
 	}
+	return newContent, matchTier, nil
+}
 
-	return newContent, nil
+// formatFuzzyMatches describes the edits that were located by a fallback
+// match tier, for inclusion in the tool response.
+func formatFuzzyMatches(matches []FuzzyEditMatch) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	notes := make([]string, 0, len(matches))
+	for _, m := range matches {
+		notes = append(notes, fmt.Sprintf("edit %d %s", m.Index, matchTierDescriptions[m.MatchTier]))
+	}
+	return "\n" + strings.Join(notes, "\n")
 }
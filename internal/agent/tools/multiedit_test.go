@@ -82,7 +82,7 @@ func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	content := "line 1\nline 2\nline 3\n"
 
 	// Test successful edit.
-	newContent, err := applyEditToContent(content, MultiEditOperation{
+	newContent, _, err := applyEditToContent(content, MultiEditOperation{
 		OldString: "line 1",
 		NewString: "LINE 1",
 	})
@@ -91,7 +91,7 @@ func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	require.Contains(t, newContent, "line 2")
 
 	// Test failed edit (string not found).
-	_, err = applyEditToContent(content, MultiEditOperation{
+	_, _, err = applyEditToContent(content, MultiEditOperation{
 		OldString: "line 99",
 		NewString: "LINE 99",
 	})
@@ -125,7 +125,7 @@ func TestMultiEditSequentialApplication(t *testing.T) {
 	successCount := 0
 
 	for i, edit := range edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, _, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
@@ -169,7 +169,7 @@ func TestMultiEditAllEditsSucceed(t *testing.T) {
 	successCount := 0
 
 	for _, edit := range edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, _, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -197,7 +197,7 @@ func TestMultiEditAllEditsFail(t *testing.T) {
 	var failedEdits []FailedEdit
 
 	for i, edit := range edits {
-		newContent, err := applyEditToContent(currentContent, edit)
+		newContent, _, err := applyEditToContent(currentContent, edit)
 		if err != nil {
 			failedEdits = append(failedEdits, FailedEdit{
 				Index: i + 1,
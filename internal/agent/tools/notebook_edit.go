@@ -0,0 +1,472 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type NotebookEditParams struct {
+	NotebookPath string `json:"notebook_path" description:"The path to the Jupyter notebook (.ipynb) to edit"`
+	EditMode     string `json:"edit_mode,omitempty" description:"replace (default), insert or delete"`
+	CellIndex    int    `json:"cell_index,omitempty" description:"0-based index of the cell to replace or delete, or the index a new cell is inserted at; ignored when cell_id is set"`
+	CellID       string `json:"cell_id,omitempty" description:"ID of the cell to replace or delete; with insert, the new cell is inserted after it"`
+	CellType     string `json:"cell_type,omitempty" description:"code or markdown; required for insert, changes the cell type on replace"`
+	NewSource    string `json:"new_source,omitempty" description:"The new source of the cell for replace and insert"`
+}
+
+type NotebookEditPermissionsParams struct {
+	NotebookPath string `json:"notebook_path"`
+	EditMode     string `json:"edit_mode"`
+	CellIndex    int    `json:"cell_index"`
+	CellID       string `json:"cell_id,omitempty"`
+	CellType     string `json:"cell_type,omitempty"`
+	OldContent   string `json:"old_content,omitempty"`
+	NewContent   string `json:"new_content,omitempty"`
+}
+
+type NotebookEditResponseMetadata struct {
+	EditMode   string `json:"edit_mode"`
+	CellIndex  int    `json:"cell_index"`
+	CellType   string `json:"cell_type,omitempty"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+}
+
+const NotebookEditToolName = "notebook_edit"
+
+const (
+	notebookEditReplace = "replace"
+	notebookEditInsert  = "insert"
+	notebookEditDelete  = "delete"
+)
+
+//go:embed notebook_edit.md
+var notebookEditDescription string
+
+func NewNotebookEditTool(
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		NotebookEditToolName,
+		notebookEditDescription,
+		func(ctx context.Context, params NotebookEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.NotebookPath == "" {
+				return fantasy.NewTextErrorResponse("notebook_path is required"), nil
+			}
+			if !strings.EqualFold(filepath.Ext(params.NotebookPath), ".ipynb") {
+				return fantasy.NewTextErrorResponse("notebook_path must be a Jupyter notebook (.ipynb); use edit for other files"), nil
+			}
+			if params.EditMode == "" {
+				params.EditMode = notebookEditReplace
+			}
+			switch params.EditMode {
+			case notebookEditReplace, notebookEditDelete:
+			case notebookEditInsert:
+				if params.CellType == "" {
+					return fantasy.NewTextErrorResponse("cell_type is required when inserting a cell"), nil
+				}
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown edit_mode %q; use replace, insert or delete", params.EditMode)), nil
+			}
+			if params.CellType != "" && params.CellType != "code" && params.CellType != "markdown" {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown cell_type %q; use code or markdown", params.CellType)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for editing a notebook")
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, params.NotebookPath)
+			fileInfo, err := os.Stat(filePath)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+				}
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
+			}
+			if fileInfo.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", filePath)), nil
+			}
+
+			lastRead := filetracker.LastReadTime(ctx, sessionID, filePath)
+			if lastRead.IsZero() {
+				return fantasy.NewTextErrorResponse("you must read the notebook before editing it. Use the View tool first"), nil
+			}
+			modTime := fileInfo.ModTime().Truncate(time.Second)
+			if modTime.After(lastRead) {
+				return fantasy.NewTextErrorResponse(
+					fmt.Sprintf(
+						"file %s has been modified since it was last read (mod time: %s, last read: %s)",
+						filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
+					),
+				), nil
+			}
+
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+			}
+			nb, err := parseNotebook(data)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to parse notebook %s: %s", filePath, err)), nil
+			}
+
+			change, err := nb.apply(params)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			newData, err := nb.encode()
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to encode notebook: %w", err)
+			}
+
+			_, additions, removals := diff.GenerateDiff(
+				change.oldSource,
+				change.newSource,
+				strings.TrimPrefix(filePath, workingDir),
+			)
+			meta := NotebookEditResponseMetadata{
+				EditMode:   params.EditMode,
+				CellIndex:  change.index,
+				CellType:   change.cellType,
+				OldContent: change.oldSource,
+				NewContent: change.newSource,
+				Additions:  additions,
+				Removals:   removals,
+			}
+
+			p, err := permissions.Request(
+				ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					ToolCallID:  call.ID,
+					ToolName:    NotebookEditToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("%s cell %d of notebook %s", change.verb, change.index, filePath),
+					Params: NotebookEditPermissionsParams{
+						NotebookPath: filePath,
+						EditMode:     params.EditMode,
+						CellIndex:    change.index,
+						CellID:       change.id,
+						CellType:     change.cellType,
+						OldContent:   change.oldSource,
+						NewContent:   change.newSource,
+					},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.WithResponseMetadata(NewPermissionDeniedResponse(), meta), nil
+			}
+
+			if err := os.WriteFile(filePath, newData, fileInfo.Mode().Perm()); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
+			}
+
+			oldContent := string(data)
+			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
+			if err != nil {
+				_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
+				}
+			}
+			if file.Content != oldContent {
+				// User manually changed the content; store an intermediate version
+				_, err = files.CreateVersion(ctx, sessionID, filePath, oldContent)
+				if err != nil {
+					slog.Error("Error creating file history version", "error", err)
+				}
+			}
+			_, err = files.CreateVersion(ctx, sessionID, filePath, string(newData))
+			if err != nil {
+				slog.Error("Error creating file history version", "error", err)
+			}
+
+			filetracker.RecordRead(ctx, sessionID, filePath)
+
+			result := fmt.Sprintf("%s %s cell %d", change.verb, change.cellType, change.index)
+			if change.id != "" {
+				result += fmt.Sprintf(" (id %s)", change.id)
+			}
+			result += " in " + filePath
+			if change.clearedOutputs {
+				result += "; its outputs were cleared"
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(fmt.Sprintf("<result>\n%s\n</result>\n", result)),
+				meta,
+			), nil
+		},
+	)
+}
+
+// notebookFile is a notebook decoded only as far as needed to edit its
+// cells, so that metadata and fields unknown to this package round-trip
+// unchanged.
+type notebookFile struct {
+	fields          map[string]json.RawMessage
+	cells           []map[string]json.RawMessage
+	indent          string
+	trailingNewline bool
+}
+
+// notebookChange describes an applied cell edit.
+type notebookChange struct {
+	verb           string
+	index          int
+	id             string
+	cellType       string
+	oldSource      string
+	newSource      string
+	clearedOutputs bool
+}
+
+func parseNotebook(data []byte) (*notebookFile, error) {
+	nb := &notebookFile{
+		indent:          jsonIndent(data),
+		trailingNewline: bytes.HasSuffix(data, []byte("\n")),
+	}
+	if err := json.Unmarshal(data, &nb.fields); err != nil {
+		return nil, err
+	}
+	raw, ok := nb.fields["cells"]
+	if !ok {
+		return nil, fmt.Errorf("missing cells")
+	}
+	if err := json.Unmarshal(raw, &nb.cells); err != nil {
+		return nil, fmt.Errorf("invalid cells: %w", err)
+	}
+	return nb, nil
+}
+
+// encode writes the notebook back the way Jupyter does: sorted keys, no
+// HTML escaping and the original indentation.
+func (nb *notebookFile) encode() ([]byte, error) {
+	cells, err := marshalNotebookJSON(nb.cells)
+	if err != nil {
+		return nil, err
+	}
+	nb.fields["cells"] = cells
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", nb.indent)
+	if err := enc.Encode(nb.fields); err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	if !nb.trailingNewline {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	return out, nil
+}
+
+func (nb *notebookFile) apply(params NotebookEditParams) (notebookChange, error) {
+	if params.EditMode == notebookEditInsert {
+		index := params.CellIndex
+		if params.CellID != "" {
+			i, err := nb.find(params.CellID, 0)
+			if err != nil {
+				return notebookChange{}, err
+			}
+			index = i + 1
+		}
+		if index < 0 || index > len(nb.cells) {
+			return notebookChange{}, fmt.Errorf("cell_index %d is out of range; a cell can be inserted at 0 to %d", index, len(nb.cells))
+		}
+
+		cell := map[string]json.RawMessage{
+			"metadata": json.RawMessage("{}"),
+		}
+		change := notebookChange{verb: "Inserted", index: index, newSource: params.NewSource}
+		if nb.hasCellIDs() {
+			change.id = nb.newCellID()
+			cell["id"], _ = marshalNotebookJSON(change.id)
+		}
+		setCellType(cell, params.CellType)
+		setCellSource(cell, params.NewSource)
+		change.cellType = params.CellType
+		nb.cells = append(nb.cells[:index], append([]map[string]json.RawMessage{cell}, nb.cells[index:]...)...)
+		return change, nil
+	}
+
+	index, err := nb.find(params.CellID, params.CellIndex)
+	if err != nil {
+		return notebookChange{}, err
+	}
+	cell := nb.cells[index]
+	change := notebookChange{
+		index:     index,
+		id:        stringField(cell, "id"),
+		cellType:  stringField(cell, "cell_type"),
+		oldSource: cellSource(cell),
+	}
+
+	if params.EditMode == notebookEditDelete {
+		change.verb = "Deleted"
+		nb.cells = append(nb.cells[:index], nb.cells[index+1:]...)
+		return change, nil
+	}
+
+	change.verb = "Replaced"
+	change.newSource = params.NewSource
+	hadOutputs := len(cell["outputs"]) > 0 && string(cell["outputs"]) != "[]"
+	if params.CellType != "" && params.CellType != change.cellType {
+		change.cellType = params.CellType
+		setCellType(cell, params.CellType)
+	} else if change.newSource == change.oldSource {
+		return notebookChange{}, fmt.Errorf("cell %d already contains the exact source. No changes made", index)
+	}
+	setCellSource(cell, params.NewSource)
+	if change.cellType == "code" {
+		// Outputs of the old source would no longer match the cell.
+		change.clearedOutputs = hadOutputs
+		cell["outputs"] = json.RawMessage("[]")
+		cell["execution_count"] = json.RawMessage("null")
+	}
+	return change, nil
+}
+
+// find returns the index of the cell with the given ID, or index itself
+// when id is empty.
+func (nb *notebookFile) find(id string, index int) (int, error) {
+	if id != "" {
+		for i, cell := range nb.cells {
+			if stringField(cell, "id") == id {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no cell with id %q in notebook", id)
+	}
+	if index < 0 || index >= len(nb.cells) {
+		return 0, fmt.Errorf("cell_index %d is out of range; the notebook has %d cells", index, len(nb.cells))
+	}
+	return index, nil
+}
+
+// hasCellIDs reports whether cells of this notebook carry IDs, which
+// nbformat requires from version 4.5.
+func (nb *notebookFile) hasCellIDs() bool {
+	for _, cell := range nb.cells {
+		if _, ok := cell["id"]; ok {
+			return true
+		}
+	}
+	var major, minor int
+	_ = json.Unmarshal(nb.fields["nbformat"], &major)
+	_ = json.Unmarshal(nb.fields["nbformat_minor"], &minor)
+	return major > 4 || (major == 4 && minor >= 5)
+}
+
+func (nb *notebookFile) newCellID() string {
+	for {
+		var b [4]byte
+		_, _ = rand.Read(b[:])
+		id := hex.EncodeToString(b[:])
+		if _, err := nb.find(id, 0); err != nil {
+			return id
+		}
+	}
+}
+
+// setCellType sets the type of a cell and adds or removes the fields only
+// code cells have.
+func setCellType(cell map[string]json.RawMessage, cellType string) {
+	cell["cell_type"], _ = marshalNotebookJSON(cellType)
+	if cellType == "code" {
+		cell["outputs"] = json.RawMessage("[]")
+		cell["execution_count"] = json.RawMessage("null")
+		return
+	}
+	delete(cell, "outputs")
+	delete(cell, "execution_count")
+}
+
+// setCellSource stores source as a list of lines, each but the last keeping
+// its newline, as Jupyter does.
+func setCellSource(cell map[string]json.RawMessage, source string) {
+	lines := []string{}
+	for source != "" {
+		i := strings.IndexByte(source, '\n')
+		if i < 0 {
+			lines = append(lines, source)
+			break
+		}
+		lines = append(lines, source[:i+1])
+		source = source[i+1:]
+	}
+	cell["source"], _ = marshalNotebookJSON(lines)
+}
+
+func cellSource(cell map[string]json.RawMessage) string {
+	var lines []string
+	if err := json.Unmarshal(cell["source"], &lines); err == nil {
+		return strings.Join(lines, "")
+	}
+	return stringField(cell, "source")
+}
+
+func stringField(cell map[string]json.RawMessage, key string) string {
+	var s string
+	_ = json.Unmarshal(cell[key], &s)
+	return s
+}
+
+// marshalNotebookJSON encodes v without escaping HTML characters, so that
+// sources containing <, > or & are written as Jupyter writes them.
+func marshalNotebookJSON(v any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonIndent returns the indentation of the first nested line of a JSON
+// document, defaulting to the single space Jupyter uses.
+func jsonIndent(data []byte) string {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return " "
+	}
+	rest := data[i+1:]
+	n := 0
+	for n < len(rest) && (rest[n] == ' ' || rest[n] == '\t') {
+		n++
+	}
+	if n == 0 {
+		return " "
+	}
+	return string(rest[:n])
+}
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testNotebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "id": "intro",
+   "metadata": {},
+   "source": [
+    "# Results <b>&</b> notes"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "id": "plot",
+   "metadata": {
+    "tags": [
+     "keep"
+    ]
+   },
+   "outputs": [
+    {
+     "name": "stdout",
+     "output_type": "stream",
+     "text": [
+      "0.25\n"
+     ]
+    }
+   ],
+   "source": [
+    "x = 1\n",
+    "print(x / 4)"
+   ]
+  }
+ ],
+ "metadata": {
+  "kernelspec": {
+   "display_name": "Python 3",
+   "language": "python",
+   "name": "python3"
+  }
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestNotebookRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	nb, err := parseNotebook([]byte(testNotebook))
+	require.NoError(t, err)
+	out, err := nb.encode()
+	require.NoError(t, err)
+	require.Equal(t, testNotebook, string(out))
+}
+
+func TestNotebookApply(t *testing.T) {
+	t.Parallel()
+
+	decode := func(t *testing.T, nb *notebookFile) []map[string]any {
+		t.Helper()
+		out, err := nb.encode()
+		require.NoError(t, err)
+		var doc struct {
+			Cells    []map[string]any `json:"cells"`
+			Metadata map[string]any   `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(out, &doc))
+		require.Contains(t, doc.Metadata, "kernelspec")
+		return doc.Cells
+	}
+
+	t.Run("replace clears outputs", func(t *testing.T) {
+		t.Parallel()
+		nb, err := parseNotebook([]byte(testNotebook))
+		require.NoError(t, err)
+
+		change, err := nb.apply(NotebookEditParams{EditMode: notebookEditReplace, CellID: "plot", NewSource: "x = 2\nprint(x)\n"})
+		require.NoError(t, err)
+		require.Equal(t, 1, change.index)
+		require.Equal(t, "x = 1\nprint(x / 4)", change.oldSource)
+		require.True(t, change.clearedOutputs)
+
+		cells := decode(t, nb)
+		require.Equal(t, []any{"x = 2\n", "print(x)\n"}, cells[1]["source"])
+		require.Empty(t, cells[1]["outputs"])
+		require.Nil(t, cells[1]["execution_count"])
+		require.Equal(t, map[string]any{"tags": []any{"keep"}}, cells[1]["metadata"])
+	})
+
+	t.Run("replace can change the cell type", func(t *testing.T) {
+		t.Parallel()
+		nb, err := parseNotebook([]byte(testNotebook))
+		require.NoError(t, err)
+
+		_, err = nb.apply(NotebookEditParams{EditMode: notebookEditReplace, CellIndex: 1, CellType: "markdown", NewSource: "Removed"})
+		require.NoError(t, err)
+
+		cells := decode(t, nb)
+		require.Equal(t, "markdown", cells[1]["cell_type"])
+		require.NotContains(t, cells[1], "outputs")
+		require.NotContains(t, cells[1], "execution_count")
+	})
+
+	t.Run("insert after id", func(t *testing.T) {
+		t.Parallel()
+		nb, err := parseNotebook([]byte(testNotebook))
+		require.NoError(t, err)
+
+		change, err := nb.apply(NotebookEditParams{EditMode: notebookEditInsert, CellID: "intro", CellType: "code", NewSource: "import numpy"})
+		require.NoError(t, err)
+		require.Equal(t, 1, change.index)
+		require.Len(t, change.id, 8)
+
+		cells := decode(t, nb)
+		require.Len(t, cells, 3)
+		require.Equal(t, change.id, cells[1]["id"])
+		require.Equal(t, []any{"import numpy"}, cells[1]["source"])
+		require.Equal(t, []any{}, cells[1]["outputs"])
+		require.Equal(t, "plot", cells[2]["id"])
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		nb, err := parseNotebook([]byte(testNotebook))
+		require.NoError(t, err)
+
+		change, err := nb.apply(NotebookEditParams{EditMode: notebookEditDelete, CellIndex: 0})
+		require.NoError(t, err)
+		require.Equal(t, "# Results <b>&</b> notes", change.oldSource)
+
+		cells := decode(t, nb)
+		require.Len(t, cells, 1)
+		require.Equal(t, "plot", cells[0]["id"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		nb, err := parseNotebook([]byte(testNotebook))
+		require.NoError(t, err)
+
+		_, err = nb.apply(NotebookEditParams{EditMode: notebookEditReplace, CellIndex: 2, NewSource: "x"})
+		require.ErrorContains(t, err, "out of range")
+		_, err = nb.apply(NotebookEditParams{EditMode: notebookEditDelete, CellID: "missing"})
+		require.ErrorContains(t, err, `no cell with id "missing"`)
+		_, err = nb.apply(NotebookEditParams{EditMode: notebookEditReplace, CellIndex: 0, NewSource: "# Results <b>&</b> notes"})
+		require.ErrorContains(t, err, "No changes made")
+	})
+}
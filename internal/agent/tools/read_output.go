@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/outputstore"
+)
+
+const (
+	ReadOutputToolName = "read_output"
+
+	defaultOutputContext = 2
+	maxOutputContext     = 20
+)
+
+//go:embed read_output.md
+var readOutputDescription string
+
+type ReadOutputParams struct {
+	Path    string `json:"path" description:"The path of the saved output, from the truncation note"`
+	Offset  int    `json:"offset,omitempty" description:"The line number to start reading or searching from (1-based, default 1)"`
+	Limit   int    `json:"limit,omitempty" description:"The number of lines to return, or of matches with pattern (default 200)"`
+	Pattern string `json:"pattern,omitempty" description:"Regular expression; when set, only matching lines are returned, with context"`
+	Context int    `json:"context,omitempty" description:"The number of lines shown before and after each match (default 2, max 20)"`
+}
+
+type ReadOutputResponseMetadata struct {
+	Path       string `json:"path"`
+	TotalLines int    `json:"total_lines"`
+	Matches    int    `json:"matches,omitempty"`
+}
+
+func NewReadOutputTool(outputs *outputstore.Store) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ReadOutputToolName,
+		readOutputDescription,
+		func(ctx context.Context, params ReadOutputParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Path == "" {
+				return fantasy.NewTextErrorResponse("missing path"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for reading output")
+			}
+
+			path, err := outputs.Resolve(sessionID, params.Path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			lines, err := readOutputLines(path)
+			if errors.Is(err, os.ErrNotExist) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("output not found: %s", params.Path)), nil
+			}
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error reading output: %w", err)
+			}
+
+			offset := max(params.Offset, 1)
+			limit := params.Limit
+			if limit <= 0 {
+				limit = DefaultReadLimit
+			}
+			metadata := ReadOutputResponseMetadata{Path: path, TotalLines: len(lines)}
+
+			if params.Pattern == "" {
+				output := pageOutput(lines, offset, limit)
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
+			}
+
+			re, err := regexp.Compile(params.Pattern)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid pattern: %s", err)), nil
+			}
+			contextLines := defaultOutputContext
+			if params.Context > 0 {
+				contextLines = min(params.Context, maxOutputContext)
+			}
+			output, matches := searchOutput(lines, re, offset, limit, contextLines)
+			metadata.Matches = matches
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
+		},
+	)
+}
+
+// readOutputLines returns the lines of the output saved at path, cutting
+// lines longer than MaxLineLength short.
+func readOutputLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if len(line) > MaxLineLength {
+				line = line[:MaxLineLength] + "..."
+			}
+			lines = append(lines, line)
+		}
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pageOutput returns limit lines of lines from the 1-based line offset.
+func pageOutput(lines []string, offset, limit int) string {
+	if offset > len(lines) {
+		return fmt.Sprintf("The output has %d lines; offset %d is past its end.", len(lines), offset)
+	}
+	end := min(offset-1+limit, len(lines))
+	var b strings.Builder
+	fmt.Fprintf(&b, "Lines %d-%d of %d:\n", offset, end, len(lines))
+	b.WriteString(addLineNumbers(strings.Join(lines[offset-1:end], "\n"), offset))
+	if end < len(lines) {
+		fmt.Fprintf(&b, "\n\n(Output has more lines. Use 'offset' %d to read on.)", end+1)
+	}
+	return b.String()
+}
+
+// searchOutput returns up to limit lines matching re from the 1-based line
+// offset, each with contextLines lines around it, and the number of
+// matches shown. Groups of lines that are not adjacent are separated by
+// --, as with grep.
+func searchOutput(lines []string, re *regexp.Regexp, offset, limit, contextLines int) (string, int) {
+	var b strings.Builder
+	matches, last, next := 0, 0, 0
+	for i := offset - 1; i < len(lines); i++ {
+		if !re.MatchString(lines[i]) {
+			continue
+		}
+		if matches == limit {
+			next = i + 1
+			break
+		}
+		matches++
+		start := max(i-contextLines, last, offset-1)
+		end := min(i+contextLines+1, len(lines))
+		if end <= start {
+			// Already shown as context of the previous match.
+			continue
+		}
+		if last > 0 && start > last {
+			b.WriteString("\n--")
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(addLineNumbers(strings.Join(lines[start:end], "\n"), start+1))
+		last = end
+	}
+
+	if matches == 0 {
+		return fmt.Sprintf("No lines match %s from line %d of %d.", re, offset, len(lines)), 0
+	}
+	header := fmt.Sprintf("%d matches of %s in %d lines:\n", matches, re, len(lines))
+	if next > 0 {
+		fmt.Fprintf(&b, "\n\n(More matches follow. Use 'offset' %d to search on.)", next)
+	}
+	return header + b.String(), matches
+}
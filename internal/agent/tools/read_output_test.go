@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/outputstore"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func runReadOutputTool(t *testing.T, tool fantasy.AgentTool, ctx context.Context, params ReadOutputParams) fantasy.ToolResponse {
+	t.Helper()
+
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "read-call", Name: ReadOutputToolName, Input: string(input)})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestBashTool_SpillsOutput(t *testing.T) {
+	t.Parallel()
+
+	outputs := outputstore.New(t.TempDir())
+	permissions := &mockBashPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleNone}
+	tool := NewBashTool(permissions, nil, outputs, t.TempDir(), attribution, "test-model", config.ToolBash{})
+	require.Contains(t, tool.Info().Description, "read it with read_output")
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp := runBashTool(t, tool, ctx, BashParams{
+		Description: "long output",
+		Command:     `i=1; while [ $i -le 5000 ]; do echo "line $i"; if [ $i -eq 2500 ]; then echo "FAILED here"; fi; i=$((i+1)); done`,
+	})
+	require.False(t, resp.IsError)
+	require.NotContains(t, resp.Content, "FAILED here")
+	match := regexp.MustCompile(`the full stdout \(5001 lines\) is saved to (\S+), use read_output`).FindStringSubmatch(resp.Content)
+	require.NotNil(t, match, resp.Content)
+
+	readTool := NewReadOutputTool(outputs)
+	resp = runReadOutputTool(t, readTool, ctx, ReadOutputParams{Path: match[1], Pattern: "FAILED", Context: 1})
+	require.Equal(t, "1 matches of FAILED in 5001 lines:\n  2500|line 2500\n  2501|FAILED here\n  2502|line 2501", resp.Content)
+
+	resp = runReadOutputTool(t, readTool, ctx, ReadOutputParams{Path: match[1], Offset: 4999, Limit: 2})
+	require.Equal(t, "Lines 4999-5000 of 5001:\n  4999|line 4998\n  5000|line 4999\n\n(Output has more lines. Use 'offset' 5001 to read on.)", resp.Content)
+
+	otherCtx := context.WithValue(context.Background(), SessionIDContextKey, "other-session")
+	resp = runReadOutputTool(t, readTool, otherCtx, ReadOutputParams{Path: match[1]})
+	require.True(t, resp.IsError)
+}
+
+func TestSearchOutput(t *testing.T) {
+	t.Parallel()
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+
+	output, matches := searchOutput(lines, regexp.MustCompile(`^line (5|6|20|21|29)$`), 1, 4, 1)
+	require.Equal(t, 4, matches)
+	require.Equal(t, strings.Join([]string{
+		"4 matches of ^line (5|6|20|21|29)$ in 30 lines:",
+		"     4|line 4",
+		"     5|line 5",
+		"     6|line 6",
+		"     7|line 7",
+		"--",
+		"    19|line 19",
+		"    20|line 20",
+		"    21|line 21",
+		"    22|line 22",
+		"",
+		"(More matches follow. Use 'offset' 29 to search on.)",
+	}, "\n"), output)
+
+	output, matches = searchOutput(lines, regexp.MustCompile(`line 5$`), 10, 4, 1)
+	require.Zero(t, matches)
+	require.Equal(t, "No lines match line 5$ from line 10 of 30.", output)
+}
@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/testrun"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+type RunTestsParams struct {
+	Package         string `json:"package,omitempty" description:"Package, directory or file to test. For Go this is a package pattern such as ./internal/foo/...; defaults to the whole project"`
+	Test            string `json:"test,omitempty" description:"Only run tests matching this name. For Go this is a -run regular expression"`
+	RunInBackground bool   `json:"run_in_background,omitempty" description:"Start the run as a background job and return its ID immediately"`
+	JobID           string `json:"job_id,omitempty" description:"Collect the results of a run started earlier in the background, waiting for it to finish"`
+}
+
+type RunTestsPermissionsParams struct {
+	Command    string `json:"command"`
+	WorkingDir string `json:"working_dir"`
+}
+
+type RunTestsResponseMetadata struct {
+	Command    string            `json:"command"`
+	Adapter    string            `json:"adapter"`
+	Passed     int               `json:"passed"`
+	Failed     int               `json:"failed"`
+	Skipped    int               `json:"skipped"`
+	Failures   []testrun.Failure `json:"failures,omitempty"`
+	Background bool              `json:"background,omitempty"`
+	JobID      string            `json:"job_id,omitempty"`
+}
+
+const RunTestsToolName = "run_tests"
+
+//go:embed run_tests.md
+var runTestsDescription string
+
+// testAdapter builds the command for a test run and turns its output into a
+// report.
+type testAdapter interface {
+	command(pkg, test string) (string, error)
+	parse(stdout, stderr string, exitCode int, startedAt time.Time) (testrun.Report, error)
+}
+
+// testJob is a test run that was moved to the background.
+type testJob struct {
+	adapter config.TestAdapter
+	parser  testAdapter
+	command string
+	started time.Time
+}
+
+// NewRunTestsTool returns the tool running the tests of the project. Runs
+// go through the sandbox, command policy and shell configured for the bash
+// tool in bashCfg, since test code is as arbitrary as a bash command.
+func NewRunTestsTool(permissions permission.Service, workingDir string, cfg config.ToolRunTests, bashCfg config.ToolBash) fantasy.AgentTool {
+	jobs := csync.NewMap[string, testJob]()
+	policy := newBashSandbox(bashCfg.Sandbox, workingDir)
+	external := newExternalShell(bashCfg.Shell)
+	blocks, policyErr := bashBlockFuncs(bashCfg.Policy)
+	return fantasy.NewAgentTool(
+		RunTestsToolName,
+		runTestsDescription,
+		func(ctx context.Context, params RunTestsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.JobID != "" {
+				job, ok := jobs.Get(params.JobID)
+				if !ok {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("no test run found with job_id %s", params.JobID)), nil
+				}
+				bgShell, ok := shell.GetBackgroundShellManager().Get(params.JobID)
+				if !ok {
+					jobs.Del(params.JobID)
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("background job %s is no longer available", params.JobID)), nil
+				}
+				if !bgShell.WaitContext(ctx) {
+					return fantasy.ToolResponse{}, ctx.Err()
+				}
+				jobs.Del(params.JobID)
+				return testRunResponse(bgShell, job)
+			}
+
+			if policyErr != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("The bash command policy in the configuration is invalid: %s", policyErr)), nil
+			}
+			if policy != nil {
+				if err := policy.Check(); err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("The bash sandbox is enabled in the configuration but cannot run on this system: %s", err)), nil
+				}
+			}
+
+			adapter := cfg.GetAdapter()
+			parser, err := newTestAdapter(adapter, cfg, workingDir)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			command, err := parser.command(params.Package, params.Test)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running tests")
+			}
+			p, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        workingDir,
+				ToolCallID:  call.ID,
+				ToolName:    RunTestsToolName,
+				Action:      "execute",
+				Description: fmt.Sprintf("Run tests: %s", command),
+				Params: RunTestsPermissionsParams{
+					Command:    command,
+					WorkingDir: workingDir,
+				},
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
+			}
+
+			job := testJob{adapter: adapter, parser: parser, command: command, started: time.Now()}
+			bgManager := shell.GetBackgroundShellManager()
+			bgManager.Cleanup()
+			// Detach the context so the run survives being moved to the
+			// background.
+			bgShell, err := bgManager.StartJob(shell.WithSandbox(context.WithoutCancel(ctx), policy), shell.JobOptions{
+				WorkingDir:  workingDir,
+				BlockFuncs:  blocks,
+				Command:     command,
+				Description: "Run tests",
+				External:    external,
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error starting tests: %w", err)
+			}
+
+			wait := time.Duration(DefaultAutoBackgroundAfter) * time.Second
+			if params.RunInBackground {
+				wait = time.Second
+			}
+			waitCtx, cancel := context.WithTimeout(ctx, wait)
+			defer cancel()
+			if bgShell.WaitContext(waitCtx) {
+				return testRunResponse(bgShell, job)
+			}
+			if ctx.Err() != nil {
+				bgManager.Kill(bgShell.ID)
+				return fantasy.ToolResponse{}, ctx.Err()
+			}
+
+			jobs.Set(bgShell.ID, job)
+			metadata := RunTestsResponseMetadata{
+				Command:    command,
+				Adapter:    string(adapter),
+				Background: true,
+				JobID:      bgShell.ID,
+			}
+			response := fmt.Sprintf("Tests are running in background job %s.\n\nCall run_tests with job_id %q to wait for the results, or job_kill to stop the run.", bgShell.ID, bgShell.ID)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), metadata), nil
+		},
+	)
+}
+
+// testRunResponse parses the output of a finished test run and removes it
+// from the background manager.
+func testRunResponse(bgShell *shell.BackgroundShell, job testJob) (fantasy.ToolResponse, error) {
+	stdout, stderr, _, execErr := bgShell.GetOutput()
+	shell.GetBackgroundShellManager().Remove(bgShell.ID)
+
+	exitCode := shell.ExitCode(execErr)
+	if exitCode == 0 && execErr != nil && !shell.IsInterrupt(execErr) {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("error running tests: %v", execErr)), nil
+	}
+
+	report, err := job.parser.parse(stdout, stderr, exitCode, job.started)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("could not parse test results: %v\n\n%s", err, truncateOutput(formatOutput(stdout, stderr, execErr, nil)))), nil
+	}
+
+	metadata := RunTestsResponseMetadata{
+		Command:  job.command,
+		Adapter:  string(job.adapter),
+		Passed:   report.Passed,
+		Failed:   report.Failed,
+		Skipped:  report.Skipped,
+		Failures: report.Failures,
+	}
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(truncateOutput(report.String())), metadata), nil
+}
+
+func newTestAdapter(adapter config.TestAdapter, cfg config.ToolRunTests, workingDir string) (testAdapter, error) {
+	switch adapter {
+	case config.TestAdapterGo:
+		return goTestAdapter{}, nil
+	case config.TestAdapterJUnit:
+		if cfg.Command == "" || cfg.Report == "" {
+			return nil, errors.New("the junit test adapter needs both tools.run_tests.command and tools.run_tests.report in crush.json")
+		}
+		return junitTestAdapter{commandLine: cfg.Command, report: cfg.Report, workingDir: workingDir}, nil
+	case config.TestAdapterGeneric:
+		if cfg.Command == "" {
+			return nil, errors.New("the generic test adapter needs tools.run_tests.command in crush.json")
+		}
+		return genericTestAdapter{commandLine: cfg.Command}, nil
+	default:
+		return nil, fmt.Errorf("unknown test adapter %q; expected go, junit or generic", adapter)
+	}
+}
+
+type goTestAdapter struct{}
+
+func (goTestAdapter) command(pkg, test string) (string, error) {
+	// Values starting with a dash would pass as go test flags such as
+	// -exec or -toolexec.
+	if strings.HasPrefix(pkg, "-") {
+		return "", fmt.Errorf("invalid package %q", pkg)
+	}
+	if strings.HasPrefix(test, "-") {
+		return "", fmt.Errorf("invalid test name %q", test)
+	}
+	args := []string{"go", "test", "-json"}
+	if test != "" {
+		args = append(args, "-run", test)
+	}
+	if pkg == "" {
+		pkg = "./..."
+	}
+	args = append(args, pkg)
+	return quoteCommand(args)
+}
+
+func (goTestAdapter) parse(stdout, stderr string, exitCode int, _ time.Time) (testrun.Report, error) {
+	report, err := testrun.ParseGoTestJSON(strings.NewReader(stdout))
+	if err != nil {
+		return report, err
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		report.Output = strings.TrimSpace(report.Output + "\n" + stderr)
+	}
+	if exitCode != 0 && report.OK() {
+		report.Failures = append(report.Failures, testrun.Failure{Name: "go test", Message: fmt.Sprintf("exited with code %d", exitCode)})
+	}
+	return report, nil
+}
+
+type junitTestAdapter struct {
+	commandLine string
+	report      string
+	workingDir  string
+}
+
+func (a junitTestAdapter) command(pkg, test string) (string, error) {
+	return withTestEnv(a.commandLine, pkg, test)
+}
+
+func (a junitTestAdapter) parse(stdout, stderr string, exitCode int, startedAt time.Time) (testrun.Report, error) {
+	pattern := a.report
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(a.workingDir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return testrun.Report{}, err
+	}
+
+	var (
+		report testrun.Report
+		found  bool
+	)
+	for _, path := range matches {
+		// Ignore reports left over from earlier runs.
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(startedAt.Add(-time.Second)) {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return report, err
+		}
+		r, err := testrun.ParseJUnit(data)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", path, err)
+		}
+		report.Merge(r)
+		found = true
+	}
+	if !found {
+		report = testrun.ParseGeneric(stdout+stderr, exitCode)
+		report.Output = fmt.Sprintf("No JUnit report matching %s was written.\n\n%s", a.report, report.Output)
+	}
+	return report, nil
+}
+
+type genericTestAdapter struct {
+	commandLine string
+}
+
+func (a genericTestAdapter) command(pkg, test string) (string, error) {
+	return withTestEnv(a.commandLine, pkg, test)
+}
+
+func (genericTestAdapter) parse(stdout, stderr string, exitCode int, _ time.Time) (testrun.Report, error) {
+	return testrun.ParseGeneric(stdout+stderr, exitCode), nil
+}
+
+// withTestEnv prefixes a configured test command with the TEST and PACKAGE
+// variables so it can narrow the run, e.g. `pytest ${PACKAGE:-tests}`.
+func withTestEnv(command, pkg, test string) (string, error) {
+	quotedTest, err := syntax.Quote(test, syntax.LangBash)
+	if err != nil {
+		return "", fmt.Errorf("invalid test name: %w", err)
+	}
+	quotedPkg, err := syntax.Quote(pkg, syntax.LangBash)
+	if err != nil {
+		return "", fmt.Errorf("invalid package: %w", err)
+	}
+	return fmt.Sprintf("export TEST=%s PACKAGE=%s; %s", quotedTest, quotedPkg, command), nil
+}
+
+func quoteCommand(args []string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		q, err := syntax.Quote(arg, syntax.LangBash)
+		if err != nil {
+			return "", fmt.Errorf("invalid argument %q: %w", arg, err)
+		}
+		quoted[i] = q
+	}
+	return strings.Join(quoted, " "), nil
+}
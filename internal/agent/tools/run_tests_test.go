@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoTestAdapterCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		pkg  string
+		test string
+		want string
+	}{
+		{name: "everything", want: "go test -json ./..."},
+		{name: "package", pkg: "./internal/diff", want: "go test -json ./internal/diff"},
+		{name: "single test", pkg: "./internal/diff", test: "^TestApply$", want: "go test -json -run '^TestApply$' ./internal/diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := goTestAdapter{}.command(tt.pkg, tt.test)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := goTestAdapter{}.command("-exec=/tmp/x", "")
+	require.EqualError(t, err, `invalid package "-exec=/tmp/x"`)
+	_, err = goTestAdapter{}.command("./...", "-toolexec=/tmp/x")
+	require.EqualError(t, err, `invalid test name "-toolexec=/tmp/x"`)
+}
+
+func TestWithTestEnv(t *testing.T) {
+	t.Parallel()
+
+	got, err := withTestEnv("pytest ${PACKAGE:-tests}", "tests/test_io.py", "test read")
+	require.NoError(t, err)
+	require.Equal(t, "export TEST='test read' PACKAGE=tests/test_io.py; pytest ${PACKAGE:-tests}", got)
+}
+
+func TestRunTestsGenericAdapter(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.ToolRunTests{Command: `echo "running $PACKAGE"; echo "src/lib.rs:10: boom"; echo "3 passed, 1 failed"; exit 1`}
+	tool := NewRunTestsTool(&mockPermissionService{}, t.TempDir(), cfg, config.ToolBash{})
+
+	input, err := json.Marshal(RunTestsParams{Package: "src"})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{
+		ID:    "test-call",
+		Name:  RunTestsToolName,
+		Input: string(input),
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "FAIL: 3 passed, 1 failed, 0 skipped")
+	require.Contains(t, resp.Content, "at src/lib.rs:10")
+	require.Contains(t, resp.Content, "running src")
+
+	var meta RunTestsResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, string(config.TestAdapterGeneric), meta.Adapter)
+	require.Equal(t, 3, meta.Passed)
+	require.Equal(t, 1, meta.Failed)
+}
@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+)
+
+const (
+	ToolSearchToolName = "tool_search"
+
+	defaultToolSearchLimit = 5
+	maxToolSearchLimit     = 20
+)
+
+//go:embed tool_search.md
+var toolSearchDescription string
+
+type ToolSearchParams struct {
+	Query string `json:"query" description:"Keywords describing the capability you need, or the exact name of a tool"`
+	Limit int    `json:"limit,omitempty" description:"Maximum number of tools to activate (default 5, max 20)"`
+}
+
+type ToolSearchResponseMetadata struct {
+	Query     string   `json:"query"`
+	Activated []string `json:"activated"`
+}
+
+// DeferredTools tracks the tools that are not sent to the model upfront,
+// and which of them each session activated with the tool_search tool.
+type DeferredTools struct {
+	mu    sync.RWMutex
+	tools map[string]fantasy.ToolInfo
+	// servers holds the MCP server of each deferred MCP tool.
+	servers map[string]string
+	// sessions holds the names of the deferred tools activated in each
+	// session.
+	sessions map[string]map[string]bool
+}
+
+// NewDeferredTools creates a tracker without deferred tools.
+func NewDeferredTools() *DeferredTools {
+	return &DeferredTools{
+		tools:    make(map[string]fantasy.ToolInfo),
+		servers:  make(map[string]string),
+		sessions: make(map[string]map[string]bool),
+	}
+}
+
+// Set replaces the deferred tools. Sessions keep the tools they activated.
+func (d *DeferredTools) Set(tools []fantasy.AgentTool) {
+	infos := make(map[string]fantasy.ToolInfo, len(tools))
+	servers := make(map[string]string)
+	for _, tool := range tools {
+		info := tool.Info()
+		infos[info.Name] = info
+		if t, ok := tool.(*Tool); ok {
+			servers[info.Name] = t.MCP()
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tools = infos
+	d.servers = servers
+}
+
+// Len returns the number of deferred tools.
+func (d *DeferredTools) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.tools)
+}
+
+// Activate activates the named deferred tools in the session.
+func (d *DeferredTools) Activate(sessionID string, names ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	active, ok := d.sessions[sessionID]
+	if !ok {
+		active = make(map[string]bool)
+		d.sessions[sessionID] = active
+	}
+	for _, name := range names {
+		active[name] = true
+	}
+}
+
+// Active returns the sorted names of the deferred tools activated in the
+// session.
+func (d *DeferredTools) Active(sessionID string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var names []string
+	for name := range d.sessions[sessionID] {
+		if _, ok := d.tools[name]; ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Filter returns tools without the deferred tools the session has not
+// activated. A nil tracker defers nothing.
+func (d *DeferredTools) Filter(sessionID string, tools []fantasy.AgentTool) []fantasy.AgentTool {
+	if d == nil {
+		return tools
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.tools) == 0 {
+		return tools
+	}
+	active := d.sessions[sessionID]
+	result := make([]fantasy.AgentTool, 0, len(tools))
+	for _, tool := range tools {
+		name := tool.Info().Name
+		if _, deferred := d.tools[name]; deferred && !active[name] {
+			continue
+		}
+		result = append(result, tool)
+	}
+	return result
+}
+
+// Search returns up to limit deferred tools matching query, best matches
+// first. A tool matches when its name or description contains any of the
+// words of query; the exact name of a tool matches it alone.
+func (d *DeferredTools) Search(query string, limit int) []fantasy.ToolInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query = strings.TrimSpace(query)
+	if info, ok := d.tools[query]; ok {
+		return []fantasy.ToolInfo{info}
+	}
+	query = strings.ToLower(query)
+
+	type match struct {
+		info  fantasy.ToolInfo
+		score int
+	}
+	var matches []match
+	terms := strings.Fields(query)
+	for _, info := range d.tools {
+		name := strings.ToLower(info.Name)
+		description := strings.ToLower(info.Description)
+		score := 0
+		for _, term := range terms {
+			if strings.Contains(name, term) {
+				// Names are short and specific, so they weigh more.
+				score += 3
+			}
+			if strings.Contains(description, term) {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, match{info: info, score: score})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), strings.Compare(a.info.Name, b.info.Name))
+	})
+
+	result := make([]fantasy.ToolInfo, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		result = append(result, m.info)
+	}
+	return result
+}
+
+// summary describes the deferred tools by the MCP server they come from,
+// so the model knows what it can search for.
+func (d *DeferredTools) summary() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	servers := make(map[string]int)
+	var builtin []string
+	for name := range d.tools {
+		if server, ok := d.servers[name]; ok {
+			servers[server]++
+		} else {
+			builtin = append(builtin, name)
+		}
+	}
+	var parts []string
+	for _, server := range slices.Sorted(maps.Keys(servers)) {
+		count := servers[server]
+		noun := "tools"
+		if count == 1 {
+			noun = "tool"
+		}
+		parts = append(parts, fmt.Sprintf("the %s MCP server (%d %s)", server, count, noun))
+	}
+	slices.Sort(builtin)
+	parts = append(parts, builtin...)
+	return strings.Join(parts, ", ")
+}
+
+// NewToolSearchTool returns the tool that activates the tools deferred by
+// deferred.
+func NewToolSearchTool(deferred *DeferredTools) fantasy.AgentTool {
+	description := toolSearchDescription
+	if summary := deferred.summary(); summary != "" {
+		description += "\nThe tools available through this search come from: " + summary + "."
+	}
+	return fantasy.NewAgentTool(
+		ToolSearchToolName,
+		description,
+		func(ctx context.Context, params ToolSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Query) == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for activating tools")
+			}
+
+			limit := params.Limit
+			if limit <= 0 {
+				limit = defaultToolSearchLimit
+			}
+			limit = min(limit, maxToolSearchLimit)
+
+			found := deferred.Search(params.Query, limit)
+			if len(found) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No tools matched %q. Try other keywords.", params.Query)), nil
+			}
+
+			metadata := ToolSearchResponseMetadata{Query: params.Query}
+			var output strings.Builder
+			fmt.Fprintf(&output, "Activated %d tools, callable from your next step on:\n", len(found))
+			for _, info := range found {
+				metadata.Activated = append(metadata.Activated, info.Name)
+				description, _, _ := strings.Cut(info.Description, "\n")
+				fmt.Fprintf(&output, "- %s: %s\n", info.Name, description)
+			}
+			deferred.Activate(sessionID, metadata.Activated...)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output.String()), metadata), nil
+		},
+	)
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// infoTool is a tool that only has a name and a description.
+type infoTool struct {
+	info fantasy.ToolInfo
+}
+
+func (t *infoTool) Info() fantasy.ToolInfo { return t.info }
+func (t *infoTool) Run(context.Context, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	return fantasy.NewTextResponse("ok"), nil
+}
+func (t *infoTool) ProviderOptions() fantasy.ProviderOptions     { return nil }
+func (t *infoTool) SetProviderOptions(_ fantasy.ProviderOptions) {}
+
+func newInfoTool(name, description string) fantasy.AgentTool {
+	return &infoTool{info: fantasy.ToolInfo{Name: name, Description: description}}
+}
+
+func newDeferredMCPTool(server, name, description string) fantasy.AgentTool {
+	return &Tool{mcpName: server, tool: &mcp.Tool{Name: name, Description: description}}
+}
+
+func testDeferredTools() *DeferredTools {
+	d := NewDeferredTools()
+	d.Set([]fantasy.AgentTool{
+		newDeferredMCPTool("github", "create_issue", "Create a new issue in a GitHub repository"),
+		newDeferredMCPTool("github", "list_pull_requests", "List the pull requests of a repository"),
+		newDeferredMCPTool("linear", "createIssue", "Create a Linear ticket"),
+		newInfoTool(SourcegraphToolName, "Search code across public repositories"),
+	})
+	return d
+}
+
+func TestDeferredToolsSearch(t *testing.T) {
+	t.Parallel()
+
+	d := testDeferredTools()
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "name before description", query: "issue", limit: 5, want: []string{"mcp_github_create_issue", "mcp_linear_createIssue"}},
+		{name: "several words", query: "pull repository", limit: 5, want: []string{"mcp_github_list_pull_requests", "mcp_github_create_issue"}},
+		{name: "limit", query: "repositor", limit: 1, want: []string{"mcp_github_create_issue"}},
+		{name: "exact name", query: "mcp_linear_createIssue", limit: 5, want: []string{"mcp_linear_createIssue"}},
+		{name: "server", query: "linear", limit: 5, want: []string{"mcp_linear_createIssue"}},
+		{name: "no match", query: "kubernetes", limit: 5, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var names []string
+			for _, info := range d.Search(tt.query, tt.limit) {
+				names = append(names, info.Name)
+			}
+			require.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestDeferredToolsFilter(t *testing.T) {
+	t.Parallel()
+
+	d := testDeferredTools()
+	all := []fantasy.AgentTool{
+		newInfoTool(ViewToolName, "View a file"),
+		newDeferredMCPTool("github", "create_issue", "Create a new issue in a GitHub repository"),
+		newInfoTool(SourcegraphToolName, "Search code across public repositories"),
+	}
+	names := func(tools []fantasy.AgentTool) []string {
+		var result []string
+		for _, tool := range tools {
+			result = append(result, tool.Info().Name)
+		}
+		return result
+	}
+
+	require.Equal(t, []string{ViewToolName}, names(d.Filter("session", all)))
+
+	d.Activate("session", SourcegraphToolName)
+	require.Equal(t, []string{ViewToolName, SourcegraphToolName}, names(d.Filter("session", all)))
+	require.Equal(t, []string{ViewToolName}, names(d.Filter("other", all)))
+	require.Equal(t, []string{SourcegraphToolName}, d.Active("session"))
+
+	// Activations survive the deferred tools being rebuilt.
+	d.Set(all[1:])
+	require.Equal(t, []string{ViewToolName, SourcegraphToolName}, names(d.Filter("session", all)))
+
+	var nilTracker *DeferredTools
+	require.Len(t, nilTracker.Filter("session", all), 3)
+}
+
+func TestToolSearchTool(t *testing.T) {
+	t.Parallel()
+
+	d := testDeferredTools()
+	tool := NewToolSearchTool(d)
+	require.Contains(t, tool.Info().Description, "the github MCP server (2 tools), the linear MCP server (1 tool), sourcegraph")
+
+	input, err := json.Marshal(ToolSearchParams{Query: "issue"})
+	require.NoError(t, err)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: ToolSearchToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "- mcp_github_create_issue: Create a new issue in a GitHub repository")
+
+	var metadata ToolSearchResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &metadata))
+	require.Equal(t, []string{"mcp_github_create_issue", "mcp_linear_createIssue"}, metadata.Activated)
+	require.Equal(t, metadata.Activated, d.Active("session"))
+}
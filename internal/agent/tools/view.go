@@ -17,6 +17,7 @@ import (
 	"unicode/utf8"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/extract"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -69,6 +70,10 @@ type ViewResponseMetadata struct {
 	ResourceType        ViewResourceType `json:"resource_type,omitempty"`
 	ResourceName        string           `json:"resource_name,omitempty"`
 	ResourceDescription string           `json:"resource_description,omitempty"`
+	// ResourceAllowedTools and ResourceStrict describe the tools a skill
+	// grants while it is loaded.
+	ResourceAllowedTools []string `json:"resource_allowed_tools,omitempty"`
+	ResourceStrict       bool     `json:"resource_strict,omitempty"`
 }
 
 const (
@@ -105,7 +110,7 @@ func NewViewTool(
 
 			// Handle builtin skill files (crush: prefix).
 			if strings.HasPrefix(params.FilePath, skills.BuiltinPrefix) {
-				resp, err := readBuiltinFile(params, skillTracker)
+				resp, err := readBuiltinFile(GetSessionFromContext(ctx), params, skillTracker)
 				return resp, err
 			}
 
@@ -227,6 +232,10 @@ func NewViewTool(
 				return fantasy.NewImageResponse(imageData, mimeType), nil
 			}
 
+			if extract.Supported(filePath) {
+				return viewDocument(ctx, filetracker, sessionID, filePath, params)
+			}
+
 			// Read the file content
 			maxContentSize := MaxViewSize
 			if isSkillFile {
@@ -267,7 +276,8 @@ func NewViewTool(
 					meta.ResourceType = ViewResourceSkill
 					meta.ResourceName = skill.Name
 					meta.ResourceDescription = skill.Description
-					skillTracker.MarkLoaded(skill.Name)
+					setSkillGrants(&meta, skill)
+					skillTracker.MarkLoadedInSession(sessionID, skill.Name)
 				}
 			}
 
@@ -304,6 +314,63 @@ func addLineNumbers(content string, startLine int) string {
 	return strings.Join(result, "\n")
 }
 
+// viewDocument returns the extracted text of a PDF, Office document, CSV
+// file or notebook, paginated like a text file. When the returned lines
+// include an image placeholder and the model supports images, the first
+// such image is attached.
+func viewDocument(ctx context.Context, filetracker filetracker.Service, sessionID, filePath string, params ViewParams) (fantasy.ToolResponse, error) {
+	doc, err := extract.File(filePath)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("Failed to read %s: %s", filePath, err)), nil
+	}
+
+	content, hasMore, err := readLines(strings.NewReader(doc.Text), params.Offset, params.Limit, MaxViewSize)
+	if err != nil {
+		var tooLarge contentTooLargeError
+		if errors.As(err, &tooLarge) {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("Content section is too large (%d bytes). Maximum size is %d bytes",
+				tooLarge.Size, tooLarge.Max)), nil
+		}
+		return fantasy.ToolResponse{}, fmt.Errorf("error reading document: %w", err)
+	}
+	lineCount := len(strings.Split(content, "\n"))
+
+	output := "<file>\n"
+	output += addLineNumbers(content, params.Offset+1)
+	if hasMore {
+		output += fmt.Sprintf("\n\n(Document has more lines. Use 'offset' parameter to read beyond line %d)",
+			params.Offset+lineCount)
+	}
+	output += "\n</file>\n"
+	filetracker.RecordRead(ctx, sessionID, filePath)
+
+	meta := ViewResponseMetadata{
+		FilePath: filePath,
+		Content:  content,
+	}
+
+	var images []extract.Image
+	for _, img := range doc.Images {
+		if img.Line >= params.Offset && img.Line < params.Offset+lineCount && len(img.Data) <= MaxViewSize {
+			images = append(images, img)
+		}
+	}
+	if len(images) == 0 || !GetSupportsImagesFromContext(ctx) {
+		return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), meta), nil
+	}
+
+	// A tool response carries a single image, so only the first one in
+	// range is attached.
+	img := images[0]
+	if len(images) > 1 {
+		output += fmt.Sprintf("\n(Attached the image on line %d. Narrow 'offset' and 'limit' to see the other %d images in this range.)\n",
+			img.Line+1, len(images)-1)
+	}
+	resp := fantasy.NewImageResponse(img.Data, sniffImageMimeType(img.Data, img.MediaType))
+	resp.Content = output
+	return fantasy.WithResponseMetadata(resp, meta), nil
+}
+
 func readTextFile(filePath string, offset, limit, maxContentSize int) (string, bool, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -311,7 +378,13 @@ func readTextFile(filePath string, offset, limit, maxContentSize int) (string, b
 	}
 	defer file.Close()
 
-	reader := bufio.NewReader(file)
+	return readLines(file, offset, limit, maxContentSize)
+}
+
+// readLines reads up to limit lines from r after skipping offset lines and
+// reports whether more lines follow.
+func readLines(r io.Reader, offset, limit, maxContentSize int) (string, bool, error) {
+	reader := bufio.NewReader(r)
 	skipped := 0
 	for skipped < offset {
 		_, err := reader.ReadString('\n')
@@ -439,7 +512,7 @@ func isInSkillsPath(filePath string, skillsPaths []string) bool {
 }
 
 // readBuiltinFile reads a file from the embedded builtin skills filesystem.
-func readBuiltinFile(params ViewParams, skillTracker *skills.Tracker) (fantasy.ToolResponse, error) {
+func readBuiltinFile(sessionID string, params ViewParams, skillTracker *skills.Tracker) (fantasy.ToolResponse, error) {
 	embeddedPath := "builtin/" + strings.TrimPrefix(params.FilePath, skills.BuiltinPrefix)
 	builtinFS := skills.BuiltinFS()
 
@@ -483,7 +556,8 @@ func readBuiltinFile(params ViewParams, skillTracker *skills.Tracker) (fantasy.T
 		meta.ResourceType = ViewResourceSkill
 		meta.ResourceName = skill.Name
 		meta.ResourceDescription = skill.Description
-		skillTracker.MarkLoaded(skill.Name)
+		setSkillGrants(&meta, skill)
+		skillTracker.MarkLoadedInSession(sessionID, skill.Name)
 	}
 
 	return fantasy.WithResponseMetadata(
@@ -491,3 +565,12 @@ func readBuiltinFile(params ViewParams, skillTracker *skills.Tracker) (fantasy.T
 		meta,
 	), nil
 }
+
+// setSkillGrants records the tools the skill grants in the metadata, for
+// the UI to show while the skill is loaded.
+func setSkillGrants(meta *ViewResponseMetadata, skill *skills.Skill) {
+	for _, rule := range skill.ToolRules() {
+		meta.ResourceAllowedTools = append(meta.ResourceAllowedTools, rule.String())
+	}
+	meta.ResourceStrict = skill.Strict
+}
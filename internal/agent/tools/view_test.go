@@ -208,6 +208,52 @@ func TestReadTextFileAllowsExactMaxContentSize(t *testing.T) {
 	require.False(t, hasMore)
 }
 
+func TestViewToolExtractsDocuments(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	filePath := filepath.Join(workingDir, "scores.csv")
+	require.NoError(t, os.WriteFile(filePath, []byte("name,score\nada,10\nbob,7\n"), 0o644))
+
+	tool := newViewToolForTest(workingDir)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp := runViewTool(t, tool, ctx, ViewParams{
+		FilePath: filePath,
+		Offset:   2,
+		Limit:    1,
+	})
+
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "     3|| ada | 10 |")
+	require.Contains(t, resp.Content, "Use 'offset' parameter to read beyond line 3")
+}
+
+func TestViewToolAttachesNotebookImages(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	filePath := filepath.Join(workingDir, "plot.ipynb")
+	png := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+	notebook := `{"cells": [{"cell_type": "code", "execution_count": 1, "source": "plot()", "outputs": [
+		{"output_type": "display_data", "data": {"image/png": "` + png + `"}}]}]}`
+	require.NoError(t, os.WriteFile(filePath, []byte(notebook), 0o644))
+
+	tool := newViewToolForTest(workingDir)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+
+	resp := runViewTool(t, tool, ctx, ViewParams{FilePath: filePath})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "[Image 1: image/png]")
+	require.Empty(t, resp.Data)
+
+	ctx = context.WithValue(ctx, SupportsImagesContextKey, true)
+	resp = runViewTool(t, tool, ctx, ViewParams{FilePath: filePath})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "     2|plot()")
+	require.Equal(t, "image/png", resp.MediaType)
+	require.NotEmpty(t, resp.Data)
+}
+
 type mockViewPermissionService struct {
 	*pubsub.Broker[permission.PermissionRequest]
 }
@@ -278,7 +324,7 @@ func TestReadBuiltinFile(t *testing.T) {
 	t.Run("reads crush-config skill", func(t *testing.T) {
 		t.Parallel()
 
-		resp, err := readBuiltinFile(ViewParams{
+		resp, err := readBuiltinFile("", ViewParams{
 			FilePath: "crush://skills/crush-config/SKILL.md",
 		}, nil)
 		require.NoError(t, err)
@@ -289,7 +335,7 @@ func TestReadBuiltinFile(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
 		t.Parallel()
 
-		resp, err := readBuiltinFile(ViewParams{
+		resp, err := readBuiltinFile("", ViewParams{
 			FilePath: "crush://skills/nonexistent/SKILL.md",
 		}, nil)
 		require.NoError(t, err)
@@ -299,7 +345,7 @@ func TestReadBuiltinFile(t *testing.T) {
 	t.Run("metadata has skill info", func(t *testing.T) {
 		t.Parallel()
 
-		resp, err := readBuiltinFile(ViewParams{
+		resp, err := readBuiltinFile("", ViewParams{
 			FilePath: "crush://skills/crush-config/SKILL.md",
 		}, nil)
 		require.NoError(t, err)
@@ -314,7 +360,7 @@ func TestReadBuiltinFile(t *testing.T) {
 	t.Run("respects offset", func(t *testing.T) {
 		t.Parallel()
 
-		resp, err := readBuiltinFile(ViewParams{
+		resp, err := readBuiltinFile("", ViewParams{
 			FilePath: "crush://skills/crush-config/SKILL.md",
 			Offset:   5,
 		}, nil)
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/websearch"
+	"github.com/charmbracelet/crush/internal/config"
 )
 
 //go:embed web_search.md.tpl
@@ -19,8 +21,18 @@ var webSearchDescriptionTpl = template.Must(
 		Parse(string(webSearchDescriptionTmpl)),
 )
 
+type webSearchDescriptionData struct {
+	GhAvailable bool
+	MaxResults  int
+}
+
+// maxWebSearchResults caps the number of results the model may ask for.
+const maxWebSearchResults = 20
+
 // NewWebSearchTool creates a web search tool for sub-agents (no permissions needed).
-func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
+// The search provider is selected by cfg; its API key is expanded with
+// resolver.
+func NewWebSearchTool(client *http.Client, cfg config.ToolWebSearch, resolver config.VariableResolver) fantasy.AgentTool {
 	if client == nil {
 		transport := http.DefaultTransport.(*http.Transport).Clone()
 		transport.MaxIdleConns = 100
@@ -33,30 +45,60 @@ func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
 		}
 	}
 
+	provider, providerErr := newSearchProvider(client, cfg, resolver)
+	if providerErr != nil {
+		slog.Warn("Web search is misconfigured", "provider", cfg.Provider, "error", providerErr)
+	}
+	defaultResults := min(cfg.GetMaxResults(), maxWebSearchResults)
+
 	return fantasy.NewParallelAgentTool(
 		WebSearchToolName,
-		renderToolDescription(webSearchDescriptionTpl),
+		renderTemplate(webSearchDescriptionTpl, webSearchDescriptionData{
+			GhAvailable: ghAvailable,
+			MaxResults:  defaultResults,
+		}),
 		func(ctx context.Context, params WebSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Query == "" {
 				return fantasy.NewTextErrorResponse("query is required"), nil
 			}
+			if providerErr != nil {
+				return fantasy.NewTextErrorResponse("Web search is not configured correctly: " + providerErr.Error()), nil
+			}
 
 			maxResults := params.MaxResults
 			if maxResults <= 0 {
-				maxResults = 10
+				maxResults = defaultResults
 			}
-			if maxResults > 20 {
-				maxResults = 20
+			if maxResults > maxWebSearchResults {
+				maxResults = maxWebSearchResults
 			}
 
-			maybeDelaySearch()
-			results, err := searchDuckDuckGo(ctx, client, params.Query, maxResults)
-			slog.Debug("Web search completed", "query", params.Query, "results", len(results), "err", err)
+			results, err := provider.Search(ctx, params.Query, websearch.Options{
+				MaxResults: maxResults,
+				SafeSearch: websearch.SafeSearch(cfg.SafeSearch),
+			})
+			slog.Debug("Web search completed", "provider", provider.Name(), "query", params.Query, "results", len(results), "err", err)
 			if err != nil {
 				return fantasy.NewTextErrorResponse("Failed to search: " + err.Error()), nil
 			}
 
-			return fantasy.NewTextResponse(formatSearchResults(results)), nil
+			return fantasy.NewTextResponse(websearch.Format(results)), nil
 		},
 	)
 }
+
+func newSearchProvider(client *http.Client, cfg config.ToolWebSearch, resolver config.VariableResolver) (websearch.Provider, error) {
+	apiKey := cfg.APIKey
+	if apiKey != "" && resolver != nil {
+		resolved, err := resolver.ResolveValue(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		apiKey = resolved
+	}
+	return websearch.New(websearch.Settings{
+		Provider: cfg.Provider,
+		BaseURL:  cfg.BaseURL,
+		APIKey:   apiKey,
+	}, client)
+}
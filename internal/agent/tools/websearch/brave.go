@@ -0,0 +1,59 @@
+package websearch
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const braveURL = "https://api.search.brave.com/res/v1/web/search"
+
+// brave queries the Brave Search API.
+type brave struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+type braveResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+func (b *brave) Name() string { return ProviderBrave }
+
+func (b *brave) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	opts = opts.withDefaults()
+	params := url.Values{
+		"q":     {query},
+		"count": {strconv.Itoa(min(opts.MaxResults, 20))},
+	}
+	if opts.SafeSearch != "" {
+		params.Set("safesearch", string(opts.SafeSearch))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cmp.Or(b.baseURL, braveURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Subscription-Token", b.apiKey)
+
+	var resp braveResponse
+	if err := doJSON(b.client, b.Name(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Web.Results))
+	for _, r := range resp.Web.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return normalize(results, opts.MaxResults), nil
+}
@@ -1,6 +1,7 @@
-package tools
+package websearch
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"io"
@@ -15,14 +16,6 @@ import (
 	"golang.org/x/net/html"
 )
 
-// SearchResult represents a single search result from DuckDuckGo.
-type SearchResult struct {
-	Title    string
-	Link     string
-	Snippet  string
-	Position int
-}
-
 var userAgents = []string{
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36",
@@ -45,12 +38,29 @@ var acceptLanguages = []string{
 	"en-CA,en;q=0.9,en-US;q=0.8",
 }
 
-func searchDuckDuckGo(ctx context.Context, client *http.Client, query string, maxResults int) ([]SearchResult, error) {
-	if maxResults <= 0 {
-		maxResults = 10
-	}
+const duckDuckGoURL = "https://lite.duckduckgo.com/lite/"
 
-	searchURL := "https://lite.duckduckgo.com/lite/?q=" + url.QueryEscape(query)
+// duckDuckGo scrapes the DuckDuckGo Lite HTML page. It needs no API key but
+// is rate limited, so searches are spaced out with maybeDelaySearch.
+type duckDuckGo struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (d *duckDuckGo) Name() string { return ProviderDuckDuckGo }
+
+func (d *duckDuckGo) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	opts = opts.withDefaults()
+
+	params := url.Values{"q": {query}}
+	// kp is DuckDuckGo's safe-search parameter: 1 strict, -1 moderate, -2 off.
+	switch opts.SafeSearch {
+	case SafeSearchStrict:
+		params.Set("kp", "1")
+	case SafeSearchOff:
+		params.Set("kp", "-2")
+	}
+	searchURL := cmp.Or(d.baseURL, duckDuckGoURL) + "?" + params.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
@@ -59,7 +69,8 @@ func searchDuckDuckGo(ctx context.Context, client *http.Client, query string, ma
 
 	setRandomizedHeaders(req)
 
-	resp, err := client.Do(req)
+	maybeDelaySearch()
+	resp, err := d.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute search: %w", err)
 	}
@@ -74,7 +85,7 @@ func searchDuckDuckGo(ctx context.Context, client *http.Client, query string, ma
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	return parseLiteSearchResults(string(body), maxResults)
+	return parseLiteSearchResults(string(body), opts.MaxResults)
 }
 
 func setRandomizedHeaders(req *http.Request) {
@@ -94,30 +105,30 @@ func setRandomizedHeaders(req *http.Request) {
 	}
 }
 
-func parseLiteSearchResults(htmlContent string, maxResults int) ([]SearchResult, error) {
+func parseLiteSearchResults(htmlContent string, maxResults int) ([]Result, error) {
 	doc, err := html.Parse(strings.NewReader(htmlContent))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	var results []SearchResult
-	var currentResult *SearchResult
+	var results []Result
+	var currentResult *Result
 
 	var traverse func(*html.Node)
 	traverse = func(n *html.Node) {
 		if n.Type == html.ElementNode {
 			if n.Data == "a" && hasClass(n, "result-link") {
-				if currentResult != nil && currentResult.Link != "" {
+				if currentResult != nil && currentResult.URL != "" {
 					currentResult.Position = len(results) + 1
 					results = append(results, *currentResult)
 					if len(results) >= maxResults {
 						return
 					}
 				}
-				currentResult = &SearchResult{Title: getTextContent(n)}
+				currentResult = &Result{Title: getTextContent(n)}
 				for _, attr := range n.Attr {
 					if attr.Key == "href" {
-						currentResult.Link = cleanDuckDuckGoURL(attr.Val)
+						currentResult.URL = cleanDuckDuckGoURL(attr.Val)
 						break
 					}
 				}
@@ -136,7 +147,7 @@ func parseLiteSearchResults(htmlContent string, maxResults int) ([]SearchResult,
 
 	traverse(doc)
 
-	if currentResult != nil && currentResult.Link != "" && len(results) < maxResults {
+	if currentResult != nil && currentResult.URL != "" && len(results) < maxResults {
 		currentResult.Position = len(results) + 1
 		results = append(results, *currentResult)
 	}
@@ -185,21 +196,6 @@ func cleanDuckDuckGoURL(rawURL string) string {
 	return rawURL
 }
 
-func formatSearchResults(results []SearchResult) string {
-	if len(results) == 0 {
-		return "No results found. Try rephrasing your search."
-	}
-
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "Found %d search results:\n\n", len(results))
-	for _, result := range results {
-		fmt.Fprintf(&sb, "%d. %s\n", result.Position, result.Title)
-		fmt.Fprintf(&sb, "   URL: %s\n", result.Link)
-		fmt.Fprintf(&sb, "   Summary: %s\n\n", result.Snippet)
-	}
-	return sb.String()
-}
-
 var (
 	lastSearchMu   sync.Mutex
 	lastSearchTime time.Time
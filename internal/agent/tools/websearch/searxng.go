@@ -0,0 +1,63 @@
+package websearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// searXNG queries a SearXNG instance through its JSON API. The instance
+// must have the json format enabled in its settings.yml.
+type searXNG struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+type searXNGResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+func (s *searXNG) Name() string { return ProviderSearXNG }
+
+func (s *searXNG) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	opts = opts.withDefaults()
+	params := url.Values{
+		"q":      {query},
+		"format": {"json"},
+	}
+	switch opts.SafeSearch {
+	case SafeSearchOff:
+		params.Set("safesearch", "0")
+	case SafeSearchModerate:
+		params.Set("safesearch", "1")
+	case SafeSearchStrict:
+		params.Set("safesearch", "2")
+	}
+
+	endpoint := strings.TrimSuffix(s.baseURL, "/") + "/search?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	var resp searXNGResponse
+	if err := doJSON(s.client, s.Name(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return normalize(results, opts.MaxResults), nil
+}
@@ -0,0 +1,116 @@
+package websearch
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	tavilyURL = "https://api.tavily.com/search"
+	exaURL    = "https://api.exa.ai/search"
+
+	// exaSnippetLength is how much page text Exa is asked to return per
+	// result; it has no separate snippet field.
+	exaSnippetLength = 300
+)
+
+// tavily queries the Tavily search API. It has no safe-search option.
+type tavily struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+type tavilyResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+func (t *tavily) Name() string { return ProviderTavily }
+
+func (t *tavily) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	opts = opts.withDefaults()
+	req, err := newJSONRequest(ctx, cmp.Or(t.baseURL, tavilyURL), map[string]any{
+		"query":        query,
+		"max_results":  opts.MaxResults,
+		"search_depth": "basic",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+
+	var resp tavilyResponse
+	if err := doJSON(t.client, t.Name(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return normalize(results, opts.MaxResults), nil
+}
+
+// exa queries the Exa search API. It has no safe-search option.
+type exa struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+type exaResponse struct {
+	Results []struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Text  string `json:"text"`
+	} `json:"results"`
+}
+
+func (e *exa) Name() string { return ProviderExa }
+
+func (e *exa) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	opts = opts.withDefaults()
+	req, err := newJSONRequest(ctx, cmp.Or(e.baseURL, exaURL), map[string]any{
+		"query":      query,
+		"numResults": opts.MaxResults,
+		"contents": map[string]any{
+			"text": map[string]any{"maxCharacters": exaSnippetLength},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", e.apiKey)
+
+	var resp exaResponse
+	if err := doJSON(e.client, e.Name(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Text})
+	}
+	return normalize(results, opts.MaxResults), nil
+}
+
+func newJSONRequest(ctx context.Context, endpoint string, body any) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,179 @@
+// Package websearch implements the search backends behind the web_search
+// tool. Every provider returns results in the same normalized shape so the
+// tool output does not depend on which backend is configured.
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Provider names accepted in the configuration.
+const (
+	ProviderDuckDuckGo = "duckduckgo"
+	ProviderSearXNG    = "searxng"
+	ProviderBrave      = "brave"
+	ProviderTavily     = "tavily"
+	ProviderExa        = "exa"
+)
+
+// SafeSearch is the safe-search level requested from providers that
+// support it.
+type SafeSearch string
+
+const (
+	SafeSearchOff      SafeSearch = "off"
+	SafeSearchModerate SafeSearch = "moderate"
+	SafeSearchStrict   SafeSearch = "strict"
+)
+
+// Result is a single normalized search result.
+type Result struct {
+	Title    string
+	URL      string
+	Snippet  string
+	Position int
+}
+
+// DefaultMaxResults is used when Options.MaxResults is not set.
+const DefaultMaxResults = 10
+
+// Options are per-query search options.
+type Options struct {
+	MaxResults int
+	SafeSearch SafeSearch
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxResults <= 0 {
+		o.MaxResults = DefaultMaxResults
+	}
+	return o
+}
+
+// Provider is a web search backend.
+type Provider interface {
+	Name() string
+	Search(ctx context.Context, query string, opts Options) ([]Result, error)
+}
+
+// Settings select and configure a provider. APIKey must already have any
+// environment variables expanded.
+type Settings struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+}
+
+// New returns the provider described by settings. An empty provider name
+// selects DuckDuckGo, which needs no configuration.
+func New(settings Settings, client *http.Client) (Provider, error) {
+	switch strings.ToLower(settings.Provider) {
+	case "", ProviderDuckDuckGo:
+		return &duckDuckGo{client: client, baseURL: settings.BaseURL}, nil
+	case ProviderSearXNG:
+		if settings.BaseURL == "" {
+			return nil, fmt.Errorf("the %s search provider requires base_url", ProviderSearXNG)
+		}
+		return &searXNG{client: client, baseURL: settings.BaseURL, apiKey: settings.APIKey}, nil
+	case ProviderBrave:
+		if settings.APIKey == "" {
+			return nil, fmt.Errorf("the %s search provider requires api_key", ProviderBrave)
+		}
+		return &brave{client: client, baseURL: settings.BaseURL, apiKey: settings.APIKey}, nil
+	case ProviderTavily:
+		if settings.APIKey == "" {
+			return nil, fmt.Errorf("the %s search provider requires api_key", ProviderTavily)
+		}
+		return &tavily{client: client, baseURL: settings.BaseURL, apiKey: settings.APIKey}, nil
+	case ProviderExa:
+		if settings.APIKey == "" {
+			return nil, fmt.Errorf("the %s search provider requires api_key", ProviderExa)
+		}
+		return &exa{client: client, baseURL: settings.BaseURL, apiKey: settings.APIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown search provider %q", settings.Provider)
+	}
+}
+
+// Format renders results for the model.
+func Format(results []Result) string {
+	if len(results) == 0 {
+		return "No results found. Try rephrasing your search."
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d search results:\n\n", len(results))
+	for _, result := range results {
+		fmt.Fprintf(&sb, "%d. %s\n", result.Position, result.Title)
+		fmt.Fprintf(&sb, "   URL: %s\n", result.URL)
+		fmt.Fprintf(&sb, "   Summary: %s\n\n", result.Snippet)
+	}
+	return sb.String()
+}
+
+// maxErrorBody bounds how much of an error response is included in the
+// returned error.
+const maxErrorBody = 512
+
+// doJSON sends req and decodes a JSON response into out.
+func doJSON(client *http.Client, name string, req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute %s search: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("%s search failed with status code %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", name, err)
+	}
+	return nil
+}
+
+// normalize drops results without a URL, strips markup from titles and
+// snippets, numbers the results and caps them at maxResults.
+func normalize(results []Result, maxResults int) []Result {
+	out := make([]Result, 0, min(len(results), maxResults))
+	for _, r := range results {
+		if r.URL == "" {
+			continue
+		}
+		if len(out) >= maxResults {
+			break
+		}
+		r.Title = stripTags(r.Title)
+		r.Snippet = stripTags(r.Snippet)
+		r.Position = len(out) + 1
+		out = append(out, r)
+	}
+	return out
+}
+
+// stripTags removes HTML markup such as the <strong> highlighting some
+// APIs put in snippets, and collapses whitespace.
+func stripTags(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(s), nil)
+	if err != nil {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	var sb strings.Builder
+	for _, n := range nodes {
+		sb.WriteString(getTextContent(n))
+		sb.WriteString(" ")
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
@@ -0,0 +1,156 @@
+package websearch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings Settings
+		handler  func(t *testing.T, w http.ResponseWriter, r *http.Request)
+	}{
+		{
+			name:     ProviderSearXNG,
+			settings: Settings{Provider: ProviderSearXNG},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/search", r.URL.Path)
+				require.Equal(t, "golang", r.URL.Query().Get("q"))
+				require.Equal(t, "json", r.URL.Query().Get("format"))
+				require.Equal(t, "2", r.URL.Query().Get("safesearch"))
+				_, _ = io.WriteString(w, `{"results":[
+					{"title":"The Go Programming Language","url":"https://go.dev","content":"Go is an <em>open source</em> language"},
+					{"title":"no url"},
+					{"title":"Go docs","url":"https://go.dev/doc","content":"Docs"},
+					{"title":"Extra","url":"https://example.com","content":"over the limit"}
+				]}`)
+			},
+		},
+		{
+			name:     ProviderBrave,
+			settings: Settings{Provider: ProviderBrave, APIKey: "brave-key"},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "brave-key", r.Header.Get("X-Subscription-Token"))
+				require.Equal(t, "2", r.URL.Query().Get("count"))
+				require.Equal(t, "strict", r.URL.Query().Get("safesearch"))
+				_, _ = io.WriteString(w, `{"web":{"results":[
+					{"title":"The Go Programming Language","url":"https://go.dev","description":"Go is an <strong>open source</strong> language"},
+					{"title":"Go docs","url":"https://go.dev/doc","description":"Docs"}
+				]}}`)
+			},
+		},
+		{
+			name:     ProviderTavily,
+			settings: Settings{Provider: ProviderTavily, APIKey: "tavily-key"},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "Bearer tavily-key", r.Header.Get("Authorization"))
+				var body map[string]any
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.Equal(t, "golang", body["query"])
+				require.EqualValues(t, 2, body["max_results"])
+				_, _ = io.WriteString(w, `{"results":[
+					{"title":"The Go Programming Language","url":"https://go.dev","content":"Go is an open source language"},
+					{"title":"Go docs","url":"https://go.dev/doc","content":"Docs"}
+				]}`)
+			},
+		},
+		{
+			name:     ProviderExa,
+			settings: Settings{Provider: ProviderExa, APIKey: "exa-key"},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "exa-key", r.Header.Get("X-Api-Key"))
+				var body map[string]any
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				require.EqualValues(t, 2, body["numResults"])
+				_, _ = io.WriteString(w, `{"results":[
+					{"title":"The Go Programming Language","url":"https://go.dev","text":"Go is an open\nsource language"},
+					{"title":"Go docs","url":"https://go.dev/doc","text":"Docs"}
+				]}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, w, r)
+			}))
+			t.Cleanup(server.Close)
+
+			settings := tt.settings
+			settings.BaseURL = server.URL
+			provider, err := New(settings, server.Client())
+			require.NoError(t, err)
+			require.Equal(t, tt.name, provider.Name())
+
+			results, err := provider.Search(t.Context(), "golang", Options{MaxResults: 2, SafeSearch: SafeSearchStrict})
+			require.NoError(t, err)
+			require.Equal(t, []Result{
+				{Title: "The Go Programming Language", URL: "https://go.dev", Snippet: "Go is an open source language", Position: 1},
+				{Title: "Go docs", URL: "https://go.dev/doc", Snippet: "Docs", Position: 2},
+			}, results)
+		})
+	}
+}
+
+func TestNewRequiresSettings(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Settings{Provider: ProviderSearXNG}, http.DefaultClient)
+	require.ErrorContains(t, err, "base_url")
+
+	_, err = New(Settings{Provider: ProviderBrave}, http.DefaultClient)
+	require.ErrorContains(t, err, "api_key")
+
+	_, err = New(Settings{Provider: "bing"}, http.DefaultClient)
+	require.ErrorContains(t, err, "unknown search provider")
+
+	provider, err := New(Settings{}, http.DefaultClient)
+	require.NoError(t, err)
+	require.Equal(t, ProviderDuckDuckGo, provider.Name())
+}
+
+func TestSearchErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := New(Settings{Provider: ProviderBrave, BaseURL: server.URL, APIKey: "k"}, server.Client())
+	require.NoError(t, err)
+
+	_, err = provider.Search(t.Context(), "golang", Options{})
+	require.ErrorContains(t, err, "status code 429")
+	require.ErrorContains(t, err, "quota exceeded")
+}
+
+func TestParseLiteSearchResults(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><body><table>
+<tr><td><a class="result-link" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&rut=x">The Go Programming Language</a></td></tr>
+<tr><td class="result-snippet">Go is an open source language</td></tr>
+<tr><td><a class="result-link" href="https://go.dev/doc">Go docs</a></td></tr>
+<tr><td class="result-snippet">Docs</td></tr>
+</table></body></html>`
+
+	results, err := parseLiteSearchResults(page, 10)
+	require.NoError(t, err)
+	require.Equal(t, []Result{
+		{Title: "The Go Programming Language", URL: "https://go.dev/", Snippet: "Go is an open source language", Position: 1},
+		{Title: "Go docs", URL: "https://go.dev/doc", Snippet: "Docs", Position: 2},
+	}, results)
+}
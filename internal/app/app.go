@@ -10,6 +10,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -30,10 +31,12 @@ import (
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/outputstore"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/charmbracelet/crush/internal/skills"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -57,6 +60,8 @@ type App struct {
 	History     history.Service
 	Permissions permission.Service
 	FileTracker filetracker.Service
+	ShellState  shellstate.Service
+	Outputs     *outputstore.Store
 
 	AgentCoordinator agent.Coordinator
 
@@ -106,6 +111,8 @@ func New(ctx context.Context, conn *sql.DB, store *config.ConfigStore, skillsMgr
 		History:     files,
 		Permissions: permission.NewPermissionService(store.WorkingDir(), skipPermissionsRequests, allowedTools),
 		FileTracker: filetracker.NewService(q),
+		ShellState:  shellstate.NewService(q),
+		Outputs:     outputstore.New(filepath.Join(cfg.Options.DataDirectory, "outputs")),
 		LSPManager:  lsp.NewManager(store),
 		Skills:      skillsMgr,
 
@@ -492,6 +499,10 @@ func (app *App) GetDefaultSmallModel(providerID string) config.SelectedModel {
 	}
 }
 
+// skillsWatchInterval is how often the lock files of installed skills are
+// checked for skills installed, updated or removed with `crush skills`.
+const skillsWatchInterval = 2 * time.Second
+
 func (app *App) setupEvents() {
 	ctx, cancel := context.WithCancel(app.globalCtx)
 	app.eventsCtx = ctx
@@ -503,10 +514,13 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "agent-notifications", app.agentNotifications.Subscribe, app.events)
 	setupSubscriberMustDeliver(ctx, app.serviceEventsWG, "run-completions", app.runCompletions.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "mcp-elicitations", mcp.SubscribeElicitations, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
 	if app.Skills != nil {
 		setupSubscriber(ctx, app.serviceEventsWG, "skills", app.Skills.SubscribeEvents, app.events)
+		app.serviceEventsWG.Go(func() { app.Skills.WatchInstalls(ctx, skillsWatchInterval) })
 	}
+	app.serviceEventsWG.Go(func() { app.cleanUpOutputs(ctx) })
 	cleanupFunc := func(context.Context) error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -516,6 +530,28 @@ func (app *App) setupEvents() {
 	app.cleanupFuncs = append(app.cleanupFuncs, cleanupFunc)
 }
 
+// cleanUpOutputs removes the command output stored for sessions that no
+// longer exist, such as those deleted from the command line, and then for
+// each session deleted until ctx is done.
+func (app *App) cleanUpOutputs(ctx context.Context) {
+	deleted := app.Sessions.Subscribe(ctx)
+	err := app.Outputs.Prune(ctx, func(ctx context.Context, sessionID string) bool {
+		_, err := app.Sessions.Get(ctx, sessionID)
+		return !errors.Is(err, sql.ErrNoRows)
+	})
+	if err != nil && ctx.Err() == nil {
+		slog.Warn("Failed to prune stored command output", "error", err)
+	}
+	for event := range deleted {
+		if event.Type != pubsub.DeletedEvent {
+			continue
+		}
+		if err := app.Outputs.Remove(event.Payload.ID); err != nil {
+			slog.Warn("Failed to remove stored command output", "error", err, "session_id", event.Payload.ID)
+		}
+	}
+}
+
 func setupSubscriber[T any](
 	ctx context.Context,
 	wg *sync.WaitGroup,
@@ -587,6 +623,8 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		app.Permissions,
 		app.History,
 		app.FileTracker,
+		app.ShellState,
+		app.Outputs,
 		app.LSPManager,
 		app.agentNotifications,
 		app.runCompletions,
@@ -90,6 +90,14 @@ func (b *Backend) runAgent(ws *Workspace, msg proto.AgentMessage, accept *agent.
 	if msg.RunID != "" {
 		ctx = agent.WithRunID(ctx, msg.RunID)
 	}
+	if msg.Command != nil {
+		ctx = agent.WithCommandRun(ctx, agent.CommandRun{
+			Name:         msg.Command.Name,
+			Model:        msg.Command.Model,
+			AllowedTools: msg.Command.AllowedTools,
+			Agent:        msg.Command.Agent,
+		})
+	}
 	ctx = agent.WithRunCompleteMarker(ctx)
 
 	_, err := ws.AgentCoordinator.RunAccepted(ctx, accept, msg.SessionID, msg.Prompt, proto.AttachmentsToMessage(msg.Attachments)...)
@@ -266,6 +266,34 @@ func (b *Backend) RefreshMCPTools(ctx context.Context, workspaceID, name string)
 	return nil
 }
 
+// RestartMCP reconnects a named MCP server, e.g. after its credentials
+// changed.
+func (b *Backend) RestartMCP(ctx context.Context, workspaceID, name string) error {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := mcptools.DisableSingle(ws.Cfg, name); err != nil {
+		return err
+	}
+	// The session outlives the request that restarted it.
+	return mcptools.InitializeSingle(context.WithoutCancel(ctx), name, ws.Cfg)
+}
+
+// RespondMCPElicitation answers the pending elicitation request of an MCP
+// server. The returned bool reports whether this call resolved it; false
+// means it was already answered or abandoned, which is not an error.
+func (b *Backend) RespondMCPElicitation(workspaceID string, resp proto.MCPElicitationResponse) (bool, error) {
+	if _, err := b.GetWorkspace(workspaceID); err != nil {
+		return false, err
+	}
+	return mcptools.RespondElicitation(mcptools.ElicitationResponse{
+		ID:      resp.ID,
+		Action:  resp.Action,
+		Content: resp.Content,
+	}), nil
+}
+
 // ReadMCPResource reads a resource from a named MCP server.
 func (b *Backend) ReadMCPResource(ctx context.Context, workspaceID, name, uri string) ([]MCPResourceContents, error) {
 	ws, err := b.GetWorkspace(workspaceID)
@@ -0,0 +1,27 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/shellstate"
+)
+
+// GetShellState returns the saved shell state of a session.
+func (b *Backend) GetShellState(ctx context.Context, workspaceID, sessionID string) (shellstate.State, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return shellstate.State{}, err
+	}
+
+	return ws.ShellState.Get(ctx, sessionID)
+}
+
+// ResetShellState forgets the saved shell state of a session.
+func (b *Backend) ResetShellState(ctx context.Context, workspaceID, sessionID string) error {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return ws.ShellState.Reset(ctx, sessionID)
+}
@@ -301,6 +301,40 @@ func (c *Client) RefreshMCPTools(ctx context.Context, id, name string) error {
 	return nil
 }
 
+// RestartMCP reconnects a named MCP server.
+func (c *Client) RestartMCP(ctx context.Context, id, name string) error {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/mcp/restart", id), nil, jsonBody(struct {
+		Name string `json:"name"`
+	}{Name: name}), http.Header{"Content-Type": []string{"application/json"}})
+	if err != nil {
+		return fmt.Errorf("failed to restart MCP: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to restart MCP: status code %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+// RespondMCPElicitation answers the elicitation request of an MCP server.
+// The returned bool is false when the request had already been answered
+// or abandoned.
+func (c *Client) RespondMCPElicitation(ctx context.Context, id string, resp proto.MCPElicitationResponse) (bool, error) {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/mcp/elicitation", id), nil, jsonBody(resp), http.Header{"Content-Type": []string{"application/json"}})
+	if err != nil {
+		return false, fmt.Errorf("failed to respond to MCP elicitation: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to respond to MCP elicitation: status code %d", rsp.StatusCode)
+	}
+	var result proto.MCPElicitationResponseResult
+	if err := json.NewDecoder(rsp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode MCP elicitation response: %w", err)
+	}
+	return result.Resolved, nil
+}
+
 // ReadMCPResource reads a resource from a named MCP server.
 func (c *Client) ReadMCPResource(ctx context.Context, id, name, uri string) ([]MCPResourceContents, error) {
 	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/mcp/read-resource", id), nil, jsonBody(struct {
@@ -183,6 +183,12 @@ func (c *Client) SubscribeEvents(ctx context.Context, id string) (<-chan any, er
 				if !sendEvent(ctx, events, e) {
 					return
 				}
+			case pubsub.PayloadTypeMCPElicitation:
+				var e pubsub.Event[proto.MCPElicitationRequest]
+				_ = json.Unmarshal(p.Payload, &e)
+				if !sendEvent(ctx, events, e) {
+					return
+				}
 			case pubsub.PayloadTypePermissionRequest:
 				var e pubsub.Event[proto.PermissionRequest]
 				_ = json.Unmarshal(p.Payload, &e)
@@ -413,12 +419,28 @@ func (c *Client) UpdateAgent(ctx context.Context, id string) error {
 // to distinguish its own turn's terminal event from any concurrent
 // turn on the same session (e.g. interactive TUI usage).
 func (c *Client) SendMessage(ctx context.Context, id string, sessionID, runID, prompt string, attachments ...message.Attachment) error {
-	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/agent", id), nil, jsonBody(proto.AgentMessage{
+	return c.sendAgentMessage(ctx, id, proto.AgentMessage{
 		SessionID:   sessionID,
 		RunID:       runID,
 		Prompt:      prompt,
 		Attachments: proto.AttachmentsFromMessage(attachments),
-	}), http.Header{"Content-Type": []string{"application/json"}})
+	})
+}
+
+// SendCommand sends the prompt of a custom command to the agent, which
+// expands its !`command` and @path references and runs it with the
+// command's overrides.
+func (c *Client) SendCommand(ctx context.Context, id string, sessionID string, command proto.CommandRun, prompt string, attachments ...message.Attachment) error {
+	return c.sendAgentMessage(ctx, id, proto.AgentMessage{
+		SessionID:   sessionID,
+		Prompt:      prompt,
+		Attachments: proto.AttachmentsFromMessage(attachments),
+		Command:     &command,
+	})
+}
+
+func (c *Client) sendAgentMessage(ctx context.Context, id string, msg proto.AgentMessage) error {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/agent", id), nil, jsonBody(msg), http.Header{"Content-Type": []string{"application/json"}})
 	if err != nil {
 		return fmt.Errorf("failed to send message to agent: %w", err)
 	}
@@ -814,6 +836,36 @@ func (c *Client) FileTrackerListReadFiles(ctx context.Context, id string, sessio
 	return files, nil
 }
 
+// GetShellState returns the saved shell state of a session.
+func (c *Client) GetShellState(ctx context.Context, id string, sessionID string) (*proto.ShellState, error) {
+	rsp, err := c.get(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/shellstate", id, sessionID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shell state: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get shell state: status code %d", rsp.StatusCode)
+	}
+	var state proto.ShellState
+	if err := json.NewDecoder(rsp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode shell state: %w", err)
+	}
+	return &state, nil
+}
+
+// ResetShellState forgets the saved shell state of a session.
+func (c *Client) ResetShellState(ctx context.Context, id string, sessionID string) error {
+	rsp, err := c.delete(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/shellstate", id, sessionID), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reset shell state: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to reset shell state: status code %d", rsp.StatusCode)
+	}
+	return nil
+}
+
 // LSPStart starts an LSP server for a path.
 func (c *Client) LSPStart(ctx context.Context, id string, path string) error {
 	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/lsps/start", id), nil, jsonBody(struct {
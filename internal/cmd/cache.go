@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/httpcache"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the fetch cache",
+	Long:  "Manage the on-disk cache of pages downloaded by the fetch, web_fetch and agentic_fetch tools.",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the fetch cache",
+	Long:  "Remove every cached page so the next fetch downloads fresh content.",
+	Example: `
+# Clear cached pages
+crush cache clear
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache := httpcache.New(config.GlobalFetchCacheDir(), 0)
+		n, err := cache.Clear()
+		if err != nil {
+			return fmt.Errorf("failed to clear fetch cache: %w", err)
+		}
+		cmd.Printf("Removed %d cached pages from %s\n", n, cache.Dir())
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+}
@@ -12,6 +12,7 @@ import (
 
 	"charm.land/log/v2"
 	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/x/term"
 	"github.com/nxadm/tail"
@@ -24,6 +25,13 @@ var logsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "View crush logs",
 	Long:  `View the logs generated by Crush. This command allows you to see the log output for debugging and monitoring.`,
+	Example: `
+# View the logs of Crush
+crush logs
+
+# View what the "filesystem" MCP server wrote to stderr
+crush logs --mcp filesystem
+  `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cwd, err := cmd.Flags().GetString("cwd")
 		if err != nil {
@@ -45,6 +53,11 @@ var logsCmd = &cobra.Command{
 			return fmt.Errorf("failed to get tail flag: %v", err)
 		}
 
+		mcpName, err := cmd.Flags().GetString("mcp")
+		if err != nil {
+			return fmt.Errorf("failed to get mcp flag: %v", err)
+		}
+
 		log.SetLevel(log.DebugLevel)
 		log.SetOutput(os.Stdout)
 		if !term.IsTerminal(os.Stdout.Fd()) {
@@ -56,26 +69,37 @@ var logsCmd = &cobra.Command{
 			return fmt.Errorf("failed to load configuration: %v", err)
 		}
 		logsFile := filepath.Join(cfg.Config().Options.DataDirectory, "logs", "crush.log")
+		printLine := printLogLine
+		if mcpName != "" {
+			// MCP servers write plain text rather than structured logs.
+			logsFile = mcp.LogFile(cfg.Config().Options.DataDirectory, mcpName)
+			printLine = func(line string) { fmt.Println(line) }
+		}
 		_, err = os.Stat(logsFile)
 		if os.IsNotExist(err) {
+			if mcpName != "" {
+				log.Warnf("No logs found for MCP server %q. Only stdio servers have logs.", mcpName)
+				return nil
+			}
 			log.Warn("Looks like you are not in a crush project. No logs found.")
 			return nil
 		}
 
 		if follow {
-			return followLogs(cmd.Context(), logsFile, tailLines)
+			return followLogs(cmd.Context(), logsFile, tailLines, printLine)
 		}
 
-		return showLogs(logsFile, tailLines)
+		return showLogs(logsFile, tailLines, printLine)
 	},
 }
 
 func init() {
 	logsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
 	logsCmd.Flags().IntP("tail", "t", defaultTailLines, "Show only the last N lines default: 1000 for performance")
+	logsCmd.Flags().String("mcp", "", "Show what the named stdio MCP server wrote to stderr")
 }
 
-func followLogs(ctx context.Context, logsFile string, tailLines int) error {
+func followLogs(ctx context.Context, logsFile string, tailLines int, printLine func(string)) error {
 	t, err := tail.TailFile(logsFile, tail.Config{
 		Follow: false,
 		ReOpen: false,
@@ -98,7 +122,7 @@ func followLogs(ctx context.Context, logsFile string, tailLines int) error {
 	t.Stop()
 
 	for _, line := range lines {
-		printLogLine(line)
+		printLine(line)
 	}
 
 	if len(lines) == tailLines {
@@ -123,14 +147,14 @@ func followLogs(ctx context.Context, logsFile string, tailLines int) error {
 			if line.Err != nil {
 				continue
 			}
-			printLogLine(line.Text)
+			printLine(line.Text)
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-func showLogs(logsFile string, tailLines int) error {
+func showLogs(logsFile string, tailLines int, printLine func(string)) error {
 	t, err := tail.TailFile(logsFile, tail.Config{
 		Follow:      false,
 		ReOpen:      false,
@@ -154,7 +178,7 @@ func showLogs(logsFile string, tailLines int) error {
 	}
 
 	for _, line := range lines {
-		printLogLine(line)
+		printLine(line)
 	}
 
 	if len(lines) == tailLines {
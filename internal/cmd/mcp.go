@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/client"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/env"
+	mcpoauth "github.com/charmbracelet/crush/internal/oauth/mcp"
+	"github.com/pkg/browser"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP servers",
+	Long:  "Manage the authorization of remote MCP servers configured in Crush.",
+}
+
+var mcpLoginCmd = &cobra.Command{
+	Use:   "login <name>",
+	Short: "Authorize Crush with a remote MCP server",
+	Long: `Authorize Crush with an HTTP or SSE MCP server using OAuth.
+
+Crush discovers the authorization server of the MCP server, registers itself as a client and
+opens the browser to authorize it. The credentials are stored in the global config and refreshed
+automatically.`,
+	Example: `
+# Authorize the "linear" MCP server
+crush mcp login linear
+
+# Force re-authorization even if already logged in
+crush mcp login -f linear
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, ws, cleanup, err := connectToServer(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		ctx := getLoginContext()
+		name := args[0]
+		m, err := remoteMCPConfig(ctx, c, ws.ID, name)
+		if err != nil {
+			return err
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force && m.OAuth != nil && m.OAuth.Token != nil {
+			fmt.Printf("You are already logged in to %s.\n", name)
+			fmt.Println("Use --force to re-authenticate.")
+			return nil
+		}
+
+		url, err := m.ResolvedURL(config.NewShellVariableResolver(env.New()))
+		if err != nil {
+			return err
+		}
+
+		creds, err := mcpoauth.Login(ctx, url, mcpoauth.LoginOptions{
+			OpenURL: func(authURL string) {
+				fmt.Println("Open the following URL to authorize Crush:")
+				fmt.Println()
+				fmt.Println(lipgloss.NewStyle().Hyperlink(authURL, "id=mcp-"+name).Render(authURL))
+				fmt.Println()
+				if err := browser.OpenURL(authURL); err != nil {
+					fmt.Println("Could not open the URL. You'll need to manually open the URL in your browser.")
+				}
+				fmt.Println("Waiting for authorization...")
+			},
+		})
+		if errors.Is(err, mcpoauth.ErrNotRequired) {
+			fmt.Printf("%s does not require authorization.\n", name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := c.SetConfigField(ctx, ws.ID, config.ScopeGlobal, fmt.Sprintf("mcp.%s.oauth", name), creds); err != nil {
+			return err
+		}
+		if err := c.RestartMCP(ctx, ws.ID, name); err != nil {
+			fmt.Printf("Authorized, but %s could not be reconnected: %v\n", name, err)
+			return nil
+		}
+
+		fmt.Println()
+		fmt.Printf("You're now authenticated with %s!\n", name)
+		return nil
+	},
+}
+
+var mcpLogoutCmd = &cobra.Command{
+	Use:   "logout <name>",
+	Short: "Remove the credentials of a remote MCP server",
+	Long:  "Remove the OAuth credentials stored for an HTTP or SSE MCP server by crush mcp login.",
+	Example: `
+# Sign out from the "linear" MCP server
+crush mcp logout linear
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, ws, cleanup, err := connectToServer(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		ctx := getLogoutContext()
+		name := args[0]
+		m, err := remoteMCPConfig(ctx, c, ws.ID, name)
+		if err != nil {
+			return err
+		}
+		if m.OAuth == nil {
+			fmt.Println(logoutPromptStyle.Render(fmt.Sprintf("You are not logged in to %s.", name)))
+			return nil
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			fmt.Print(logoutPromptStyle.Render(fmt.Sprintf("Are you sure you want to logout %s? (y/N) ", name)))
+			var response string
+			_, err := fmt.Scanln(&response)
+			if err != nil || (response != "y" && response != "Y" && response != "yes" && response != "Yes" && response != "YES") {
+				fmt.Println(logoutHeaderStyle.Render("Logout cancelled."))
+				return nil
+			}
+		}
+
+		if err := c.RemoveConfigField(ctx, ws.ID, config.ScopeGlobal, fmt.Sprintf("mcp.%s.oauth", name)); err != nil {
+			return err
+		}
+		// Servers that require authorization now refuse the connection,
+		// which is the point.
+		_ = c.RestartMCP(ctx, ws.ID, name)
+
+		fmt.Println(logoutHeaderStyle.Render(fmt.Sprintf("Successfully logged out of %s.", name)))
+		return nil
+	},
+}
+
+func init() {
+	mcpLoginCmd.Flags().BoolP("force", "f", false, "Force re-authentication even if already logged in")
+	mcpLogoutCmd.Flags().BoolP("force", "f", false, "Skip logout confirmation prompt")
+	mcpCmd.AddCommand(mcpLoginCmd)
+	mcpCmd.AddCommand(mcpLogoutCmd)
+}
+
+// remoteMCPConfig returns the configuration of the named HTTP or SSE MCP
+// server.
+func remoteMCPConfig(ctx context.Context, c *client.Client, wsID, name string) (config.MCPConfig, error) {
+	cfg, err := c.GetConfig(ctx, wsID)
+	if err != nil {
+		return config.MCPConfig{}, fmt.Errorf("failed to get config: %w", err)
+	}
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return config.MCPConfig{}, fmt.Errorf("mcp %q not found in configuration", name)
+	}
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return config.MCPConfig{}, fmt.Errorf("mcp %q is a %s server; only http and sse servers support OAuth", name, m.Type)
+	}
+	return m, nil
+}
@@ -72,6 +72,9 @@ func init() {
 		loginCmd,
 		statsCmd,
 		sessionCmd,
+		cacheCmd,
+		skillsCmd,
+		mcpCmd,
 	)
 }
 
@@ -298,8 +301,7 @@ func setupLocalWorkspace(cmd *cobra.Command) (workspace.Workspace, func(), error
 	skillsMgr := skills.NewManager(
 		allSkills, activeSkills, skillStates,
 		skills.WithGlobalMirror(),
-		skills.WithResolvedPaths(discoveryCfg.ResolvePaths()),
-		skills.WithWorkingDir(discoveryCfg.WorkingDir),
+		skills.WithDiscoveryConfig(discoveryCfg),
 	)
 
 	appInstance, err := app.New(ctx, conn, store, skillsMgr)
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var skillsCmd = &cobra.Command{
+	Use:     "skills",
+	Aliases: []string{"skill"},
+	Short:   "Manage installed skills",
+	Long: `Install, update and remove Agent Skills from git repositories, .tar.gz archives and local directories.
+
+Skills are installed in the global skills directory, or with --project in .crush/skills of the
+project. The source and pinned revision of each skill are recorded in a skills-lock.json file next
+to them. Running Crush sessions pick up the changes without a restart.`,
+}
+
+var (
+	skillsProject    bool
+	skillsGlobal     bool
+	skillsInstallRef string
+	skillsInstallFor []string
+	skillsListJSON   bool
+)
+
+var skillsInstallCmd = &cobra.Command{
+	Use:   "install <git-url|path|tar.gz>",
+	Short: "Install skills from a source",
+	Long:  "Install the skills found in a git repository, .tar.gz archive or local directory. Every SKILL.md is validated before anything is installed.",
+	Example: `
+# Install every skill of a repository globally
+crush skills install https://github.com/example/skills.git
+
+# Install one skill of a repository at a tag in the current project
+crush skills install https://github.com/example/skills.git --ref v1.2.0 --skill pdf-tools --project
+
+# Install from an archive or a local directory
+crush skills install https://example.com/release-skill.tar.gz
+crush skills install ../shared-skills
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scopes, err := skillScopes(cmd, false)
+		if err != nil {
+			return err
+		}
+		results, err := scopes[0].installer.Install(cmd.Context(), args[0], skills.InstallOptions{
+			Ref:    skillsInstallRef,
+			Skills: skillsInstallFor,
+		})
+		for _, r := range results {
+			cmd.Printf("Installed %s (%s) to %s\n", r.Name, shortRevision(r.Revision), r.Path)
+		}
+		return err
+	},
+}
+
+var skillsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List installed skills",
+	Long:    "List the skills installed with crush skills install, with their source and pinned revision. Use --json for machine-readable output.",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scopes, err := skillScopes(cmd, true)
+		if err != nil {
+			return err
+		}
+
+		type installedSkill struct {
+			skills.InstalledSkill
+			Scope string `json:"scope"`
+		}
+		var installed []installedSkill
+		for _, scope := range scopes {
+			list, err := scope.installer.List()
+			if err != nil {
+				return err
+			}
+			for _, s := range list {
+				installed = append(installed, installedSkill{InstalledSkill: s, Scope: scope.name})
+			}
+		}
+
+		if skillsListJSON {
+			output := struct {
+				Skills []installedSkill `json:"skills"`
+			}{Skills: installed}
+			if output.Skills == nil {
+				output.Skills = []installedSkill{}
+			}
+			data, err := json.Marshal(output)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		if len(installed) == 0 {
+			cmd.Println("No skills installed yet.")
+			return nil
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("Name", "Scope", "Source", "Revision", "Installed")
+			for _, s := range installed {
+				t.Row(s.Name, s.Scope, skillSource(s.LockedSkill), shortRevision(s.Revision), s.InstalledAt.Local().Format("2006-01-02 15:04"))
+			}
+			lipgloss.Println(t)
+			return nil
+		}
+
+		for _, s := range installed {
+			cmd.Printf("%s\t%s\t%s\t%s\t%s\n", s.Name, s.Scope, skillSource(s.LockedSkill), s.Revision, s.InstalledAt.Format("2006-01-02T15:04:05Z07:00"))
+		}
+		return nil
+	},
+}
+
+var skillsUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Update installed skills",
+	Long:  "Reinstall the named skills, or all installed skills, from their sources at the ref they were installed from, and record their new revisions.",
+	Example: `
+# Update every installed skill
+crush skills update
+
+# Update one project skill
+crush skills update pdf-tools --project
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scopes, err := skillScopes(cmd, true)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]bool)
+		for _, scope := range scopes {
+			names, err := installedSkillNames(scope.installer, args)
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 && len(names) == 0 {
+				continue
+			}
+			for _, name := range names {
+				found[name] = true
+			}
+			results, err := scope.installer.Update(cmd.Context(), names...)
+			for _, r := range results {
+				if r.Revision == r.PreviousRevision {
+					cmd.Printf("%s is up to date (%s)\n", r.Name, shortRevision(r.Revision))
+				} else {
+					cmd.Printf("Updated %s from %s to %s\n", r.Name, shortRevision(r.PreviousRevision), shortRevision(r.Revision))
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return missingSkills(args, found)
+	},
+}
+
+var skillsRemoveCmd = &cobra.Command{
+	Use:     "remove <name...>",
+	Aliases: []string{"rm", "uninstall"},
+	Short:   "Remove installed skills",
+	Long:    "Remove skills installed with crush skills install, along with their lock file entries.",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scopes, err := skillScopes(cmd, true)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]bool)
+		for _, scope := range scopes {
+			names, err := installedSkillNames(scope.installer, args)
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				continue
+			}
+			if err := scope.installer.Remove(names...); err != nil {
+				return err
+			}
+			for _, name := range names {
+				found[name] = true
+				cmd.Printf("Removed %s from %s\n", name, scope.installer.Dir())
+			}
+		}
+		return missingSkills(args, found)
+	},
+}
+
+func init() {
+	skillsCmd.PersistentFlags().BoolVar(&skillsProject, "project", false, "use the skills of the current project")
+	skillsCmd.PersistentFlags().BoolVar(&skillsGlobal, "global", false, "use the global skills (default for install)")
+	skillsInstallCmd.Flags().StringVar(&skillsInstallRef, "ref", "", "git branch, tag or commit to install from")
+	skillsInstallCmd.Flags().StringSliceVar(&skillsInstallFor, "skill", nil, "install only the skills with these names")
+	skillsListCmd.Flags().BoolVar(&skillsListJSON, "json", false, "output in JSON format")
+	skillsCmd.AddCommand(skillsInstallCmd)
+	skillsCmd.AddCommand(skillsListCmd)
+	skillsCmd.AddCommand(skillsUpdateCmd)
+	skillsCmd.AddCommand(skillsRemoveCmd)
+}
+
+type skillScope struct {
+	name      string
+	installer *skills.Installer
+}
+
+// skillScopes returns the skills directories selected by --project and
+// --global: the global one by default, or both if all is set.
+func skillScopes(cmd *cobra.Command, all bool) ([]skillScope, error) {
+	if skillsProject && skillsGlobal {
+		return nil, errors.New("--project and --global cannot be used together")
+	}
+	var scopes []skillScope
+	if !skillsProject {
+		scopes = append(scopes, skillScope{"global", skills.NewInstaller(config.GlobalSkillsDirs()[0])})
+	}
+	if skillsProject || (all && !skillsGlobal) {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, skillScope{"project", skills.NewInstaller(filepath.Join(cwd, ".crush", "skills"))})
+	}
+	return scopes, nil
+}
+
+// installedSkillNames returns the names among names installed by inst, or
+// the names of all the skills it installed if names is empty.
+func installedSkillNames(inst *skills.Installer, names []string) ([]string, error) {
+	installed, err := inst.List()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, s := range installed {
+		if len(names) == 0 || slices.Contains(names, s.Name) {
+			res = append(res, s.Name)
+		}
+	}
+	return res, nil
+}
+
+func missingSkills(names []string, found map[string]bool) error {
+	var missing []string
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("not installed: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func skillSource(s skills.LockedSkill) string {
+	source := s.Source
+	if s.Ref != "" {
+		source += "@" + s.Ref
+	}
+	if s.Subdir != "" {
+		source += " (" + s.Subdir + ")"
+	}
+	return source
+}
+
+// shortRevision abbreviates commits and digests for display.
+func shortRevision(revision string) string {
+	revision = strings.TrimPrefix(revision, "sha256:")
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
@@ -2,10 +2,14 @@ package commands
 
 import (
 	"context"
+	"fmt"
+	"hash/fnv"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -27,6 +31,7 @@ type Argument struct {
 	ID          string
 	Title       string
 	Description string
+	Default     string
 	Required    bool
 }
 
@@ -42,12 +47,25 @@ type MCPPrompt struct {
 
 // CustomCommand represents a user-defined custom command loaded from markdown files.
 type CustomCommand struct {
-	ID        string
-	Name      string
+	ID           string
+	Name         string
+	Description  string
+	ArgumentHint string
+	// Content is the prompt of the command, without its frontmatter.
 	Content   string
 	Arguments []Argument
+	// Model, AllowedTools and Agent are the overrides the command runs
+	// with; see [Frontmatter].
+	Model        string
+	AllowedTools []string
+	Agent        string
 	// Skill is set when this command represents a user-invocable skill
 	Skill *skills.Skill
+	// Path is the file the command was loaded from.
+	Path string
+	// Overrides lists the files of commands with the same ID that this
+	// one shadows, from the farthest to the nearest source.
+	Overrides []string
 }
 
 type commandSource struct {
@@ -56,9 +74,60 @@ type commandSource struct {
 }
 
 // LoadCustomCommands loads custom commands from multiple sources including
-// XDG config directory, home directory, and project directory.
-func LoadCustomCommands(cfg *config.Config) ([]CustomCommand, error) {
-	return loadAll(buildCommandSources(cfg))
+// XDG config directory, home directory, the committed project command
+// directories between the git root and workingDir, and the project data
+// directory. When several sources define the same command, the later one
+// wins.
+func LoadCustomCommands(cfg *config.Config, workingDir string) ([]CustomCommand, error) {
+	return loadAll(buildCommandSources(cfg, workingDir))
+}
+
+// SourcesStamp returns a value that changes whenever a command file is
+// added, removed or modified in any of the command sources, so callers can
+// cheaply poll for changes and reload.
+func SourcesStamp(cfg *config.Config, workingDir string) uint64 {
+	h := fnv.New64a()
+	for _, source := range buildCommandSources(cfg, workingDir) {
+		_ = filepath.WalkDir(source.path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !isMarkdownFile(d.Name()) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00%d\x00", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return h.Sum64()
+}
+
+// Conflicts maps the ID of every custom command and MCP prompt to the IDs of
+// the other commands sharing its name across user, project and MCP sources.
+// Commands without such a conflict are omitted.
+func Conflicts(customCommands []CustomCommand, mcpPrompts []MCPPrompt) map[string][]string {
+	byName := make(map[string][]string)
+	for _, cmd := range customCommands {
+		name := strings.TrimPrefix(strings.TrimPrefix(cmd.ID, userCommandPrefix), projectCommandPrefix)
+		byName[name] = append(byName[name], cmd.ID)
+	}
+	for _, prompt := range mcpPrompts {
+		byName[prompt.PromptID] = append(byName[prompt.PromptID], prompt.ID)
+	}
+
+	conflicts := make(map[string][]string)
+	for _, ids := range byName {
+		if len(ids) < 2 {
+			continue
+		}
+		for _, id := range ids {
+			conflicts[id] = slices.DeleteFunc(slices.Clone(ids), func(other string) bool {
+				return other == id
+			})
+		}
+	}
+	return conflicts
 }
 
 // FromSkillCatalog converts user-invocable catalog entries into custom
@@ -118,8 +187,8 @@ func LoadMCPPrompts() ([]MCPPrompt, error) {
 	return commands, nil
 }
 
-func buildCommandSources(cfg *config.Config) []commandSource {
-	return []commandSource{
+func buildCommandSources(cfg *config.Config, workingDir string) []commandSource {
+	sources := []commandSource{
 		{
 			path:   filepath.Join(home.Config(), "crush", "commands"),
 			prefix: userCommandPrefix,
@@ -128,19 +197,42 @@ func buildCommandSources(cfg *config.Config) []commandSource {
 			path:   filepath.Join(home.Dir(), ".crush", "commands"),
 			prefix: userCommandPrefix,
 		},
-		{
-			path:   filepath.Join(cfg.Options.DataDirectory, "commands"),
+	}
+	for _, dir := range config.ProjectCommandsDirs(workingDir) {
+		sources = append(sources, commandSource{
+			path:   dir,
 			prefix: projectCommandPrefix,
-		},
+		})
 	}
+	// The data directory is usually git-ignored, so its commands are
+	// personal and override the ones shared through the repository.
+	return append(sources, commandSource{
+		path:   filepath.Join(cfg.Options.DataDirectory, "commands"),
+		prefix: projectCommandPrefix,
+	})
 }
 
 func loadAll(sources []commandSource) ([]CustomCommand, error) {
 	var commands []CustomCommand
+	index := make(map[string]int)
 
 	for _, source := range sources {
-		if cmds, err := loadFromSource(source); err == nil {
-			commands = append(commands, cmds...)
+		cmds, err := loadFromSource(source)
+		if err != nil {
+			continue
+		}
+		for _, cmd := range cmds {
+			i, ok := index[cmd.ID]
+			if !ok {
+				index[cmd.ID] = len(commands)
+				commands = append(commands, cmd)
+				continue
+			}
+			// Keep the position of the first definition so the list stays
+			// stable, but let the nearer source win.
+			prev := commands[i]
+			cmd.Overrides = append(prev.Overrides, prev.Path)
+			commands[i] = cmd
 		}
 	}
 
@@ -177,13 +269,25 @@ func loadCommand(path, baseDir, prefix string) (CustomCommand, error) {
 		return CustomCommand{}, err
 	}
 
+	fm, prompt, err := parseCommandFile(string(content))
+	if err != nil {
+		slog.Warn("Skipping invalid custom command", "path", path, "error", err)
+		return CustomCommand{}, err
+	}
+
 	id := buildCommandID(path, baseDir, prefix)
 
 	return CustomCommand{
-		ID:        id,
-		Name:      id,
-		Content:   string(content),
-		Arguments: extractArgNames(string(content)),
+		ID:           id,
+		Name:         id,
+		Path:         path,
+		Description:  fm.Description,
+		ArgumentHint: fm.ArgumentHint,
+		Content:      prompt,
+		Arguments:    fm.arguments(prompt),
+		Model:        fm.Model,
+		AllowedTools: fm.AllowedTools,
+		Agent:        fm.Agent,
 	}, nil
 }
 
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -54,6 +56,58 @@ func TestLoadAll_MixedSources(t *testing.T) {
 	require.Equal(t, "user:cmd", cmds[0].ID)
 }
 
+func TestLoadAll_NearerSourceOverrides(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	nested := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "git"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(nested, "git"), 0o755))
+	rootReview := filepath.Join(root, "git", "review.md")
+	nestedReview := filepath.Join(nested, "git", "review.md")
+	require.NoError(t, os.WriteFile(rootReview, []byte("root review"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "deploy.md"), []byte("deploy"), 0o644))
+	require.NoError(t, os.WriteFile(nestedReview, []byte("nested review"), 0o644))
+
+	cmds, err := loadAll([]commandSource{
+		{path: root, prefix: projectCommandPrefix},
+		{path: nested, prefix: projectCommandPrefix},
+	})
+	require.NoError(t, err)
+	require.Len(t, cmds, 2)
+
+	byID := make(map[string]CustomCommand)
+	for _, cmd := range cmds {
+		byID[cmd.ID] = cmd
+	}
+	review := byID["project:git:review"]
+	require.Equal(t, "nested review", review.Content)
+	require.Equal(t, nestedReview, review.Path)
+	require.Equal(t, []string{rootReview}, review.Overrides)
+	require.Empty(t, byID["project:deploy"].Overrides)
+}
+
+func TestConflicts(t *testing.T) {
+	t.Parallel()
+
+	conflicts := Conflicts(
+		[]CustomCommand{
+			{ID: "user:review"},
+			{ID: "project:review"},
+			{ID: "project:deploy"},
+		},
+		[]MCPPrompt{
+			{ID: "github:review", PromptID: "review"},
+			{ID: "github:issues", PromptID: "issues"},
+		},
+	)
+	require.Equal(t, map[string][]string{
+		"user:review":    {"project:review", "github:review"},
+		"project:review": {"user:review", "github:review"},
+		"github:review":  {"user:review", "project:review"},
+	}, conflicts)
+}
+
 func TestFromSkillCatalog_UserInvocableOnly(t *testing.T) {
 	t.Parallel()
 
@@ -112,3 +166,101 @@ func TestFromSkillCatalog_UsesDiscoveredSymlinkedSkills(t *testing.T) {
 	require.Equal(t, "linked-skill", cmds[0].Skill.Name)
 	require.Equal(t, filepath.Join(link, skills.SkillFileName), cmds[0].Skill.SkillFilePath)
 }
+
+func TestLoadFromSource_Frontmatter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := `---
+description: Review the staged changes
+argument-hint: "[focus]"
+arguments:
+  - name: FOCUS
+    description: What to focus on
+    default: correctness
+model: small
+allowed-tools: Bash(git diff:*) Read
+agent: task
+---
+Review this diff for $FOCUS, then $EXTRA:
+
+` + "!`git diff --staged`\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review-staged.md"), []byte(content), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.md"), []byte("---\nmodel: [\n---\nbody"), 0o644))
+
+	cmds, err := loadFromSource(commandSource{path: dir, prefix: projectCommandPrefix})
+	require.NoError(t, err)
+	require.Len(t, cmds, 1)
+	cmd := cmds[0]
+	require.Equal(t, "project:review-staged", cmd.ID)
+	require.Equal(t, "Review the staged changes", cmd.Description)
+	require.Equal(t, "[focus]", cmd.ArgumentHint)
+	require.Equal(t, "Review this diff for $FOCUS, then $EXTRA:\n\n!`git diff --staged`\n", cmd.Content)
+	require.Equal(t, []Argument{
+		{ID: "FOCUS", Title: "FOCUS", Description: "What to focus on", Default: "correctness"},
+		{ID: "EXTRA", Title: "EXTRA", Required: true},
+	}, cmd.Arguments)
+	require.Equal(t, "small", cmd.Model)
+	require.Equal(t, []string{"Bash(git diff:*)", "Read"}, cmd.AllowedTools)
+	require.Equal(t, "task", cmd.Agent)
+}
+
+func TestParseCommandFile_InvalidArgumentName(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := parseCommandFile("---\narguments:\n  - name: focus\n---\nbody")
+	require.ErrorContains(t, err, `invalid argument name "focus"`)
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("# Notes\n"), 0o644))
+
+	var ran []string
+	run := func(_ context.Context, command string) (string, error) {
+		ran = append(ran, command)
+		if command == "false" {
+			return "", errors.New("permission denied")
+		}
+		return "diff --git a/x b/x\n", nil
+	}
+
+	prompt, attachments, err := Expand(t.Context(), "Review @notes.md, ask @alice.\n\n!`git diff --staged`", ExpandOptions{WorkingDir: dir, Run: run})
+	require.NoError(t, err)
+	require.Equal(t, "Review @notes.md, ask @alice.\n\ndiff --git a/x b/x", prompt)
+	require.Equal(t, []string{"git diff --staged"}, ran)
+	require.Len(t, attachments, 1)
+	require.Equal(t, filepath.Join(dir, "notes.md"), attachments[0].FilePath)
+	require.Equal(t, "notes.md", attachments[0].FileName)
+	require.True(t, attachments[0].IsText())
+	require.Equal(t, []byte("# Notes\n"), attachments[0].Content)
+
+	_, _, err = Expand(t.Context(), "!`false`", ExpandOptions{WorkingDir: dir, Run: run})
+	require.EqualError(t, err, `running "false": permission denied`)
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret\n"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link.txt")))
+	var asked []string
+	allow := func(granted bool) func(context.Context, string) (bool, error) {
+		return func(_ context.Context, path string) (bool, error) {
+			asked = append(asked, path)
+			return granted, nil
+		}
+	}
+
+	_, _, err = Expand(t.Context(), "see @"+outside, ExpandOptions{WorkingDir: dir})
+	require.EqualError(t, err, "cannot include "+outside+": it is outside the working directory")
+	_, _, err = Expand(t.Context(), "see @link.txt", ExpandOptions{WorkingDir: dir, Allow: allow(false)})
+	require.EqualError(t, err, "cannot include link.txt: permission denied")
+	_, attachments, err = Expand(t.Context(), "see @"+outside+" and @notes.md", ExpandOptions{WorkingDir: dir, Allow: allow(true)})
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+	require.Equal(t, []byte("secret\n"), attachments[0].Content)
+	require.Equal(t, []string{filepath.Join(dir, "link.txt"), outside}, asked)
+
+	require.True(t, HasExpansions("see @notes.md"))
+	require.False(t, HasExpansions("mail me at a@b.c, it costs $5"))
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+var (
+	// shellPattern matches the !`command` expansions of a prompt.
+	shellPattern = regexp.MustCompile("!`([^`\n]+)`")
+	// includePattern matches the @path includes of a prompt.
+	includePattern = regexp.MustCompile(`(^|\s)@([^\s` + "`" + `]+)`)
+)
+
+// maxIncludeSize is the largest file an @path include attaches.
+const maxIncludeSize = 5 * 1024 * 1024
+
+// ExpandOptions configures [Expand].
+type ExpandOptions struct {
+	// WorkingDir resolves the relative paths of @path includes.
+	WorkingDir string
+	// Run runs a shell command and returns its output. The caller decides
+	// how it runs, and whether it needs the user's permission.
+	Run func(ctx context.Context, command string) (string, error)
+	// Allow decides whether a file outside WorkingDir may be included,
+	// typically by asking the user. Without it, including such a file is
+	// an error.
+	Allow func(ctx context.Context, path string) (bool, error)
+}
+
+// HasExpansions reports whether the prompt has !`command` expansions or
+// @path includes.
+func HasExpansions(prompt string) bool {
+	return shellPattern.MatchString(prompt) || includePattern.MatchString(prompt)
+}
+
+// Expand replaces the !`command` expansions of a custom command prompt
+// with the output of their commands, and attaches the files of its @path
+// includes. Includes of paths that are not existing files, such as
+// mentions, are left alone; including a file outside the working directory
+// takes [ExpandOptions.Allow].
+func Expand(ctx context.Context, prompt string, opts ExpandOptions) (string, []message.Attachment, error) {
+	var attachments []message.Attachment
+	seen := make(map[string]bool)
+	for _, match := range includePattern.FindAllStringSubmatch(prompt, -1) {
+		path := strings.TrimRight(match[2], ".,;:!?)]}'\"")
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		attachment, ok, err := includeFile(ctx, path, opts)
+		if err != nil {
+			return "", nil, err
+		}
+		if ok {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	var runErr error
+	expanded := shellPattern.ReplaceAllStringFunc(prompt, func(s string) string {
+		if runErr != nil {
+			return s
+		}
+		command := strings.TrimSpace(shellPattern.FindStringSubmatch(s)[1])
+		if opts.Run == nil {
+			runErr = fmt.Errorf("cannot run %q: shell commands are not available", command)
+			return s
+		}
+		output, err := opts.Run(ctx, command)
+		if err != nil {
+			runErr = fmt.Errorf("running %q: %w", command, err)
+			return s
+		}
+		return strings.TrimRight(output, "\n")
+	})
+	if runErr != nil {
+		return "", nil, runErr
+	}
+	return expanded, attachments, nil
+}
+
+// includeFile reads the file of an @path include. It reports false for
+// paths that are not files.
+func includeFile(ctx context.Context, path string, opts ExpandOptions) (message.Attachment, bool, error) {
+	abs := path
+	if strings.HasPrefix(abs, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			abs = filepath.Join(home, abs[2:])
+		}
+	}
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(opts.WorkingDir, abs)
+	}
+	abs, err := filepath.Abs(abs)
+	if err != nil {
+		return message.Attachment{}, false, nil
+	}
+	info, err := os.Stat(abs)
+	if err != nil || !info.Mode().IsRegular() {
+		return message.Attachment{}, false, nil
+	}
+	if outsideDir(abs, opts.WorkingDir) {
+		if opts.Allow == nil {
+			return message.Attachment{}, false, fmt.Errorf("cannot include %s: it is outside the working directory", path)
+		}
+		allowed, err := opts.Allow(ctx, abs)
+		if err != nil {
+			return message.Attachment{}, false, err
+		}
+		if !allowed {
+			return message.Attachment{}, false, fmt.Errorf("cannot include %s: permission denied", path)
+		}
+	}
+	if info.Size() > maxIncludeSize {
+		return message.Attachment{}, false, fmt.Errorf("cannot include %s: file is larger than %d MB", path, maxIncludeSize/1024/1024)
+	}
+	content, err := os.ReadFile(abs)
+	if err != nil {
+		return message.Attachment{}, false, fmt.Errorf("cannot include %s: %w", path, err)
+	}
+	return message.Attachment{
+		FilePath: abs,
+		FileName: filepath.Base(abs),
+		MimeType: http.DetectContentType(content[:min(512, len(content))]),
+		Content:  content,
+	}, true, nil
+}
+
+// outsideDir reports whether path, after resolving symlinks, lies outside
+// dir.
+func outsideDir(path, dir string) bool {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
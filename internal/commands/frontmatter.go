@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/skills"
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter is the optional YAML frontmatter of a custom command file.
+type Frontmatter struct {
+	Description string `yaml:"description"`
+	// ArgumentHint describes the arguments of the command, as in
+	// "<branch> [remote]".
+	ArgumentHint string        `yaml:"argument-hint"`
+	Arguments    []ArgumentDef `yaml:"arguments"`
+	// Model runs the command with another model: large, small, a model ID
+	// or a provider/model pair.
+	Model string `yaml:"model"`
+	// AllowedTools are the tools the command may use without asking for
+	// permission, in the allowed-tools syntax of skills.
+	AllowedTools skills.AllowedTools `yaml:"allowed-tools"`
+	// Agent runs the command with the tools and model of another agent.
+	Agent string `yaml:"agent"`
+}
+
+// ArgumentDef declares an argument of a custom command.
+type ArgumentDef struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	// Required defaults to true for arguments without a default.
+	Required *bool `yaml:"required"`
+}
+
+// parseCommandFile splits the content of a command file into its
+// frontmatter and its prompt. Files without frontmatter are all prompt.
+func parseCommandFile(content string) (Frontmatter, string, error) {
+	var fm Frontmatter
+	normalized := strings.ReplaceAll(strings.TrimPrefix(content, "\uFEFF"), "\r\n", "\n")
+	rest, ok := strings.CutPrefix(normalized, "---\n")
+	if !ok {
+		return fm, content, nil
+	}
+	header, body, ok := strings.Cut(rest, "\n---")
+	if !ok {
+		return fm, "", errors.New("unterminated frontmatter")
+	}
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return fm, "", fmt.Errorf("parsing frontmatter: %w", err)
+	}
+	for _, def := range fm.Arguments {
+		if namedArgPattern.FindString("$"+def.Name) != "$"+def.Name {
+			return fm, "", fmt.Errorf("invalid argument name %q: use upper case letters, digits and underscores", def.Name)
+		}
+	}
+	// Drop the rest of the closing delimiter line.
+	if _, body, ok = strings.Cut(body, "\n"); !ok {
+		body = ""
+	}
+	return fm, strings.TrimLeft(body, "\n"), nil
+}
+
+// arguments returns the arguments of a command: those declared in the
+// frontmatter, in order, followed by the other $ARGS of its prompt.
+func (fm Frontmatter) arguments(prompt string) []Argument {
+	var args []Argument
+	declared := make(map[string]bool)
+	for _, def := range fm.Arguments {
+		if declared[def.Name] {
+			continue
+		}
+		declared[def.Name] = true
+		required := def.Default == ""
+		if def.Required != nil {
+			required = *def.Required
+		}
+		args = append(args, Argument{
+			ID:          def.Name,
+			Title:       def.Name,
+			Description: def.Description,
+			Default:     def.Default,
+			Required:    required,
+		})
+	}
+	for _, arg := range extractArgNames(prompt) {
+		if !declared[arg.ID] {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
+	mcpoauth "github.com/charmbracelet/crush/internal/oauth/mcp"
 	"github.com/invopop/jsonschema"
 )
 
@@ -197,6 +198,14 @@ type MCPConfig struct {
 	EnabledTools  []string          `json:"enabled_tools,omitempty" jsonschema:"description=Allow list of tools from this MCP server,example=get-library-doc"`
 	Timeout       int               `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for MCP server connections,default=15,example=30,example=60,example=120"`
 
+	// SamplingMaxTokens caps the completions the server may request
+	// from the user's model through MCP sampling.
+	SamplingMaxTokens int64 `json:"sampling_max_tokens,omitempty" jsonschema:"description=Maximum number of tokens the MCP server may generate per sampling request,default=4096,example=1024"`
+
+	// ToolSearchThreshold overrides tool_search.threshold for this server
+	// when tool search is enabled.
+	ToolSearchThreshold *int `json:"tool_search_threshold,omitempty" jsonschema:"description=Number of tools past which the tools of this MCP server are only loaded on demand through tool_search. 0 always defers them. Overrides options.tool_search.threshold,example=0,example=20"`
+
 	// Headers are HTTP headers for HTTP/SSE MCP servers. Values run
 	// through shell expansion at MCP startup, so $VAR and $(cmd)
 	// work. A header whose value resolves to the empty string (unset
@@ -204,6 +213,10 @@ type MCPConfig struct {
 	// omitted from the outgoing request rather than sent as
 	// "Header:".
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	// OAuth holds the credentials obtained with `crush mcp login` for
+	// HTTP/SSE MCP servers that require authorization.
+	OAuth *mcpoauth.Credentials `json:"oauth,omitempty" jsonschema:"description=OAuth credentials obtained with crush mcp login"`
 }
 
 type LSPConfig struct {
@@ -265,6 +278,19 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 	}
 }
 
+// ToolSearch configures lazy tool loading: deferred tools are not sent to
+// the model upfront, which activates them with the tool_search tool
+// instead.
+type ToolSearch struct {
+	Enabled       bool     `json:"enabled,omitempty" jsonschema:"description=Only send deferred tools to the model once it activated them with the tool_search tool,default=false"`
+	Threshold     *int     `json:"threshold,omitempty" jsonschema:"description=Number of tools past which the tools of an MCP server are deferred,default=10,example=0,example=30"`
+	DeferredTools []string `json:"deferred_tools,omitempty" jsonschema:"description=Built-in tools to defer as well,example=sourcegraph,example=notebook_edit"`
+}
+
+// defaultToolSearchThreshold is the number of tools an MCP server may
+// expose before its tools are deferred.
+const defaultToolSearchThreshold = 10
+
 type Options struct {
 	ContextPaths         []string    `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
 	GlobalContextPaths   []string    `json:"global_context_paths,omitempty" jsonschema:"description=Paths to files containing global context information for the AI,default=~/.config/crush/CRUSH.md,default=~/.config/AGENTS.md"`
@@ -289,6 +315,12 @@ type Options struct {
 	DisableNotifications      bool         `json:"disable_notifications,omitempty" jsonschema:"description=Deprecated: Use notification_style instead. Disable desktop notifications,default=false"`
 	NotificationStyle         string       `json:"notification_style,omitempty" jsonschema:"description=Notification style to use. Options: auto (default), native, osc, bell, disabled. Auto selects based on environment: native for local sessions, osc for SSH (with automatic OSC 99/777 detection).,enum=auto,enum=native,enum=osc,enum=bell,enum=disabled,default=auto"`
 	DisabledSkills            []string     `json:"disabled_skills,omitempty" jsonschema:"description=List of skill names to disable and hide from the agent,example=crush-config"`
+	// MCPRoots are directories advertised to MCP servers as roots in
+	// addition to the working directory.
+	MCPRoots []string `json:"mcp_roots,omitempty" jsonschema:"description=Additional directories advertised to MCP servers as roots. Relative paths are resolved against the working directory; ~ and $VAR are expanded,example=../shared"`
+	// ToolSearch defers rarely needed tools until the model searches for
+	// them, which keeps large MCP setups from flooding every request.
+	ToolSearch *ToolSearch `json:"tool_search,omitempty" jsonschema:"description=Lazy tool loading through the tool_search tool"`
 }
 
 type MCPs map[string]MCPConfig
@@ -525,8 +557,13 @@ type Agent struct {
 }
 
 type Tools struct {
-	Ls   ToolLs   `json:"ls,omitzero"`
-	Grep ToolGrep `json:"grep,omitzero"`
+	Ls          ToolLs          `json:"ls,omitzero"`
+	Grep        ToolGrep        `json:"grep,omitzero"`
+	RunTests    ToolRunTests    `json:"run_tests,omitzero"`
+	HTTPRequest ToolHTTPRequest `json:"http_request,omitzero"`
+	WebSearch   ToolWebSearch   `json:"web_search,omitzero"`
+	Fetch       ToolFetch       `json:"fetch,omitzero"`
+	Bash        ToolBash        `json:"bash,omitzero"`
 }
 
 type ToolLs struct {
@@ -548,6 +585,99 @@ func (t ToolGrep) GetTimeout() time.Duration {
 	return ptrValOr(t.Timeout, 5*time.Second)
 }
 
+type ToolWebSearch struct {
+	Provider   string `json:"provider,omitempty" jsonschema:"description=Search backend used by web_search and agentic_fetch,enum=duckduckgo,enum=searxng,enum=brave,enum=tavily,enum=exa,default=duckduckgo"`
+	BaseURL    string `json:"base_url,omitempty" jsonschema:"description=Base URL of the search API. Required for searxng; overrides the default endpoint of other providers,example=https://searx.example.com"`
+	APIKey     string `json:"api_key,omitempty" jsonschema:"description=API key for the search provider. Supports $VAR expansion,example=$BRAVE_API_KEY"`
+	MaxResults *int   `json:"max_results,omitempty" jsonschema:"description=Number of results returned when the model does not ask for a specific number,default=10,example=5"`
+	SafeSearch string `json:"safe_search,omitempty" jsonschema:"description=Safe-search level for providers that support it,enum=off,enum=moderate,enum=strict"`
+}
+
+// GetMaxResults returns the user-defined default result count or 10.
+func (t ToolWebSearch) GetMaxResults() int {
+	return ptrValOr(t.MaxResults, 10)
+}
+
+type ToolFetch struct {
+	DisableCache bool `json:"disable_cache,omitempty" jsonschema:"description=Disable the on-disk cache shared by fetch, web_fetch and agentic_fetch,default=false"`
+	MaxCacheSize *int `json:"max_cache_size,omitempty" jsonschema:"description=Maximum size of the fetch cache in megabytes,default=100,example=500"`
+}
+
+// GetMaxCacheSize returns the user-defined cache size cap in bytes or the
+// 100MB default.
+func (t ToolFetch) GetMaxCacheSize() int64 {
+	return int64(ptrValOr(t.MaxCacheSize, 100)) * 1024 * 1024
+}
+
+type ToolBash struct {
+	Sandbox ToolBashSandbox      `json:"sandbox,omitzero"`
+	Policy  []ToolBashPolicyRule `json:"policy,omitempty" jsonschema:"description=Rules allowing or denying commands run by the bash tool. Every simple command is checked, including those in pipelines, subshells and sh -c scripts. The first matching rule decides; commands no rule matches are checked against the built-in list of banned commands"`
+	State   ToolBashState        `json:"state,omitzero"`
+	Shell   ToolBashShell        `json:"shell,omitzero"`
+}
+
+type ToolBashShell struct {
+	Path   string   `json:"path,omitempty" jsonschema:"description=Shell program that runs bash tool commands and background jobs instead of the built-in interpreter. Banned commands and the command policy are still checked against the parsed command before it runs,example=/bin/bash,example=zsh"`
+	Args   []string `json:"args,omitempty" jsonschema:"description=Arguments passed to the shell before the command. Defaults to -c,example=-c,example=-lc"`
+	RCFile string   `json:"rc_file,omitempty" jsonschema:"description=File sourced before each command, such as one defining shell functions; ~ and $VAR are expanded,example=~/.bashrc"`
+}
+
+type ToolBashState struct {
+	Disabled   bool     `json:"disabled,omitempty" jsonschema:"description=Stop carrying the working directory and exported variables of the bash tool over between commands and restarts of a session,default=false"`
+	ExcludeEnv []string `json:"exclude_env,omitempty" jsonschema:"description=Case-insensitive globs of variable names that are never saved, in addition to the built-in patterns for tokens, secrets, passwords and keys,example=AWS_*,example=*_URL"`
+}
+
+type ToolBashPolicyRule struct {
+	Action      string   `json:"action" jsonschema:"required,description=Whether matching commands are allowed, even ones banned by default, or denied,enum=allow,enum=deny"`
+	Command     string   `json:"command" jsonschema:"required,description=Glob matched against the command name without its directory,example=git,example=curl"`
+	Subcommands []string `json:"subcommands,omitempty" jsonschema:"description=Globs matched in order against the leading arguments that are not flags,example=push"`
+	Flags       []string `json:"flags,omitempty" jsonschema:"description=Globs of flags. A deny rule matches when any of them matches a flag of the command; an allow rule only when every flag of the command matches one of them. Short flag clusters such as -rf also count as -r and -f. Allow rules match name=value flags with their value,example=--force*,example=-f"`
+	Args        []string `json:"args,omitempty" jsonschema:"description=Globs matched against the remaining arguments. A deny rule matches when any argument or name=value flag value matches; an allow rule only when every argument does,example=/,example=http://localhost*"`
+	Reason      string   `json:"reason,omitempty" jsonschema:"description=Why a deny rule blocks the command; returned to the model,example=Force pushes rewrite shared history"`
+}
+
+type ToolBashSandbox struct {
+	Enabled        bool     `json:"enabled,omitempty" jsonschema:"description=Run bash tool commands and background jobs in a sandbox that can only write to the working directory and temporary directories. Linux only,default=false"`
+	WritablePaths  []string `json:"writable_paths,omitempty" jsonschema:"description=Additional directories sandboxed commands may write to. Relative paths are resolved against the working directory; ~ and $VAR are expanded,example=~/.cache/go-build,example=~/go/pkg/mod"`
+	HiddenPaths    []string `json:"hidden_paths,omitempty" jsonschema:"description=Files and directories whose contents sandboxed commands cannot read; ~ and $VAR are expanded,example=~/.ssh,example=~/.aws,example=.env"`
+	DisableNetwork bool     `json:"disable_network,omitempty" jsonschema:"description=Cut sandboxed commands off the network. Requires unprivileged user namespaces,default=false"`
+	AllowedHosts   []string `json:"allowed_hosts,omitempty" jsonschema:"description=Hosts sandboxed commands may still reach through an HTTP proxy when the network is disabled. Entries are globs matched against host:port; an entry without a port matches any port,example=proxy.golang.org,example=*.npmjs.org:443"`
+}
+
+type ToolHTTPRequest struct {
+	AllowedHosts []string `json:"allowed_hosts,omitempty" jsonschema:"description=Hosts the http_request tool may call without asking for permission. Entries are globs matched against host:port; an entry without a port matches any port,example=localhost:*,example=127.0.0.1:*"`
+}
+
+type TestAdapter string
+
+const (
+	TestAdapterGo      TestAdapter = "go"
+	TestAdapterJUnit   TestAdapter = "junit"
+	TestAdapterGeneric TestAdapter = "generic"
+)
+
+type ToolRunTests struct {
+	Adapter TestAdapter `json:"adapter,omitempty" jsonschema:"description=How test results are collected: go runs go test -json; junit runs command and reads its JUnit XML report; generic runs command and summarizes its output,enum=go,enum=junit,enum=generic,example=junit"`
+	Command string      `json:"command,omitempty" jsonschema:"description=Command that runs the tests. TEST and PACKAGE are set in its environment when a single test or package is requested,example=pytest --junitxml=report.xml ${PACKAGE:-tests}"`
+	Report  string      `json:"report,omitempty" jsonschema:"description=Path or glob of the JUnit XML report files written by command relative to the working directory,example=report.xml"`
+}
+
+// GetAdapter returns the configured adapter, inferring it from the other
+// fields when unset: go when no command is configured, junit when a report
+// path is, and generic otherwise.
+func (t ToolRunTests) GetAdapter() TestAdapter {
+	switch {
+	case t.Adapter != "":
+		return t.Adapter
+	case t.Command == "":
+		return TestAdapterGo
+	case t.Report != "":
+		return TestAdapterJUnit
+	default:
+		return TestAdapterGeneric
+	}
+}
+
 // HookConfig defines a user-configured shell command that fires on a hook
 // event (e.g. PreToolUse). This is a pure-data struct: matcher compilation
 // is owned by hooks.Runner so a JSON round-trip, merge, or reload can't
@@ -670,33 +800,63 @@ func (c *Config) SmallModel() *catwalk.Model {
 	return c.GetModel(model.Provider, model.Model)
 }
 
+// DefersMCPTools reports whether the tools of the named MCP server, which
+// exposes count tools, are only loaded on demand through tool_search.
+func (c *Config) DefersMCPTools(name string, count int) bool {
+	if c.Options == nil || c.Options.ToolSearch == nil || !c.Options.ToolSearch.Enabled {
+		return false
+	}
+	threshold := ptrValOr(c.Options.ToolSearch.Threshold, defaultToolSearchThreshold)
+	if m, ok := c.MCP[name]; ok && m.ToolSearchThreshold != nil {
+		threshold = *m.ToolSearchThreshold
+	}
+	return count > threshold
+}
+
+// DefersTool reports whether the named built-in tool is only loaded on
+// demand through tool_search.
+func (c *Config) DefersTool(name string) bool {
+	if c.Options == nil || c.Options.ToolSearch == nil || !c.Options.ToolSearch.Enabled {
+		return false
+	}
+	return slices.Contains(c.Options.ToolSearch.DeferredTools, name)
+}
+
 const maxRecentModelsPerType = 5
 
 func allToolNames() []string {
 	return []string{
 		"agent",
 		"bash",
+		"git",
 		"crush_info",
 		"crush_logs",
 		"job_output",
+		"job_input",
 		"job_kill",
+		"read_output",
 		"download",
 		"edit",
 		"multiedit",
+		"apply_patch",
+		"notebook_edit",
 		"lsp_diagnostics",
 		"lsp_references",
 		"lsp_restart",
 		"fetch",
 		"agentic_fetch",
+		"http_request",
 		"glob",
 		"grep",
 		"ls",
+		"run_tests",
 		"sourcegraph",
 		"todos",
 		"view",
 		"write",
 		"list_mcp_resources",
 		"read_mcp_resource",
+		"tool_search",
 	}
 }
 
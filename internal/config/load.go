@@ -67,7 +67,7 @@ func Load(workingDir, dataDir string, debug bool) (*ConfigStore, error) {
 		if !json.Valid(wsData) {
 			return nil, fmt.Errorf("invalid JSON in config file %s", store.workspacePath)
 		}
-		merged, mergeErr := loadFromBytes(append([][]byte{mustMarshalConfig(cfg)}, wsData))
+		merged, mergeErr := loadFromBytes(append([][]byte{mustMarshalConfig(cfg)}, withoutMCPOAuth(wsData)))
 		if mergeErr == nil {
 			// Preserve defaults that setDefaults already applied.
 			dataDir := cfg.Options.DataDirectory
@@ -836,6 +836,7 @@ func lookupConfigs(cwd string) []string {
 func loadFromConfigPaths(configPaths []string) (*Config, []string, error) {
 	var configs [][]byte
 	var loaded []string
+	globalConfigs := []string{GlobalConfig(), GlobalConfigData()}
 
 	for _, path := range configPaths {
 		data, err := os.ReadFile(path)
@@ -851,6 +852,9 @@ func loadFromConfigPaths(configPaths []string) (*Config, []string, error) {
 		if !json.Valid(data) {
 			return nil, nil, fmt.Errorf("invalid JSON in config file %s", path)
 		}
+		if !slices.Contains(globalConfigs, path) {
+			data = withoutMCPOAuth(data)
+		}
 		configs = append(configs, data)
 		loaded = append(loaded, path)
 	}
@@ -862,6 +866,25 @@ func loadFromConfigPaths(configPaths []string) (*Config, []string, error) {
 	return cfg, loaded, nil
 }
 
+// withoutMCPOAuth drops the OAuth credentials of MCP servers from a
+// project config. They are only trusted from the global config, where
+// `crush mcp login` stores them: a project config could otherwise send the
+// tokens to an endpoint of its choosing.
+func withoutMCPOAuth(data []byte) []byte {
+	result := data
+	gjson.GetBytes(data, "mcp").ForEach(func(name, value gjson.Result) bool {
+		if !value.Get("oauth").Exists() {
+			return true
+		}
+		slog.Warn("Ignoring MCP OAuth credentials outside the global config", "name", name.String())
+		if stripped, err := sjson.DeleteBytes(result, "mcp."+gjson.Escape(name.String())+".oauth"); err == nil {
+			result = stripped
+		}
+		return true
+	})
+	return result
+}
+
 func loadFromBytes(configs [][]byte) (*Config, error) {
 	if len(configs) == 0 {
 		return &Config{}, nil
@@ -1000,6 +1023,13 @@ func GlobalCacheDir() string {
 	return filepath.Join(home.Dir(), ".cache", appName)
 }
 
+// GlobalFetchCacheDir returns the directory of the persistent cache used by
+// the fetch tools. It lives in the data directory so cached pages survive
+// across sessions.
+func GlobalFetchCacheDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfigData()), "fetch-cache")
+}
+
 // ProjectConfigs returns list of current project configs paths.
 func ProjectConfigs(cwd string) []string {
 	return lookupConfigs(cwd)
@@ -1161,6 +1191,47 @@ func ProjectSkillsDir(workingDir string) []string {
 	return dirs
 }
 
+// projectCommandSubdirs lists the conventional subdirectories, meant to be
+// committed to the repository, where project-level commands are discovered.
+var projectCommandSubdirs = []string{
+	".agents/commands",
+	".crush-commands",
+}
+
+// ProjectCommandsDirs returns the committed project directories for which
+// Crush will look for custom commands, from the git working tree root down
+// to the working directory. Directories nearer to the working directory
+// come last so their commands take precedence over repository-level ones.
+func ProjectCommandsDirs(workingDir string) []string {
+	if workingDir == "" {
+		return nil
+	}
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return nil
+	}
+
+	chain := []string{workingDir}
+	root := projectBoundary(workingDir)
+	for dir := workingDir; dir != root; {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		chain = append(chain, dir)
+	}
+	slices.Reverse(chain)
+
+	dirs := make([]string, 0, len(chain)*len(projectCommandSubdirs))
+	for _, dir := range chain {
+		for _, sub := range projectCommandSubdirs {
+			dirs = append(dirs, filepath.Join(dir, sub))
+		}
+	}
+	return dirs
+}
+
 func isAppleTerminal() bool { return os.Getenv("TERM_PROGRAM") == "Apple_Terminal" }
 
 // normalizeHookEvent maps user-provided event names to their canonical
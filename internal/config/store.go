@@ -18,6 +18,7 @@ import (
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/oauth/hyper"
+	mcpoauth "github.com/charmbracelet/crush/internal/oauth/mcp"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -458,6 +459,41 @@ func (s *ConfigStore) RefreshOAuthToken(ctx context.Context, scope Scope, provid
 	return nil
 }
 
+// RefreshMCPOAuthToken refreshes the OAuth token of the given MCP server and
+// persists it to the global config, where `crush mcp login` stores it.
+func (s *ConfigStore) RefreshMCPOAuthToken(ctx context.Context, name string) error {
+	m, exists := s.config.MCP[name]
+	if !exists {
+		return fmt.Errorf("mcp %s not found", name)
+	}
+	if m.OAuth == nil || m.OAuth.Token == nil || m.OAuth.Token.RefreshToken == "" {
+		return fmt.Errorf("mcp %s does not have a refreshable OAuth token", name)
+	}
+	// The refresh token is only sent on behalf of the server it was
+	// issued for.
+	url, err := m.ResolvedURL(s.resolver)
+	if err != nil {
+		return fmt.Errorf("failed to resolve url of mcp %s: %w", name, err)
+	}
+	if !mcpoauth.MatchesResource(m.OAuth.Client.Resource, url) {
+		return fmt.Errorf("mcp %s url %s does not match the resource %q of its OAuth credentials", name, url, m.OAuth.Client.Resource)
+	}
+
+	token, err := mcpoauth.RefreshToken(ctx, m.OAuth.Client, m.OAuth.Token.RefreshToken, nil)
+	if err != nil {
+		return fmt.Errorf("failed to refresh OAuth token for mcp %s: %w", name, err)
+	}
+
+	slog.Info("Successfully refreshed MCP OAuth token", "name", name)
+	m.OAuth = &mcpoauth.Credentials{Client: m.OAuth.Client, Token: token}
+	s.config.MCP[name] = m
+
+	if err := s.SetConfigField(ScopeGlobal, fmt.Sprintf("mcp.%s.oauth.token", name), token); err != nil {
+		return fmt.Errorf("failed to persist refreshed token: %w", err)
+	}
+	return nil
+}
+
 // applyToken updates the in-memory provider config with the given token.
 func (s *ConfigStore) applyToken(providerConfig ProviderConfig, token *oauth.Token, providerID string) error {
 	providerConfig.OAuthToken = token
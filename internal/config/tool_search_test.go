@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefersMCPTools(t *testing.T) {
+	t.Parallel()
+
+	zero, twenty := 0, 20
+	tests := []struct {
+		name       string
+		toolSearch *ToolSearch
+		mcp        MCPConfig
+		count      int
+		want       bool
+	}{
+		{name: "disabled", toolSearch: nil, count: 100, want: false},
+		{name: "not enabled", toolSearch: &ToolSearch{}, count: 100, want: false},
+		{name: "below default threshold", toolSearch: &ToolSearch{Enabled: true}, count: 10, want: false},
+		{name: "above default threshold", toolSearch: &ToolSearch{Enabled: true}, count: 11, want: true},
+		{name: "global threshold", toolSearch: &ToolSearch{Enabled: true, Threshold: &twenty}, count: 15, want: false},
+		{name: "server threshold", toolSearch: &ToolSearch{Enabled: true, Threshold: &twenty}, mcp: MCPConfig{ToolSearchThreshold: &zero}, count: 1, want: true},
+		{name: "server without tools", toolSearch: &ToolSearch{Enabled: true}, mcp: MCPConfig{ToolSearchThreshold: &zero}, count: 0, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &Config{
+				Options: &Options{ToolSearch: tt.toolSearch},
+				MCP:     MCPs{"github": tt.mcp},
+			}
+			require.Equal(t, tt.want, cfg.DefersMCPTools("github", tt.count))
+		})
+	}
+}
+
+func TestDefersTool(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{Options: &Options{ToolSearch: &ToolSearch{DeferredTools: []string{"sourcegraph"}}}}
+	require.False(t, cfg.DefersTool("sourcegraph"))
+
+	cfg.Options.ToolSearch.Enabled = true
+	require.True(t, cfg.DefersTool("sourcegraph"))
+	require.False(t, cfg.DefersTool("bash"))
+}
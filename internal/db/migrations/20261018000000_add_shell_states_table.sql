@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS shell_states (
+    session_id TEXT PRIMARY KEY CHECK (session_id != ''),
+    working_dir TEXT NOT NULL,
+    env TEXT NOT NULL DEFAULT '[]',  -- JSON array of NAME=value exported by the agent's commands
+    updated_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS shell_states;
+-- +goose StatementEnd
@@ -50,3 +50,10 @@ type Session struct {
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
 	Todos            sql.NullString `json:"todos"`
 }
+
+type ShellState struct {
+	SessionID  string `json:"session_id"`
+	WorkingDir string `json:"working_dir"`
+	Env        string `json:"env"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
@@ -0,0 +1,496 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DevNull is the path used by unified diffs to denote a missing file on one
+// side of the patch.
+const DevNull = "/dev/null"
+
+// maxContextFuzz is the number of leading and trailing context lines that
+// may be ignored when a hunk cannot be located with its full context, the
+// same default GNU patch uses.
+const maxContextFuzz = 2
+
+// ErrHunksFailed is returned by [Apply] when one or more hunks could not be
+// applied.
+var ErrHunksFailed = errors.New("one or more hunks failed to apply")
+
+// FilePatch holds the changes a patch makes to a single file.
+type FilePatch struct {
+	// OldPath is the path of the file before the patch, empty when the patch
+	// creates the file.
+	OldPath string
+	// NewPath is the path of the file after the patch, empty when the patch
+	// deletes the file.
+	NewPath string
+	Hunks   []Hunk
+}
+
+// IsCreate reports whether the patch creates a new file.
+func (f FilePatch) IsCreate() bool {
+	return f.OldPath == "" && f.NewPath != ""
+}
+
+// IsDelete reports whether the patch deletes the file.
+func (f FilePatch) IsDelete() bool {
+	return f.OldPath != "" && f.NewPath == ""
+}
+
+// IsRename reports whether the patch moves the file to a different path.
+func (f FilePatch) IsRename() bool {
+	return f.OldPath != "" && f.NewPath != "" && f.OldPath != f.NewPath
+}
+
+// Path returns the path the patch should be reported under: the new path,
+// or the old one for deletions.
+func (f FilePatch) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Hunk is a single "@@" section of a unified diff.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []HunkLine
+}
+
+// Header returns the hunk's "@@ -a,b +c,d @@" header.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// HunkLine is a single line of a hunk. Op is one of ' ', '-' or '+'.
+type HunkLine struct {
+	Op   byte
+	Text string
+	// NoNewline is set when the line is followed by a
+	// "\ No newline at end of file" marker.
+	NoNewline bool
+}
+
+// HunkResult describes the outcome of applying a single hunk.
+type HunkResult struct {
+	// Index is the 1-based position of the hunk within its file.
+	Index   int    `json:"index"`
+	Header  string `json:"header"`
+	Applied bool   `json:"applied"`
+	// Line is the 1-based line in the original content where the hunk was
+	// applied.
+	Line int `json:"line,omitempty"`
+	// Offset is how many lines away from the header position the hunk was
+	// found.
+	Offset int `json:"offset,omitempty"`
+	// Fuzz describes the relaxed matching used to locate the hunk, if any.
+	Fuzz  string `json:"fuzz,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ParsePatch parses a unified or git-style diff that may touch several files.
+func ParsePatch(patch string) ([]FilePatch, error) {
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	lines := strings.Split(patch, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		files   []FilePatch
+		current *FilePatch
+		gitFile bool
+	)
+	flush := func() {
+		if current != nil {
+			files = append(files, *current)
+		}
+		current = nil
+		gitFile = false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			oldPath, newPath := parseGitHeader(strings.TrimPrefix(line, "diff --git "))
+			current = &FilePatch{OldPath: oldPath, NewPath: newPath}
+			gitFile = true
+		case current != nil && gitFile && strings.HasPrefix(line, "new file mode"):
+			current.OldPath = ""
+		case current != nil && gitFile && strings.HasPrefix(line, "deleted file mode"):
+			current.NewPath = ""
+		case current != nil && gitFile && strings.HasPrefix(line, "rename from "):
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case current != nil && gitFile && strings.HasPrefix(line, "rename to "):
+			current.NewPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || !gitFile || len(current.Hunks) > 0 {
+				flush()
+				current = &FilePatch{}
+			}
+			current.OldPath = parseFileHeaderPath(strings.TrimPrefix(line, "--- "), "a/")
+			current.NewPath = parseFileHeaderPath(strings.TrimPrefix(lines[i+1], "+++ "), "b/")
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.Hunks = append(current.Hunks, hunk)
+			i = next - 1
+		}
+	}
+	flush()
+
+	if len(files) == 0 {
+		return nil, errors.New("no file changes found in patch")
+	}
+	for _, f := range files {
+		if f.OldPath == "" && f.NewPath == "" {
+			return nil, errors.New("patch contains a file header without paths")
+		}
+	}
+	return files, nil
+}
+
+// parseGitHeader extracts the paths from the remainder of a
+// "diff --git a/x b/y" line.
+func parseGitHeader(rest string) (string, string) {
+	if idx := strings.Index(rest, " b/"); strings.HasPrefix(rest, "a/") && idx > 0 {
+		return rest[2:idx], rest[idx+3:]
+	}
+	fields := strings.Fields(rest)
+	if len(fields) != 2 {
+		return "", ""
+	}
+	return fields[0], fields[1]
+}
+
+// parseFileHeaderPath extracts the path from a "---" or "+++" header,
+// dropping timestamps and the git "a/" or "b/" prefix.
+func parseFileHeaderPath(header, prefix string) string {
+	if idx := strings.IndexByte(header, '\t'); idx >= 0 {
+		header = header[:idx]
+	}
+	header = strings.TrimSpace(header)
+	if header == DevNull {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
+
+// parseHunk parses the hunk starting at lines[start] and returns the index
+// of the first line after it.
+func parseHunk(lines []string, start int) (Hunk, int, error) {
+	hunk, err := parseHunkHeader(lines[start])
+	if err != nil {
+		return Hunk{}, 0, fmt.Errorf("line %d: %w", start+1, err)
+	}
+
+	i := start + 1
+loop:
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isHunkTerminator(lines, i) {
+			break
+		}
+		if line == "" {
+			// Blank context lines frequently lose their leading space.
+			hunk.Lines = append(hunk.Lines, HunkLine{Op: ' '})
+			continue
+		}
+		switch line[0] {
+		case ' ', '-', '+':
+			hunk.Lines = append(hunk.Lines, HunkLine{Op: line[0], Text: line[1:]})
+		case '\\':
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].NoNewline = true
+			}
+		default:
+			break loop
+		}
+	}
+
+	// Drop trailing blank context lines that exceed the header counts; they
+	// are usually the separator before the next file.
+	for len(hunk.Lines) > 0 {
+		last := hunk.Lines[len(hunk.Lines)-1]
+		if last.Op != ' ' || last.Text != "" || countOld(hunk.Lines) <= hunk.OldLines {
+			break
+		}
+		hunk.Lines = hunk.Lines[:len(hunk.Lines)-1]
+	}
+	return hunk, i, nil
+}
+
+func isHunkTerminator(lines []string, i int) bool {
+	line := lines[i]
+	switch {
+	case strings.HasPrefix(line, "@@"), strings.HasPrefix(line, "diff --git "):
+		return true
+	case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+		return true
+	}
+	return false
+}
+
+func parseHunkHeader(line string) (Hunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		// Some models emit bare "@@" separators; locate the hunk purely by
+		// its content in that case.
+		return Hunk{}, nil
+	}
+	var (
+		h   Hunk
+		err error
+	)
+	if h.OldStart, h.OldLines, err = parseRange(fields[1][1:]); err != nil {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(fields[2][1:]); err != nil {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	return h, nil
+}
+
+func parseRange(s string) (int, int, error) {
+	start, count, found := strings.Cut(s, ",")
+	first, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return first, 1, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, 0, err
+	}
+	return first, n, nil
+}
+
+func countOld(lines []HunkLine) int {
+	n := 0
+	for _, l := range lines {
+		if l.Op != '+' {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply applies the hunks of a file patch to content. When some hunks fail,
+// it returns the content with the remaining hunks applied, a result for
+// every hunk and [ErrHunksFailed].
+func Apply(content string, fp FilePatch) (string, []HunkResult, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+	trailingNewline := strings.HasSuffix(content, "\n")
+	if trailingNewline || content == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		results []HunkResult
+		failed  bool
+		// delta is the number of lines added minus removed so far, used to
+		// translate header positions into positions in the new content.
+		delta int
+		// floor is the first line later hunks may touch.
+		floor int
+	)
+	for i, hunk := range fp.Hunks {
+		result := HunkResult{Index: i + 1, Header: hunk.Header()}
+
+		pos, hunkLines, fuzz, ok := locateHunk(lines, hunk, floor, delta)
+		if !ok {
+			result.Error = describeFailure(hunk)
+			results = append(results, result)
+			failed = true
+			continue
+		}
+
+		var replacement []string
+		oldCount := 0
+		for _, l := range hunkLines {
+			switch l.Op {
+			case ' ':
+				replacement = append(replacement, lines[pos+oldCount])
+				oldCount++
+			case '-':
+				oldCount++
+			case '+':
+				replacement = append(replacement, l.Text)
+			}
+		}
+		if n := len(hunkLines); n > 0 && pos+oldCount == len(lines) {
+			last := hunkLines[n-1]
+			if last.Op != '-' {
+				trailingNewline = !last.NoNewline
+			}
+		}
+
+		lines = append(lines[:pos], append(replacement, lines[pos+oldCount:]...)...)
+		result.Applied = true
+		result.Line = pos - delta + 1
+		if hunk.OldStart > 0 {
+			result.Offset = pos - expectedPosition(hunk, delta)
+		}
+		result.Fuzz = fuzz
+		results = append(results, result)
+
+		delta += len(replacement) - oldCount
+		floor = pos + len(replacement)
+	}
+
+	out := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		out += "\n"
+	}
+	if failed {
+		return out, results, ErrHunksFailed
+	}
+	return out, results, nil
+}
+
+func expectedPosition(hunk Hunk, delta int) int {
+	pos := hunk.OldStart - 1
+	if hunk.OldLines == 0 {
+		// Pure insertions are positioned after line OldStart.
+		pos = hunk.OldStart
+	}
+	return max(pos, 0) + delta
+}
+
+// locateHunk finds where a hunk applies, trying progressively looser
+// comparisons and then dropping outer context lines. It returns the
+// position, the hunk lines that matched and the fuzz used.
+func locateHunk(lines []string, hunk Hunk, floor, delta int) (int, []HunkLine, string, bool) {
+	expected := expectedPosition(hunk, delta)
+
+	matchers := []struct {
+		name string
+		eq   func(a, b string) bool
+	}{
+		{"", func(a, b string) bool { return a == b }},
+		{"trailing whitespace", func(a, b string) bool {
+			return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t")
+		}},
+		{"whitespace", func(a, b string) bool {
+			return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+		}},
+	}
+
+	for fuzz := 0; fuzz <= maxContextFuzz; fuzz++ {
+		hunkLines, ok := trimContext(hunk.Lines, fuzz)
+		if !ok {
+			break
+		}
+		old := oldSide(hunkLines)
+		if len(old) == 0 {
+			if fuzz > 0 {
+				break
+			}
+			pos := min(max(expected, floor), len(lines))
+			return pos, hunkLines, "", true
+		}
+		for _, m := range matchers {
+			pos, ok := findBlock(lines, old, floor, expected, m.eq)
+			if !ok {
+				continue
+			}
+			var parts []string
+			if m.name != "" {
+				parts = append(parts, "ignored "+m.name)
+			}
+			if fuzz > 0 {
+				parts = append(parts, fmt.Sprintf("ignored %d line(s) of outer context", fuzz))
+			}
+			return pos, hunkLines, strings.Join(parts, ", "), true
+		}
+	}
+	return 0, nil, "", false
+}
+
+// trimContext drops up to n leading and trailing context lines from a hunk.
+func trimContext(lines []HunkLine, n int) ([]HunkLine, bool) {
+	if n == 0 {
+		return lines, true
+	}
+	start, end := 0, len(lines)
+	for i := 0; i < n && start < end && lines[start].Op == ' '; i++ {
+		start++
+	}
+	for i := 0; i < n && end > start && lines[end-1].Op == ' '; i++ {
+		end--
+	}
+	if start == 0 && end == len(lines) {
+		return nil, false
+	}
+	return lines[start:end], true
+}
+
+func oldSide(lines []HunkLine) []string {
+	var old []string
+	for _, l := range lines {
+		if l.Op != '+' {
+			old = append(old, l.Text)
+		}
+	}
+	return old
+}
+
+// findBlock searches for block in lines at or after floor, preferring the
+// match closest to the expected position.
+func findBlock(lines, block []string, floor, expected int, eq func(a, b string) bool) (int, bool) {
+	matchesAt := func(pos int) bool {
+		if pos < floor || pos+len(block) > len(lines) {
+			return false
+		}
+		for i, want := range block {
+			if !eq(lines[pos+i], want) {
+				return false
+			}
+		}
+		return true
+	}
+
+	expected = min(max(expected, floor), len(lines))
+	for dist := 0; dist <= len(lines); dist++ {
+		if matchesAt(expected - dist) {
+			return expected - dist, true
+		}
+		if dist > 0 && matchesAt(expected+dist) {
+			return expected + dist, true
+		}
+	}
+	return 0, false
+}
+
+func describeFailure(hunk Hunk) string {
+	old := oldSide(hunk.Lines)
+	if len(old) == 0 {
+		return "could not determine where to insert the hunk"
+	}
+	first := old[0]
+	for _, l := range old {
+		if strings.TrimSpace(l) != "" {
+			first = l
+			break
+		}
+	}
+	return fmt.Sprintf("could not find the %d context/removed line(s) of this hunk in the file; first expected line: %q", len(old), first)
+}
@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatch(t *testing.T) {
+	t.Parallel()
+
+	patch := `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+
++import "fmt"
+ func main() {}
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/a.txt b/b.txt
+similarity index 100%
+rename from a.txt
+rename to b.txt
+`
+	files, err := ParsePatch(patch)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	require.Equal(t, "main.go", files[0].OldPath)
+	require.Equal(t, "main.go", files[0].NewPath)
+	require.Len(t, files[0].Hunks, 1)
+	require.Len(t, files[0].Hunks[0].Lines, 4)
+	require.Equal(t, byte(' '), files[0].Hunks[0].Lines[1].Op)
+
+	require.True(t, files[1].IsCreate())
+	require.Equal(t, "new.txt", files[1].Path())
+
+	require.True(t, files[2].IsDelete())
+	require.Equal(t, "old.txt", files[2].Path())
+
+	require.True(t, files[3].IsRename())
+	require.Equal(t, "a.txt", files[3].OldPath)
+	require.Equal(t, "b.txt", files[3].NewPath)
+	require.Empty(t, files[3].Hunks)
+}
+
+func TestParsePatchPlainUnified(t *testing.T) {
+	t.Parallel()
+
+	patch := "--- foo.txt\t2024-01-01 00:00:00\n+++ foo.txt\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-a\n+b\n--- bar.txt\n+++ bar.txt\n@@ -1 +1 @@\n-c\n+d\n"
+	files, err := ParsePatch(patch)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "foo.txt", files[0].NewPath)
+	require.Equal(t, "bar.txt", files[1].NewPath)
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParsePatch("just some text")
+	require.Error(t, err)
+
+	_, err = ParsePatch("@@ -1 +1 @@\n-a\n+b\n")
+	require.ErrorContains(t, err, "hunk without a file header")
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		patch   string
+		want    string
+		fuzz    string
+	}{
+		{
+			name:    "exact",
+			content: "a\nb\nc\n",
+			patch:   "--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "a\nB\nc\n",
+		},
+		{
+			name:    "offset",
+			content: "x\ny\na\nb\nc\n",
+			patch:   "--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "x\ny\na\nB\nc\n",
+		},
+		{
+			name:    "trailing whitespace",
+			content: "a  \nb\nc\n",
+			patch:   "--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "a  \nB\nc\n",
+			fuzz:    "ignored trailing whitespace",
+		},
+		{
+			name:    "indentation",
+			content: "func() {\n\treturn 1\n}\n",
+			patch:   "--- f\n+++ f\n@@ -1,3 +1,3 @@\n func() {\n-    return 1\n+\treturn 2\n }\n",
+			want:    "func() {\n\treturn 2\n}\n",
+			fuzz:    "ignored whitespace",
+		},
+		{
+			name:    "stale outer context",
+			content: "one\ntwo\nthree\nfour\nfive\n",
+			patch:   "--- f\n+++ f\n@@ -2,3 +2,3 @@\n TWO\n-three\n+3\n four\n",
+			want:    "one\ntwo\n3\nfour\nfive\n",
+			fuzz:    "ignored 1 line(s) of outer context",
+		},
+		{
+			name:    "no newline at end",
+			content: "a\nb",
+			patch:   "--- f\n+++ f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n",
+			want:    "a\nc\n",
+		},
+		{
+			name:    "create",
+			content: "",
+			patch:   "--- /dev/null\n+++ f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+			want:    "a\nb\n",
+		},
+		{
+			name:    "crlf content",
+			content: "a\r\nb\r\n",
+			patch:   "--- f\n+++ f\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			want:    "a\nc\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			files, err := ParsePatch(tt.patch)
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+
+			got, results, err := Apply(tt.content, files[0])
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Len(t, results, len(files[0].Hunks))
+			require.True(t, results[0].Applied)
+			require.Equal(t, tt.fuzz, results[0].Fuzz)
+		})
+	}
+}
+
+func TestApplyMultipleHunks(t *testing.T) {
+	t.Parallel()
+
+	content := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	patch := `--- f
++++ f
+@@ -1,3 +1,4 @@
+ 1
++1.5
+ 2
+ 3
+@@ -8,3 +9,2 @@
+ 8
+-9
+ 10
+`
+	files, err := ParsePatch(patch)
+	require.NoError(t, err)
+
+	got, results, err := Apply(content, files[0])
+	require.NoError(t, err)
+	require.Equal(t, "1\n1.5\n2\n3\n4\n5\n6\n7\n8\n10\n", got)
+	require.Len(t, results, 2)
+	require.Equal(t, 1, results[0].Line)
+	require.Equal(t, 8, results[1].Line)
+	require.Zero(t, results[1].Offset)
+}
+
+func TestApplyReportsFailedHunks(t *testing.T) {
+	t.Parallel()
+
+	content := "a\nb\nc\n"
+	patch := `--- f
++++ f
+@@ -1,2 +1,2 @@
+-a
++A
+ b
+@@ -3 +3 @@
+-missing
++found
+`
+	files, err := ParsePatch(patch)
+	require.NoError(t, err)
+
+	got, results, err := Apply(content, files[0])
+	require.ErrorIs(t, err, ErrHunksFailed)
+	require.Equal(t, "A\nb\nc\n", got)
+	require.Len(t, results, 2)
+	require.True(t, results[0].Applied)
+	require.False(t, results[1].Applied)
+	require.Equal(t, 2, results[1].Index)
+	require.Contains(t, results[1].Error, `"missing"`)
+}
@@ -0,0 +1,30 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+)
+
+// CSV renders delimited data as a Markdown table with the first record as
+// the header. Records may have differing numbers of fields.
+func CSV(data []byte, comma rune) (string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, record)
+	}
+	return markdownTable(rows), nil
+}
@@ -0,0 +1,214 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DOCX extracts the body of a Word document. Headings become Markdown
+// headings, list paragraphs become bullets and tables become Markdown
+// tables; images, headers, footers and comments are skipped.
+func DOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	f, err := openZipFile(zr, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := &docxWriter{}
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tbl":
+				w.tables = append(w.tables, &docxTable{})
+			case "tr":
+				if tbl := w.table(); tbl != nil {
+					tbl.row = nil
+				}
+			case "tc":
+				if tbl := w.table(); tbl != nil {
+					tbl.cell = nil
+				}
+			case "p":
+				w.para.Reset()
+				w.style = ""
+				w.list = false
+			case "pStyle":
+				w.style = attr(t, "val")
+			case "numPr":
+				w.list = true
+			case "t":
+				var s string
+				if err := dec.DecodeElement(&s, &t); err != nil {
+					return "", err
+				}
+				w.para.WriteString(s)
+			case "tab":
+				// Tab stop definitions in paragraph properties carry
+				// a position; tabs inside runs do not.
+				if attr(t, "pos") == "" {
+					w.para.WriteString("\t")
+				}
+			case "br", "cr":
+				w.para.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				w.endParagraph()
+			case "tc":
+				if tbl := w.table(); tbl != nil {
+					tbl.row = append(tbl.row, strings.Join(tbl.cell, " "))
+				}
+			case "tr":
+				if tbl := w.table(); tbl != nil {
+					tbl.rows = append(tbl.rows, tbl.row)
+				}
+			case "tbl":
+				w.endTable()
+			}
+		}
+	}
+	return strings.TrimSpace(w.out.String()), nil
+}
+
+type docxTable struct {
+	rows [][]string
+	row  []string
+	cell []string
+}
+
+type docxWriter struct {
+	out    strings.Builder
+	para   strings.Builder
+	style  string
+	list   bool
+	spaced bool
+	tables []*docxTable
+}
+
+func (w *docxWriter) table() *docxTable {
+	if len(w.tables) == 0 {
+		return nil
+	}
+	return w.tables[len(w.tables)-1]
+}
+
+func (w *docxWriter) endParagraph() {
+	text := strings.TrimSpace(w.para.String())
+	w.para.Reset()
+	if text == "" {
+		return
+	}
+	if tbl := w.table(); tbl != nil {
+		tbl.cell = append(tbl.cell, text)
+		return
+	}
+
+	if level := headingLevel(w.style); level > 0 {
+		text = strings.Repeat("#", level) + " " + text
+	} else if w.list {
+		text = "- " + text
+	}
+	w.writeBlock(text)
+}
+
+func (w *docxWriter) endTable() {
+	tbl := w.table()
+	if tbl == nil {
+		return
+	}
+	w.tables = w.tables[:len(w.tables)-1]
+
+	// Nested tables are flattened into the enclosing cell.
+	if outer := w.table(); outer != nil {
+		for _, row := range tbl.rows {
+			outer.cell = append(outer.cell, strings.Join(row, " "))
+		}
+		return
+	}
+	if table := markdownTable(tbl.rows); table != "" {
+		w.writeBlock(table)
+	}
+}
+
+// writeBlock writes a paragraph or table. Headings and tables are set off
+// by blank lines; consecutive paragraphs are not, to keep the line count
+// close to the document's.
+func (w *docxWriter) writeBlock(text string) {
+	spaced := strings.HasPrefix(text, "#") || strings.HasPrefix(text, "|")
+	if w.out.Len() > 0 {
+		w.out.WriteString("\n")
+		if spaced || w.spaced {
+			w.out.WriteString("\n")
+		}
+	}
+	w.out.WriteString(text)
+	w.spaced = spaced
+}
+
+// headingLevel returns the heading level of a paragraph style such as
+// "Heading2" or "Title", or 0 for other styles.
+func headingLevel(style string) int {
+	if strings.EqualFold(style, "Title") {
+		return 1
+	}
+	lower := strings.ToLower(style)
+	if !strings.HasPrefix(lower, "heading") {
+		return 0
+	}
+	level, err := strconv.Atoi(strings.TrimSpace(lower[len("heading"):]))
+	if err != nil || level < 1 {
+		return 0
+	}
+	return min(level, 6)
+}
+
+func attr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// maxZipFileSize is the largest decompressed part of a DOCX or XLSX
+// document that will be read, so that a small document cannot expand to
+// exhaust memory.
+const maxZipFileSize = 4 * MaxFileSize
+
+// openZipFile opens the named part of a document. Parts that decompress to
+// more than maxZipFileSize are refused; the zip reader itself fails on
+// parts longer than their header declares.
+func openZipFile(zr *zip.Reader, name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if strings.EqualFold(f.Name, name) {
+			if f.UncompressedSize64 > maxZipFileSize {
+				return nil, fmt.Errorf("%s is too large (%d bytes, maximum is %d bytes)", name, f.UncompressedSize64, maxZipFileSize)
+			}
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
@@ -0,0 +1,148 @@
+// Package extract turns binary documents into plain text that can be paged
+// through line by line: PDF, DOCX, XLSX, CSV/TSV and Jupyter notebooks.
+// Tabular content is rendered as Markdown tables.
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxFileSize is the largest document that will be extracted. Documents are
+// read into memory in full, so this bounds memory use independently of how
+// much text is later returned.
+const MaxFileSize = 50 * 1024 * 1024 // 50MB
+
+// ErrTooLarge is returned for documents larger than MaxFileSize.
+var ErrTooLarge = errors.New("document is too large to extract")
+
+// Image is an image embedded in a document, such as a notebook plot.
+type Image struct {
+	Data      []byte
+	MediaType string
+	// Line is the 0-based line of Document.Text holding the image's
+	// placeholder.
+	Line int
+}
+
+// Document is the extracted content of a file.
+type Document struct {
+	Text   string
+	Images []Image
+}
+
+// Kind returns a short name for the document format of path, or "" when the
+// format is not supported.
+func Kind(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return "PDF"
+	case ".docx":
+		return "DOCX"
+	case ".xlsx":
+		return "XLSX"
+	case ".csv":
+		return "CSV"
+	case ".tsv":
+		return "TSV"
+	case ".ipynb":
+		return "notebook"
+	default:
+		return ""
+	}
+}
+
+// Supported reports whether path has an extension this package can extract.
+func Supported(path string) bool {
+	return Kind(path) != ""
+}
+
+// File extracts the document at path, choosing the format by extension.
+func File(path string) (*Document, error) {
+	kind := Kind(path)
+	if kind == "" {
+		return nil, fmt.Errorf("unsupported document type: %s", filepath.Ext(path))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxFileSize {
+		return nil, fmt.Errorf("%w (%d bytes, maximum is %d bytes)", ErrTooLarge, info.Size(), MaxFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == "notebook" {
+		return Notebook(data)
+	}
+
+	var text string
+	switch kind {
+	case "PDF":
+		text, err = PDF(data)
+	case "DOCX":
+		text, err = DOCX(data)
+	case "XLSX":
+		text, err = XLSX(data)
+	case "CSV":
+		text, err = CSV(data, ',')
+	case "TSV":
+		text, err = CSV(data, '\t')
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", kind, err)
+	}
+	return &Document{Text: text}, nil
+}
+
+// markdownTable renders rows as a Markdown table, using the first row as the
+// header. Rows are padded to the widest row.
+func markdownTable(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := range width {
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			sb.WriteString(" ")
+			sb.WriteString(escapeCell(cell))
+			sb.WriteString(" |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	sb.WriteString("|")
+	sb.WriteString(strings.Repeat(" --- |", width))
+	sb.WriteString("\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+var cellReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func escapeCell(s string) string {
+	return cellReplacer.Replace(strings.TrimSpace(s))
+}
@@ -0,0 +1,237 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// minimalPDF builds a PDF with one page per entry in pages, each showing
+// its lines of text.
+func minimalPDF(pages ...[]string) []byte {
+	var objects []string
+	var kids []string
+	fontObj := 3 + 2*len(pages)
+	for i, lines := range pages {
+		pageObj := 3 + 2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+		var stream strings.Builder
+		stream.WriteString("BT /F1 12 Tf 72 720 Td 14 TL")
+		for _, line := range lines {
+			fmt.Fprintf(&stream, " (%s) Tj T*", line)
+		}
+		stream.WriteString(" ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>", pageObj+1, fontObj),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()),
+		)
+	}
+	objects = append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+	}, objects...)
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestPDF(t *testing.T) {
+	t.Parallel()
+
+	text, err := PDF(minimalPDF([]string{"Hello PDF", "Second line"}, []string{"Page two"}))
+	require.NoError(t, err)
+	require.Contains(t, text, "--- Page 1 ---")
+	require.Contains(t, text, "Hello PDF\nSecond line")
+	require.Contains(t, text, "--- Page 2 ---\nPage two")
+
+	_, err = PDF([]byte("not a pdf"))
+	require.Error(t, err)
+}
+
+func TestDOCX(t *testing.T) {
+	t.Parallel()
+
+	const document = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs></w:pPr><w:r><w:t>Design</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">The service </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>must</w:t></w:r><w:r><w:t xml:space="preserve"> scale.</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>First item</w:t></w:r></w:p>
+<w:p/>
+<w:tbl>
+<w:tr><w:tc><w:p><w:r><w:t>Name</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Value</w:t></w:r></w:p></w:tc></w:tr>
+<w:tr><w:tc><w:p><w:r><w:t>a|b</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>1</w:t></w:r></w:p><w:p><w:r><w:t>2</w:t></w:r></w:p></w:tc></w:tr>
+</w:tbl>
+<w:p><w:r><w:t>After</w:t></w:r><w:r><w:tab/><w:t>tab</w:t></w:r></w:p>
+</w:body>
+</w:document>`
+
+	text, err := DOCX(zipArchive(t, map[string]string{"word/document.xml": document}))
+	require.NoError(t, err)
+	require.Equal(t, `# Design
+
+The service must scale.
+- First item
+
+| Name | Value |
+| --- | --- |
+| a\|b | 1 2 |
+
+After	tab`, text)
+
+	_, err = DOCX(zipArchive(t, map[string]string{"other.xml": "<x/>"}))
+	require.ErrorContains(t, err, "word/document.xml")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{Name: "word/document.xml", Method: zip.Deflate, UncompressedSize64: maxZipFileSize + 1})
+	require.NoError(t, err)
+	_, err = w.Write([]byte{0x03, 0x00})
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	_, err = DOCX(buf.Bytes())
+	require.ErrorContains(t, err, "word/document.xml is too large")
+}
+
+func TestXLSX(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Data" sheetId="1" r:id="rId1"/><sheet name="Empty" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="worksheet" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Name</t></si><si><t>Score</t></si><si><r><t>Ada </t></r><r><t>Lovelace</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>Passed</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>97.5</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="3"><c r="C3"><f>SUM(B2)</f><v>97.5</v></c><c r="ZZZZZZZZ3"><v>1</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+	}
+
+	text, err := XLSX(zipArchive(t, files))
+	require.NoError(t, err)
+	require.Equal(t, `## Sheet: Data
+
+| Name | Score | Passed |
+| --- | --- | --- |
+| Ada Lovelace | 97.5 | TRUE |
+|  |  | 97.5 |
+
+## Sheet: Empty
+
+(empty)`, text)
+}
+
+func TestCSV(t *testing.T) {
+	t.Parallel()
+
+	text, err := CSV([]byte("\xef\xbb\xbfname,notes\nada,\"multi\nline\"\nbob\n"), ',')
+	require.NoError(t, err)
+	require.Equal(t, `| name | notes |
+| --- | --- |
+| ada | multi<br>line |
+| bob |  |`, text)
+
+	text, err = CSV([]byte("a\tb\n1\t2\n"), '\t')
+	require.NoError(t, err)
+	require.Equal(t, "| a | b |\n| --- | --- |\n| 1 | 2 |", text)
+}
+
+func TestNotebook(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	nb := fmt.Sprintf(`{
+ "cells": [
+  {"cell_type": "markdown", "id": "intro", "metadata": {}, "source": ["# Analysis\n", "Loading data."]},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "source": "print('hi')\nplot()",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["hi\n"]},
+    {"output_type": "display_data", "data": {"image/png": "%s\n", "text/plain": ["<Figure>"]}, "metadata": {}},
+    {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[0;31mValueError\u001b[0m: bad"]}
+   ]},
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "source": [], "outputs": []}
+ ],
+ "metadata": {},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`, base64.StdEncoding.EncodeToString(png))
+
+	doc, err := Notebook([]byte(nb))
+	require.NoError(t, err)
+	require.Equal(t, `--- Cell 1 (markdown, id intro) ---
+# Analysis
+Loading data.
+--- Cell 2 (code, In [2]) ---
+print('hi')
+plot()
+--- Output ---
+hi
+[Image 1: image/png]
+<Figure>
+ValueError: bad
+--- Cell 3 (code) ---`, doc.Text)
+	require.Len(t, doc.Images, 1)
+	require.Equal(t, png, doc.Images[0].Data)
+	require.Equal(t, "image/png", doc.Images[0].MediaType)
+	require.Equal(t, "[Image 1: image/png]", strings.Split(doc.Text, "\n")[doc.Images[0].Line])
+
+	_, err = Notebook([]byte("{"))
+	require.Error(t, err)
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "data.CSV")
+	require.NoError(t, os.WriteFile(csvPath, []byte("a,b\n1,2\n"), 0o644))
+
+	require.True(t, Supported(csvPath))
+	require.False(t, Supported(filepath.Join(dir, "main.go")))
+
+	doc, err := File(csvPath)
+	require.NoError(t, err)
+	require.Equal(t, "| a | b |\n| --- | --- |\n| 1 | 2 |", doc.Text)
+
+	_, err = File(filepath.Join(dir, "main.go"))
+	require.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package extract
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// notebookText is a multiline string in nbformat, stored either as one
+// string or as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = notebookText(s)
+	return nil
+}
+
+type notebookOutput struct {
+	OutputType string                  `json:"output_type"`
+	Name       string                  `json:"name"`
+	Text       notebookText            `json:"text"`
+	Data       map[string]notebookText `json:"data"`
+	EName      string                  `json:"ename"`
+	EValue     string                  `json:"evalue"`
+	Traceback  []string                `json:"traceback"`
+}
+
+type notebookCell struct {
+	ID             string           `json:"id"`
+	CellType       string           `json:"cell_type"`
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebook struct {
+	Cells []notebookCell `json:"cells"`
+}
+
+// Notebook renders a Jupyter notebook (nbformat 4) cell by cell. Each cell
+// starts with a "--- Cell N (type) ---" marker, numbered from 1 and
+// including the cell ID when the notebook has one, and code cells are
+// followed by their outputs. Image outputs are returned in Document.Images with a
+// placeholder line in the text.
+func Notebook(data []byte) (*Document, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	doc := &Document{}
+	var lines []string
+	add := func(text string) {
+		lines = append(lines, strings.Split(strings.TrimRight(text, "\n"), "\n")...)
+	}
+
+	for i, cell := range nb.Cells {
+		info := cell.CellType
+		if cell.CellType == "code" && cell.ExecutionCount != nil {
+			info += fmt.Sprintf(", In [%d]", *cell.ExecutionCount)
+		}
+		if cell.ID != "" {
+			info += ", id " + cell.ID
+		}
+		lines = append(lines, fmt.Sprintf("--- Cell %d (%s) ---", i+1, info))
+		if cell.Source != "" {
+			add(string(cell.Source))
+		}
+
+		if len(cell.Outputs) == 0 {
+			continue
+		}
+		lines = append(lines, "--- Output ---")
+		for _, out := range cell.Outputs {
+			switch out.OutputType {
+			case "stream":
+				add(string(out.Text))
+			case "error":
+				if len(out.Traceback) > 0 {
+					add(ansi.Strip(strings.Join(out.Traceback, "\n")))
+				} else {
+					add(fmt.Sprintf("%s: %s", out.EName, out.EValue))
+				}
+			case "execute_result", "display_data":
+				if img, ok := notebookImage(out.Data); ok {
+					img.Line = len(lines)
+					doc.Images = append(doc.Images, img)
+					lines = append(lines, fmt.Sprintf("[Image %d: %s]", len(doc.Images), img.MediaType))
+				}
+				if text, ok := out.Data["text/plain"]; ok {
+					add(ansi.Strip(string(text)))
+				} else if text, ok := out.Data["text/markdown"]; ok {
+					add(string(text))
+				}
+			}
+		}
+	}
+
+	doc.Text = strings.Join(lines, "\n")
+	return doc, nil
+}
+
+// notebookImage returns the first raster image in an output's data bundle.
+func notebookImage(data map[string]notebookText) (Image, bool) {
+	for _, mediaType := range []string{"image/png", "image/jpeg", "image/gif", "image/webp"} {
+		encoded, ok := data[mediaType]
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(encoded)), ""))
+		if err != nil {
+			continue
+		}
+		return Image{Data: raw, MediaType: mediaType}, true
+	}
+	return Image{}, false
+}
@@ -0,0 +1,57 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// PDF extracts the text of every page, each page preceded by a
+// "--- Page N ---" marker. Scanned pages without a text layer come out
+// empty.
+func PDF(data []byte) (text string, err error) {
+	// The PDF reader panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", i, err)
+		}
+		if i > 1 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "--- Page %d ---\n", i)
+		sb.WriteString(cleanPageText(pageText))
+	}
+	return sb.String(), nil
+}
+
+func cleanPageText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,185 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r xlsxRichText) String() string {
+	if len(r.Runs) == 0 {
+		return r.T
+	}
+	var sb strings.Builder
+	for _, run := range r.Runs {
+		sb.WriteString(run.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// XLSX extracts every worksheet of an Excel workbook as a Markdown table
+// under a "## Sheet: name" heading. Cell values are shown as stored:
+// formulas show their cached result and dates their serial number.
+func XLSX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	var shared xlsxSharedStrings
+	// Workbooks without any text cells have no shared strings part.
+	_ = decodeZipXML(zr, "xl/sharedStrings.xml", &shared)
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	var sb strings.Builder
+	for i, s := range workbook.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			continue
+		}
+		var sheet xlsxSheet
+		if err := decodeZipXML(zr, target, &sheet); err != nil {
+			return "", fmt.Errorf("sheet %q: %w", s.Name, err)
+		}
+
+		var rows [][]string
+		for _, row := range sheet.Rows {
+			var cells []string
+			for j, c := range row.Cells {
+				col := j
+				if idx, ok := columnIndex(c.Ref); ok {
+					if idx >= xlsxMaxColumns {
+						continue
+					}
+					col = idx
+				}
+				for len(cells) < col {
+					cells = append(cells, "")
+				}
+				cells = append(cells, cellValue(c.Type, c.Value, c.Inline, shared.Items))
+			}
+			rows = append(rows, cells)
+		}
+
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "## Sheet: %s\n\n", s.Name)
+		if table := markdownTable(rows); table != "" {
+			sb.WriteString(table)
+		} else {
+			sb.WriteString("(empty)")
+		}
+	}
+	return sb.String(), nil
+}
+
+func cellValue(typ, value string, inline xlsxRichText, shared []xlsxRichText) string {
+	switch typ {
+	case "s":
+		idx, err := strconv.Atoi(value)
+		if err != nil || idx < 0 || idx >= len(shared) {
+			return ""
+		}
+		return shared[idx].String()
+	case "inlineStr":
+		return inline.String()
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return value
+	}
+}
+
+// xlsxMaxColumns is the number of columns of a worksheet, up to column XFD.
+// Cells referencing columns beyond it are skipped.
+const xlsxMaxColumns = 16384
+
+// columnIndex converts the column letters of a cell reference such as "C7"
+// to a 0-based index. Indexes past the last column are reported as
+// xlsxMaxColumns.
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = min(col*26+int(r-'A'+1), xlsxMaxColumns+1)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	f, err := openZipFile(zr, name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return xml.NewDecoder(f).Decode(v)
+}
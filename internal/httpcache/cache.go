@@ -0,0 +1,337 @@
+// Package httpcache is a small persistent cache for the content returned by
+// the fetch tools. Entries are keyed by URL and output format, honour
+// Cache-Control, Expires, ETag and Last-Modified, and are evicted least
+// recently used first once the cache grows past its size cap.
+//
+// The fetch tools get a nil *Cache when caching is turned off: Get then
+// always misses, and Store and Revalidated do nothing.
+package httpcache
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/etag"
+)
+
+// DefaultMaxSize is the size cap used when none is configured.
+const DefaultMaxSize int64 = 100 * 1024 * 1024 // 100MB
+
+// maxHeuristicTTL caps how long a response without explicit freshness
+// information is considered fresh.
+const maxHeuristicTTL = 24 * time.Hour
+
+const entryExt = ".json"
+
+// Entry is a cached response, already converted to the requested format.
+type Entry struct {
+	URL          string    `json:"url"`
+	Format       string    `json:"format"`
+	Content      string    `json:"content"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	Expires      time.Time `json:"expires"`
+}
+
+// Fresh reports whether the entry can be used without revalidating it with
+// the server.
+func (e *Entry) Fresh() bool {
+	return e != nil && time.Now().Before(e.Expires)
+}
+
+// SetConditionalHeaders adds If-None-Match and If-Modified-Since to req so
+// the server can answer 304 Not Modified when the entry is still valid.
+func (e *Entry) SetConditionalHeaders(req *http.Request) {
+	if e == nil {
+		return
+	}
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// Cache is an on-disk cache of fetched content. It is safe for concurrent
+// use, and entries are written atomically so several processes can share a
+// directory.
+type Cache struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+}
+
+// New returns a cache stored in dir that holds at most maxSize bytes. A
+// maxSize of zero or less uses DefaultMaxSize.
+func New(dir string, maxSize int64) *Cache {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Cache{dir: dir, maxSize: maxSize}
+}
+
+// Dir returns the directory the cache is stored in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) path(url, format string) string {
+	return filepath.Join(c.dir, etag.Of([]byte(format+"\x00"+url))+entryExt)
+}
+
+// Get returns the entry for url and format, or nil if there is none. The
+// entry may be stale; check [Entry.Fresh] before using it without
+// revalidation.
+func (c *Cache) Get(url, format string) *Entry {
+	if c == nil {
+		return nil
+	}
+
+	path := c.path(url, format)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url || entry.Format != format {
+		return nil
+	}
+
+	// The modification time doubles as the last access time for LRU
+	// eviction.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &entry
+}
+
+// Store caches content converted from resp, a 200 response for url. It does
+// nothing when the response forbids storing or cannot be revalidated once
+// stale. Errors are logged, not returned: caching is best effort.
+func (c *Cache) Store(url, format string, resp *http.Response, content string) {
+	if c == nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	now := time.Now()
+	expires, ok := expiry(resp.Header, now)
+	if !ok {
+		return
+	}
+	entry := Entry{
+		URL:          url,
+		Format:       format,
+		Content:      content,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     now,
+		Expires:      expires,
+	}
+	if !entry.Expires.After(now) && entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+	c.write(&entry)
+}
+
+// Revalidated refreshes entry after the server answered a conditional
+// request with 304 Not Modified.
+func (c *Cache) Revalidated(entry *Entry, resp *http.Response) {
+	if c == nil || entry == nil {
+		return
+	}
+
+	now := time.Now()
+	expires, ok := expiry(resp.Header, now)
+	if !ok {
+		c.remove(entry.URL, entry.Format)
+		return
+	}
+	entry.Expires = expires
+	entry.StoredAt = now
+	entry.ETag = cmp.Or(resp.Header.Get("ETag"), entry.ETag)
+	entry.LastModified = cmp.Or(resp.Header.Get("Last-Modified"), entry.LastModified)
+	c.write(entry)
+}
+
+// Clear removes every entry and returns how many were removed.
+func (c *Cache) Clear() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		if strings.HasSuffix(e.Name(), entryExt) {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (c *Cache) write(entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Warn("Failed to encode fetch cache entry", "url", entry.URL, "error", err)
+		return
+	}
+	if int64(len(data)) > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		slog.Warn("Failed to create fetch cache directory", "dir", c.dir, "error", err)
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		slog.Warn("Failed to write fetch cache entry", "url", entry.URL, "error", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(entry.URL, entry.Format))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		slog.Warn("Failed to write fetch cache entry", "url", entry.URL, "error", err)
+		return
+	}
+
+	c.evict()
+}
+
+func (c *Cache) remove(url, format string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = os.Remove(c.path(url, format))
+}
+
+// evict removes the least recently used entries until the cache fits in
+// maxSize. It must be called with c.mu held.
+func (c *Cache) evict() {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		files []file
+		total int64
+	)
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), entryExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{filepath.Join(c.dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	if total <= c.maxSize {
+		return
+	}
+
+	slices.SortFunc(files, func(a, b file) int {
+		return a.modTime.Compare(b.modTime)
+	})
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(f.path); err == nil || errors.Is(err, fs.ErrNotExist) {
+			total -= f.size
+		}
+	}
+}
+
+// expiry computes when a response stops being fresh. It returns false when
+// the response must not be stored at all.
+func expiry(header http.Header, now time.Time) (time.Time, bool) {
+	directives := parseCacheControl(header.Values("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return time.Time{}, false
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return now, true
+	}
+	if v, ok := directives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return now.Add(time.Duration(max(seconds, 0)) * time.Second), true
+		}
+		return now, true
+	}
+
+	date := now
+	if t, err := http.ParseTime(header.Get("Date")); err == nil {
+		date = t
+	}
+	if v := header.Get("Expires"); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil {
+			// Invalid dates such as "0" mean already expired.
+			return now, true
+		}
+		return now.Add(t.Sub(date)), true
+	}
+
+	// Without explicit freshness information, use the common heuristic of
+	// a tenth of the time since the document was last modified.
+	if t, err := http.ParseTime(header.Get("Last-Modified")); err == nil && date.After(t) {
+		return now.Add(min(date.Sub(t)/10, maxHeuristicTTL)), true
+	}
+	return now, true
+}
+
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
@@ -0,0 +1,165 @@
+package httpcache
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func response(header ...string) *http.Response {
+	resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}
+	for i := 0; i+1 < len(header); i += 2 {
+		resp.Header.Add(header[i], header[i+1])
+	}
+	return resp
+}
+
+func TestExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header []string
+		want   time.Duration
+		store  bool
+	}{
+		{name: "max-age", header: []string{"Cache-Control", "public, max-age=300"}, want: 5 * time.Minute, store: true},
+		{name: "no-cache", header: []string{"Cache-Control", "no-cache"}, want: 0, store: true},
+		{name: "no-store", header: []string{"Cache-Control", "no-store"}, store: false},
+		{name: "expires", header: []string{"Date", "Thu, 02 Jan 2025 12:00:00 GMT", "Expires", "Thu, 02 Jan 2025 13:00:00 GMT"}, want: time.Hour, store: true},
+		{name: "invalid expires", header: []string{"Expires", "0"}, want: 0, store: true},
+		{name: "last-modified heuristic", header: []string{"Date", "Thu, 02 Jan 2025 12:00:00 GMT", "Last-Modified", "Thu, 02 Jan 2025 02:00:00 GMT"}, want: time.Hour, store: true},
+		{name: "heuristic cap", header: []string{"Date", "Thu, 02 Jan 2025 12:00:00 GMT", "Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT"}, want: maxHeuristicTTL, store: true},
+		{name: "no information", want: 0, store: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := expiry(response(tt.header...).Header, now)
+			require.Equal(t, tt.store, ok)
+			if ok {
+				require.Equal(t, tt.want, got.Sub(now))
+			}
+		})
+	}
+}
+
+func TestCacheStoreAndGet(t *testing.T) {
+	t.Parallel()
+
+	c := New(t.TempDir(), 0)
+	const url = "https://example.com/docs"
+
+	c.Store(url, "markdown", response("Cache-Control", "max-age=60", "ETag", `"v1"`), "# Docs")
+
+	entry := c.Get(url, "markdown")
+	require.NotNil(t, entry)
+	require.True(t, entry.Fresh())
+	require.Equal(t, "# Docs", entry.Content)
+	require.Equal(t, `"v1"`, entry.ETag)
+
+	require.Nil(t, c.Get(url, "html"), "entries are keyed by format")
+	require.Nil(t, c.Get(url+"/other", "markdown"))
+}
+
+func TestCacheSkipsUnrevalidatable(t *testing.T) {
+	t.Parallel()
+
+	c := New(t.TempDir(), 0)
+	c.Store("https://example.com/a", "text", response(), "a")
+	c.Store("https://example.com/b", "text", response("Cache-Control", "no-store", "ETag", `"x"`), "b")
+	resp := response("Cache-Control", "max-age=60")
+	resp.StatusCode = http.StatusNotFound
+	c.Store("https://example.com/c", "text", resp, "c")
+
+	require.Nil(t, c.Get("https://example.com/a", "text"))
+	require.Nil(t, c.Get("https://example.com/b", "text"))
+	require.Nil(t, c.Get("https://example.com/c", "text"))
+}
+
+func TestCacheRevalidated(t *testing.T) {
+	t.Parallel()
+
+	c := New(t.TempDir(), 0)
+	const url = "https://example.com/docs"
+	c.Store(url, "text", response("Cache-Control", "no-cache", "ETag", `"v1"`), "docs")
+
+	entry := c.Get(url, "text")
+	require.NotNil(t, entry)
+	require.False(t, entry.Fresh())
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	entry.SetConditionalHeaders(req)
+	require.Equal(t, `"v1"`, req.Header.Get("If-None-Match"))
+
+	c.Revalidated(entry, &http.Response{
+		StatusCode: http.StatusNotModified,
+		Header:     http.Header{"Cache-Control": {"max-age=60"}},
+	})
+	entry = c.Get(url, "text")
+	require.NotNil(t, entry)
+	require.True(t, entry.Fresh())
+	require.Equal(t, "docs", entry.Content)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	body := strings.Repeat("x", 1000)
+	c := New(dir, 2500)
+
+	c.Store("https://example.com/1", "text", response("Cache-Control", "max-age=60"), body)
+	c.Store("https://example.com/2", "text", response("Cache-Control", "max-age=60"), body)
+
+	// Make entry 1 the oldest, then read it so entry 2 becomes the least
+	// recently used.
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(c.path("https://example.com/1", "text"), old, old))
+	require.NoError(t, os.Chtimes(c.path("https://example.com/2", "text"), old.Add(time.Minute), old.Add(time.Minute)))
+	require.NotNil(t, c.Get("https://example.com/1", "text"))
+
+	c.Store("https://example.com/3", "text", response("Cache-Control", "max-age=60"), body)
+
+	require.NotNil(t, c.Get("https://example.com/1", "text"))
+	require.Nil(t, c.Get("https://example.com/2", "text"))
+	require.NotNil(t, c.Get("https://example.com/3", "text"))
+}
+
+func TestCacheClear(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	c := New(dir, 0)
+	c.Store("https://example.com/1", "text", response("Cache-Control", "max-age=60"), "1")
+	c.Store("https://example.com/2", "html", response("Cache-Control", "max-age=60"), "2")
+
+	n, err := c.Clear()
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	n, err = New(filepath.Join(dir, "missing"), 0).Clear()
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestNilCache(t *testing.T) {
+	t.Parallel()
+
+	var c *Cache
+	require.Nil(t, c.Get("https://example.com", "text"))
+	c.Store("https://example.com", "text", response("Cache-Control", "max-age=60"), "x")
+	c.Revalidated(nil, response())
+}
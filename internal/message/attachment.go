@@ -10,6 +10,9 @@ type Attachment struct {
 	FileName string
 	MimeType string
 	Content  []byte
+	// MCPName is the name of the MCP server of a resource attachment, whose
+	// FilePath is the URI of the resource.
+	MCPName string
 }
 
 func (a Attachment) IsText() bool     { return strings.HasPrefix(a.MimeType, "text/") }
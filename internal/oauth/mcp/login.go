@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// LoginOptions configures [Login].
+type LoginOptions struct {
+	// HTTPClient is used for discovery, registration and token requests.
+	HTTPClient *http.Client
+	// OpenURL is called with the URL the user must visit to authorize
+	// Crush, typically opening it in a browser.
+	OpenURL func(url string)
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// Login runs the whole authorization flow against the MCP server at
+// serverURL: it discovers the authorization server, registers a client
+// redirecting to a loopback listener, waits for the user to authorize it,
+// and exchanges the resulting code for a token.
+func Login(ctx context.Context, serverURL string, opts LoginOptions) (*Credentials, error) {
+	meta, err := Discover(ctx, serverURL, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen for authorization callback: %w", err)
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	client, err := Register(ctx, meta, redirectURI, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+
+	state := rand.Text()
+	authURL, verifier, err := AuthorizationURL(meta, client, redirectURI, state)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{Handler: callbackHandler(state, results)}
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Close()
+
+	if opts.OpenURL != nil {
+		opts.OpenURL(authURL)
+	}
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	token, err := ExchangeCode(ctx, client, result.code, verifier, redirectURI, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	return &Credentials{Client: *client, Token: token}, nil
+}
+
+// callbackHandler handles the redirect of the authorization server, sending
+// the outcome to results once.
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var result callbackResult
+		switch {
+		case q.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s: %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("state") != state:
+			result.err = errors.New("authorization failed: state mismatch")
+		case q.Get("code") == "":
+			result.err = errors.New("authorization failed: no code in callback")
+		default:
+			result.code = q.Get("code")
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, result.err)
+		} else {
+			fmt.Fprintln(w, "Crush is now authorized. You can close this window.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	return mux
+}
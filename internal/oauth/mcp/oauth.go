@@ -0,0 +1,338 @@
+// Package mcp implements the OAuth 2.1 authorization flow for remote MCP
+// servers: protected resource and authorization server discovery, dynamic
+// client registration, and the authorization code grant with PKCE over a
+// loopback redirect.
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+)
+
+// ErrNotRequired is returned by [Discover] and [Login] when the MCP server
+// accepts unauthenticated requests.
+var ErrNotRequired = errors.New("server does not require authorization")
+
+// Credentials are the OAuth client and token Crush keeps for an MCP server.
+type Credentials struct {
+	Client Client       `json:"client"`
+	Token  *oauth.Token `json:"token,omitempty"`
+}
+
+// Authorizes reports whether c holds a token for requests to serverURL.
+// Tokens are bound to the resource they were issued for, so they are never
+// sent to another server, such as one a project config points to.
+func (c *Credentials) Authorizes(serverURL string) bool {
+	return c != nil && c.Token != nil && c.Token.AccessToken != "" && MatchesResource(c.Client.Resource, serverURL)
+}
+
+// MatchesResource reports whether serverURL is the resource, or lies
+// beneath it: both must have the same scheme and host, and the path of
+// serverURL must be the path of the resource or one of its sub-paths.
+func MatchesResource(resource, serverURL string) bool {
+	if resource == "" {
+		return false
+	}
+	r, err := url.Parse(resource)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(r.Scheme, u.Scheme) || !strings.EqualFold(r.Host, u.Host) {
+		return false
+	}
+	resourcePath := strings.TrimSuffix(r.Path, "/")
+	serverPath := strings.TrimSuffix(u.Path, "/")
+	return serverPath == resourcePath || strings.HasPrefix(serverPath, resourcePath+"/")
+}
+
+// Client is an OAuth client registered with the authorization server of an
+// MCP server, along with what is needed to refresh its tokens.
+type Client struct {
+	ClientID      string `json:"client_id"`
+	ClientSecret  string `json:"client_secret,omitempty"`
+	TokenEndpoint string `json:"token_endpoint"`
+	Resource      string `json:"resource,omitempty"`
+}
+
+// Metadata describes how to authorize against an MCP server.
+type Metadata struct {
+	// Resource is the canonical URI of the MCP server, sent as the
+	// resource indicator of authorization and token requests.
+	Resource   string
+	Scopes     []string
+	AuthServer *oauthex.AuthServerMeta
+}
+
+// Discover sends an unauthenticated request to the MCP server at serverURL
+// and, when it is rejected, discovers the protected resource metadata and
+// the authorization server metadata it points to.
+func Discover(ctx context.Context, serverURL string, client *http.Client) (*Metadata, error) {
+	client = httpClient(client)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return nil, ErrNotRequired
+	}
+
+	challenges, err := oauthex.ParseWWWAuthenticate(resp.Header.Values("WWW-Authenticate"))
+	if err != nil {
+		return nil, fmt.Errorf("parse WWW-Authenticate header: %w", err)
+	}
+
+	prm, err := protectedResource(ctx, challenges, serverURL, client)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := prm.AuthorizationServers[0]
+	asm, err := auth.GetAuthServerMetadata(ctx, issuer, client)
+	if err != nil {
+		return nil, fmt.Errorf("get authorization server metadata: %w", err)
+	}
+	if asm == nil {
+		// Servers without metadata use the default endpoints.
+		asm = &oauthex.AuthServerMeta{
+			Issuer:                        issuer,
+			AuthorizationEndpoint:         issuer + "/authorize",
+			TokenEndpoint:                 issuer + "/token",
+			RegistrationEndpoint:          issuer + "/register",
+			CodeChallengeMethodsSupported: []string{"S256"},
+		}
+	}
+	if !slices.Contains(asm.CodeChallengeMethodsSupported, "S256") {
+		return nil, fmt.Errorf("authorization server %s does not support PKCE with S256", issuer)
+	}
+
+	scopes := challengeScopes(challenges)
+	if len(scopes) == 0 {
+		scopes = prm.ScopesSupported
+	}
+	return &Metadata{
+		Resource:   prm.Resource,
+		Scopes:     scopes,
+		AuthServer: asm,
+	}, nil
+}
+
+// protectedResource looks the protected resource metadata up at the URL
+// advertised in the challenges, then at the well-known locations for
+// serverURL. Servers without any are their own authorization server.
+func protectedResource(ctx context.Context, challenges []oauthex.Challenge, serverURL string, client *http.Client) (*oauthex.ProtectedResourceMetadata, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse server URL: %w", err)
+	}
+	origin := &url.URL{Scheme: u.Scheme, Host: u.Host}
+
+	type candidate struct{ metadataURL, resource string }
+	var candidates []candidate
+	for _, c := range challenges {
+		if metadataURL := c.Params["resource_metadata"]; metadataURL != "" {
+			candidates = append(candidates, candidate{metadataURL, serverURL})
+			break
+		}
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		candidates = append(candidates, candidate{origin.JoinPath("/.well-known/oauth-protected-resource", path).String(), serverURL})
+	}
+	candidates = append(candidates, candidate{origin.JoinPath("/.well-known/oauth-protected-resource").String(), origin.String()})
+
+	for _, c := range candidates {
+		prm, err := oauthex.GetProtectedResourceMetadata(ctx, c.metadataURL, c.resource, client)
+		if err != nil || prm == nil {
+			continue
+		}
+		if len(prm.AuthorizationServers) == 0 {
+			return nil, fmt.Errorf("protected resource metadata at %s lists no authorization servers", c.metadataURL)
+		}
+		return prm, nil
+	}
+
+	return &oauthex.ProtectedResourceMetadata{
+		Resource:             serverURL,
+		AuthorizationServers: []string{origin.String()},
+	}, nil
+}
+
+func challengeScopes(challenges []oauthex.Challenge) []string {
+	for _, c := range challenges {
+		if c.Scheme == "bearer" && c.Params["scope"] != "" {
+			return strings.Fields(c.Params["scope"])
+		}
+	}
+	return nil
+}
+
+// Register registers Crush as a public client with the authorization server
+// using dynamic client registration.
+func Register(ctx context.Context, meta *Metadata, redirectURI string, client *http.Client) (*Client, error) {
+	if meta.AuthServer.RegistrationEndpoint == "" {
+		return nil, fmt.Errorf("authorization server %s does not support dynamic client registration", meta.AuthServer.Issuer)
+	}
+	resp, err := oauthex.RegisterClient(ctx, meta.AuthServer.RegistrationEndpoint, &oauthex.ClientRegistrationMetadata{
+		ClientName:              "Crush",
+		ClientURI:               "https://github.com/charmbracelet/crush",
+		RedirectURIs:            []string{redirectURI},
+		GrantTypes:              []string{"authorization_code", "refresh_token"},
+		ResponseTypes:           []string{"code"},
+		TokenEndpointAuthMethod: "none",
+		ApplicationType:         "native",
+		Scope:                   strings.Join(meta.Scopes, " "),
+	}, httpClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("register client: %w", err)
+	}
+	return &Client{
+		ClientID:      resp.ClientID,
+		ClientSecret:  resp.ClientSecret,
+		TokenEndpoint: meta.AuthServer.TokenEndpoint,
+		Resource:      meta.Resource,
+	}, nil
+}
+
+// AuthorizationURL returns the URL the user visits to authorize client,
+// along with the PKCE code verifier to exchange the resulting code with.
+func AuthorizationURL(meta *Metadata, client *Client, redirectURI, state string) (authURL, verifier string, err error) {
+	u, err := url.Parse(meta.AuthServer.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("parse authorization endpoint: %w", err)
+	}
+	verifier = rand.Text() + rand.Text()
+	challenge := sha256.Sum256([]byte(verifier))
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", client.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	q.Set("resource", meta.Resource)
+	if len(meta.Scopes) > 0 {
+		q.Set("scope", strings.Join(meta.Scopes, " "))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), verifier, nil
+}
+
+// ExchangeCode exchanges an authorization code for a token.
+func ExchangeCode(ctx context.Context, client *Client, code, verifier, redirectURI string, httpc *http.Client) (*oauth.Token, error) {
+	return requestToken(ctx, client, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {redirectURI},
+	}, httpc)
+}
+
+// RefreshToken exchanges refreshToken for a new token. The refresh token is
+// kept when the authorization server does not rotate it.
+func RefreshToken(ctx context.Context, client Client, refreshToken string, httpc *http.Client) (*oauth.Token, error) {
+	token, err := requestToken(ctx, &client, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}, httpc)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func requestToken(ctx context.Context, client *Client, form url.Values, httpc *http.Client) (*oauth.Token, error) {
+	form.Set("client_id", client.ClientID)
+	if client.ClientSecret != "" {
+		form.Set("client_secret", client.ClientSecret)
+	}
+	if client.Resource != "" {
+		form.Set("resource", client.Resource)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "crush")
+
+	resp, err := httpClient(httpc).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	var tokenResp tokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("token request failed: status %d, body %q", resp.StatusCode, string(body))
+	}
+	if tokenResp.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s: %s", tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed: status %d, body %q", resp.StatusCode, string(body))
+	}
+
+	token := &oauth.Token{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		ExpiresIn:    tokenResp.ExpiresIn,
+	}
+	if token.ExpiresIn > 0 {
+		token.SetExpiresAt()
+	} else {
+		// Tokens without an expiry are valid until rejected.
+		token.ExpiresAt = time.Now().AddDate(1, 0, 0).Unix()
+	}
+	return token, nil
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// authServer is a stand-in MCP server that is also its own authorization
+// server.
+type authServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	challenge string
+	redirect  string
+	refreshes int
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	t.Helper()
+
+	s := &authServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+s.URL+`/.well-known/oauth-protected-resource/mcp", scope="tools"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"resource":              s.URL + "/mcp",
+			"authorization_servers": []string{s.URL},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                           s.URL,
+			"authorization_endpoint":           s.URL + "/authorize",
+			"token_endpoint":                   s.URL + "/token",
+			"registration_endpoint":            s.URL + "/register",
+			"response_types_supported":         []string{"code"},
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		var meta struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&meta); err != nil || len(meta.RedirectURIs) != 1 {
+			http.Error(w, "bad registration", http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.redirect = meta.RedirectURIs[0]
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"client_id":     "client-1",
+			"redirect_uris": meta.RedirectURIs,
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if q.Get("client_id") != "client-1" ||
+			q.Get("redirect_uri") != s.redirect ||
+			q.Get("code_challenge_method") != "S256" ||
+			q.Get("resource") != s.URL+"/mcp" ||
+			q.Get("scope") != "tools" {
+			http.Error(w, "bad authorization request", http.StatusBadRequest)
+			return
+		}
+		s.challenge = q.Get("code_challenge")
+		http.Redirect(w, r, s.redirect+"?code=code-1&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.PostForm.Get("client_id") != "client-1" || r.PostForm.Get("resource") != s.URL+"/mcp" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]any{"error": "invalid_client"})
+			return
+		}
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("code") != "code-1" || base64.RawURLEncoding.EncodeToString(sum[:]) != s.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]any{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, map[string]any{"access_token": "access-1", "refresh_token": "refresh-1", "expires_in": 3600})
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]any{"error": "invalid_grant", "error_description": "unknown refresh token"})
+				return
+			}
+			s.refreshes++
+			writeJSON(w, map[string]any{"access_token": "access-2", "expires_in": 3600})
+		}
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	srv := newAuthServer(t)
+	creds, err := Login(t.Context(), srv.URL+"/mcp", LoginOptions{
+		OpenURL: func(authURL string) {
+			// Play the user: the authorization server redirects the
+			// browser to the loopback callback.
+			go func() {
+				resp, err := http.Get(authURL)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, Client{
+		ClientID:      "client-1",
+		TokenEndpoint: srv.URL + "/token",
+		Resource:      srv.URL + "/mcp",
+	}, creds.Client)
+	require.Equal(t, "access-1", creds.Token.AccessToken)
+	require.Equal(t, "refresh-1", creds.Token.RefreshToken)
+	require.False(t, creds.Token.IsExpired())
+
+	token, err := RefreshToken(t.Context(), creds.Client, creds.Token.RefreshToken, nil)
+	require.NoError(t, err)
+	require.Equal(t, "access-2", token.AccessToken)
+	require.Equal(t, "refresh-1", token.RefreshToken, "refresh token must be kept when not rotated")
+	srv.mu.Lock()
+	require.Equal(t, 1, srv.refreshes)
+	srv.mu.Unlock()
+
+	_, err = RefreshToken(t.Context(), creds.Client, "stale", nil)
+	require.ErrorContains(t, err, "invalid_grant: unknown refresh token")
+}
+
+func TestDiscover_NotRequired(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := Discover(t.Context(), srv.URL+"/mcp", nil)
+	require.ErrorIs(t, err, ErrNotRequired)
+}
+
+func TestCallbackHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		code    string
+		wantErr string
+	}{
+		{name: "code", query: "code=abc&state=s1", code: "abc"},
+		{name: "state mismatch", query: "code=abc&state=other", wantErr: "state mismatch"},
+		{name: "denied", query: "error=access_denied&error_description=nope&state=s1", wantErr: "access_denied: nope"},
+		{name: "no code", query: "state=s1", wantErr: "no code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results := make(chan callbackResult, 1)
+			rec := httptest.NewRecorder()
+			callbackHandler("s1", results).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback?"+tt.query, nil))
+
+			result := <-results
+			if tt.wantErr != "" {
+				require.ErrorContains(t, result.err, tt.wantErr)
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				return
+			}
+			require.NoError(t, result.err)
+			require.Equal(t, tt.code, result.code)
+			require.True(t, strings.Contains(rec.Body.String(), "authorized"))
+		})
+	}
+}
+
+func TestMatchesResource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resource  string
+		serverURL string
+		want      bool
+	}{
+		{name: "same url", resource: "https://mcp.example.com/mcp", serverURL: "https://mcp.example.com/mcp", want: true},
+		{name: "trailing slash", resource: "https://mcp.example.com/mcp/", serverURL: "https://mcp.example.com/mcp", want: true},
+		{name: "sub-path", resource: "https://mcp.example.com", serverURL: "https://mcp.example.com/mcp", want: true},
+		{name: "host case", resource: "https://MCP.example.com/mcp", serverURL: "https://mcp.example.com/mcp", want: true},
+		{name: "other host", resource: "https://mcp.example.com/mcp", serverURL: "https://evil.example.com/mcp", want: false},
+		{name: "host suffix", resource: "https://mcp.example.com", serverURL: "https://mcp.example.com.evil.com/mcp", want: false},
+		{name: "other scheme", resource: "https://mcp.example.com/mcp", serverURL: "http://mcp.example.com/mcp", want: false},
+		{name: "path prefix", resource: "https://mcp.example.com/mcp", serverURL: "https://mcp.example.com/mcp-evil", want: false},
+		{name: "other port", resource: "https://mcp.example.com/mcp", serverURL: "https://mcp.example.com:8443/mcp", want: false},
+		{name: "no resource", resource: "", serverURL: "https://mcp.example.com/mcp", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, MatchesResource(tt.resource, tt.serverURL))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package outputstore keeps the full output of commands that is too long to
+// return to the model whole, in one directory per session, so that it can
+// be paged through and searched later without running the command again.
+//
+// When storing output is turned off the store is nil: Save and Resolve then
+// report that it is disabled, and Remove and Prune do nothing.
+package outputstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store saves output under a directory holding one subdirectory per
+// session.
+type Store struct {
+	dir string
+}
+
+// New returns a store keeping its files under dir, which is created when
+// output is first saved.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory holding the output of the session.
+func (s *Store) Dir(sessionID string) string {
+	if s == nil {
+		return ""
+	}
+	return filepath.Join(s.dir, dirName(sessionID))
+}
+
+// Save writes content to the file name in the directory of the session and
+// returns its path.
+func (s *Store) Save(sessionID, name, content string) (string, error) {
+	if s == nil {
+		return "", errors.New("output store is disabled")
+	}
+	dir := s.Dir(sessionID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("creating output directory: %w", err)
+	}
+	path := filepath.Join(dir, fileName(name))
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return "", fmt.Errorf("writing output: %w", err)
+	}
+	return path, nil
+}
+
+// Resolve returns the path of a file in the directory of the session,
+// given either its path or its name. Paths outside that directory are
+// rejected, so that the output of other sessions and other files cannot be
+// read through it.
+func (s *Store) Resolve(sessionID, path string) (string, error) {
+	if s == nil {
+		return "", errors.New("output store is disabled")
+	}
+	dir := s.Dir(sessionID)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.ContainsRune(rel, filepath.Separator) {
+		return "", fmt.Errorf("%s is not stored output of this session", path)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
+// Remove deletes the output of the session.
+func (s *Store) Remove(sessionID string) error {
+	if s == nil {
+		return nil
+	}
+	return os.RemoveAll(s.Dir(sessionID))
+}
+
+// Prune deletes the output of the sessions for which exists reports false,
+// such as sessions deleted while Crush was not running.
+func (s *Store) Prune(ctx context.Context, exists func(ctx context.Context, sessionID string) bool) error {
+	if s == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		sessionID, err := url.PathUnescape(entry.Name())
+		if !entry.IsDir() || err != nil || exists(ctx, sessionID) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, entry.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dirName escapes the characters of a session ID that are not safe in file
+// names on every platform, such as the $ of the sessions of sub-agents, so
+// that [Store.Prune] can tell the session of a directory.
+func dirName(sessionID string) string {
+	var b strings.Builder
+	for i := 0; i < len(sessionID); i++ {
+		if c := sessionID[i]; isSafe(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// fileName replaces the characters of name that are not safe in file
+// names on every platform.
+func fileName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !isSafe(c) && (c != '.' || i == 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func isSafe(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
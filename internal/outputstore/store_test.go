@@ -0,0 +1,73 @@
+package outputstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	store := New(t.TempDir())
+	path, err := store.Save("session", "call/1.stdout", "full output")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(store.Dir("session"), "call_1.stdout"), path)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "full output", string(content))
+
+	resolved, err := store.Resolve("session", path)
+	require.NoError(t, err)
+	require.Equal(t, path, resolved)
+	resolved, err = store.Resolve("session", "call_1.stdout")
+	require.NoError(t, err)
+	require.Equal(t, path, resolved)
+
+	_, err = store.Resolve("other", path)
+	require.Error(t, err)
+	_, err = store.Resolve("session", filepath.Join(store.Dir("session"), "..", "other", "x"))
+	require.Error(t, err)
+	_, err = store.Resolve("session", "../x")
+	require.Error(t, err)
+
+	require.NoError(t, store.Remove("session"))
+	require.NoDirExists(t, store.Dir("session"))
+}
+
+func TestStorePrune(t *testing.T) {
+	t.Parallel()
+
+	store := New(t.TempDir())
+	for _, id := range []string{"kept", "deleted", "message$$call"} {
+		_, err := store.Save(id, "out", "x")
+		require.NoError(t, err)
+	}
+
+	var seen []string
+	err := store.Prune(t.Context(), func(_ context.Context, id string) bool {
+		seen = append(seen, id)
+		return id != "deleted"
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"kept", "deleted", "message$$call"}, seen)
+	require.DirExists(t, store.Dir("kept"))
+	require.DirExists(t, store.Dir("message$$call"))
+	require.NoDirExists(t, store.Dir("deleted"))
+
+	require.NoError(t, New(filepath.Join(t.TempDir(), "missing")).Prune(t.Context(), nil))
+}
+
+func TestNilStore(t *testing.T) {
+	t.Parallel()
+
+	var store *Store
+	_, err := store.Save("session", "out", "x")
+	require.Error(t, err)
+	require.NoError(t, store.Remove("session"))
+	require.NoError(t, store.Prune(t.Context(), nil))
+}
@@ -35,6 +35,27 @@ func hookApproved(ctx context.Context, toolCallID string) bool {
 	return v == toolCallID
 }
 
+// skillApprovalKey is the unexported context key used to mark a tool call
+// as pre-approved by the allowed-tools of a skill loaded in the session.
+// Like hook approvals, the value is the tool call ID.
+type skillApprovalKey struct{}
+
+// WithSkillApproval returns a context that marks the given tool call ID as
+// pre-approved by a loaded skill.
+func WithSkillApproval(ctx context.Context, toolCallID string) context.Context {
+	return context.WithValue(ctx, skillApprovalKey{}, toolCallID)
+}
+
+// skillApproved reports whether the context carries a skill approval for
+// the given tool call ID.
+func skillApproved(ctx context.Context, toolCallID string) bool {
+	if toolCallID == "" {
+		return false
+	}
+	v, _ := ctx.Value(skillApprovalKey{}).(string)
+	return v == toolCallID
+}
+
 type CreatePermissionRequest struct {
 	SessionID   string `json:"session_id"`
 	ToolCallID  string `json:"tool_call_id"`
@@ -189,11 +210,12 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		return true, nil
 	}
 
-	// A PreToolUse hook that returned decision=allow stamps the context
-	// with the tool call ID. Treat that as a pre-approval and skip the
-	// prompt entirely. We still publish a granted notification so the UI
-	// and audit subscribers see the outcome.
-	if hookApproved(ctx, opts.ToolCallID) {
+	// A PreToolUse hook that returned decision=allow, or a loaded skill
+	// listing the tool in its allowed-tools, stamps the context with the
+	// tool call ID. Treat that as a pre-approval and skip the prompt
+	// entirely. We still publish a granted notification so the UI and
+	// audit subscribers see the outcome.
+	if hookApproved(ctx, opts.ToolCallID) || skillApproved(ctx, opts.ToolCallID) {
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -190,6 +190,24 @@ func TestPermissionService_HookApproval(t *testing.T) {
 	})
 }
 
+func TestPermissionService_SkillApproval(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", false, nil)
+	ctx := WithSkillApproval(t.Context(), "call-7")
+	granted, err := service.Request(ctx, CreatePermissionRequest{
+		SessionID:   "s1",
+		ToolCallID:  "call-7",
+		ToolName:    "bash",
+		Action:      "execute",
+		Description: "command allowed by a skill",
+		Path:        "/tmp",
+	})
+	require.NoError(t, err)
+	assert.True(t, granted, "skill-approved call should bypass the prompt")
+	assert.False(t, skillApproved(ctx, "call-8"), "approval is scoped to the stamped tool call ID")
+}
+
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
 		service := NewPermissionService("/tmp", false, []string{})
@@ -133,6 +133,8 @@ type MCPClientInfo struct {
 	PromptCount   int       `json:"prompt_count,omitempty"`
 	ResourceCount int       `json:"resource_count,omitempty"`
 	ConnectedAt   time.Time `json:"connected_at"`
+	Restarts      int       `json:"restarts,omitempty"`
+	Stderr        string    `json:"stderr,omitempty"`
 }
 
 // MarshalJSON implements the [json.Marshaler] interface.
@@ -170,3 +172,41 @@ func (i *MCPClientInfo) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// MCPElicitationField is a field of the form requested by an MCP server.
+type MCPElicitationField struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Enum        []string `json:"enum,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Default     any      `json:"default,omitempty"`
+}
+
+// MCPElicitationRequest is a request of an MCP server for input from the
+// user. A deleted event for the same ID means it was answered or
+// abandoned.
+type MCPElicitationRequest struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	SessionID string                `json:"session_id,omitempty"`
+	Message   string                `json:"message"`
+	Fields    []MCPElicitationField `json:"fields,omitempty"`
+}
+
+// MCPElicitationResponse answers an [MCPElicitationRequest]. Action is
+// one of "accept", "decline" or "cancel"; Content holds the values of the
+// fields when accepting.
+type MCPElicitationResponse struct {
+	ID      string         `json:"id"`
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// MCPElicitationResponseResult is the server's response to an
+// elicitation response. Resolved is false when the request had already
+// been answered or abandoned.
+type MCPElicitationResponseResult struct {
+	Resolved bool `json:"resolved"`
+}
@@ -620,6 +620,7 @@ type Attachment struct {
 	FileName string `json:"file_name"`
 	MimeType string `json:"mime_type"`
 	Content  []byte `json:"content"`
+	MCPName  string `json:"mcp_name,omitempty"`
 }
 
 // ToMessage converts a proto Attachment to a [message.Attachment].
@@ -629,6 +630,7 @@ func (a Attachment) ToMessage() message.Attachment {
 		FileName: a.FileName,
 		MimeType: a.MimeType,
 		Content:  a.Content,
+		MCPName:  a.MCPName,
 	}
 }
 
@@ -640,6 +642,7 @@ func AttachmentFromMessage(a message.Attachment) Attachment {
 		FileName: a.FileName,
 		MimeType: a.MimeType,
 		Content:  a.Content,
+		MCPName:  a.MCPName,
 	}
 }
 
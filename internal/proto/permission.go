@@ -112,6 +112,42 @@ func unmarshalToolParams(toolName string, raw json.RawMessage) (any, error) {
 			return nil, err
 		}
 		return params, nil
+	case ApplyPatchToolName:
+		var params ApplyPatchPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	case HTTPRequestToolName:
+		var params HTTPRequestPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	case JobInputToolName:
+		var params JobInputPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	case RunTestsToolName:
+		var params RunTestsPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	case NotebookEditToolName:
+		var params NotebookEditPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	case GitToolName:
+		var params GitPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
 	case FetchToolName:
 		var params FetchPermissionsParams
 		if err := json.Unmarshal(raw, &params); err != nil {
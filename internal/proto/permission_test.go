@@ -85,6 +85,106 @@ func TestPermissionRequestParamsTypeAssertable(t *testing.T) {
 				require.Equal(t, "/tmp/x.go", v.FilePath)
 			},
 		},
+		{
+			name:     "apply_patch",
+			toolName: tools.ApplyPatchToolName,
+			params: tools.ApplyPatchPermissionsParams{
+				FilePath:   "/tmp/y.go",
+				OldPath:    "/tmp/x.go",
+				Operation:  tools.PatchOperationRename,
+				OldContent: "old",
+				NewContent: "new",
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.ApplyPatchPermissionsParams)
+				require.True(t, ok, "params must decode as tools.ApplyPatchPermissionsParams, got %T", got)
+				require.Equal(t, "/tmp/y.go", v.FilePath)
+				require.Equal(t, "/tmp/x.go", v.OldPath)
+				require.Equal(t, tools.PatchOperationRename, v.Operation)
+			},
+		},
+		{
+			name:     "notebook_edit",
+			toolName: tools.NotebookEditToolName,
+			params: tools.NotebookEditPermissionsParams{
+				NotebookPath: "/tmp/analysis.ipynb",
+				EditMode:     "replace",
+				CellIndex:    2,
+				OldContent:   "print(1)",
+				NewContent:   "print(2)",
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.NotebookEditPermissionsParams)
+				require.True(t, ok, "params must decode as tools.NotebookEditPermissionsParams, got %T", got)
+				require.Equal(t, "/tmp/analysis.ipynb", v.NotebookPath)
+				require.Equal(t, 2, v.CellIndex)
+				require.Equal(t, "print(2)", v.NewContent)
+			},
+		},
+		{
+			name:     "git",
+			toolName: tools.GitToolName,
+			params: tools.GitPermissionsParams{
+				Operation: tools.GitOperationCommit,
+				Message:   "fix the thing",
+				Paths:     []string{"main.go"},
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.GitPermissionsParams)
+				require.True(t, ok, "params must decode as tools.GitPermissionsParams, got %T", got)
+				require.Equal(t, tools.GitOperationCommit, v.Operation)
+				require.Equal(t, "fix the thing", v.Message)
+				require.Equal(t, []string{"main.go"}, v.Paths)
+			},
+		},
+		{
+			name:     "http_request",
+			toolName: tools.HTTPRequestToolName,
+			params: tools.HTTPRequestPermissionsParams{
+				Method:  "POST",
+				URL:     "http://localhost:8080/items",
+				Headers: []string{"Accept: application/json"},
+				Body:    `{"name":"x"}`,
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.HTTPRequestPermissionsParams)
+				require.True(t, ok, "params must decode as tools.HTTPRequestPermissionsParams, got %T", got)
+				require.Equal(t, "POST", v.Method)
+				require.Equal(t, "http://localhost:8080/items", v.URL)
+				require.Equal(t, []string{"Accept: application/json"}, v.Headers)
+				require.Equal(t, `{"name":"x"}`, v.Body)
+			},
+		},
+		{
+			name:     "run_tests",
+			toolName: tools.RunTestsToolName,
+			params: tools.RunTestsPermissionsParams{
+				Command:    "go test -json ./...",
+				WorkingDir: "/tmp",
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.RunTestsPermissionsParams)
+				require.True(t, ok, "params must decode as tools.RunTestsPermissionsParams, got %T", got)
+				require.Equal(t, "go test -json ./...", v.Command)
+				require.Equal(t, "/tmp", v.WorkingDir)
+			},
+		},
+		{
+			name:     "job_input",
+			toolName: tools.JobInputToolName,
+			params: tools.JobInputPermissionsParams{
+				ShellID: "004",
+				Command: "python3",
+				Input:   "print(1)\n",
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.JobInputPermissionsParams)
+				require.True(t, ok, "params must decode as tools.JobInputPermissionsParams, got %T", got)
+				require.Equal(t, "004", v.ShellID)
+				require.Equal(t, "python3", v.Command)
+				require.Equal(t, "print(1)\n", v.Input)
+			},
+		},
 		{
 			name:     "ls",
 			toolName: tools.LSToolName,
@@ -128,11 +128,24 @@ func (a AgentInfo) IsZero() bool {
 // callers must fall back to SessionID-only filtering, which
 // remains correct only when no other turns are in flight for the
 // same session.
+//
+// Command, when set, marks the prompt as the expansion of a custom
+// command: its !`command` and @path references are expanded on the
+// server, and the turn runs with the command's overrides.
 type AgentMessage struct {
 	SessionID   string       `json:"session_id"`
 	RunID       string       `json:"run_id,omitempty"`
 	Prompt      string       `json:"prompt"`
 	Attachments []Attachment `json:"attachments,omitempty"`
+	Command     *CommandRun  `json:"command,omitempty"`
+}
+
+// CommandRun describes the custom command a prompt was expanded from.
+type CommandRun struct {
+	Name         string   `json:"name"`
+	Model        string   `json:"model,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+	Agent        string   `json:"agent,omitempty"`
 }
 
 // AgentSession represents a session with its busy status.
@@ -0,0 +1,10 @@
+package proto
+
+// ShellState represents the saved state of the bash tool's shell in a
+// session.
+type ShellState struct {
+	SessionID  string   `json:"session_id"`
+	WorkingDir string   `json:"working_dir"`
+	Env        []string `json:"env"`
+	UpdatedAt  int64    `json:"updated_at"`
+}
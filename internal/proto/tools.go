@@ -99,6 +99,48 @@ const AgenticFetchToolName = tools.AgenticFetchToolName
 // agentic_fetch tool.
 type AgenticFetchPermissionsParams = tools.AgenticFetchPermissionsParams
 
+// ApplyPatchToolName is the name of the apply_patch tool.
+const ApplyPatchToolName = tools.ApplyPatchToolName
+
+// ApplyPatchPermissionsParams represents the permission parameters for the
+// apply_patch tool.
+type ApplyPatchPermissionsParams = tools.ApplyPatchPermissionsParams
+
+// NotebookEditToolName is the name of the notebook_edit tool.
+const NotebookEditToolName = tools.NotebookEditToolName
+
+// NotebookEditPermissionsParams represents the permission parameters for the
+// notebook_edit tool.
+type NotebookEditPermissionsParams = tools.NotebookEditPermissionsParams
+
+// GitToolName is the name of the git tool.
+const GitToolName = tools.GitToolName
+
+// GitPermissionsParams represents the permission parameters for the git
+// tool.
+type GitPermissionsParams = tools.GitPermissionsParams
+
+// HTTPRequestToolName is the name of the http_request tool.
+const HTTPRequestToolName = tools.HTTPRequestToolName
+
+// HTTPRequestPermissionsParams represents the permission parameters for the
+// http_request tool.
+type HTTPRequestPermissionsParams = tools.HTTPRequestPermissionsParams
+
+// JobInputToolName is the name of the job_input tool.
+const JobInputToolName = tools.JobInputToolName
+
+// JobInputPermissionsParams represents the permission parameters for the
+// job_input tool.
+type JobInputPermissionsParams = tools.JobInputPermissionsParams
+
+// RunTestsToolName is the name of the run_tests tool.
+const RunTestsToolName = tools.RunTestsToolName
+
+// RunTestsPermissionsParams represents the permission parameters for the
+// run_tests tool.
+type RunTestsPermissionsParams = tools.RunTestsPermissionsParams
+
 const GlobToolName = "glob"
 
 // GlobParams represents the parameters for the glob tool.
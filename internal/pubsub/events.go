@@ -18,6 +18,7 @@ type PayloadType = string
 const (
 	PayloadTypeLSPEvent               PayloadType = "lsp_event"
 	PayloadTypeMCPEvent               PayloadType = "mcp_event"
+	PayloadTypeMCPElicitation         PayloadType = "mcp_elicitation"
 	PayloadTypePermissionRequest      PayloadType = "permission_request"
 	PayloadTypePermissionNotification PayloadType = "permission_notification"
 	PayloadTypeMessage                PayloadType = "message"
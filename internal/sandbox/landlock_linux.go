@@ -0,0 +1,162 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// accessRead are the rights to read and execute files.
+	accessRead = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR
+
+	// accessFile are the rights that can be granted on a file rather than
+	// a directory.
+	accessFile = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE |
+		unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+)
+
+// landlockAccess returns the filesystem rights Landlock ABI version abi can
+// restrict.
+func landlockAccess(abi int) uint64 {
+	// ABI 1 covers EXECUTE through MAKE_SYM.
+	access := uint64(unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		access |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	return access
+}
+
+func landlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	switch errno {
+	case 0:
+		return int(abi), nil
+	case unix.ENOSYS, unix.EOPNOTSUPP:
+		return 0, errors.New("Landlock is not available; the sandbox requires Linux 5.13 or later with Landlock enabled")
+	default:
+		return 0, fmt.Errorf("could not query Landlock: %w", errno)
+	}
+}
+
+type landlockRule struct {
+	path   string
+	access uint64
+}
+
+// restrictSelf confines the calling thread, and the processes it starts
+// afterwards, to reading below readable and writing below writable. The
+// names of directories stay readable everywhere.
+func restrictSelf(readable, writable []string) error {
+	abi, err := landlockABI()
+	if err != nil {
+		return err
+	}
+	handled := landlockAccess(abi)
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("could not create Landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	rules := []landlockRule{{path: "/", access: unix.LANDLOCK_ACCESS_FS_READ_DIR}}
+	for _, p := range readable {
+		rules = append(rules, landlockRule{path: p, access: accessRead})
+	}
+	for _, p := range writable {
+		rules = append(rules, landlockRule{path: p, access: handled})
+	}
+	for _, rule := range rules {
+		if err := addLandlockRule(int(fd), rule.path, rule.access&handled); err != nil {
+			return fmt.Errorf("could not allow access to %s: %w", rule.path, err)
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("could not set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("could not enforce Landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockRule grants access below path. Paths that do not exist are
+// skipped.
+func addLandlockRule(rulesetFD int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if errors.Is(err, unix.ENOENT) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return err
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= accessFile
+	}
+
+	attr := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFD), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// readableRoots returns the files and directories that together cover the
+// whole filesystem except the hidden paths. Landlock only grants access, so
+// hiding a path means granting access to each of its siblings and to the
+// siblings of each of its parents instead.
+func readableRoots(hidden []string) []string {
+	var roots []string
+	var walk func(dir string)
+	walk = func(dir string) {
+		if slices.ContainsFunc(hidden, func(h string) bool { return within(dir, h) }) {
+			return
+		}
+		if !slices.ContainsFunc(hidden, func(h string) bool { return within(h, dir) }) {
+			roots = append(roots, dir)
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			// Rules follow symlinks, which could lead back into a hidden
+			// path; their targets are covered where they live.
+			if entry.Type()&os.ModeSymlink != 0 {
+				continue
+			}
+			walk(filepath.Join(dir, entry.Name()))
+		}
+	}
+	walk("/")
+	return roots
+}
@@ -0,0 +1,139 @@
+package sandbox
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// proxyDialTimeout bounds how long the proxy waits to connect to an allowed
+// host.
+const proxyDialTimeout = 30 * time.Second
+
+// proxy is an HTTP proxy listening on a Unix socket that only forwards
+// requests to allowed hosts. Sandboxed commands without network access
+// reach it through a TCP port the sandbox forwards to the socket.
+type proxy struct {
+	socket       string
+	allowedHosts []string
+	reverse      *httputil.ReverseProxy
+	dialer       net.Dialer
+}
+
+func newProxy(allowedHosts []string) (*proxy, error) {
+	dir, err := os.MkdirTemp("", "crush-sandbox-")
+	if err != nil {
+		return nil, err
+	}
+	p := &proxy{
+		socket:       filepath.Join(dir, "proxy.sock"),
+		allowedHosts: allowedHosts,
+		dialer:       net.Dialer{Timeout: proxyDialTimeout},
+	}
+	p.reverse = &httputil.ReverseProxy{
+		// Requests to a proxy carry absolute URLs, which are forwarded
+		// as they are.
+		Rewrite:  func(*httputil.ProxyRequest) {},
+		ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelDebug),
+	}
+
+	ln, err := net.Listen("unix", p.socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	server := &http.Server{Handler: p, ReadHeaderTimeout: proxyDialTimeout}
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			slog.Error("Sandbox proxy stopped", "error", err)
+		}
+	}()
+	return p, nil
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hostPort := r.Host
+	if r.Method != http.MethodConnect {
+		if r.URL.Host == "" {
+			http.Error(w, "only proxy requests are supported", http.StatusBadRequest)
+			return
+		}
+		hostPort = urlHostPort(r)
+	}
+	if !HostAllowed(hostPort, p.allowedHosts) {
+		slog.Debug("Sandbox proxy blocked request", "host", hostPort)
+		http.Error(w, (&Error{Reason: hostPort + " is not in the allowed hosts"}).Error(), http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	p.reverse.ServeHTTP(w, r)
+}
+
+// tunnel connects the client of a CONNECT request to the requested host.
+func (p *proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dialer.DialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close()
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+
+	// Bytes the client sent after the request may already be buffered.
+	if n := rw.Reader.Buffered(); n > 0 {
+		buffered, _ := rw.Reader.Peek(n)
+		if _, err := upstream.Write(buffered); err != nil {
+			return
+		}
+	}
+	splice(conn, upstream)
+}
+
+// splice copies between a and b until either side is done.
+func splice(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	cp := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+			_ = cw.CloseWrite()
+		} else {
+			_ = dst.Close()
+		}
+	}
+	go cp(a, b)
+	go cp(b, a)
+	wg.Wait()
+}
+
+// urlHostPort returns the host and port a proxied request is sent to.
+func urlHostPort(r *http.Request) string {
+	port := r.URL.Port()
+	if port == "" {
+		port = "80"
+		if r.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(r.URL.Hostname(), port)
+}
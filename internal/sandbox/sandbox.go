@@ -0,0 +1,218 @@
+// Package sandbox confines commands run by the bash tool. Sandboxed
+// commands can only write to a set of directories, cannot read the contents
+// of configured secret paths and, optionally, cannot use the network except
+// to reach an allowlist of hosts through an HTTP proxy.
+//
+// Confinement is only implemented on Linux, using Landlock for the
+// filesystem and user and network namespaces for the network. Commands are
+// confined by running them through Crush's own executable, which applies
+// the restrictions before executing the command; see [Policy.Command].
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported is returned when commands cannot be sandboxed on this
+// platform.
+var ErrUnsupported = errors.New("the sandbox is only supported on Linux")
+
+// Options configures a [Policy]. Paths must be absolute.
+type Options struct {
+	// WritablePaths are the directories commands may write to, in addition
+	// to the temporary directories and /dev.
+	WritablePaths []string
+	// HiddenPaths are files and directories whose contents commands
+	// cannot read.
+	HiddenPaths []string
+	// DisableNetwork cuts commands off the network.
+	DisableNetwork bool
+	// AllowedHosts are the hosts commands may still reach through an HTTP
+	// proxy when DisableNetwork is set. Entries are globs matched against
+	// host:port; an entry without a port matches any port.
+	AllowedHosts []string
+}
+
+// Policy describes what sandboxed commands may access. A Policy is safe
+// for concurrent use.
+type Policy struct {
+	writable       []string
+	hidden         []string
+	disableNetwork bool
+	allowedHosts   []string
+
+	checkOnce sync.Once
+	checkErr  error
+
+	proxyOnce sync.Once
+	proxy     *proxy
+	proxyErr  error
+}
+
+// New returns a policy for opts.
+func New(opts Options) *Policy {
+	p := &Policy{
+		writable:       resolvePaths(append(defaultWritablePaths(), opts.WritablePaths...)),
+		hidden:         resolvePaths(opts.HiddenPaths),
+		disableNetwork: opts.DisableNetwork,
+	}
+	if opts.DisableNetwork {
+		p.allowedHosts = opts.AllowedHosts
+	}
+	return p
+}
+
+// resolvePaths cleans paths, resolves their symlinks and drops duplicates
+// and relative paths.
+func resolvePaths(paths []string) []string {
+	var resolved []string
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			continue
+		}
+		p = resolvePath(p)
+		if !slices.Contains(resolved, p) {
+			resolved = append(resolved, p)
+		}
+	}
+	return resolved
+}
+
+// resolvePath resolves the symlinks of the longest existing prefix of the
+// absolute path p, so that paths which do not exist yet are matched against
+// the directory they would be created in.
+func resolvePath(p string) string {
+	p = filepath.Clean(p)
+	var rest []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(append([]string{p}, rest...)...)
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
+
+// within reports whether p is dir or a path below it.
+func within(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Error is returned when the policy does not allow opening a file.
+type Error struct {
+	// Reason describes what the sandbox does not allow.
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return "blocked by sandbox: " + e.Reason
+}
+
+// CheckOpen reports whether the policy allows opening the absolute path
+// for reading or, when write is set, for writing. It is used for files
+// Crush opens on behalf of a command, such as the targets of redirects,
+// which are not covered by [Policy.Command].
+func (p *Policy) CheckOpen(name string, write bool) error {
+	name = resolvePath(name)
+	for _, hidden := range p.hidden {
+		if within(name, hidden) {
+			return &Error{Reason: fmt.Sprintf("%s is hidden", hidden)}
+		}
+	}
+	if write && !slices.ContainsFunc(p.writable, func(dir string) bool { return within(name, dir) }) {
+		return &Error{Reason: "writes are limited to " + strings.Join(p.writable, ", ")}
+	}
+	return nil
+}
+
+// Describe summarizes the restrictions of the policy in a sentence.
+func (p *Policy) Describe() string {
+	parts := []string{"writes are limited to " + strings.Join(p.writable, ", ")}
+	if len(p.hidden) > 0 {
+		parts = append(parts, "the contents of "+strings.Join(p.hidden, ", ")+" cannot be read")
+	}
+	switch {
+	case !p.disableNetwork:
+	case len(p.allowedHosts) == 0:
+		parts = append(parts, "network access is disabled")
+	default:
+		parts = append(parts, "network access is limited to "+strings.Join(p.allowedHosts, ", ")+" through an HTTP proxy set in HTTP_PROXY and HTTPS_PROXY")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Check reports whether commands can be sandboxed with the policy on this
+// system. The result is computed once.
+func (p *Policy) Check() error {
+	p.checkOnce.Do(func() {
+		p.checkErr = p.check()
+	})
+	return p.checkErr
+}
+
+// proxySocket returns the Unix socket of the proxy through which sandboxed
+// commands reach allowed hosts, starting the proxy on first use.
+func (p *Policy) proxySocket() (string, error) {
+	p.proxyOnce.Do(func() {
+		p.proxy, p.proxyErr = newProxy(p.allowedHosts)
+	})
+	if p.proxyErr != nil {
+		return "", fmt.Errorf("could not start the sandbox proxy: %w", p.proxyErr)
+	}
+	return p.proxy.socket, nil
+}
+
+// blockedMarkers are fragments of the error messages of commands denied
+// access by the sandbox.
+var blockedMarkers = []string{
+	"blocked by sandbox",
+	"Permission denied",
+	"permission denied",
+	"Read-only file system",
+	"read-only file system",
+	"Operation not permitted",
+	"operation not permitted",
+	"Network is unreachable",
+	"network is unreachable",
+	"Could not resolve host",
+	"Temporary failure in name resolution",
+	"CONNECT tunnel failed",
+}
+
+// LooksBlocked reports whether the output of a failed command looks like
+// the sandbox denied it access to a file or the network. The kernel does not
+// tell the two apart from ordinary permission errors, so callers should word
+// the result as a possibility.
+func LooksBlocked(output string) bool {
+	return slices.ContainsFunc(blockedMarkers, func(marker string) bool {
+		return strings.Contains(output, marker)
+	})
+}
+
+// HostAllowed reports whether hostPort matches one of the allowlist
+// patterns. Patterns are globs such as "proxy.golang.org" or
+// "*.npmjs.org:443"; patterns without a port match any port.
+func HostAllowed(hostPort string, patterns []string) bool {
+	host, _, _ := net.SplitHostPort(hostPort)
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		target := strings.ToLower(hostPort)
+		if _, _, err := net.SplitHostPort(pattern); err != nil {
+			target = strings.ToLower(host)
+		}
+		ok, _ := path.Match(pattern, target)
+		return ok
+	})
+}
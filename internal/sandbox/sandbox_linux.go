@@ -0,0 +1,301 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// helperName is the argv[0] under which Crush's executable confines
+	// and then executes a command.
+	helperName = "crush-sandbox"
+	// specEnv carries the helperSpec of a command to the helper.
+	specEnv = "CRUSH_SANDBOX_SPEC"
+	// helperExitCode is the exit status of a command the helper could not
+	// confine, which shells use for commands that cannot be executed.
+	helperExitCode = 126
+)
+
+// helperSpec tells the helper how to confine the command it runs.
+type helperSpec struct {
+	Path     string   `json:"path"`
+	Writable []string `json:"writable"`
+	Hidden   []string `json:"hidden,omitempty"`
+	// Isolate is set while the helper runs in a new network namespace,
+	// before it starts itself again to confine the command.
+	Isolate bool   `json:"isolate,omitempty"`
+	Proxy   string `json:"proxy,omitempty"`
+	UID     int    `json:"uid"`
+	GID     int    `json:"gid"`
+	// Probe makes the helper exit once the command would be confined.
+	Probe bool `json:"probe,omitempty"`
+}
+
+// init turns the process into the helper when Crush's executable is
+// started through [Policy.Command]. Doing this in init rather than in main
+// keeps the helper working in any binary that sandboxes commands,
+// including tests.
+func init() {
+	if len(os.Args) > 0 && os.Args[0] == helperName {
+		os.Exit(runHelper())
+	}
+}
+
+func defaultWritablePaths() []string {
+	return []string{os.TempDir(), "/tmp", "/var/tmp", "/dev"}
+}
+
+// Command rewrites cmd to run confined by the policy. cmd must not have
+// been started, and its Path must be absolute.
+//
+// Writes outside the writable paths fail with EACCES, as do reads of the
+// hidden paths. Without network access, cmd runs in its own user and
+// network namespaces with only a loopback interface; if hosts are allowed,
+// HTTP_PROXY and HTTPS_PROXY point at a proxy that forwards to them.
+func (p *Policy) Command(cmd *exec.Cmd) error {
+	if err := p.Check(); err != nil {
+		return err
+	}
+	return p.wrap(cmd, false)
+}
+
+func (p *Policy) check() error {
+	var stderr bytes.Buffer
+	cmd := &exec.Cmd{Args: []string{"probe"}, Stderr: &stderr}
+	if err := p.wrap(cmd, true); err != nil {
+		return err
+	}
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &exitErr):
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(strings.TrimPrefix(msg, helperName+": "))
+		}
+		return err
+	case p.disableNetwork:
+		return fmt.Errorf("disabling the network requires unprivileged user namespaces, which are not available: %w", err)
+	default:
+		return err
+	}
+}
+
+func (p *Policy) wrap(cmd *exec.Cmd, probe bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find the sandbox helper: %w", err)
+	}
+
+	spec := helperSpec{
+		Path:     cmd.Path,
+		Writable: p.writable,
+		Hidden:   p.hidden,
+		Isolate:  p.disableNetwork,
+		UID:      os.Getuid(),
+		GID:      os.Getgid(),
+		Probe:    probe,
+	}
+	if p.disableNetwork && len(p.allowedHosts) > 0 {
+		if spec.Proxy, err = p.proxySocket(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(slices.Clip(env), specEnv+"="+string(data))
+	cmd.Args = append([]string{helperName}, cmd.Args...)
+	cmd.Path = exe
+
+	if spec.Isolate {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		// The helper runs as root of the new user namespace so that it
+		// can configure the network namespace.
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: spec.UID, Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: spec.GID, Size: 1}}
+		cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	}
+	return nil
+}
+
+// runHelper confines and executes the command described by the
+// environment and arguments of the process, and returns the exit status
+// of the process when that fails or the command runs as a child.
+func runHelper() int {
+	env := slices.DeleteFunc(os.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, specEnv+"=")
+	})
+	var spec helperSpec
+	if err := json.Unmarshal([]byte(os.Getenv(specEnv)), &spec); err != nil {
+		return helperFailed(fmt.Errorf("invalid sandbox spec: %w", err))
+	}
+
+	if spec.Isolate {
+		code, err := runIsolated(spec, env)
+		if err != nil {
+			return helperFailed(err)
+		}
+		return code
+	}
+
+	// Landlock confines the calling thread only, which the command is
+	// executed from.
+	runtime.LockOSThread()
+	if err := restrictSelf(readableRoots(spec.Hidden), spec.Writable); err != nil {
+		return helperFailed(err)
+	}
+	if spec.Probe {
+		return 0
+	}
+	err := syscall.Exec(spec.Path, os.Args[1:], env)
+	return helperFailed(fmt.Errorf("%s: %w", spec.Path, err))
+}
+
+func helperFailed(err error) int {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", helperName, err)
+	return helperExitCode
+}
+
+// runIsolated sets up the network namespace the helper was started in and
+// runs the helper again as a child in a nested user namespace, which maps
+// the user back to the one running Crush, to confine and execute the
+// command. It returns the exit status of the child.
+func runIsolated(spec helperSpec, env []string) (int, error) {
+	if err := loopbackUp(); err != nil {
+		return 0, fmt.Errorf("could not set up the loopback interface: %w", err)
+	}
+	if spec.Proxy != "" {
+		addr, err := forwardToProxy(spec.Proxy)
+		if err != nil {
+			return 0, fmt.Errorf("could not forward to the sandbox proxy: %w", err)
+		}
+		proxyURL := "http://" + addr
+		env = setEnv(env, "HTTP_PROXY", proxyURL)
+		env = setEnv(env, "http_proxy", proxyURL)
+		env = setEnv(env, "HTTPS_PROXY", proxyURL)
+		env = setEnv(env, "https_proxy", proxyURL)
+		env = setEnv(env, "NO_PROXY", "localhost,127.0.0.1,::1")
+		env = setEnv(env, "no_proxy", "localhost,127.0.0.1,::1")
+	}
+
+	spec.Isolate = false
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return 0, err
+	}
+	cmd := &exec.Cmd{
+		Path:   "/proc/self/exe",
+		Args:   os.Args,
+		Env:    append(env, specEnv+"="+string(data)),
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		SysProcAttr: &syscall.SysProcAttr{
+			Cloneflags:                 syscall.CLONE_NEWUSER,
+			UidMappings:                []syscall.SysProcIDMap{{ContainerID: spec.UID, HostID: 0, Size: 1}},
+			GidMappings:                []syscall.SysProcIDMap{{ContainerID: spec.GID, HostID: 0, Size: 1}},
+			GidMappingsEnableSetgroups: false,
+		},
+	}
+
+	// Signals for the command reach it through its process group; the
+	// helper outlives it to report its exit status.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 0, err
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		signal.Reset(status.Signal())
+		_ = syscall.Kill(os.Getpid(), status.Signal())
+		return 128 + int(status.Signal()), nil
+	}
+	return exitErr.ExitCode(), nil
+}
+
+// loopbackUp brings up the loopback interface of a new network namespace,
+// so that commands can still talk to servers they start themselves.
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// forwardToProxy listens on a loopback port of the network namespace and
+// forwards its connections to the proxy socket, which is reachable from
+// every network namespace. It returns the address it listens on.
+func forwardToProxy(socket string) (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				upstream, err := net.Dial("unix", socket)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				splice(conn, upstream)
+			}()
+		}
+	}()
+	return ln.Addr().String(), nil
+}
+
+// setEnv sets key to value in env.
+func setEnv(env []string, key, value string) []string {
+	env = slices.DeleteFunc(env, func(kv string) bool {
+		return strings.HasPrefix(kv, key+"=")
+	})
+	return append(env, key+"="+value)
+}
@@ -0,0 +1,182 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/sandbox/sandboxtest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperProcess is not a real test: sandboxed commands in the tests
+// below run the test binary again to exercise the network from inside the
+// sandbox.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("SANDBOX_TEST_HELPER") {
+	case "dial":
+		conn, err := net.Dial("tcp", os.Getenv("SANDBOX_TEST_ADDR"))
+		if err != nil {
+			fmt.Println("dial:", err)
+			os.Exit(1)
+		}
+		conn.Close()
+		os.Exit(0)
+	case "get":
+		proxyURL, err := url.Parse(os.Getenv("HTTP_PROXY"))
+		if err != nil || proxyURL.Host == "" {
+			fmt.Println("no proxy:", err)
+			os.Exit(1)
+		}
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := client.Get(os.Getenv("SANDBOX_TEST_URL"))
+		if err != nil {
+			fmt.Println("get:", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%d %s", resp.StatusCode, body)
+		os.Exit(0)
+	}
+}
+
+// requireSandbox skips the test when the kernel or environment cannot
+// sandbox commands with p.
+func requireSandbox(t *testing.T, p *Policy) {
+	t.Helper()
+	if err := p.Check(); err != nil {
+		t.Skipf("sandbox not available: %v", err)
+	}
+}
+
+// runSandboxed runs args confined by p and returns its combined output.
+func runSandboxed(t *testing.T, p *Policy, env []string, args ...string) (string, error) {
+	t.Helper()
+	path, err := exec.LookPath(args[0])
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := &exec.Cmd{
+		Path:   path,
+		Args:   args,
+		Env:    append(os.Environ(), env...),
+		Stdout: &out,
+		Stderr: &out,
+	}
+	require.NoError(t, p.Command(cmd))
+	err = cmd.Run()
+	return out.String(), err
+}
+
+func TestCommandFilesystem(t *testing.T) {
+	t.Parallel()
+
+	readOnly := sandboxtest.ReadOnlyDir(t)
+
+	work := t.TempDir()
+	secrets := filepath.Join(readOnly, "secrets")
+	require.NoError(t, os.Mkdir(secrets, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(secrets, "token"), []byte("s3cret"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(readOnly, "notes"), []byte("visible"), 0o600))
+
+	p := New(Options{WritablePaths: []string{work}, HiddenPaths: []string{secrets}})
+	requireSandbox(t, p)
+
+	out, err := runSandboxed(t, p, nil, "sh", "-c", "echo ok > "+filepath.Join(work, "out")+" && cat "+filepath.Join(readOnly, "notes"))
+	require.NoError(t, err, out)
+	require.Equal(t, "visible", out)
+
+	out, err = runSandboxed(t, p, nil, "sh", "-c", "echo no > "+filepath.Join(readOnly, "out"))
+	require.Error(t, err)
+	require.True(t, LooksBlocked(out), out)
+	require.NoFileExists(t, filepath.Join(readOnly, "out"))
+
+	out, err = runSandboxed(t, p, nil, "cat", filepath.Join(secrets, "token"))
+	require.Error(t, err)
+	require.NotContains(t, out, "s3cret")
+}
+
+func TestCommandNetwork(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	env := []string{"SANDBOX_TEST_HELPER=dial", "SANDBOX_TEST_ADDR=" + ln.Addr().String()}
+
+	open := New(Options{})
+	requireSandbox(t, open)
+	out, err := runSandboxed(t, open, env, exe, "-test.run=TestHelperProcess")
+	require.NoError(t, err, out)
+
+	closed := New(Options{DisableNetwork: true})
+	requireSandbox(t, closed)
+	out, err = runSandboxed(t, closed, env, exe, "-test.run=TestHelperProcess")
+	require.Error(t, err)
+	require.Contains(t, out, "dial:")
+}
+
+func TestCommandAllowedHosts(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	t.Cleanup(server.Close)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	p := New(Options{DisableNetwork: true, AllowedHosts: []string{"127.0.0.1"}})
+	requireSandbox(t, p)
+	out, err := runSandboxed(t, p, []string{"SANDBOX_TEST_HELPER=get", "SANDBOX_TEST_URL=" + server.URL}, exe, "-test.run=TestHelperProcess")
+	require.NoError(t, err, out)
+	require.Equal(t, "200 hello", out)
+
+	p = New(Options{DisableNetwork: true, AllowedHosts: []string{"example.com"}})
+	requireSandbox(t, p)
+	out, err = runSandboxed(t, p, []string{"SANDBOX_TEST_HELPER=get", "SANDBOX_TEST_URL=" + server.URL}, exe, "-test.run=TestHelperProcess")
+	require.NoError(t, err, out)
+	require.Contains(t, out, "403 blocked by sandbox")
+}
+
+func TestReadableRoots(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"a/secret", "a/public", "b"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+	}
+	require.NoError(t, os.Symlink(filepath.Join(root, "a", "secret"), filepath.Join(root, "a", "link")))
+
+	roots := readableRoots([]string{filepath.Join(root, "a", "secret")})
+	require.Contains(t, roots, filepath.Join(root, "a", "public"))
+	require.Contains(t, roots, filepath.Join(root, "b"))
+	require.NotContains(t, roots, filepath.Join(root, "a", "secret"))
+	require.NotContains(t, roots, filepath.Join(root, "a", "link"))
+	require.NotContains(t, roots, filepath.Join(root, "a"))
+	require.NotContains(t, roots, "/")
+}
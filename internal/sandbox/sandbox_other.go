@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import "os/exec"
+
+func defaultWritablePaths() []string {
+	return nil
+}
+
+// Command returns [ErrUnsupported]: commands can only be sandboxed on
+// Linux.
+func (p *Policy) Command(*exec.Cmd) error {
+	return ErrUnsupported
+}
+
+func (p *Policy) check() error {
+	return ErrUnsupported
+}
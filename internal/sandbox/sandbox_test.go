@@ -0,0 +1,93 @@
+package sandbox
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOpen(t *testing.T) {
+	t.Parallel()
+
+	// The paths need not exist, and must not be below the temporary
+	// directory, which is always writable.
+	root := "/crush-sandbox-test"
+	work := filepath.Join(root, "work")
+	secrets := filepath.Join(root, "secrets")
+	p := New(Options{
+		WritablePaths: []string{work, "relative/paths/are/ignored"},
+		HiddenPaths:   []string{secrets},
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		write   bool
+		blocked string
+	}{
+		{"read anywhere", filepath.Join(root, "other", "file"), false, ""},
+		{"write in writable path", filepath.Join(work, "sub", "new.txt"), true, ""},
+		{"write outside writable paths", filepath.Join(root, "other", "file"), true, "writes are limited to"},
+		{"write to parent of writable path", root + "/work/../file", true, "writes are limited to"},
+		{"read hidden path", filepath.Join(secrets, "token"), false, secrets + " is hidden"},
+		{"write hidden path", secrets, true, secrets + " is hidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := p.CheckOpen(tt.path, tt.write)
+			if tt.blocked == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.blocked)
+			require.ErrorAs(t, err, new(*Error))
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	p := New(Options{HiddenPaths: []string{"/secrets"}, DisableNetwork: true})
+	require.Contains(t, p.Describe(), "the contents of /secrets cannot be read")
+	require.Contains(t, p.Describe(), "network access is disabled")
+
+	p = New(Options{DisableNetwork: true, AllowedHosts: []string{"proxy.golang.org"}})
+	require.Contains(t, p.Describe(), "network access is limited to proxy.golang.org")
+
+	p = New(Options{AllowedHosts: []string{"proxy.golang.org"}})
+	require.NotContains(t, p.Describe(), "network")
+}
+
+func TestHostAllowed(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"proxy.golang.org", "*.npmjs.org:443", "localhost:*"}
+	tests := []struct {
+		hostPort string
+		want     bool
+	}{
+		{"proxy.golang.org:443", true},
+		{"proxy.golang.org:80", true},
+		{"registry.npmjs.org:443", true},
+		{"registry.npmjs.org:80", false},
+		{"LOCALHOST:8080", true},
+		{"example.com:443", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, HostAllowed(tt.hostPort, patterns), tt.hostPort)
+	}
+	require.True(t, HostAllowed("[::1]:8080", []string{"::1"}))
+	require.False(t, HostAllowed("localhost:8080", []string{"localhost:3000"}))
+	require.False(t, HostAllowed("localhost:8080", nil))
+}
+
+func TestLooksBlocked(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, LooksBlocked("touch: cannot touch '/etc/x': Permission denied"))
+	require.True(t, LooksBlocked("curl: (6) Could not resolve host: example.com"))
+	require.False(t, LooksBlocked("FAIL: TestFoo"))
+}
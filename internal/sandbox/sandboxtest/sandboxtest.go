@@ -0,0 +1,25 @@
+// Package sandboxtest provides helpers for tests that run commands in a
+// sandbox. It is imported only from _test.go files.
+package sandboxtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ReadOnlyDir creates a directory that a sandbox writing only to its
+// writable paths must leave untouched, and removes it when the test ends.
+// Sandboxes always allow writes to the temporary directory, so unlike
+// t.TempDir the directory is created in the package directory of the test.
+func ReadOnlyDir(t testing.TB) string {
+	t.Helper()
+	dir, err := os.MkdirTemp(".", "readonly-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	dir, err = filepath.Abs(dir)
+	require.NoError(t, err)
+	return dir
+}
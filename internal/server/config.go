@@ -382,6 +382,67 @@ func (c *controllerV1) handlePostWorkspaceMCPRefreshTools(w http.ResponseWriter,
 	w.WriteHeader(http.StatusOK)
 }
 
+// handlePostWorkspaceMCPRestart reconnects a named MCP server.
+//
+//	@Summary		Restart MCP server
+//	@Tags			mcp
+//	@Accept			json
+//	@Param			id		path	string					true	"Workspace ID"
+//	@Param			request	body	proto.MCPNameRequest	true	"MCP name request"
+//	@Success		200
+//	@Failure		400	{object}	proto.Error
+//	@Failure		404	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/mcp/restart [post]
+func (c *controllerV1) handlePostWorkspaceMCPRestart(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req proto.MCPNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.server.logError(r, "Failed to decode request", "error", err)
+		jsonError(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	if err := c.backend.RestartMCP(r.Context(), id, req.Name); err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handlePostWorkspaceMCPElicitation answers the elicitation request of an
+// MCP server.
+//
+//	@Summary		Answer MCP elicitation
+//	@Tags			mcp
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Workspace ID"
+//	@Param			request	body		proto.MCPElicitationResponse	true	"Elicitation response"
+//	@Success		200		{object}	proto.MCPElicitationResponseResult
+//	@Failure		400		{object}	proto.Error
+//	@Failure		404		{object}	proto.Error
+//	@Failure		500		{object}	proto.Error
+//	@Router			/workspaces/{id}/mcp/elicitation [post]
+func (c *controllerV1) handlePostWorkspaceMCPElicitation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req proto.MCPElicitationResponse
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.server.logError(r, "Failed to decode request", "error", err)
+		jsonError(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	resolved, err := c.backend.RespondMCPElicitation(id, req)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, proto.MCPElicitationResponseResult{Resolved: resolved})
+}
+
 // handlePostWorkspaceMCPReadResource reads a resource from an MCP server.
 //
 //	@Summary		Read MCP resource
@@ -467,6 +528,8 @@ func (c *controllerV1) handleGetWorkspaceMCPStates(w http.ResponseWriter, r *htt
 			PromptCount:   v.Counts.Prompts,
 			ResourceCount: v.Counts.Resources,
 			ConnectedAt:   v.ConnectedAt,
+			Restarts:      v.Restarts,
+			Stderr:        v.Stderr,
 		}
 	}
 	jsonEncode(w, result)
@@ -16,6 +16,7 @@ import (
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/charmbracelet/crush/internal/skills"
 )
 
@@ -47,6 +48,11 @@ func wrapEvent(ev any) *pubsub.Payload {
 				ToolCount: e.Payload.Counts.Tools,
 			},
 		})
+	case pubsub.Event[mcp.ElicitationRequest]:
+		return envelope(pubsub.PayloadTypeMCPElicitation, pubsub.Event[proto.MCPElicitationRequest]{
+			Type:    e.Type,
+			Payload: mcpElicitationToProto(e.Payload),
+		})
 	case pubsub.Event[permission.PermissionRequest]:
 		return envelope(pubsub.PayloadTypePermissionRequest, pubsub.Event[proto.PermissionRequest]{
 			Type: e.Type,
@@ -153,6 +159,27 @@ func mcpEventTypeToProto(t mcp.EventType) proto.MCPEventType {
 	}
 }
 
+func mcpElicitationToProto(r mcp.ElicitationRequest) proto.MCPElicitationRequest {
+	out := proto.MCPElicitationRequest{
+		ID:        r.ID,
+		Name:      r.Name,
+		SessionID: r.SessionID,
+		Message:   r.Message,
+	}
+	for _, f := range r.Fields {
+		out.Fields = append(out.Fields, proto.MCPElicitationField{
+			Name:        f.Name,
+			Title:       f.Title,
+			Description: f.Description,
+			Type:        f.Type,
+			Enum:        f.Enum,
+			Required:    f.Required,
+			Default:     f.Default,
+		})
+	}
+	return out
+}
+
 func sessionToProto(s session.Session) proto.Session {
 	return proto.Session{
 		ID:               s.ID,
@@ -219,6 +246,19 @@ func fileToProto(f history.File) proto.File {
 	}
 }
 
+func shellStateToProto(s shellstate.State) proto.ShellState {
+	var updatedAt int64
+	if !s.UpdatedAt.IsZero() {
+		updatedAt = s.UpdatedAt.Unix()
+	}
+	return proto.ShellState{
+		SessionID:  s.SessionID,
+		WorkingDir: s.WorkingDir,
+		Env:        s.Env,
+		UpdatedAt:  updatedAt,
+	}
+}
+
 func messageToProto(m message.Message) proto.Message {
 	msg := proto.Message{
 		ID:        m.ID,
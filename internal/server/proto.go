@@ -670,6 +670,50 @@ func (c *controllerV1) handleGetWorkspaceFileTrackerLastRead(w http.ResponseWrit
 	jsonEncode(w, t)
 }
 
+// handleGetWorkspaceSessionShellState returns the saved shell state of a
+// session.
+//
+//	@Summary		Get shell state for session
+//	@Tags			shellstate
+//	@Produce		json
+//	@Param			id	path		string	true	"Workspace ID"
+//	@Param			sid	path		string	true	"Session ID"
+//	@Success		200	{object}	proto.ShellState
+//	@Failure		404	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/shellstate [get]
+func (c *controllerV1) handleGetWorkspaceSessionShellState(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+	state, err := c.backend.GetShellState(r.Context(), id, sid)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, shellStateToProto(state))
+}
+
+// handleDeleteWorkspaceSessionShellState resets the saved shell state of a
+// session.
+//
+//	@Summary		Reset shell state for session
+//	@Tags			shellstate
+//	@Param			id	path	string	true	"Workspace ID"
+//	@Param			sid	path	string	true	"Session ID"
+//	@Success		200
+//	@Failure		404	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/shellstate [delete]
+func (c *controllerV1) handleDeleteWorkspaceSessionShellState(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+	if err := c.backend.ResetShellState(r.Context(), id, sid); err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // handlePostWorkspaceLSPStart starts an LSP server for a path.
 //
 //	@Summary		Start LSP server
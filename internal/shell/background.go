@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"slices"
 	"sync"
 	"sync/atomic"
@@ -54,6 +55,7 @@ type BackgroundShell struct {
 	cancel      context.CancelFunc
 	stdout      *syncBuffer
 	stderr      *syncBuffer
+	input       *jobInput // nil unless started with StartInteractive
 	done        chan struct{}
 	exitErr     error
 	completedAt atomic.Int64 // Unix timestamp when job completed (0 if still running)
@@ -85,18 +87,64 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 	return backgroundManager
 }
 
+// JobOptions describe a job started with [BackgroundShellManager.StartJob].
+type JobOptions struct {
+	WorkingDir string
+	// Env is the environment of the job; nil means the environment of the
+	// current process.
+	Env         []string
+	BlockFuncs  []BlockFunc
+	Command     string
+	Description string
+	// Interactive keeps the standard input of the job open for WriteInput.
+	// On Linux the job runs under a pseudo-terminal so that programs
+	// prompting for input behave as they would in a terminal.
+	Interactive bool
+	// External, if set, runs the command through an external shell
+	// instead of the built-in interpreter.
+	External *ExternalShell
+}
+
 // Start creates and starts a new background shell with the given command.
 func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	return m.StartJob(ctx, JobOptions{
+		WorkingDir:  workingDir,
+		BlockFuncs:  blockFuncs,
+		Command:     command,
+		Description: description,
+	})
+}
+
+// StartInteractive is like Start but keeps the standard input of the job
+// open for WriteInput. On Linux the job runs under a pseudo-terminal so
+// that programs prompting for input behave as they would in a terminal.
+func (m *BackgroundShellManager) StartInteractive(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	return m.StartJob(ctx, JobOptions{
+		WorkingDir:  workingDir,
+		BlockFuncs:  blockFuncs,
+		Command:     command,
+		Description: description,
+		Interactive: true,
+	})
+}
+
+// StartJob creates and starts a new background shell as described by opts.
+// Once the job is done, the working directory and environment its command
+// left behind are available from the Shell of the job.
+func (m *BackgroundShellManager) StartJob(ctx context.Context, opts JobOptions) (*BackgroundShell, error) {
 	// Check job limit
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("maximum number of background jobs (%d) reached. Please terminate or wait for some jobs to complete", MaxBackgroundJobs)
 	}
 
 	id := fmt.Sprintf("%03X", idCounter.Add(1))
+	workingDir, command := opts.WorkingDir, opts.Command
 
 	shell := NewShell(&Options{
 		WorkingDir: workingDir,
-		BlockFuncs: blockFuncs,
+		Env:        opts.Env,
+		BlockFuncs: opts.BlockFuncs,
+		External:   opts.External,
 	})
 
 	shellCtx, cancel := context.WithCancel(ctx)
@@ -104,7 +152,7 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 	bgShell := &BackgroundShell{
 		ID:          id,
 		Command:     command,
-		Description: description,
+		Description: opts.Description,
 		WorkingDir:  workingDir,
 		Shell:       shell,
 		ctx:         shellCtx,
@@ -114,12 +162,33 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 		done:        make(chan struct{}),
 	}
 
+	if opts.Interactive {
+		input, err := newJobInput(bgShell.stdout)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("could not open job input: %w", err)
+		}
+		bgShell.input = input
+	}
+
 	m.shells.Set(id, bgShell)
 
 	go func() {
 		defer close(bgShell.done)
 
-		err := shell.ExecStream(shellCtx, command, bgShell.stdout, bgShell.stderr)
+		var err error
+		if in := bgShell.input; in != nil {
+			runCtx := shellCtx
+			var stdout, stderr io.Writer = bgShell.stdout, bgShell.stderr
+			if in.tty {
+				runCtx = withTerminal(shellCtx, in.r)
+				stdout, stderr = in.r, in.r
+			}
+			err = shell.ExecStreamInput(runCtx, command, in.r, stdout, stderr)
+			in.finish()
+		} else {
+			err = shell.ExecStream(shellCtx, command, bgShell.stdout, bgShell.stderr)
+		}
 
 		bgShell.exitErr = err
 		bgShell.completedAt.Store(time.Now().Unix())
@@ -2,6 +2,8 @@ package shell
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -328,3 +330,91 @@ func TestBackgroundShell_WaitContext_Canceled(t *testing.T) {
 
 	require.False(t, bgShell.WaitContext(ctx))
 }
+
+func TestBackgroundShell_Interactive(t *testing.T) {
+	t.Parallel()
+
+	manager := newBackgroundShellManager()
+	bgShell, err := manager.StartInteractive(t.Context(), t.TempDir(), nil, "read name; echo \"hello $name\"; cat", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Kill(bgShell.ID) })
+	require.True(t, bgShell.Interactive())
+
+	require.NoError(t, bgShell.WriteInput("crush\n"))
+	require.Eventually(t, func() bool {
+		stdout, _, _, _ := bgShell.GetOutput()
+		return strings.Contains(stdout, "hello crush")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, bgShell.WriteInput("piped\n"))
+	require.NoError(t, bgShell.CloseInput())
+	require.True(t, bgShell.WaitContext(waitCtx(t)))
+
+	stdout, _, done, err := bgShell.GetOutput()
+	require.True(t, done)
+	require.NoError(t, err)
+	require.Contains(t, stdout, "piped")
+}
+
+func TestBackgroundShell_NotInteractive(t *testing.T) {
+	t.Parallel()
+
+	manager := newBackgroundShellManager()
+	bgShell, err := manager.Start(t.Context(), t.TempDir(), nil, "echo hi", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Kill(bgShell.ID) })
+
+	require.False(t, bgShell.Interactive())
+	require.ErrorIs(t, bgShell.WriteInput("x\n"), ErrNotInteractive)
+}
+
+func TestBackgroundShell_Interrupt(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("jobs run under a terminal on Linux only")
+	}
+	t.Parallel()
+
+	manager := newBackgroundShellManager()
+	bgShell, err := manager.StartInteractive(t.Context(), t.TempDir(), nil, "sleep 30", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Kill(bgShell.ID) })
+	require.True(t, bgShell.Terminal())
+
+	// Give sleep time to start and take the terminal.
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, bgShell.Interrupt())
+	require.True(t, bgShell.WaitContext(waitCtx(t)))
+
+	_, _, _, err = bgShell.GetOutput()
+	require.Equal(t, 130, ExitCode(err))
+}
+
+func waitCtx(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestBackgroundShellManager_StartJobState(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	manager := newBackgroundShellManager()
+	env := append(os.Environ(), "GREETING=hello", "KEPT=1")
+
+	bgShell, err := manager.StartJob(t.Context(), JobOptions{
+		WorkingDir: workingDir,
+		Env:        env,
+		Command:    `mkdir sub && cd sub && export GREETING="$GREETING world" NEW=value && echo "$GREETING"`,
+	})
+	require.NoError(t, err)
+	bgShell.Wait()
+
+	stdout, _, done, err := bgShell.GetOutput()
+	require.True(t, done)
+	require.NoError(t, err)
+	require.Equal(t, "hello world\n", stdout)
+	require.Equal(t, filepath.Join(workingDir, "sub"), bgShell.Shell.GetWorkingDir())
+	require.Equal(t, []string{"GREETING=hello world", "NEW=value"}, EnvChanges(env, bgShell.Shell.GetEnv()))
+}
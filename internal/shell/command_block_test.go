@@ -17,8 +17,8 @@ func TestCommandBlocking(t *testing.T) {
 		{
 			name: "block simple command",
 			blockFuncs: []BlockFunc{
-				func(args []string) bool {
-					return len(args) > 0 && args[0] == "curl"
+				func(args []string) (bool, string) {
+					return len(args) > 0 && args[0] == "curl", ""
 				},
 			},
 			command:     "curl https://example.com",
@@ -27,8 +27,8 @@ func TestCommandBlocking(t *testing.T) {
 		{
 			name: "allow non-blocked command",
 			blockFuncs: []BlockFunc{
-				func(args []string) bool {
-					return len(args) > 0 && args[0] == "curl"
+				func(args []string) (bool, string) {
+					return len(args) > 0 && args[0] == "curl", ""
 				},
 			},
 			command:     "echo hello",
@@ -37,8 +37,8 @@ func TestCommandBlocking(t *testing.T) {
 		{
 			name: "block subcommand",
 			blockFuncs: []BlockFunc{
-				func(args []string) bool {
-					return len(args) >= 2 && args[0] == "brew" && args[1] == "install"
+				func(args []string) (bool, string) {
+					return len(args) >= 2 && args[0] == "brew" && args[1] == "install", ""
 				},
 			},
 			command:     "brew install wget",
@@ -47,8 +47,8 @@ func TestCommandBlocking(t *testing.T) {
 		{
 			name: "allow different subcommand",
 			blockFuncs: []BlockFunc{
-				func(args []string) bool {
-					return len(args) >= 2 && args[0] == "brew" && args[1] == "install"
+				func(args []string) (bool, string) {
+					return len(args) >= 2 && args[0] == "brew" && args[1] == "install", ""
 				},
 			},
 			command:     "brew list",
@@ -253,7 +253,7 @@ func TestArgumentsBlocker(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			blocker := ArgumentsBlocker(tt.cmd, tt.args, tt.flags)
-			result := blocker(tt.input)
+			result, _ := blocker(tt.input)
 			require.Equal(t, tt.shouldBlock, result,
 				"Expected block=%v for input %v", tt.shouldBlock, tt.input)
 		})
@@ -302,7 +302,7 @@ func TestCommandsBlocker(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			blocker := CommandsBlocker(tt.banned)
-			result := blocker(tt.input)
+			result, _ := blocker(tt.input)
 			require.Equal(t, tt.shouldBlock, result,
 				"Expected block=%v for input %v", tt.shouldBlock, tt.input)
 		})
@@ -56,6 +56,10 @@ func scriptDispatchHandler(blockFuncs []BlockFunc) func(next interp.ExecHandlerF
 			// the process cwd — hook commands are authored with the hook
 			// Runner's cwd in mind and sub-shells can cd before an exec.
 			scriptPath := filepathext.SmartJoin(interp.HandlerCtx(ctx).Dir, args[0])
+			if err := checkSandboxOpen(ctx, scriptPath, false); err != nil {
+				fmt.Fprintf(interp.HandlerCtx(ctx).Stderr, "crush: %s\n", err)
+				return interp.ExitStatus(126)
+			}
 			probe, err := probeFile(scriptPath)
 			if err != nil {
 				return err
@@ -198,6 +202,10 @@ func dispatchShebang(ctx context.Context, scriptPath string, probe []byte, args
 	cmd.Stdout = hc.Stdout
 	cmd.Stderr = hc.Stderr
 	isolateProcess(cmd)
+	if err := confineCommand(ctx, cmd); err != nil {
+		fmt.Fprintf(hc.Stderr, "crush: %s: %s\n", scriptPath, err)
+		return interp.ExitStatus(126)
+	}
 
 	if err := cmd.Run(); err != nil {
 		var exitErr *exec.ExitError
@@ -393,6 +401,7 @@ func runShellSource(ctx context.Context, path string, args []string, blockFuncs
 		interp.Interactive(false),
 		interp.Env(hc.Env),
 		interp.Dir(hc.Dir),
+		interp.OpenHandler(sandboxOpenHandler()),
 		execHandlerOption(blockFuncs),
 	}
 	if len(args) > 1 {
@@ -30,6 +30,19 @@ func isolateProcess(cmd *exec.Cmd) {
 	cmd.SysProcAttr.Setsid = true
 }
 
+// setControllingTerminal makes the job terminal carried by ctx the
+// controlling terminal of cmd when it is cmd's stdin, so that Ctrl-C
+// written to the terminal interrupts the command. It reports whether it did.
+func setControllingTerminal(ctx context.Context, cmd *exec.Cmd, stdin io.Reader) bool {
+	tty := terminalFromContext(ctx)
+	if tty == nil || stdin != io.Reader(tty) {
+		return false
+	}
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+	return true
+}
+
 // processGroupExecHandler returns an ExecHandlerFunc that replaces
 // interp.DefaultExecHandler with one that fully isolates child processes
 // from Crush's session and controlling terminal.
@@ -38,9 +51,11 @@ func isolateProcess(cmd *exec.Cmd) {
 // framework files will attempt to take over the TTY, causing SIGTTIN/SIGTTOU
 // signals and corrupting the parent terminal state.
 //
-// The implementation mirrors interp.DefaultExecHandler with two additions:
-// isolateProcess(&cmd) after construction, and negative-PID signal targeting
-// in the cancellation callback so the entire child process group is killed.
+// The implementation mirrors interp.DefaultExecHandler with four additions:
+// isolateProcess(cmd) after construction, negative-PID signal targeting in
+// the cancellation callback so the entire child process group is killed,
+// acquiring the job terminal of interactive background jobs, and confining
+// the command to the sandbox carried by the context.
 func processGroupExecHandler(killTimeout time.Duration) interp.ExecHandlerFunc {
 	return func(ctx context.Context, args []string) error {
 		hc := interp.HandlerCtx(ctx)
@@ -50,18 +65,35 @@ func processGroupExecHandler(killTimeout time.Duration) interp.ExecHandlerFunc {
 			return interp.ExitStatus(127)
 		}
 
-		cmd := exec.Cmd{
-			Path:   path,
-			Args:   args,
-			Env:    execEnvList(hc.Env),
-			Dir:    hc.Dir,
-			Stdin:  hc.Stdin,
-			Stdout: hc.Stdout,
-			Stderr: hc.Stderr,
+		newCmd := func() *exec.Cmd {
+			cmd := &exec.Cmd{
+				Path:   path,
+				Args:   args,
+				Env:    execEnvList(hc.Env),
+				Dir:    hc.Dir,
+				Stdin:  hc.Stdin,
+				Stdout: hc.Stdout,
+				Stderr: hc.Stderr,
+			}
+			isolateProcess(cmd)
+			return cmd
+		}
+		start := func(cmd *exec.Cmd) error {
+			if err := confineCommand(ctx, cmd); err != nil {
+				return err
+			}
+			return cmd.Start()
 		}
-		isolateProcess(&cmd)
 
-		err = cmd.Start()
+		cmd := newCmd()
+		ctty := setControllingTerminal(ctx, cmd, hc.Stdin)
+		err = start(cmd)
+		if err != nil && ctty {
+			// Another command of the job still owns the terminal; run
+			// this one without a controlling terminal.
+			cmd = newCmd()
+			err = start(cmd)
+		}
 		if err == nil {
 			stopf := context.AfterFunc(ctx, func() {
 				if killTimeout <= 0 {
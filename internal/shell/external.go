@@ -0,0 +1,259 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ExternalShell is a shell program, such as bash or zsh, that runs the
+// commands of a [Shell] in place of the built-in interpreter, for commands
+// that depend on its dialect or on functions defined in its rc file.
+//
+// Commands are still parsed ahead of execution so that [BlockFunc]s see
+// every simple command of the script, but commands the shell only
+// discovers at run time, such as those in the body of functions defined by
+// the rc file, are out of their sight.
+type ExternalShell struct {
+	// Path is the shell program, looked up in PATH if it has no directory.
+	Path string
+	// Args precede the script on the command line of the shell. Defaults
+	// to -c.
+	Args []string
+	// RCFile, if set, is sourced before each command.
+	RCFile string
+}
+
+// externalStateVars are the variables shells maintain themselves, which
+// are not carried over from one command to the next.
+var externalStateVars = []string{"SHLVL", "_"}
+
+// execExternal runs command through the external shell of s, updating the
+// working directory and environment of s from the ones the command left.
+func (s *Shell) execExternal(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	env := withNonInteractiveEnv(s.env)
+	if err := checkScript(expand.ListEnviron(env...), command, s.blockFuncs); err != nil {
+		return err
+	}
+
+	state, err := os.CreateTemp("", "crush-shell-state-*")
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+	state.Close()
+	defer os.Remove(state.Name())
+
+	script, err := s.external.script(command, state.Name())
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+	line, err := quotedCommand(append(append([]string{s.external.Path}, s.external.args()...), script))
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+
+	// The interpreter only launches the shell, through the same exec
+	// handler as any other command: isolated in its own process group,
+	// killed with it on cancellation and confined to the sandbox.
+	runner, err := interp.New(
+		interp.StdIO(stdin, stdout, stderr),
+		interp.Interactive(false),
+		interp.Env(expand.ListEnviron(env...)),
+		interp.Dir(s.cwd),
+		interp.ExecHandler(processGroupExecHandler(defaultKillTimeout)), //nolint:staticcheck // ExecHandlers always appends DefaultExecHandler which lacks process isolation.
+	)
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+	err = runner.Run(ctx, line)
+	s.updateShellFromState(state.Name())
+	return err
+}
+
+func (e *ExternalShell) args() []string {
+	if len(e.Args) == 0 {
+		return []string{"-c"}
+	}
+	return e.Args
+}
+
+// script returns the script the external shell runs for command: sourcing
+// the rc file, if any, running command, and on exit writing the working
+// directory and environment to stateFile.
+func (e *ExternalShell) script(command, stateFile string) (string, error) {
+	quotedState, err := syntax.Quote(stateFile, syntax.LangBash)
+	if err != nil {
+		return "", err
+	}
+	trap, err := syntax.Quote(fmt.Sprintf("__crush_status=$?; { pwd; env; } >%s 2>/dev/null; exit $__crush_status", quotedState), syntax.LangBash)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "trap %s EXIT\n", trap)
+	if e.RCFile != "" {
+		rc, err := syntax.Quote(e.RCFile, syntax.LangBash)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, ". %s\n", rc)
+	}
+	b.WriteString(command)
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// quotedCommand returns a file running the simple command args.
+func quotedCommand(args []string) (*syntax.File, error) {
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		word, err := syntax.Quote(arg, syntax.LangBash)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return syntax.NewParser().Parse(strings.NewReader(strings.Join(words, " ")), "")
+}
+
+// updateShellFromState updates the shell from the state file written by
+// the external shell on exit: the working directory on its first line,
+// then the output of env. The shell is left as it was if the file is
+// empty, as when the shell was killed.
+func (s *Shell) updateShellFromState(name string) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	if !scanner.Scan() {
+		return
+	}
+	// Shells on Windows may report POSIX-style paths the directory
+	// cannot be changed to; keep the previous one then.
+	if dir := scanner.Text(); dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			s.cwd = dir
+		}
+	}
+
+	var env []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Lines not starting with a name are the continuation of a
+		// value spanning several lines.
+		if name, _, ok := strings.Cut(line, "="); (!ok || !syntax.ValidName(name)) && len(env) > 0 {
+			env[len(env)-1] += "\n" + line
+			continue
+		}
+		env = append(env, line)
+	}
+	if scanner.Err() != nil || len(env) == 0 {
+		return
+	}
+	s.env = slices.DeleteFunc(env, func(kv string) bool {
+		name, _, _ := strings.Cut(kv, "=")
+		return slices.Contains(externalStateVars, name)
+	})
+}
+
+// checkScript applies blockFuncs to the commands of script ahead of its
+// execution, as far as they can be told without running it: the simple
+// commands of the script including those of command substitutions, the
+// scripts of eval, and the commands they run in turn; see
+// [nestedCommands]. Scripts that cannot be parsed, or that run a command
+// whose name is not written out literally, are rejected when there are
+// blockFuncs to apply, since their commands cannot be checked.
+func checkScript(env expand.Environ, script string, blockFuncs []BlockFunc) error {
+	if len(blockFuncs) == 0 {
+		return nil
+	}
+	cmds, err := staticCommands(env, false, script, 0)
+	if err != nil {
+		return fmt.Errorf("could not parse command: %w", err)
+	}
+	for _, cmd := range cmds {
+		if err := blockCommand(blockFuncs, cmd); err != nil {
+			return err
+		}
+	}
+	literal, err := staticCommands(nil, true, script, 0)
+	if err != nil {
+		return fmt.Errorf("could not parse command: %w", err)
+	}
+	return checkLiteralNames(literal)
+}
+
+// staticCommands returns the commands script runs as far as they can be
+// told without running it. literal is passed on to [callCommands].
+func staticCommands(env expand.Environ, literal bool, script string, depth int) ([][]string, error) {
+	if depth >= maxNestingDepth {
+		return nil, nil
+	}
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, err
+	}
+
+	var cmds [][]string
+	for _, cmd := range callCommands(env, literal, file) {
+		cmd = unwrapBuiltins(cmd)
+		if len(cmd) == 0 {
+			continue
+		}
+		if cmd[0] == "eval" {
+			nested, err := staticCommands(env, literal, strings.Join(cmd[1:], " "), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds, nested...)
+			continue
+		}
+		cmds = append(cmds, cmd)
+		cmds = appendNestedCommands(cmds, env, literal, cmd, 0)
+	}
+	return cmds, nil
+}
+
+// unwrapBuiltins returns the command run by the command, exec and builtin
+// builtins, which the built-in interpreter resolves itself before a
+// command reaches the [BlockFunc]s. Lookups such as `command -v` run
+// nothing.
+func unwrapBuiltins(args []string) []string {
+	for len(args) > 0 {
+		switch args[0] {
+		case "builtin":
+			args = args[1:]
+		case "command", "exec":
+			args = args[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				if args[0] == "--" {
+					args = args[1:]
+					break
+				}
+				if strings.ContainsAny(args[0], "vV") {
+					return nil
+				}
+				if args[0] == "-a" {
+					args = args[1:]
+				}
+				args = args[1:]
+			}
+		default:
+			return args
+		}
+	}
+	return args
+}
@@ -0,0 +1,96 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalShell(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	rc := filepath.Join(dir, "rc")
+	require.NoError(t, os.WriteFile(rc, []byte("greet() { echo \"hello $1\"; }\n"), 0o644))
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0o755))
+
+	sh := NewShell(&Options{
+		WorkingDir: dir,
+		Env:        append(os.Environ(), "BASE=1"),
+		BlockFuncs: []BlockFunc{CommandsBlocker([]string{"curl"})},
+		External:   &ExternalShell{Path: "sh", RCFile: rc},
+	})
+
+	stdout, _, err := sh.Exec(t.Context(), `greet world; echo "$CRUSH $BASE $PAGER"`)
+	require.NoError(t, err)
+	require.Equal(t, "hello world\n1 1 cat\n", stdout)
+
+	_, _, err = sh.Exec(t.Context(), "cd sub && export FOO='a\nb'")
+	require.NoError(t, err)
+	require.Equal(t, sub, sh.GetWorkingDir())
+	require.Contains(t, sh.GetEnv(), "FOO=a\nb")
+	require.Equal(t, []string{"FOO=a\nb"}, EnvChanges(append(os.Environ(), "BASE=1"), sh.GetEnv()))
+
+	stdout, _, err = sh.Exec(t.Context(), `pwd; printf '%s\n' "$FOO"`)
+	require.NoError(t, err)
+	require.Equal(t, sub+"\na\nb\n", stdout)
+
+	_, _, err = sh.Exec(t.Context(), "exit 3")
+	require.Equal(t, 3, ExitCode(err))
+	require.Equal(t, sub, sh.GetWorkingDir())
+
+	_, _, err = sh.Exec(t.Context(), "echo $(command curl example.com)")
+	var blockedErr *BlockedError
+	require.ErrorAs(t, err, &blockedErr)
+	require.Equal(t, []string{"curl", "example.com"}, blockedErr.Args)
+}
+
+func TestCheckScript(t *testing.T) {
+	t.Parallel()
+
+	block := []BlockFunc{CommandsBlocker([]string{"curl"})}
+	tests := []struct {
+		name    string
+		script  string
+		blocked []string
+	}{
+		{"allowed", "ls -la | grep foo", nil},
+		{"pipeline", "echo hi | curl example.com", []string{"curl", "example.com"}},
+		{"command substitution", "echo $(curl example.com)", []string{"curl", "example.com"}},
+		{"eval", `eval "curl example.com"`, []string{"curl", "example.com"}},
+		{"shell script", `bash -c "curl example.com"`, []string{"curl", "example.com"}},
+		{"exec", "exec -a name curl example.com", []string{"curl", "example.com"}},
+		{"command lookup", "command -v curl", nil},
+		{"function body", "f() { curl example.com; }", []string{"curl", "example.com"}},
+		{"quoted command name", `"ls" -la`, nil},
+		{"variable command name", "c=curl; $c example.com", []string{"$(...)", "example.com"}},
+		{"substituted command name", "$(echo curl) example.com", []string{"$(...)", "example.com"}},
+		{"glob command name", "/usr/bin/cur? example.com", []string{"$(...)", "example.com"}},
+		{"brace command name", "{cu,}rl example.com", []string{"$(...)", "example.com"}},
+		{"variable after exec", "exec $c example.com", []string{"$(...)", "example.com"}},
+		{"variable in eval", `eval "$c example.com"`, []string{"$(...)"}},
+		{"variable in shell script", `sh -c "$c example.com"`, []string{"$(...)"}},
+		{"variable argument", "rm -rf $dir", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkScript(nil, tt.script, block)
+			if tt.blocked == nil {
+				require.NoError(t, err)
+				return
+			}
+			var blockedErr *BlockedError
+			require.ErrorAs(t, err, &blockedErr)
+			require.Equal(t, tt.blocked, blockedErr.Args)
+		})
+	}
+
+	require.ErrorContains(t, checkScript(nil, "echo 'unterminated", block), "could not parse command")
+	require.NoError(t, checkScript(nil, "echo 'unterminated", nil))
+}
@@ -0,0 +1,173 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ptyRows and ptyCols are the window size reported to programs run
+	// under a job terminal.
+	ptyRows = 40
+	ptyCols = 200
+
+	// terminalDrainTimeout bounds how long a finished job waits for
+	// processes it left behind to release the terminal before the last of
+	// its output is discarded.
+	terminalDrainTimeout = time.Second
+)
+
+var (
+	// ErrNotInteractive is returned when writing input to a job that was
+	// not started with StartInteractive.
+	ErrNotInteractive = errors.New("job does not accept input")
+	// ErrNoTerminal is returned when interrupting an interactive job that
+	// does not run under a pseudo-terminal.
+	ErrNoTerminal = errors.New("job does not run under a terminal")
+	// ErrInputClosed is returned when writing to a job whose standard input
+	// has been closed.
+	ErrInputClosed = errors.New("standard input of the job is closed")
+)
+
+type terminalContextKey struct{}
+
+// withTerminal returns a context carrying the terminal of an interactive
+// job, which the exec handler makes the controlling terminal of commands
+// reading from it.
+func withTerminal(ctx context.Context, tty *os.File) context.Context {
+	return context.WithValue(ctx, terminalContextKey{}, tty)
+}
+
+func terminalFromContext(ctx context.Context) *os.File {
+	tty, _ := ctx.Value(terminalContextKey{}).(*os.File)
+	return tty
+}
+
+// jobInput is the standard input of an interactive background job: either a
+// pseudo-terminal, whose master side also carries the job's output, or a
+// pipe.
+type jobInput struct {
+	mu     sync.Mutex
+	w      io.WriteCloser // terminal master or write end of the pipe
+	r      *os.File       // terminal or read end of the pipe, the job's stdin
+	tty    bool
+	closed bool
+	copied chan struct{} // closed once terminal output is copied; nil for pipes
+}
+
+// newJobInput opens a pseudo-terminal whose output is copied to stdout,
+// falling back to a pipe where terminals are not supported.
+func newJobInput(stdout io.Writer) (*jobInput, error) {
+	master, tty, err := openPTY()
+	if err == nil {
+		in := &jobInput{w: master, r: tty, tty: true, copied: make(chan struct{})}
+		go func() {
+			defer close(in.copied)
+			// Reading fails with EIO once every process has closed the
+			// terminal.
+			_, _ = io.Copy(stdout, master)
+		}()
+		return in, nil
+	}
+	slog.Debug("Running interactive job without a terminal", "error", err)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	return &jobInput{w: w, r: r}, nil
+}
+
+// finish releases the job's end of its input once the interpreter has
+// returned, waiting briefly for the remaining terminal output.
+func (in *jobInput) finish() {
+	in.r.Close()
+	if in.tty {
+		select {
+		case <-in.copied:
+		case <-time.After(terminalDrainTimeout):
+		}
+	}
+
+	// Closing first also fails a write blocked on a full buffer, which
+	// holds the lock.
+	in.w.Close()
+	in.mu.Lock()
+	in.closed = true
+	in.mu.Unlock()
+	if in.tty {
+		<-in.copied
+	}
+}
+
+func (in *jobInput) write(data []byte) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.closed {
+		return ErrInputClosed
+	}
+	_, err := in.w.Write(data)
+	return err
+}
+
+// Interactive reports whether the job accepts input through WriteInput.
+func (bs *BackgroundShell) Interactive() bool {
+	return bs.input != nil
+}
+
+// Terminal reports whether the job runs under a pseudo-terminal. Output of
+// such jobs is captured as stdout only and includes the terminal's echo of
+// the input.
+func (bs *BackgroundShell) Terminal() bool {
+	return bs.input != nil && bs.input.tty
+}
+
+// WriteInput writes text to the standard input of an interactive job. On a
+// terminal, newlines are sent as carriage returns, as the Enter key does.
+func (bs *BackgroundShell) WriteInput(text string) error {
+	if bs.input == nil {
+		return ErrNotInteractive
+	}
+	if bs.input.tty {
+		text = strings.ReplaceAll(text, "\n", "\r")
+	}
+	return bs.input.write([]byte(text))
+}
+
+// Interrupt sends Ctrl-C to the foreground command of an interactive job
+// running under a terminal.
+func (bs *BackgroundShell) Interrupt() error {
+	if bs.input == nil {
+		return ErrNotInteractive
+	}
+	if !bs.input.tty {
+		return ErrNoTerminal
+	}
+	return bs.input.write([]byte{0x03})
+}
+
+// CloseInput signals end of input to an interactive job. On a terminal it
+// sends Ctrl-D, which programs reading lines treat as end of file and which
+// can be sent again; otherwise it closes the input pipe for good.
+func (bs *BackgroundShell) CloseInput() error {
+	if bs.input == nil {
+		return ErrNotInteractive
+	}
+	if bs.input.tty {
+		return bs.input.write([]byte{0x04})
+	}
+
+	bs.input.mu.Lock()
+	defer bs.input.mu.Unlock()
+	if bs.input.closed {
+		return nil
+	}
+	bs.input.closed = true
+	return bs.input.w.Close()
+}
@@ -218,6 +218,9 @@ func readInputs(ctx context.Context, stdin io.Reader, files []string, nullInput,
 	var readers []io.Reader
 	if len(files) > 0 {
 		for _, f := range files {
+			if err := checkSandboxOpen(ctx, f, false); err != nil {
+				return nil, err
+			}
 			file, err := os.Open(f)
 			if err != nil {
 				return nil, err
@@ -0,0 +1,270 @@
+package shell
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxNestingDepth bounds how deep [nestedCommands] looks into commands
+// that run other commands, such as `sh -c "env sh -c '...'"`.
+const maxNestingDepth = 8
+
+// shells are the commands whose -c script [nestedCommands] parses.
+var shells = map[string]bool{
+	"ash":  true,
+	"bash": true,
+	"dash": true,
+	"ksh":  true,
+	"mksh": true,
+	"sh":   true,
+	"zsh":  true,
+}
+
+// wrapper describes the command line of a command that runs the command
+// following its own flags and arguments.
+type wrapper struct {
+	// valueFlags are the flags followed by a separate value.
+	valueFlags []string
+	// positionals is the number of arguments before the command, such as
+	// the duration of timeout.
+	positionals int
+	// assignments reports whether NAME=VALUE words may precede the
+	// command, as with env.
+	assignments bool
+	// splitFlag is the flag, if any, whose value is split into the
+	// leading words of the command, as with env -S.
+	splitFlag string
+}
+
+var wrappers = map[string]wrapper{
+	"doas":    {valueFlags: []string{"-C", "-u"}},
+	"env":     {valueFlags: []string{"-C", "-u", "--chdir", "--unset"}, assignments: true, splitFlag: "-S"},
+	"nice":    {valueFlags: []string{"-n", "--adjustment"}},
+	"nohup":   {},
+	"setsid":  {},
+	"stdbuf":  {valueFlags: []string{"-e", "-i", "-o", "--error", "--input", "--output"}},
+	"sudo":    {valueFlags: []string{"-C", "-D", "-g", "-h", "-p", "-R", "-r", "-t", "-U", "-u", "--chdir", "--group", "--host", "--prompt", "--user"}},
+	"time":    {valueFlags: []string{"-f", "-o", "--format", "--output"}},
+	"timeout": {valueFlags: []string{"-k", "-s", "--kill-after", "--signal"}, positionals: 1},
+	"xargs":   {valueFlags: []string{"-a", "-d", "-E", "-I", "-L", "-n", "-P", "-s", "--arg-file", "--delimiter", "--max-args", "--max-lines", "--max-procs", "--max-chars"}},
+}
+
+// commandName returns the name args[0] runs a command by, without its
+// directory or, on Windows, its .exe extension.
+func commandName(arg0 string) string {
+	return strings.TrimSuffix(filepath.Base(arg0), ".exe")
+}
+
+// nestedCommands returns the commands args runs in turn: the commands in
+// the script of a shell's -c flag, the command run by wrappers such as
+// env, nohup, timeout and xargs, and the commands of find -exec. The
+// interpreter sees the simple commands of pipelines, subshells and eval
+// itself, but these run in another process, out of its sight. env expands
+// the parameters of -c scripts, and may be nil.
+func nestedCommands(env expand.Environ, args []string) [][]string {
+	return appendNestedCommands(nil, env, false, args, 0)
+}
+
+// literalNestedCommands is like [nestedCommands], but only keeps the words
+// of -c scripts that are written out literally; see [callCommands].
+func literalNestedCommands(args []string) [][]string {
+	return appendNestedCommands(nil, nil, true, args, 0)
+}
+
+func appendNestedCommands(cmds [][]string, env expand.Environ, literal bool, args []string, depth int) [][]string {
+	if len(args) == 0 || depth >= maxNestingDepth {
+		return cmds
+	}
+
+	var nested [][]string
+	name := commandName(args[0])
+	switch {
+	case shells[name]:
+		if script, ok := shellScript(args[1:]); ok {
+			nested = scriptCommands(env, literal, script)
+		}
+	case name == "find":
+		nested = findExecCommands(args[1:])
+	default:
+		if w, ok := wrappers[name]; ok {
+			if cmd := w.command(args[1:]); len(cmd) > 0 {
+				nested = [][]string{cmd}
+			}
+		}
+	}
+
+	for _, cmd := range nested {
+		cmds = append(cmds, cmd)
+		cmds = appendNestedCommands(cmds, env, literal, cmd, depth+1)
+	}
+	return cmds
+}
+
+// shellScript returns the script a shell with the arguments args runs
+// with -c, if any.
+func shellScript(args []string) (string, bool) {
+	hasC := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			if hasC && i+1 < len(args) {
+				return args[i+1], true
+			}
+			return "", false
+		case arg == "-o" || arg == "+o" || arg == "-O" || arg == "+O":
+			i++
+		case strings.HasPrefix(arg, "--"):
+		case strings.HasPrefix(arg, "-"):
+			hasC = hasC || strings.ContainsRune(arg[1:], 'c')
+		case strings.HasPrefix(arg, "+"):
+		default:
+			return arg, hasC
+		}
+	}
+	return "", false
+}
+
+// scriptCommands returns the simple commands of script. Words the parser
+// cannot expand statically, such as command substitutions, are left
+// empty; the commands inside them are returned in their own right.
+func scriptCommands(env expand.Environ, literal bool, script string) [][]string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil
+	}
+	return callCommands(env, literal, file)
+}
+
+// dynamicWord stands in for the words [callCommands] does not expand when
+// only literal words are kept. Parsed again, as the script of eval or sh
+// -c, it is still not literal.
+const dynamicWord = "$(...)"
+
+// callCommands returns the simple commands of file, expanded as far as
+// they can be statically; see [scriptCommands]. If literal is set, words
+// that are not written out literally are replaced with [dynamicWord]
+// instead, since the script may assign the parameters they expand before
+// running them.
+func callCommands(env expand.Environ, literal bool, file *syntax.File) [][]string {
+	cfg := &expand.Config{Env: env}
+	var cmds [][]string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		cmd := make([]string, 0, len(call.Args))
+		for _, word := range call.Args {
+			if literal && !literalWord(word) {
+				cmd = append(cmd, dynamicWord)
+				continue
+			}
+			lit, err := expand.Literal(cfg, word)
+			if err != nil {
+				lit = word.Lit()
+			}
+			cmd = append(cmd, lit)
+		}
+		cmds = append(cmds, cmd)
+		return true
+	})
+	return cmds
+}
+
+// literalWord reports whether word reads as the shell expands it: no
+// parameter expansion, command substitution, glob or brace expansion in
+// it can turn it into another word, as with `c=curl; $c` or
+// `/usr/bin/cur?`.
+func literalWord(word *syntax.Word) bool {
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			if strings.ContainsAny(part.Value, "*?[{") {
+				return false
+			}
+		case *syntax.SglQuoted:
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				if _, ok := inner.(*syntax.Lit); !ok {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkLiteralNames returns a [BlockedError] for the first of cmds whose
+// name is not written out literally, such as one run through a variable,
+// since any command could hide behind it.
+func checkLiteralNames(cmds [][]string) error {
+	for _, cmd := range cmds {
+		if len(cmd) > 0 && (cmd[0] == "" || strings.Contains(cmd[0], dynamicWord)) {
+			return &BlockedError{Args: cmd, Reason: "the command name must be written out literally"}
+		}
+	}
+	return nil
+}
+
+// findExecCommands returns the commands run by the -exec, -execdir, -ok
+// and -okdir actions of find.
+func findExecCommands(args []string) [][]string {
+	var cmds [][]string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-exec", "-execdir", "-ok", "-okdir":
+		default:
+			continue
+		}
+		end := i + 1
+		for end < len(args) && args[end] != ";" && args[end] != "+" {
+			end++
+		}
+		if end > i+1 {
+			cmds = append(cmds, args[i+1:end])
+		}
+		i = end
+	}
+	return cmds
+}
+
+// command returns the command a wrapper with the arguments args runs.
+func (w wrapper) command(args []string) []string {
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+		if arg == w.splitFlag && i+1 < len(args) {
+			return append(strings.Fields(args[i+1]), args[i+2:]...)
+		}
+		if slices.Contains(w.valueFlags, arg) {
+			i++
+		}
+	}
+
+	i += w.positionals
+	if w.assignments {
+		for ; i < len(args); i++ {
+			if name, _, ok := strings.Cut(args[i], "="); !ok || !syntax.ValidName(name) {
+				break
+			}
+		}
+	}
+	if i >= len(args) {
+		return nil
+	}
+	return args[i:]
+}
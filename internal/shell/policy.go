@@ -0,0 +1,271 @@
+package shell
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PolicyAction is what a [PolicyRule] does with the commands it matches.
+type PolicyAction string
+
+const (
+	// PolicyAllow lets matching commands run, even ones the fallback
+	// [BlockFunc]s of [PolicyBlocker] would block.
+	PolicyAllow PolicyAction = "allow"
+	// PolicyDeny blocks matching commands.
+	PolicyDeny PolicyAction = "deny"
+)
+
+// PolicyRule matches simple commands by name, subcommands, flags and
+// arguments. Patterns are globs in which * matches any text, including
+// slashes, and ? matches a single character.
+type PolicyRule struct {
+	Action PolicyAction
+	// Command matches the name of the command, without its directory.
+	Command string
+	// Subcommands match the leading arguments that are not flags, in
+	// order, such as "push" in `git push`.
+	Subcommands []string
+	// Flags, if set, make a deny rule match when any of them is present.
+	// A cluster of short flags such as -rf also counts as -r and -f, and
+	// --name=value as --name. An allow rule instead only matches commands
+	// whose flags all match one of them, so that an unexpected flag such
+	// as --output cannot ride along; --name=value flags must match with
+	// their value, as in --max-time=*.
+	Flags []string
+	// Args, if set, match the arguments following the subcommands that
+	// are not flags. A deny rule matches when any argument, or the value
+	// of a --name=value flag, matches one of them; an allow rule only when
+	// every argument does, so that extra arguments cannot ride along on an
+	// allowed command.
+	Args []string
+	// Reason explains why a deny rule blocks a command.
+	Reason string
+}
+
+// PolicyBlocker compiles rules into a [BlockFunc]. The first rule matching
+// a command decides: deny blocks it with the rule's reason, allow lets it
+// run. Commands no rule matches are checked against fallback.
+func PolicyBlocker(rules []PolicyRule, fallback []BlockFunc) (BlockFunc, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		compiled = append(compiled, c)
+	}
+
+	return func(args []string) (bool, string) {
+		if len(args) == 0 {
+			return false, ""
+		}
+		for _, rule := range compiled {
+			if !rule.matches(args) {
+				continue
+			}
+			if rule.Action == PolicyAllow {
+				return false, ""
+			}
+			return true, cmp.Or(rule.Reason, "denied by the command policy")
+		}
+		for _, blockFunc := range fallback {
+			if blocked, reason := blockFunc(args); blocked {
+				return true, reason
+			}
+		}
+		return false, ""
+	}, nil
+}
+
+type compiledRule struct {
+	PolicyRule
+	command     *regexp.Regexp
+	subcommands []*regexp.Regexp
+	flags       []*regexp.Regexp
+	args        []*regexp.Regexp
+}
+
+func compileRule(rule PolicyRule) (compiledRule, error) {
+	switch rule.Action {
+	case PolicyAllow, PolicyDeny:
+	case "":
+		return compiledRule{}, errors.New("missing action")
+	default:
+		return compiledRule{}, fmt.Errorf("unknown action %q", rule.Action)
+	}
+	if rule.Command == "" {
+		return compiledRule{}, errors.New("missing command")
+	}
+	return compiledRule{
+		PolicyRule:  rule,
+		command:     globRegexp(rule.Command),
+		subcommands: globRegexps(rule.Subcommands),
+		flags:       globRegexps(rule.Flags),
+		args:        globRegexps(rule.Args),
+	}, nil
+}
+
+func (r compiledRule) matches(args []string) bool {
+	if !r.command.MatchString(commandName(args[0])) {
+		return false
+	}
+
+	words := policyWords(args[1:])
+	if r.Action == PolicyAllow {
+		return r.allows(words)
+	}
+
+	// A value given to a flag as a separate argument, as in `git -C dir
+	// push`, cannot be told apart from a subcommand without knowing the
+	// flag, so deny rules also try the subcommands after every run of
+	// arguments that could be flag values.
+	for skip := 0; skip+len(r.subcommands) <= len(words.positionals); skip++ {
+		if r.denies(words, skip) {
+			return true
+		}
+		if len(r.subcommands) == 0 || !words.mayBeValue[skip] {
+			break
+		}
+	}
+	return false
+}
+
+func (r compiledRule) denies(words policyArgs, skip int) bool {
+	positionals := words.positionals[skip:]
+	for i, sub := range r.subcommands {
+		if !sub.MatchString(positionals[i]) {
+			return false
+		}
+	}
+	if len(r.flags) > 0 && !slices.ContainsFunc(words.flags, matchesAny(r.flags)) {
+		return false
+	}
+	if len(r.args) == 0 {
+		return true
+	}
+	match := matchesAny(r.args)
+	return slices.ContainsFunc(positionals[len(r.subcommands):], match) ||
+		slices.ContainsFunc(words.values, match)
+}
+
+func (r compiledRule) allows(words policyArgs) bool {
+	if len(words.positionals) < len(r.subcommands) {
+		return false
+	}
+	for i, sub := range r.subcommands {
+		if !sub.MatchString(words.positionals[i]) {
+			return false
+		}
+	}
+
+	flag := matchesAny(r.flags)
+	for _, arg := range words.raw {
+		if flag(arg) {
+			continue
+		}
+		if strings.Contains(arg, "=") || arg[1] == '-' {
+			return false
+		}
+		for _, c := range arg[1:] {
+			if !flag("-" + string(c)) {
+				return false
+			}
+		}
+	}
+	if len(r.args) == 0 {
+		return true
+	}
+
+	match := matchesAny(r.args)
+	return !slices.ContainsFunc(words.positionals[len(r.subcommands):], func(arg string) bool { return !match(arg) })
+}
+
+// policyArgs are the arguments of a command sorted by policyWords.
+type policyArgs struct {
+	// positionals are the arguments that are not flags.
+	positionals []string
+	// mayBeValue reports for each positional whether it directly follows
+	// a flag, and so may be its value rather than an argument.
+	mayBeValue []bool
+	// flags are the flag names, with short flag clusters expanded.
+	flags []string
+	// values are the values of name=value flags.
+	values []string
+	// raw are the flags as written.
+	raw []string
+}
+
+// policyWords sorts the arguments of a command into the ones that are not
+// flags and the flags, expanding short flag clusters and splitting off the
+// values of name=value flags. Arguments after -- are never flags.
+func policyWords(args []string) policyArgs {
+	var words policyArgs
+	afterFlag := false
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			for _, rest := range args[i+1:] {
+				words.positionals = append(words.positionals, rest)
+				words.mayBeValue = append(words.mayBeValue, false)
+			}
+			return words
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			words.positionals = append(words.positionals, arg)
+			words.mayBeValue = append(words.mayBeValue, afterFlag)
+			afterFlag = false
+		default:
+			name, value, hasValue := strings.Cut(arg, "=")
+			words.raw = append(words.raw, arg)
+			words.flags = append(words.flags, name)
+			if hasValue {
+				words.values = append(words.values, value)
+			} else if len(arg) > 2 && arg[1] != '-' {
+				for _, c := range arg[1:] {
+					words.flags = append(words.flags, "-"+string(c))
+				}
+			}
+			afterFlag = !hasValue
+		}
+	}
+	return words
+}
+
+func matchesAny(patterns []*regexp.Regexp) func(string) bool {
+	return func(s string) bool {
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(s)
+		})
+	}
+}
+
+func globRegexps(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		res = append(res, globRegexp(pattern))
+	}
+	return res
+}
+
+// globRegexp compiles a glob in which * matches any text and ? any single
+// character.
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString("(?s:.*)")
+		case '?':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
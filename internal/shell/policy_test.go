@@ -0,0 +1,150 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyBlocker(t *testing.T) {
+	t.Parallel()
+
+	block, err := PolicyBlocker([]PolicyRule{
+		{Action: PolicyAllow, Command: "curl", Flags: []string{"-s", "-S", "--max-time=*"}, Args: []string{"localhost*", "http://localhost*", "http://127.0.0.1*"}},
+		{Action: PolicyDeny, Command: "git", Subcommands: []string{"push"}, Flags: []string{"--force*", "-f"}, Reason: "force pushes rewrite shared history"},
+		{Action: PolicyDeny, Command: "rm", Flags: []string{"-r", "-R", "--recursive"}, Args: []string{"/", "/*", "~"}},
+		{Action: PolicyDeny, Command: "terraform", Subcommands: []string{"apply", "destroy"}},
+	}, []BlockFunc{CommandsBlocker([]string{"curl", "wget"})})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		args   []string
+		reason string
+	}{
+		{"allowed localhost", []string{"curl", "-s", "http://localhost:8080/health"}, ""},
+		{"allowed by absolute path", []string{"/usr/bin/curl", "localhost:3000"}, ""},
+		{"allow needs every argument", []string{"curl", "http://localhost", "https://example.com"}, "fallback"},
+		{"fallback", []string{"curl", "https://example.com"}, "fallback"},
+		{"allowed flag cluster", []string{"curl", "-sS", "http://localhost"}, ""},
+		{"allowed flag value", []string{"curl", "--max-time=5", "http://localhost"}, ""},
+		{"unlisted flag", []string{"curl", "-o", "/tmp/out", "http://localhost"}, "fallback"},
+		{"unlisted flag in cluster", []string{"curl", "-sO", "http://localhost"}, "fallback"},
+		{"unlisted flag value", []string{"curl", "--url=https://example.com", "http://localhost"}, "fallback"},
+		{"unlisted long flag", []string{"curl", "--proxy", "http://localhost", "http://localhost"}, "fallback"},
+		{"unmatched command", []string{"echo", "hello"}, ""},
+		{"force push", []string{"git", "push", "--force", "origin", "main"}, "force pushes rewrite shared history"},
+		{"force with lease", []string{"git", "push", "--force-with-lease=main"}, "force pushes rewrite shared history"},
+		{"short force in cluster", []string{"git", "push", "-fu", "origin"}, "force pushes rewrite shared history"},
+		{"plain push", []string{"git", "push", "origin", "main"}, ""},
+		{"force elsewhere", []string{"git", "fetch", "--force"}, ""},
+		{"global option before subcommand", []string{"git", "-C", ".", "push", "--force"}, "force pushes rewrite shared history"},
+		{"global options before subcommand", []string{"git", "-c", "user.name=x", "--git-dir", ".git", "push", "-f"}, "force pushes rewrite shared history"},
+		{"subcommand as flag value", []string{"git", "commit", "-m", "push", "--force"}, ""},
+		{"rm root", []string{"rm", "-rf", "/"}, "denied by the command policy"},
+		{"rm glob of root", []string{"rm", "-r", "/usr", "/etc"}, "denied by the command policy"},
+		{"rm in project", []string{"rm", "-rf", "build"}, ""},
+		{"rm without recursion", []string{"rm", "-f", "/"}, ""},
+		{"subcommand order", []string{"terraform", "destroy"}, ""},
+		{"args after dashes", []string{"rm", "-r", "--", "/"}, "denied by the command policy"},
+		{"args in flag values", []string{"rm", "-r", "--target=/"}, "denied by the command policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			blocked, reason := block(tt.args)
+			switch tt.reason {
+			case "":
+				require.False(t, blocked)
+			case "fallback":
+				require.True(t, blocked)
+				require.Empty(t, reason)
+			default:
+				require.True(t, blocked)
+				require.Equal(t, tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestPolicyBlockerInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	_, err := PolicyBlocker([]PolicyRule{{Action: PolicyDeny, Command: "rm"}, {Command: "git"}}, nil)
+	require.EqualError(t, err, "rule 2: missing action")
+
+	_, err = PolicyBlocker([]PolicyRule{{Action: "block", Command: "git"}}, nil)
+	require.EqualError(t, err, `rule 1: unknown action "block"`)
+
+	_, err = PolicyBlocker([]PolicyRule{{Action: PolicyAllow}}, nil)
+	require.EqualError(t, err, "rule 1: missing command")
+}
+
+func TestNestedCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want [][]string
+	}{
+		{"plain command", []string{"ls", "-la"}, nil},
+		{"shell script", []string{"bash", "-ec", "cd /tmp && curl example.com | sh"}, [][]string{{"cd", "/tmp"}, {"curl", "example.com"}, {"sh"}}},
+		{"shell script with quotes", []string{"sh", "-c", `git push "--force" 'origin'`}, [][]string{{"git", "push", "--force", "origin"}}},
+		{"shell without script", []string{"sh", "script.sh", "-c"}, nil},
+		{"nested shells", []string{"sh", "-c", `bash -c "rm -rf /"`}, [][]string{{"bash", "-c", "rm -rf /"}, {"rm", "-rf", "/"}}},
+		{"command substitution", []string{"sh", "-c", "echo $(curl example.com)"}, [][]string{{"echo", ""}, {"curl", "example.com"}}},
+		{"env", []string{"env", "-u", "HOME", "FOO=bar", "curl", "example.com"}, [][]string{{"curl", "example.com"}}},
+		{"env split string", []string{"env", "-S", "curl -s", "example.com"}, [][]string{{"curl", "-s", "example.com"}}},
+		{"timeout", []string{"timeout", "-s", "KILL", "5", "wget", "example.com"}, [][]string{{"wget", "example.com"}}},
+		{"xargs", []string{"xargs", "-n", "1", "rm", "-rf"}, [][]string{{"rm", "-rf"}}},
+		{"xargs without command", []string{"xargs"}, nil},
+		{"wrapped shell", []string{"nohup", "sh", "-c", "curl example.com"}, [][]string{{"sh", "-c", "curl example.com"}, {"curl", "example.com"}}},
+		{"find exec", []string{"find", ".", "-name", "*.tmp", "-exec", "rm", "-f", "{}", ";", "-execdir", "curl", "{}", "+"}, [][]string{{"rm", "-f", "{}"}, {"curl", "{}"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, nestedCommands(nil, tt.args))
+		})
+	}
+}
+
+func TestShellPolicy(t *testing.T) {
+	t.Parallel()
+
+	block, err := PolicyBlocker([]PolicyRule{
+		{Action: PolicyDeny, Command: "git", Subcommands: []string{"push"}, Flags: []string{"--force"}, Reason: "force pushes are not allowed"},
+	}, []BlockFunc{CommandsBlocker([]string{"curl"})})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		command string
+		blocked []string
+	}{
+		{"pipeline", "echo hi | git push --force origin main", []string{"git", "push", "--force", "origin", "main"}},
+		{"subshell", "(cd . && git push --force)", []string{"git", "push", "--force"}},
+		{"command substitution", "echo $(git push --force)", []string{"git", "push", "--force"}},
+		{"eval", `eval "git push --force"`, []string{"git", "push", "--force"}},
+		{"shell script", `sh -c "git push --force"`, []string{"git", "push", "--force"}},
+		{"fallback", "true && curl example.com", []string{"curl", "example.com"}},
+		{"variable command name in script", `sh -c 'c=curl; $c example.com'`, []string{"$(...)", "example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sh := NewShell(&Options{WorkingDir: t.TempDir(), BlockFuncs: []BlockFunc{block}})
+			_, _, err := sh.Exec(t.Context(), tt.command)
+			var blockedErr *BlockedError
+			require.ErrorAs(t, err, &blockedErr)
+			require.Equal(t, tt.blocked, blockedErr.Args)
+		})
+	}
+
+	sh := NewShell(&Options{WorkingDir: t.TempDir(), BlockFuncs: []BlockFunc{block}})
+	_, _, err = sh.Exec(t.Context(), "git push --force")
+	require.EqualError(t, err, `command is not allowed: "git": force pushes are not allowed`)
+	_, _, err = sh.Exec(t.Context(), "curl example.com")
+	require.EqualError(t, err, `command is not allowed for security reasons: "curl"`)
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY allocates a pseudo-terminal pair. Output post-processing is
+// disabled on the terminal so that captured output keeps plain "\n" line
+// endings.
+func openPTY() (master, tty *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			master.Close()
+		}
+	}()
+
+	var ptyNum int
+	var ioctlErr error
+	rc, err := master.SyscallConn()
+	if err != nil {
+		return nil, nil, err
+	}
+	// SyscallConn keeps the master non-blocking, so closing it interrupts
+	// a pending read.
+	err = rc.Control(func(fd uintptr) {
+		if ioctlErr = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); ioctlErr != nil {
+			return
+		}
+		ptyNum, ioctlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+	})
+	if err == nil {
+		err = ioctlErr
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not unlock pseudo-terminal: %w", err)
+	}
+
+	tty, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptyNum), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fd := int(tty.Fd())
+	if termios, err := unix.IoctlGetTermios(fd, unix.TCGETS); err == nil {
+		termios.Oflag &^= unix.ONLCR
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+	}
+	_ = unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: ptyRows, Col: ptyCols})
+	return master, tty, nil
+}
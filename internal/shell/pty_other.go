@@ -0,0 +1,14 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"os"
+)
+
+// openPTY is only implemented on Linux; interactive jobs elsewhere read
+// their input from a pipe.
+func openPTY() (master, tty *os.File, err error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported on this platform")
+}
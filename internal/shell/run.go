@@ -96,6 +96,7 @@ func newRunner(cwd string, env []string, stdin io.Reader, stdout, stderr io.Writ
 		interp.Interactive(false),
 		interp.Env(expand.ListEnviron(env...)),
 		interp.Dir(cwd),
+		interp.OpenHandler(sandboxOpenHandler()),
 		execHandlerOption(blockFuncs),
 	)
 }
@@ -175,11 +176,24 @@ func standardHandlers(blockFuncs []BlockFunc) []func(next interp.ExecHandlerFunc
 		blockHandler(blockFuncs),
 	}
 	if useGoCoreUtils {
-		handlers = append(handlers, coreutils.ExecHandler)
+		handlers = append(handlers, coreutilsHandler)
 	}
 	return handlers
 }
 
+// coreutilsHandler runs coreutils in-process unless the command is
+// sandboxed: in-process commands would escape the sandbox, so sandboxed
+// ones always run the system's binaries.
+func coreutilsHandler(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	inProcess := coreutils.ExecHandler(next)
+	return func(ctx context.Context, args []string) error {
+		if sandboxFromContext(ctx) != nil {
+			return next(ctx, args)
+		}
+		return inProcess(ctx, args)
+	}
+}
+
 // builtinHandler returns middleware that dispatches recognized Crush
 // builtins to their in-process Go implementations. Currently: jq.
 func builtinHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
@@ -201,19 +215,35 @@ func builtinHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 
 // blockHandler returns middleware that rejects commands matched by any of
 // the provided [BlockFunc]s before they reach the underlying exec path.
-// A nil or empty blockFuncs slice is a no-op.
+// Commands the exec'd command would run itself, such as the script of
+// `sh -c` or the command of `xargs`, are checked as well; see
+// [nestedCommands]; those whose names are not written out literally are
+// rejected. A nil or empty blockFuncs slice is a no-op.
 func blockHandler(blockFuncs []BlockFunc) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 		return func(ctx context.Context, args []string) error {
-			if len(args) == 0 {
+			if len(args) == 0 || len(blockFuncs) == 0 {
 				return next(ctx, args)
 			}
-			for _, blockFunc := range blockFuncs {
-				if blockFunc(args) {
-					return fmt.Errorf("command is not allowed for security reasons: %q", args[0])
+			for _, cmd := range append([][]string{args}, nestedCommands(interp.HandlerCtx(ctx).Env, args)...) {
+				if err := blockCommand(blockFuncs, cmd); err != nil {
+					return err
 				}
 			}
+			if err := checkLiteralNames(literalNestedCommands(args)); err != nil {
+				return err
+			}
 			return next(ctx, args)
 		}
 	}
 }
+
+// blockCommand returns a [BlockedError] if any of blockFuncs blocks cmd.
+func blockCommand(blockFuncs []BlockFunc, cmd []string) error {
+	for _, blockFunc := range blockFuncs {
+		if blocked, reason := blockFunc(cmd); blocked {
+			return &BlockedError{Args: cmd, Reason: reason}
+		}
+	}
+	return nil
+}
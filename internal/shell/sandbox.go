@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/sandbox"
+	"mvdan.cc/sh/v3/interp"
+)
+
+type sandboxContextKey struct{}
+
+// WithSandbox returns a context under which commands run by [Shell], [Run]
+// and background jobs are confined by policy. External commands run
+// through [sandbox.Policy.Command]; files the interpreter opens itself,
+// such as the targets of redirects, are checked with
+// [sandbox.Policy.CheckOpen].
+func WithSandbox(ctx context.Context, policy *sandbox.Policy) context.Context {
+	if policy == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sandboxContextKey{}, policy)
+}
+
+func sandboxFromContext(ctx context.Context) *sandbox.Policy {
+	policy, _ := ctx.Value(sandboxContextKey{}).(*sandbox.Policy)
+	return policy
+}
+
+// confineCommand rewrites cmd to run in the sandbox carried by ctx, if any.
+func confineCommand(ctx context.Context, cmd *exec.Cmd) error {
+	policy := sandboxFromContext(ctx)
+	if policy == nil {
+		return nil
+	}
+	return policy.Command(cmd)
+}
+
+// checkSandboxOpen reports whether the sandbox carried by ctx, if any,
+// allows the interpreter to open name. Relative names are resolved against
+// the working directory of the process, as os.Open does.
+func checkSandboxOpen(ctx context.Context, name string, write bool) error {
+	policy := sandboxFromContext(ctx)
+	if policy == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	if err := policy.CheckOpen(abs, write); err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return nil
+}
+
+// sandboxOpenHandler returns an interp.OpenHandlerFunc that applies the
+// sandbox carried by the context to files opened by the interpreter.
+// Blocked opens fail like any other open, printing the error and setting
+// the exit status to 1.
+func sandboxOpenHandler() interp.OpenHandlerFunc {
+	open := interp.DefaultOpenHandler()
+	return func(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+		if sandboxFromContext(ctx) != nil && path != "" {
+			name := path
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(interp.HandlerCtx(ctx).Dir, name)
+			}
+			write := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0
+			if err := checkSandboxOpen(ctx, name, write); err != nil {
+				return nil, err
+			}
+		}
+		return open(ctx, path, flag, perm)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/sandbox"
+	"github.com/charmbracelet/crush/internal/sandbox/sandboxtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellSandbox(t *testing.T) {
+	t.Parallel()
+
+	readOnly := sandboxtest.ReadOnlyDir(t)
+	secret := filepath.Join(readOnly, "secret.json")
+	require.NoError(t, os.WriteFile(secret, []byte(`{"token":"s3cret"}`), 0o600))
+
+	work := t.TempDir()
+	policy := sandbox.New(sandbox.Options{WritablePaths: []string{work}, HiddenPaths: []string{secret}})
+	if err := policy.Check(); err != nil {
+		t.Skipf("sandbox not available: %v", err)
+	}
+	ctx := WithSandbox(t.Context(), policy)
+	t.Cleanup(func() {
+		require.NoFileExists(t, filepath.Join(readOnly, "redirect"))
+		require.NoFileExists(t, filepath.Join(readOnly, "touch"))
+		require.NoFileExists(t, filepath.Join(readOnly, "background"))
+	})
+
+	tests := []struct {
+		name    string
+		command string
+		stderr  string
+	}{
+		{"redirect outside writable paths", "echo no > " + filepath.Join(readOnly, "redirect"), "blocked by sandbox: writes are limited to"},
+		{"command writing outside writable paths", "touch " + filepath.Join(readOnly, "touch"), "Permission denied"},
+		{"redirect from hidden path", "cat < " + secret, secret + " is hidden"},
+		{"command reading hidden path", "cat " + secret, "Permission denied"},
+		{"builtin reading hidden path", "jq .token " + secret, secret + " is hidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sh := NewShell(&Options{WorkingDir: work})
+			stdout, stderr, err := sh.Exec(ctx, tt.command)
+			require.NotZero(t, ExitCode(err))
+			require.NotContains(t, stdout, "s3cret")
+			require.Contains(t, stderr, tt.stderr)
+		})
+	}
+
+	t.Run("writable paths", func(t *testing.T) {
+		t.Parallel()
+		sh := NewShell(&Options{WorkingDir: work})
+		_, stderr, err := sh.Exec(ctx, "echo a > redirect && touch touched && mkdir dir")
+		require.NoError(t, err, stderr)
+		require.FileExists(t, filepath.Join(work, "redirect"))
+		require.FileExists(t, filepath.Join(work, "touched"))
+		require.DirExists(t, filepath.Join(work, "dir"))
+	})
+
+	t.Run("background job", func(t *testing.T) {
+		t.Parallel()
+		bgManager := GetBackgroundShellManager()
+		bgShell, err := bgManager.Start(ctx, work, nil, "touch "+filepath.Join(readOnly, "background"), "")
+		require.NoError(t, err)
+		defer bgManager.Kill(bgShell.ID)
+
+		bgShell.Wait()
+		_, stderr, _, err := bgShell.GetOutput()
+		require.NotZero(t, ExitCode(err))
+		require.Contains(t, stderr, "Permission denied")
+	})
+}
@@ -58,8 +58,25 @@ type noopLogger struct{}
 
 func (noopLogger) InfoPersist(msg string, keysAndValues ...any) {}
 
-// BlockFunc is a function that determines if a command should be blocked
-type BlockFunc func(args []string) bool
+// BlockFunc is a function that determines if a command should be blocked.
+// The reason, if not empty, is reported in the resulting [BlockedError].
+type BlockFunc func(args []string) (blocked bool, reason string)
+
+// BlockedError is returned when a [BlockFunc] blocks a command.
+type BlockedError struct {
+	// Args is the blocked command, which may be one nested in the
+	// command line that was run, such as the script of `sh -c`.
+	Args []string
+	// Reason explains the block, if the BlockFunc gave a reason.
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("command is not allowed for security reasons: %q", e.Args[0])
+	}
+	return fmt.Sprintf("command is not allowed: %q: %s", e.Args[0], e.Reason)
+}
 
 // Shell provides cross-platform shell execution with optional state persistence
 type Shell struct {
@@ -68,6 +85,7 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	external   *ExternalShell
 }
 
 // Options for creating a new shell
@@ -76,6 +94,9 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	// External, if set, runs commands through an external shell instead
+	// of the built-in interpreter.
+	External *ExternalShell
 }
 
 // NewShell creates a new shell instance with the given options
@@ -94,8 +115,10 @@ func NewShell(opts *Options) *Shell {
 		env = os.Environ()
 	}
 
-	// Allow tools to detect execution by Crush.
-	env = append(env, CrushEnvMarkers()...)
+	// Allow tools to detect execution by Crush. The shell rewrites its
+	// environment in place after each command, so it must not share the
+	// backing array of opts.Env.
+	env = slices.Concat(env, CrushEnvMarkers())
 
 	logger := opts.Logger
 	if logger == nil {
@@ -107,6 +130,7 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		external:   opts.External,
 	}
 }
 
@@ -126,6 +150,15 @@ func (s *Shell) ExecStream(ctx context.Context, command string, stdout, stderr i
 	return s.execStream(ctx, command, stdout, stderr)
 }
 
+// ExecStreamInput is like ExecStream but reads the command's standard input
+// from stdin.
+func (s *Shell) ExecStreamInput(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.execCommon(ctx, command, stdin, stdout, stderr)
+}
+
 // GetWorkingDir returns the current working directory
 func (s *Shell) GetWorkingDir() string {
 	s.mu.Lock()
@@ -157,6 +190,34 @@ func (s *Shell) GetEnv() []string {
 	return env
 }
 
+// EnvChanges returns the variables of env that are not in base or have a
+// different value there, sorted. The variables Crush sets on every shell,
+// and PWD and OLDPWD, are left out. Variables unset in env are not
+// reported.
+func EnvChanges(base, env []string) []string {
+	skip := map[string]bool{"PWD": true, "OLDPWD": true}
+	for _, kv := range slices.Concat(CrushEnvMarkers(), nonInteractiveEnvVars) {
+		key, _, _ := strings.Cut(kv, "=")
+		skip[key] = true
+	}
+	baseVars := make(map[string]string, len(base))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		baseVars[key] = kv
+	}
+
+	var changes []string
+	for _, kv := range env {
+		key, _, ok := strings.Cut(kv, "=")
+		if !ok || skip[key] || baseVars[key] == kv {
+			continue
+		}
+		changes = append(changes, kv)
+	}
+	slices.Sort(changes)
+	return changes
+}
+
 // SetEnv sets an environment variable
 func (s *Shell) SetEnv(key, value string) {
 	s.mu.Lock()
@@ -187,31 +248,31 @@ func CommandsBlocker(cmds []string) BlockFunc {
 		bannedSet[cmd] = struct{}{}
 	}
 
-	return func(args []string) bool {
+	return func(args []string) (bool, string) {
 		if len(args) == 0 {
-			return false
+			return false, ""
 		}
 		_, ok := bannedSet[args[0]]
-		return ok
+		return ok, ""
 	}
 }
 
 // ArgumentsBlocker creates a BlockFunc that blocks specific subcommand
 func ArgumentsBlocker(cmd string, args []string, flags []string) BlockFunc {
-	return func(parts []string) bool {
+	return func(parts []string) (bool, string) {
 		if len(parts) == 0 || parts[0] != cmd {
-			return false
+			return false, ""
 		}
 
 		argParts, flagParts := splitArgsFlags(parts[1:])
 		if len(argParts) < len(args) || len(flagParts) < len(flags) {
-			return false
+			return false, ""
 		}
 
 		argsMatch := slices.Equal(argParts[:len(args)], args)
 		flagsMatch := slice.IsSubset(flags, flagParts)
 
-		return argsMatch && flagsMatch
+		return argsMatch && flagsMatch, ""
 	}
 }
 
@@ -251,7 +312,14 @@ func (s *Shell) updateShellFromRunner(runner *interp.Runner) {
 }
 
 // execCommon is the shared implementation for executing commands
-func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr io.Writer) (err error) {
+func (s *Shell) execCommon(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+	if s.external != nil {
+		defer func() {
+			s.logger.InfoPersist("command finished", "command", command, "err", err)
+		}()
+		return s.execExternal(ctx, command, stdin, stdout, stderr)
+	}
+
 	var runner *interp.Runner
 	defer func() {
 		if r := recover(); r != nil {
@@ -268,7 +336,7 @@ func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr i
 		return fmt.Errorf("could not parse command: %w", err)
 	}
 
-	runner, err = s.newInterp(nil, stdout, stderr)
+	runner, err = s.newInterp(stdin, stdout, stderr)
 	if err != nil {
 		return fmt.Errorf("could not run command: %w", err)
 	}
@@ -280,13 +348,13 @@ func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr i
 // exec executes commands using a cross-platform shell interpreter.
 func (s *Shell) exec(ctx context.Context, command string) (string, string, error) {
 	var stdout, stderr bytes.Buffer
-	err := s.execCommon(ctx, command, &stdout, &stderr)
+	err := s.execCommon(ctx, command, nil, &stdout, &stderr)
 	return stdout.String(), stderr.String(), err
 }
 
 // execStream executes commands using POSIX shell emulation with streaming output
 func (s *Shell) execStream(ctx context.Context, command string, stdout, stderr io.Writer) error {
-	return s.execCommon(ctx, command, stdout, stderr)
+	return s.execCommon(ctx, command, nil, stdout, stderr)
 }
 
 // IsInterrupt checks if an error is due to interruption
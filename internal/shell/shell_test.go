@@ -4,9 +4,12 @@ import (
 	"context"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 // Benchmark to measure CPU efficiency
@@ -118,3 +121,19 @@ func TestCrossPlatformExecution(t *testing.T) {
 		t.Errorf("Echo output should contain 'hello', got: %q", stdout)
 	}
 }
+
+func TestEnvChanges(t *testing.T) {
+	t.Parallel()
+
+	base := []string{"HOME=/home/user", "PATH=/usr/bin", "EDITOR=nvim"}
+	env := slices.Concat([]string{
+		"HOME=/home/user",
+		"PATH=/opt/bin:/usr/bin",
+		"GOFLAGS=-mod=mod",
+		"PWD=/tmp",
+		"OLDPWD=/home/user",
+	}, nonInteractiveEnvVars, CrushEnvMarkers())
+
+	require.Equal(t, []string{"GOFLAGS=-mod=mod", "PATH=/opt/bin:/usr/bin"}, EnvChanges(base, env))
+	require.Empty(t, EnvChanges(env, env))
+}
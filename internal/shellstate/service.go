@@ -0,0 +1,137 @@
+// Package shellstate persists the working directory and exported variables
+// of the bash tool's shell per session, so that they survive restarts.
+package shellstate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+// DefaultExcludeEnv are the patterns of the names of variables that are
+// never persisted because they likely hold secrets.
+var DefaultExcludeEnv = []string{
+	"*TOKEN*",
+	"*SECRET*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*CREDENTIAL*",
+	"*API_KEY*",
+	"*APIKEY*",
+	"*PRIVATE_KEY*",
+	"*ACCESS_KEY*",
+	"*AUTH*",
+}
+
+// State is the shell state of a session.
+type State struct {
+	SessionID string `json:"session_id"`
+	// WorkingDir is the directory the last command left the shell in, or
+	// empty if no state was saved.
+	WorkingDir string `json:"working_dir"`
+	// Env holds the NAME=value variables the agent's commands exported.
+	Env       []string  `json:"env"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsZero reports whether no state was saved.
+func (s State) IsZero() bool {
+	return s.WorkingDir == "" && len(s.Env) == 0
+}
+
+// Service stores the shell state of sessions.
+type Service interface {
+	// Get returns the shell state of a session, which is zero if none was
+	// saved.
+	Get(ctx context.Context, sessionID string) (State, error)
+
+	// Save replaces the shell state of a session.
+	Save(ctx context.Context, state State) error
+
+	// Reset forgets the shell state of a session.
+	Reset(ctx context.Context, sessionID string) error
+}
+
+type service struct {
+	q *db.Queries
+}
+
+// NewService creates a new shell state service.
+func NewService(q *db.Queries) Service {
+	return &service{q: q}
+}
+
+// Get returns the shell state of a session, which is zero if none was
+// saved.
+func (s *service) Get(ctx context.Context, sessionID string) (State, error) {
+	row, err := s.q.GetShellState(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return State{SessionID: sessionID}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("getting shell state: %w", err)
+	}
+
+	var env []string
+	if err := json.Unmarshal([]byte(row.Env), &env); err != nil {
+		return State{}, fmt.Errorf("decoding shell state environment: %w", err)
+	}
+	return State{
+		SessionID:  row.SessionID,
+		WorkingDir: row.WorkingDir,
+		Env:        env,
+		UpdatedAt:  time.Unix(row.UpdatedAt, 0),
+	}, nil
+}
+
+// Save replaces the shell state of a session.
+func (s *service) Save(ctx context.Context, state State) error {
+	if state.Env == nil {
+		state.Env = []string{}
+	}
+	env, err := json.Marshal(state.Env)
+	if err != nil {
+		return fmt.Errorf("encoding shell state environment: %w", err)
+	}
+	if err := s.q.UpsertShellState(ctx, db.UpsertShellStateParams{
+		SessionID:  state.SessionID,
+		WorkingDir: state.WorkingDir,
+		Env:        string(env),
+	}); err != nil {
+		return fmt.Errorf("saving shell state: %w", err)
+	}
+	return nil
+}
+
+// Reset forgets the shell state of a session.
+func (s *service) Reset(ctx context.Context, sessionID string) error {
+	if err := s.q.DeleteShellState(ctx, sessionID); err != nil {
+		return fmt.Errorf("resetting shell state: %w", err)
+	}
+	return nil
+}
+
+// FilterEnv returns the NAME=value variables of env whose names match none
+// of the patterns in exclude. Patterns are globs as in [path.Match],
+// matched case-insensitively.
+func FilterEnv(env, exclude []string) []string {
+	var kept []string
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if !slices.ContainsFunc(exclude, func(pattern string) bool {
+			ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(name))
+			return ok
+		}) {
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,100 @@
+package shellstate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTest(t *testing.T) (*db.Queries, Service) {
+	t.Helper()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	return q, NewService(q)
+}
+
+func createSession(t *testing.T, q *db.Queries, sessionID string) {
+	t.Helper()
+	_, err := q.CreateSession(t.Context(), db.CreateSessionParams{
+		ID:    sessionID,
+		Title: "Test Session",
+	})
+	require.NoError(t, err)
+}
+
+func TestService_SaveGetReset(t *testing.T) {
+	t.Parallel()
+
+	q, svc := setupTest(t)
+	createSession(t, q, "session-1")
+	createSession(t, q, "session-2")
+
+	state, err := svc.Get(t.Context(), "session-1")
+	require.NoError(t, err)
+	require.True(t, state.IsZero())
+	require.Equal(t, "session-1", state.SessionID)
+
+	require.NoError(t, svc.Save(t.Context(), State{
+		SessionID:  "session-1",
+		WorkingDir: "/project/sub",
+		Env:        []string{"GOFLAGS=-mod=mod"},
+	}))
+	require.NoError(t, svc.Save(t.Context(), State{SessionID: "session-2", WorkingDir: "/other"}))
+
+	state, err = svc.Get(t.Context(), "session-1")
+	require.NoError(t, err)
+	require.Equal(t, "/project/sub", state.WorkingDir)
+	require.Equal(t, []string{"GOFLAGS=-mod=mod"}, state.Env)
+	require.WithinDuration(t, time.Now(), state.UpdatedAt, 2*time.Second)
+
+	require.NoError(t, svc.Save(t.Context(), State{SessionID: "session-1", WorkingDir: "/project"}))
+	state, err = svc.Get(t.Context(), "session-1")
+	require.NoError(t, err)
+	require.Equal(t, "/project", state.WorkingDir)
+	require.Empty(t, state.Env)
+
+	require.NoError(t, svc.Reset(t.Context(), "session-1"))
+	state, err = svc.Get(t.Context(), "session-1")
+	require.NoError(t, err)
+	require.True(t, state.IsZero())
+
+	state, err = svc.Get(t.Context(), "session-2")
+	require.NoError(t, err)
+	require.Equal(t, "/other", state.WorkingDir)
+}
+
+func TestService_DeletedWithSession(t *testing.T) {
+	t.Parallel()
+
+	q, svc := setupTest(t)
+	createSession(t, q, "session-1")
+	require.NoError(t, svc.Save(t.Context(), State{SessionID: "session-1", WorkingDir: "/project"}))
+
+	require.NoError(t, q.DeleteSession(t.Context(), "session-1"))
+	state, err := svc.Get(t.Context(), "session-1")
+	require.NoError(t, err)
+	require.True(t, state.IsZero())
+}
+
+func TestFilterEnv(t *testing.T) {
+	t.Parallel()
+
+	env := []string{
+		"GOFLAGS=-mod=mod",
+		"GITHUB_TOKEN=ghp_secret",
+		"db_password=hunter2",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"OPENAI_API_KEY=sk-secret",
+		"NODE_ENV=test",
+		"MY_CERT=cert",
+	}
+	require.Equal(t, []string{"GOFLAGS=-mod=mod", "NODE_ENV=test", "MY_CERT=cert"}, FilterEnv(env, DefaultExcludeEnv))
+	require.Equal(t, []string{"GOFLAGS=-mod=mod", "NODE_ENV=test"}, FilterEnv(env, append(DefaultExcludeEnv, "my_*")))
+	require.Equal(t, env, FilterEnv(env, nil))
+}
@@ -0,0 +1,429 @@
+package skills
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// AllowedTools is the allowed-tools field of a skill: the tools it may
+// use without asking for permission while it is loaded. The spec writes
+// it as a space-delimited string such as "Bash(git:*) Read"; a YAML list
+// of rules is accepted too.
+type AllowedTools []string
+
+// UnmarshalYAML implements [yaml.Unmarshaler].
+func (a *AllowedTools) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*a = splitAllowedTools(node.Value)
+	case yaml.SequenceNode:
+		var rules []string
+		if err := node.Decode(&rules); err != nil {
+			return err
+		}
+		*a = nil
+		for _, rule := range rules {
+			if rule = strings.TrimSpace(rule); rule != "" {
+				*a = append(*a, rule)
+			}
+		}
+	default:
+		return errors.New("allowed-tools must be a string or a list")
+	}
+	return nil
+}
+
+// splitAllowedTools splits rules on spaces and commas outside of
+// parentheses, so that "Bash(git log:*) Read" is two rules.
+func splitAllowedTools(s string) []string {
+	return splitOutside(s, '(', ')')
+}
+
+// splitOutside splits s on spaces and commas that are not between open and
+// close.
+func splitOutside(s string, open, close rune) []string {
+	var rules []string
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == open:
+			depth++
+		case r == close && depth > 0:
+			depth--
+		case depth == 0 && (r == ',' || unicode.IsSpace(r)):
+			if b.Len() > 0 {
+				rules = append(rules, b.String())
+				b.Reset()
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		rules = append(rules, b.String())
+	}
+	return rules
+}
+
+// toolAliases maps the tool names other agents use in allowed-tools to
+// the names of the Crush tools doing the same.
+var toolAliases = map[string]string{
+	"read":         "view",
+	"webfetch":     "fetch",
+	"websearch":    "web_search",
+	"notebookedit": "notebook_edit",
+	"todowrite":    "todos",
+	"task":         "agent",
+}
+
+// ToolRule is a parsed allowed-tools rule: a tool name, and optionally a
+// pattern its input must match, as in Bash(git:*).
+type ToolRule struct {
+	Tool    string
+	Pattern string
+}
+
+// ParseToolRule parses an allowed-tools rule.
+func ParseToolRule(rule string) ToolRule {
+	name, pattern, _ := strings.Cut(strings.TrimSpace(rule), "(")
+	pattern, _ = strings.CutSuffix(pattern, ")")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := toolAliases[name]; ok {
+		name = alias
+	}
+	return ToolRule{Tool: name, Pattern: strings.TrimSpace(pattern)}
+}
+
+// String returns the rule in the allowed-tools syntax, with the Crush name
+// of its tool.
+func (r ToolRule) String() string {
+	if r.Pattern == "" {
+		return r.Tool
+	}
+	return r.Tool + "(" + r.Pattern + ")"
+}
+
+// ToolRules returns the parsed allowed-tools rules of the skill.
+func (s *Skill) ToolRules() []ToolRule {
+	rules := make([]ToolRule, 0, len(s.AllowedTools))
+	for _, rule := range s.AllowedTools {
+		rules = append(rules, ParseToolRule(rule))
+	}
+	return rules
+}
+
+// ToolCall is a tool call checked against the allowed tools of the loaded
+// skills by [CheckTool].
+type ToolCall struct {
+	Name string
+	// Input is the JSON input of the call.
+	Input string
+	// WorkingDir resolves the relative paths of the call.
+	WorkingDir string
+}
+
+// ToolDecision is the outcome of [CheckTool].
+type ToolDecision int
+
+const (
+	// ToolUnaffected leaves the call to the usual permission checks.
+	ToolUnaffected ToolDecision = iota
+	// ToolGranted pre-approves the call.
+	ToolGranted
+	// ToolRefused refuses the call, which a strict skill does not allow.
+	ToolRefused
+)
+
+// CheckTool decides a tool call against the skills loaded in a session.
+// A call is granted when one of them lists it in allowed-tools, or when
+// it runs the skill's own scripts or reads its own files. Other calls are
+// refused while a strict skill is loaded, except for reading SKILL.md
+// files so that another skill can be loaded. The skill returned is the
+// one that granted or refused the call.
+func CheckTool(loaded []*Skill, call ToolCall) (ToolDecision, *Skill) {
+	if len(loaded) == 0 {
+		return ToolUnaffected, nil
+	}
+	input := toolInput(call.Input)
+	for _, skill := range loaded {
+		if skill.grants(call, input) {
+			return ToolGranted, skill
+		}
+	}
+	if call.Name == "view" && (filepath.Base(input.path) == SkillFileName || strings.HasPrefix(input.path, BuiltinPrefix)) {
+		return ToolUnaffected, nil
+	}
+	for _, skill := range loaded {
+		if skill.Strict {
+			return ToolRefused, skill
+		}
+	}
+	return ToolUnaffected, nil
+}
+
+// callInput holds the fields of tool inputs rules are matched against.
+type callInput struct {
+	command string
+	path    string
+}
+
+func toolInput(input string) callInput {
+	var fields struct {
+		Command      string `json:"command"`
+		FilePath     string `json:"file_path"`
+		Path         string `json:"path"`
+		NotebookPath string `json:"notebook_path"`
+		URL          string `json:"url"`
+	}
+	_ = json.Unmarshal([]byte(input), &fields)
+	return callInput{
+		command: fields.Command,
+		path:    cmp.Or(fields.FilePath, fields.Path, fields.NotebookPath, fields.URL),
+	}
+}
+
+func (s *Skill) grants(call ToolCall, input callInput) bool {
+	if call.Name == "bash" {
+		cmds, ok := bashCommands(input.command)
+		if !ok || len(cmds) == 0 {
+			return false
+		}
+		rules := s.ToolRules()
+		for _, cmd := range cmds {
+			if !s.isOwnScript(cmd, call.WorkingDir) && !slices.ContainsFunc(rules, func(r ToolRule) bool {
+				return r.Tool == "bash" && r.matchesCommand(cmd)
+			}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch call.Name {
+	case "view", "ls", "glob", "grep":
+		if s.contains(input.path, call.WorkingDir) {
+			return true
+		}
+	}
+	for _, rule := range s.ToolRules() {
+		if rule.Tool != call.Name {
+			continue
+		}
+		if rule.Pattern == "" || globMatch(rule.Pattern, input.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesCommand matches the words of a simple command: a pattern ending
+// in :* matches commands starting with the words before it, and any other
+// pattern is a glob over the whole command.
+func (r ToolRule) matchesCommand(words []string) bool {
+	if r.Pattern == "" {
+		return true
+	}
+	command := strings.Join(words, " ")
+	if prefix, ok := strings.CutSuffix(r.Pattern, ":*"); ok {
+		return command == prefix || strings.HasPrefix(command, prefix+" ")
+	}
+	return globMatch(r.Pattern, command)
+}
+
+// scriptInterpreters are the programs that run the script given as their
+// first argument.
+var scriptInterpreters = []string{"sh", "bash", "zsh", "python", "python3", "node", "deno", "bun", "ruby", "perl", "php", "uv", "tsx"}
+
+// inlineCodeFlags are the short flags with which interpreters run code
+// given on the command line or load code from elsewhere, such as python -c,
+// node -e or perl -M.
+const inlineCodeFlags = "ceEmMpr"
+
+// inlineCodeLongFlags are the long flags with which interpreters run code
+// given on the command line or load code from elsewhere.
+var inlineCodeLongFlags = []string{"--command", "--eval", "--print", "--require", "--import", "--loader", "--experimental-loader", "--preload"}
+
+// isOwnScript reports whether the simple command runs a script of the
+// skill, directly or through an interpreter. Commands passing the
+// interpreter code inline are refused, whatever script they name.
+func (s *Skill) isOwnScript(words []string, workingDir string) bool {
+	if s.Builtin || s.Path == "" {
+		return false
+	}
+	if s.contains(words[0], workingDir) {
+		return true
+	}
+	if !slices.Contains(scriptInterpreters, filepath.Base(words[0])) {
+		return false
+	}
+	for _, arg := range words[1:] {
+		switch {
+		case arg == "--", arg == "run" && filepath.Base(words[0]) == "uv":
+		case strings.HasPrefix(arg, "-"):
+			if isInlineCodeFlag(arg) {
+				return false
+			}
+		default:
+			return s.contains(arg, workingDir)
+		}
+	}
+	return false
+}
+
+// isInlineCodeFlag reports whether the interpreter flag arg, possibly
+// grouped with other short flags as in -uc, runs or loads code other than
+// the script.
+func isInlineCodeFlag(arg string) bool {
+	if strings.HasPrefix(arg, "--") {
+		name, _, _ := strings.Cut(arg, "=")
+		return slices.Contains(inlineCodeLongFlags, name)
+	}
+	return strings.ContainsAny(arg[1:], inlineCodeFlags)
+}
+
+// contains reports whether path is in the directory of the skill.
+func (s *Skill) contains(path, workingDir string) bool {
+	if s.Builtin || s.Path == "" || path == "" {
+		return false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	rel, err := filepath.Rel(s.Path, filepath.Clean(path))
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// bashCommands returns the words of the simple commands of a script. It
+// reports false for scripts it cannot vouch for: those that do not parse,
+// redirect output to files, define functions, or assign variables, since a
+// variable such as NODE_OPTIONS or GIT_SSH_COMMAND changes what a command
+// runs.
+func bashCommands(script string) ([][]string, bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, false
+	}
+	var cmds [][]string
+	ok := true
+	printer := syntax.NewPrinter()
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Stmt:
+			for _, redir := range node.Redirs {
+				if redir.Op != syntax.RdrIn && !(redir.Op == syntax.DplOut && isFd(redir.Word)) && !isDevNull(redir.Word) {
+					ok = false
+				}
+			}
+		case *syntax.FuncDecl, *syntax.DeclClause:
+			ok = false
+		case *syntax.CallExpr:
+			if len(node.Assigns) > 0 {
+				ok = false
+			}
+			if len(node.Args) == 0 {
+				return true
+			}
+			words := make([]string, 0, len(node.Args))
+			for _, word := range node.Args {
+				words = append(words, wordText(printer, word))
+			}
+			cmds = append(cmds, words)
+		}
+		return ok
+	})
+	return cmds, ok
+}
+
+func isDevNull(word *syntax.Word) bool {
+	return word != nil && word.Lit() == "/dev/null"
+}
+
+// isFd reports whether word names a file descriptor, as in 2>&1 or >&-,
+// rather than a file, as in >&out.txt.
+func isFd(word *syntax.Word) bool {
+	if word == nil {
+		return false
+	}
+	lit := word.Lit()
+	if lit == "-" {
+		return true
+	}
+	return lit != "" && strings.Trim(lit, "0123456789") == ""
+}
+
+// wordText returns the text of a word with its quotes removed, or its
+// source for words that are not literal.
+func wordText(printer *syntax.Printer, word *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					b.WriteString(lit.Value)
+				} else {
+					_ = printer.Print(&b, inner)
+				}
+			}
+		default:
+			_ = printer.Print(&b, part)
+		}
+	}
+	return b.String()
+}
+
+// globMatch matches s against a glob in which * matches any text,
+// including slashes, and ? a single character.
+func globMatch(pattern, s string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString("(?s:.*)")
+		case '?':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+// describeRules lists rules for messages.
+func describeRules(rules []ToolRule) string {
+	if len(rules) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(rules))
+	for _, r := range rules {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RefusalMessage explains to the model why a strict skill refused a tool
+// call.
+func (s *Skill) RefusalMessage(tool string) string {
+	return fmt.Sprintf("Tool %q is not allowed while the strict skill %q is active. Allowed tools: %s.", tool, s.Name, describeRules(s.ToolRules()))
+}
@@ -0,0 +1,137 @@
+package skills
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAllowedTools(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    AllowedTools
+	}{
+		{"spec string", "allowed-tools: Bash(git log:*) Read, Grep", AllowedTools{"Bash(git log:*)", "Read", "Grep"}},
+		{"list", "allowed-tools:\n  - Bash(npm run test)\n  - edit", AllowedTools{"Bash(npm run test)", "edit"}},
+		{"missing", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			skill, err := ParseContent([]byte("---\nname: s\ndescription: d\n" + tt.content + "\n---\n"))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, skill.AllowedTools)
+		})
+	}
+
+	_, err := ParseContent([]byte("---\nname: s\ndescription: d\nallowed-tools:\n  bash: true\n---\n"))
+	require.ErrorContains(t, err, "allowed-tools must be a string or a list")
+}
+
+func TestParseToolRule(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ToolRule{Tool: "bash", Pattern: "git:*"}, ParseToolRule("Bash(git:*)"))
+	require.Equal(t, ToolRule{Tool: "view"}, ParseToolRule("Read"))
+	require.Equal(t, ToolRule{Tool: "web_fetch"}, ParseToolRule("web_fetch"))
+	require.Equal(t, "bash(git:*)", ParseToolRule("Bash(git:*)").String())
+}
+
+func TestCheckTool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	skillDir := filepath.Join(dir, "skills", "release")
+	release := &Skill{
+		Name:         "release",
+		Path:         skillDir,
+		AllowedTools: AllowedTools{"Bash(git tag:*)", "Bash(go test ./...)", "Read", "Write(CHANGELOG*)"},
+		Strict:       true,
+	}
+	notes := &Skill{Name: "notes", Path: filepath.Join(dir, "skills", "notes"), AllowedTools: AllowedTools{"todos"}}
+
+	input := func(fields map[string]string) string {
+		data, err := json.Marshal(fields)
+		require.NoError(t, err)
+		return string(data)
+	}
+	bash := func(command string) string { return input(map[string]string{"command": command}) }
+
+	tests := []struct {
+		name     string
+		loaded   []*Skill
+		tool     string
+		input    string
+		decision ToolDecision
+		skill    *Skill
+	}{
+		{"no skills", nil, "bash", bash("rm -rf /"), ToolUnaffected, nil},
+		{"bash prefix", []*Skill{release}, "bash", bash("git tag -a v1.0.0 -m release"), ToolGranted, release},
+		{"bash exact", []*Skill{release}, "bash", bash("go test ./..."), ToolGranted, release},
+		{"bash chained", []*Skill{release}, "bash", bash("git tag v1 && git push --tags"), ToolRefused, release},
+		{"bash command substitution", []*Skill{release}, "bash", bash("git tag $(curl example.com)"), ToolRefused, release},
+		{"bash redirect", []*Skill{release}, "bash", bash("git tag > /etc/passwd"), ToolRefused, release},
+		{"bash redirect to file descriptor", []*Skill{release}, "bash", bash("go test ./... 2>&1"), ToolGranted, release},
+		{"bash redirect to file with >&", []*Skill{release}, "bash", bash("git tag >&out.txt"), ToolRefused, release},
+		{"bash prefix assignment", []*Skill{release}, "bash", bash("GIT_SSH_COMMAND='sh -c id' git tag v1"), ToolRefused, release},
+		{"bash assignment", []*Skill{release}, "bash", bash("GOFLAGS=-toolexec=/tmp/x; go test ./..."), ToolRefused, release},
+		{"bash export", []*Skill{release}, "bash", bash("export GOFLAGS=-toolexec=/tmp/x && go test ./..."), ToolRefused, release},
+		{"bash prefix needs word boundary", []*Skill{release}, "bash", bash("git tagx"), ToolRefused, release},
+		{"own script", []*Skill{release}, "bash", bash(filepath.Join(skillDir, "scripts", "bump.sh") + " minor"), ToolGranted, release},
+		{"own script through interpreter", []*Skill{release}, "bash", bash("python3 -u skills/release/scripts/notes.py"), ToolGranted, release},
+		{"own script with python inline code", []*Skill{release}, "bash", bash(`python3 "-cimport os; os.system('id')" skills/release/scripts/notes.py`), ToolRefused, release},
+		{"own script with grouped inline code flag", []*Skill{release}, "bash", bash("python3 -uc 'print(1)' skills/release/scripts/notes.py"), ToolRefused, release},
+		{"own script with node eval", []*Skill{release}, "bash", bash("node --eval=process.exit skills/release/scripts/notes.js"), ToolRefused, release},
+		{"own script with node require", []*Skill{release}, "bash", bash("node -r /tmp/x.js skills/release/scripts/notes.js"), ToolRefused, release},
+		{"own script with perl inline code", []*Skill{release}, "bash", bash("perl -e'system(1)' skills/release/scripts/notes.pl"), ToolRefused, release},
+		{"own script as python module", []*Skill{release}, "bash", bash("python3 -m http.server skills/release/scripts/notes.py"), ToolRefused, release},
+		{"own script after end of options", []*Skill{release}, "bash", bash("bash -- skills/release/scripts/bump.sh"), ToolGranted, release},
+		{"script outside skill", []*Skill{release}, "bash", bash("python3 skills/release/../evil.py"), ToolRefused, release},
+		{"tool without pattern", []*Skill{release}, "view", input(map[string]string{"file_path": "main.go"}), ToolGranted, release},
+		{"tool pattern", []*Skill{release}, "write", input(map[string]string{"file_path": "CHANGELOG.md"}), ToolGranted, release},
+		{"tool pattern mismatch", []*Skill{release}, "write", input(map[string]string{"file_path": "main.go"}), ToolRefused, release},
+		{"own files", []*Skill{release}, "ls", input(map[string]string{"path": skillDir}), ToolGranted, release},
+		{"other skill file", []*Skill{release}, "view", input(map[string]string{"file_path": "/x/other/SKILL.md"}), ToolGranted, release},
+		{"granted by another skill", []*Skill{notes, release}, "todos", "{}", ToolGranted, notes},
+		{"not strict", []*Skill{notes}, "edit", input(map[string]string{"file_path": "main.go"}), ToolUnaffected, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			decision, skill := CheckTool(tt.loaded, ToolCall{Name: tt.tool, Input: tt.input, WorkingDir: dir})
+			require.Equal(t, tt.decision, decision)
+			require.Equal(t, tt.skill, skill)
+		})
+	}
+
+	strictNoView := &Skill{Name: "locked", Path: skillDir, AllowedTools: AllowedTools{"todos"}, Strict: true}
+	decision, _ := CheckTool([]*Skill{strictNoView}, ToolCall{Name: "view", Input: input(map[string]string{"file_path": "/x/other/SKILL.md"})})
+	require.Equal(t, ToolUnaffected, decision)
+	require.Equal(t, `Tool "edit" is not allowed while the strict skill "locked" is active. Allowed tools: todos.`, strictNoView.RefusalMessage("edit"))
+}
+
+func TestTracker_SessionSkills(t *testing.T) {
+	t.Parallel()
+
+	goDoc := &Skill{Name: "go-doc"}
+	bash := &Skill{Name: "bash"}
+	tracker := NewTracker([]*Skill{goDoc, bash})
+	tracker.MarkLoadedInSession("a", "go-doc")
+	tracker.MarkLoadedInSession("a", "bash")
+	tracker.MarkLoadedInSession("b", "unknown")
+
+	require.Equal(t, []*Skill{bash, goDoc}, tracker.SessionSkills("a"))
+	require.Empty(t, tracker.SessionSkills("b"))
+	require.True(t, tracker.IsLoaded("go-doc"))
+
+	tracker.SetActive([]*Skill{bash})
+	require.Equal(t, []*Skill{bash}, tracker.SessionSkills("a"))
+
+	var nilTracker *Tracker
+	require.Nil(t, nilTracker.SessionSkills("a"))
+}
@@ -0,0 +1,698 @@
+package skills
+
+import (
+	"archive/tar"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LockFileName is the file recording the skills installed in a skills
+// directory, next to them.
+const LockFileName = "skills-lock.json"
+
+// InstallSourceType is the kind of source a skill is installed from.
+type InstallSourceType string
+
+const (
+	// InstallFromGit is a git repository, pinned to the commit it was at.
+	InstallFromGit InstallSourceType = "git"
+	// InstallFromArchive is a .tar.gz archive, by URL or path, pinned to the
+	// digest of the archive.
+	InstallFromArchive InstallSourceType = "archive"
+	// InstallFromPath is a local directory, pinned to the digest of the files
+	// of the skill.
+	InstallFromPath InstallSourceType = "path"
+)
+
+// LockedSkill records where an installed skill came from.
+type LockedSkill struct {
+	Source string            `json:"source"`
+	Type   InstallSourceType `json:"type"`
+	// Ref is the git branch, tag or commit asked for, if any.
+	Ref string `json:"ref,omitempty"`
+	// Subdir is the directory of the skill within the source.
+	Subdir      string    `json:"subdir,omitempty"`
+	Revision    string    `json:"revision"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// LockFile is the content of a [LockFileName] file.
+type LockFile struct {
+	Version int                    `json:"version"`
+	Skills  map[string]LockedSkill `json:"skills"`
+}
+
+const lockFileVersion = 1
+
+const (
+	// maxArchiveSize is the most an archive may extract to, and
+	// maxArchiveEntries the most entries it may have.
+	maxArchiveSize    = 100 * 1024 * 1024
+	maxArchiveEntries = 10_000
+	// archiveDownloadTimeout bounds the download of an archive.
+	archiveDownloadTimeout = 5 * time.Minute
+)
+
+// archiveClient downloads archives.
+var archiveClient = &http.Client{Timeout: archiveDownloadTimeout}
+
+// InstalledSkill is a skill recorded in the lock file of an
+// [Installer].
+type InstalledSkill struct {
+	LockedSkill
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// InstallResult describes a skill installed or updated by an
+// [Installer]. PreviousRevision is empty for new skills and equal to
+// Revision for skills that were already up to date.
+type InstallResult struct {
+	Name             string
+	Path             string
+	Revision         string
+	PreviousRevision string
+}
+
+// InstallOptions configures [Installer.Install].
+type InstallOptions struct {
+	// Ref is the git branch, tag or commit to install from. Defaults to
+	// the default branch of the repository.
+	Ref string
+	// Skills limits the installation to the skills of the source with
+	// these names. All of them are installed if empty.
+	Skills []string
+}
+
+// Installer installs skills from git repositories, archives and local
+// directories into a skills directory, recording their sources in its
+// lock file. Every SKILL.md is validated before anything is copied, so a
+// source with an invalid skill installs nothing.
+type Installer struct {
+	dir string
+}
+
+// NewInstaller returns an installer for the skills directory dir, which is
+// created on the first installation.
+func NewInstaller(dir string) *Installer {
+	return &Installer{dir: dir}
+}
+
+// Dir returns the skills directory of the installer.
+func (i *Installer) Dir() string {
+	return i.dir
+}
+
+// Install installs the skills found in source, replacing the ones
+// previously installed with the same names.
+func (i *Installer) Install(ctx context.Context, source string, opts InstallOptions) ([]InstallResult, error) {
+	locked := LockedSkill{Source: source, Type: sourceTypeOf(source), Ref: opts.Ref}
+	if locked.Type != InstallFromGit && !isURL(source) {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return nil, err
+		}
+		locked.Source = abs
+	}
+	if opts.Ref != "" && locked.Type != InstallFromGit {
+		return nil, errors.New("a ref can only be given for git repositories")
+	}
+
+	lock, err := i.readLock()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := fetchSource(ctx, locked)
+	if err != nil {
+		return nil, err
+	}
+	defer src.cleanup()
+
+	found, err := findSkills(src.root)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Skills) > 0 {
+		for _, name := range opts.Skills {
+			if !slices.ContainsFunc(found, func(s *Skill) bool { return s.Name == name }) {
+				return nil, fmt.Errorf("skill %q not found in %s", name, source)
+			}
+		}
+		found = slices.DeleteFunc(found, func(s *Skill) bool { return !slices.Contains(opts.Skills, s.Name) })
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no %s found in %s", SkillFileName, source)
+	}
+	for _, skill := range found {
+		if _, ok := lock.Skills[skill.Name]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(i.dir, skill.Name)); err == nil {
+			return nil, fmt.Errorf("skill %q already exists in %s and was not installed from a source; remove it first", skill.Name, i.dir)
+		}
+	}
+
+	results := make([]InstallResult, 0, len(found))
+	for _, skill := range found {
+		entry := locked
+		entry.Subdir, _ = filepath.Rel(src.root, skill.Path)
+		entry.Subdir = filepath.ToSlash(entry.Subdir)
+		if entry.Subdir == "." {
+			entry.Subdir = ""
+		}
+		result, err := i.install(lock, skill, entry, src.revision)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, i.writeLock(lock)
+}
+
+// Update reinstalls the named skills, or all installed skills if names is
+// empty, from their sources at the ref they were installed from. Skills
+// whose revision did not change are left as they are.
+func (i *Installer) Update(ctx context.Context, names ...string) ([]InstallResult, error) {
+	lock, err := i.readLock()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		for name := range lock.Skills {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if _, ok := lock.Skills[name]; !ok {
+			return nil, fmt.Errorf("skill %q is not installed in %s", name, i.dir)
+		}
+	}
+
+	// Skills sharing a source are updated from a single fetch of it.
+	type sourceKey struct {
+		source string
+		typ    InstallSourceType
+		ref    string
+	}
+	sources := make(map[sourceKey]*fetchedSource)
+	defer func() {
+		for _, src := range sources {
+			src.cleanup()
+		}
+	}()
+
+	var results []InstallResult
+	for _, name := range names {
+		entry := lock.Skills[name]
+		key := sourceKey{entry.Source, entry.Type, entry.Ref}
+		src, ok := sources[key]
+		if !ok {
+			src, err = fetchSource(ctx, entry)
+			if err != nil {
+				return results, fmt.Errorf("updating skill %q: %w", name, err)
+			}
+			sources[key] = src
+		}
+
+		dir := filepath.Join(src.root, filepath.FromSlash(entry.Subdir))
+		skill, err := parseInstallable(dir, dir == src.root)
+		if err != nil {
+			return results, fmt.Errorf("updating skill %q: %w", name, err)
+		}
+		if skill.Name != name {
+			return results, fmt.Errorf("updating skill %q: %s now holds skill %q", name, entry.Source, skill.Name)
+		}
+		result, err := i.install(lock, skill, entry, src.revision)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, i.writeLock(lock)
+}
+
+// Remove deletes the named installed skills and their lock file entries.
+func (i *Installer) Remove(names ...string) error {
+	lock, err := i.readLock()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := lock.Skills[name]; !ok {
+			return fmt.Errorf("skill %q is not installed in %s", name, i.dir)
+		}
+	}
+	for _, name := range names {
+		if err := os.RemoveAll(filepath.Join(i.dir, name)); err != nil {
+			return fmt.Errorf("removing skill %q: %w", name, err)
+		}
+		delete(lock.Skills, name)
+	}
+	return i.writeLock(lock)
+}
+
+// List returns the installed skills, sorted by name.
+func (i *Installer) List() ([]InstalledSkill, error) {
+	lock, err := i.readLock()
+	if err != nil {
+		return nil, err
+	}
+	installed := make([]InstalledSkill, 0, len(lock.Skills))
+	for name, entry := range lock.Skills {
+		installed = append(installed, InstalledSkill{
+			LockedSkill: entry,
+			Name:        name,
+			Path:        filepath.Join(i.dir, name),
+		})
+	}
+	slices.SortFunc(installed, func(a, b InstalledSkill) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return installed, nil
+}
+
+// install copies skill into the skills directory, replacing the installed
+// copy if its revision changed, and records it in lock. revision is the
+// revision of the whole source, if it has one.
+func (i *Installer) install(lock *LockFile, skill *Skill, entry LockedSkill, revision string) (InstallResult, error) {
+	if revision == "" {
+		var err error
+		if revision, err = digestDir(skill.Path); err != nil {
+			return InstallResult{}, err
+		}
+	}
+	target := filepath.Join(i.dir, skill.Name)
+	result := InstallResult{Name: skill.Name, Path: target, Revision: revision}
+	if prev, ok := lock.Skills[skill.Name]; ok {
+		result.PreviousRevision = prev.Revision
+		if _, err := os.Stat(target); err == nil && prev.Revision == revision && prev.Source == entry.Source {
+			return result, nil
+		}
+	}
+
+	if err := os.MkdirAll(i.dir, 0o755); err != nil {
+		return result, fmt.Errorf("creating skills directory: %w", err)
+	}
+	// Stage next to the skills directory rather than in it, so that
+	// discovery never sees half-copied skills.
+	staging, err := os.MkdirTemp(filepath.Dir(i.dir), ".skill-install-")
+	if err != nil {
+		return result, fmt.Errorf("installing skill %q: %w", skill.Name, err)
+	}
+	defer os.RemoveAll(staging)
+
+	staged := filepath.Join(staging, skill.Name)
+	if err := copyDir(skill.Path, staged); err != nil {
+		return result, fmt.Errorf("installing skill %q: %w", skill.Name, err)
+	}
+	if err := os.Rename(target, filepath.Join(staging, "previous")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return result, fmt.Errorf("installing skill %q: %w", skill.Name, err)
+	}
+	if err := os.Rename(staged, target); err != nil {
+		return result, fmt.Errorf("installing skill %q: %w", skill.Name, err)
+	}
+
+	entry.Revision = revision
+	entry.InstalledAt = time.Now().UTC().Truncate(time.Second)
+	lock.Skills[skill.Name] = entry
+	return result, nil
+}
+
+func (i *Installer) readLock() (*LockFile, error) {
+	lock := &LockFile{Version: lockFileVersion, Skills: make(map[string]LockedSkill)}
+	data, err := os.ReadFile(filepath.Join(i.dir, LockFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", LockFileName, err)
+	}
+	if lock.Skills == nil {
+		lock.Skills = make(map[string]LockedSkill)
+	}
+	return lock, nil
+}
+
+// writeLock replaces the lock file atomically, so that watchers never
+// read a partial one, and removes it once no skill is left.
+func (i *Installer) writeLock(lock *LockFile) error {
+	path := filepath.Join(i.dir, LockFileName)
+	if len(lock.Skills) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(i.dir, ".lock-")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", LockFileName, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", LockFileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", LockFileName, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", LockFileName, err)
+	}
+	return nil
+}
+
+// sourceTypeOf tells the type of source from its name: archives by their
+// extension, existing directories as paths and anything else as git.
+func sourceTypeOf(source string) InstallSourceType {
+	lower := strings.ToLower(source)
+	if strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") {
+		return InstallFromArchive
+	}
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return InstallFromPath
+	}
+	return InstallFromGit
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// fetchedSource is a source made available as a local directory.
+type fetchedSource struct {
+	root string
+	// revision is the revision of the whole source, or empty if each
+	// skill has its own.
+	revision string
+	cleanup  func()
+}
+
+func fetchSource(ctx context.Context, entry LockedSkill) (*fetchedSource, error) {
+	if entry.Type == InstallFromPath {
+		info, err := os.Stat(entry.Source)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", entry.Source)
+		}
+		return &fetchedSource{root: entry.Source, cleanup: func() {}}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "crush-skill-*")
+	if err != nil {
+		return nil, err
+	}
+	src := &fetchedSource{root: tmp, cleanup: func() { os.RemoveAll(tmp) }}
+	switch entry.Type {
+	case InstallFromGit:
+		src.revision, err = fetchGit(ctx, entry.Source, entry.Ref, tmp)
+	case InstallFromArchive:
+		src.revision, err = fetchArchive(ctx, entry.Source, tmp)
+	default:
+		err = fmt.Errorf("unknown source type %q", entry.Type)
+	}
+	if err != nil {
+		src.cleanup()
+		return nil, err
+	}
+	return src, nil
+}
+
+// fetchGit checks out ref, or the default branch, of the repository at url
+// into dir and returns the commit it is at. url and ref may come from a
+// lock file committed to a project, so neither may pass for an option, and
+// the ext:: transport, which runs commands, is off.
+func fetchGit(ctx context.Context, url, ref, dir string) (string, error) {
+	if strings.HasPrefix(url, "-") {
+		return "", fmt.Errorf("invalid repository %q", url)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q", ref)
+	}
+	git := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "protocol.ext.allow=never", "-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if _, err := git("init", "--quiet"); err != nil {
+		return "", err
+	}
+	if _, err := git("fetch", "--quiet", "--depth", "1", "--end-of-options", url, cmp.Or(ref, "HEAD")); err != nil {
+		return "", err
+	}
+	if _, err := git("checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	return git("rev-parse", "HEAD")
+}
+
+// fetchArchive extracts the .tar.gz archive at source, a URL or a path,
+// into dir and returns its digest.
+func fetchArchive(ctx context.Context, source, dir string) (string, error) {
+	var r io.Reader
+	if isURL(source) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := archiveClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("downloading %s: %s", source, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	hash := sha256.New()
+	if err := extractTarGz(io.TeeReader(r, hash), dir); err != nil {
+		return "", fmt.Errorf("extracting %s: %w", source, err)
+	}
+	// Drain what gzip left unread so the digest covers the whole archive.
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extractTarGz extracts the regular files and directories of a .tar.gz
+// stream into dir, rejecting entries that would land outside of it and
+// archives extracting to more than maxArchiveSize or maxArchiveEntries.
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	budget := int64(maxArchiveSize)
+	for entries := 0; ; entries++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if entries >= maxArchiveEntries {
+			return fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("entry %q is outside of the archive", hdr.Name)
+		}
+		path := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if budget -= hdr.Size; budget < 0 {
+				return fmt.Errorf("archive extracts to more than %d MB", maxArchiveSize/1024/1024)
+			}
+			if err := writeFile(path, io.LimitReader(tr, hdr.Size), fileMode(hdr.FileInfo().Mode())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// findSkills parses and validates every SKILL.md under root. A SKILL.md at
+// root itself names the directory the skill is installed in; elsewhere,
+// its name must match its directory as everywhere else.
+func findSkills(root string) ([]*Skill, error) {
+	var found []*Skill
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || d.Name() != SkillFileName {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		skill, err := parseInstallable(dir, dir == root)
+		if err != nil {
+			return err
+		}
+		found = append(found, skill)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, skill := range found {
+		if j := slices.IndexFunc(found[:i], func(s *Skill) bool { return s.Name == skill.Name }); j >= 0 {
+			return nil, fmt.Errorf("skill %q is defined in both %s and %s", skill.Name, found[j].SkillFilePath, skill.SkillFilePath)
+		}
+	}
+	return found, nil
+}
+
+// parseInstallable parses and validates the SKILL.md of dir.
+func parseInstallable(dir string, root bool) (*Skill, error) {
+	path := filepath.Join(dir, SkillFileName)
+	skill, err := Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	check := *skill
+	if root {
+		check.Path = ""
+	}
+	if err := check.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return skill, nil
+}
+
+// copyDir copies the regular files and directories under src to dst,
+// leaving out git metadata and symbolic links.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case !d.Type().IsRegular():
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeFile(target, f, fileMode(info.Mode()))
+	})
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fileMode keeps whether a file is executable, for the scripts of skills.
+func fileMode(mode fs.FileMode) os.FileMode {
+	if mode&0o111 != 0 {
+		return 0o755
+	}
+	return 0o644
+}
+
+// digestDir returns a digest of the names and contents of the files under
+// dir, standing for the revision of skills installed from directories.
+func digestDir(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(rel))
+		_, err = io.Copy(hash, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,278 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSkill(t *testing.T, dir, name, description string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	content := "---\nname: " + name + "\ndescription: " + description + "\n---\n\nInstructions.\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SkillFileName), []byte(content), 0o644))
+}
+
+func readLockFile(t *testing.T, dir string) LockFile {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	require.NoError(t, err)
+	var lock LockFile
+	require.NoError(t, json.Unmarshal(data, &lock))
+	return lock
+}
+
+func TestInstaller_Path(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeSkill(t, filepath.Join(src, "skills", "pdf-tools"), "pdf-tools", "Work with PDFs.")
+	writeSkill(t, filepath.Join(src, "skills", "changelog"), "changelog", "Write changelogs.")
+	require.NoError(t, os.WriteFile(filepath.Join(src, "skills", "pdf-tools", "split.sh"), []byte("#!/bin/sh\n"), 0o755))
+
+	dir := filepath.Join(t.TempDir(), "skills")
+	inst := NewInstaller(dir)
+	results, err := inst.Install(t.Context(), src, InstallOptions{Skills: []string{"pdf-tools"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "pdf-tools", results[0].Name)
+	require.Empty(t, results[0].PreviousRevision)
+
+	info, err := os.Stat(filepath.Join(dir, "pdf-tools", "split.sh"))
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode()&0o100)
+	require.NoDirExists(t, filepath.Join(dir, "changelog"))
+
+	lock := readLockFile(t, dir)
+	require.Equal(t, lockFileVersion, lock.Version)
+	entry := lock.Skills["pdf-tools"]
+	require.Equal(t, src, entry.Source)
+	require.Equal(t, InstallFromPath, entry.Type)
+	require.Equal(t, "skills/pdf-tools", entry.Subdir)
+	require.Equal(t, results[0].Revision, entry.Revision)
+
+	// Installed skills are discovered like any other.
+	found := Discover([]string{dir})
+	require.Len(t, found, 1)
+	require.Equal(t, "pdf-tools", found[0].Name)
+
+	results, err = inst.Update(t.Context())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, results[0].PreviousRevision, results[0].Revision)
+
+	writeSkill(t, filepath.Join(src, "skills", "pdf-tools"), "pdf-tools", "Work with PDF files.")
+	results, err = inst.Update(t.Context(), "pdf-tools")
+	require.NoError(t, err)
+	require.NotEqual(t, results[0].PreviousRevision, results[0].Revision)
+	skill, err := Parse(filepath.Join(dir, "pdf-tools", SkillFileName))
+	require.NoError(t, err)
+	require.Equal(t, "Work with PDF files.", skill.Description)
+
+	installed, err := inst.List()
+	require.NoError(t, err)
+	require.Len(t, installed, 1)
+	require.Equal(t, filepath.Join(dir, "pdf-tools"), installed[0].Path)
+
+	require.NoError(t, inst.Remove("pdf-tools"))
+	require.NoDirExists(t, filepath.Join(dir, "pdf-tools"))
+	require.NoFileExists(t, filepath.Join(dir, LockFileName))
+	require.EqualError(t, inst.Remove("pdf-tools"), `skill "pdf-tools" is not installed in `+dir)
+}
+
+func TestInstaller_InvalidSkill(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeSkill(t, filepath.Join(src, "good"), "good", "A valid skill.")
+	writeSkill(t, filepath.Join(src, "bad"), "not-bad", "Name does not match its directory.")
+
+	dir := t.TempDir()
+	_, err := NewInstaller(dir).Install(t.Context(), src, InstallOptions{})
+	require.ErrorContains(t, err, `name "not-bad" must match directory "bad"`)
+	require.NoDirExists(t, filepath.Join(dir, "good"))
+	require.NoFileExists(t, filepath.Join(dir, LockFileName))
+}
+
+func TestInstaller_ExistingSkill(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeSkill(t, filepath.Join(src, "notes"), "notes", "Take notes.")
+	dir := t.TempDir()
+	writeSkill(t, filepath.Join(dir, "notes"), "notes", "Hand-written notes skill.")
+
+	_, err := NewInstaller(dir).Install(t.Context(), src, InstallOptions{})
+	require.ErrorContains(t, err, `skill "notes" already exists`)
+}
+
+func TestInstaller_Archive(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"SKILL.md":         "---\nname: release\ndescription: Cut releases.\n---\n",
+		"scripts/bump.txt": "bump",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	archive := filepath.Join(t.TempDir(), "release.tar.gz")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o644))
+
+	dir := t.TempDir()
+	results, err := NewInstaller(dir).Install(t.Context(), archive, InstallOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "release", results[0].Name)
+	require.FileExists(t, filepath.Join(dir, "release", "scripts", "bump.txt"))
+
+	entry := readLockFile(t, dir).Skills["release"]
+	require.Equal(t, InstallFromArchive, entry.Type)
+	require.Empty(t, entry.Subdir)
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, entry.Revision)
+}
+
+func TestInstaller_ArchiveOutsideEntry(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0o644, Typeflag: tar.TypeReg}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	archive := filepath.Join(t.TempDir(), "evil.tgz")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o644))
+
+	_, err := NewInstaller(t.TempDir()).Install(t.Context(), archive, InstallOptions{})
+	require.ErrorContains(t, err, `entry "../evil" is outside of the archive`)
+}
+
+func TestInstaller_ArchiveLimits(t *testing.T) {
+	t.Parallel()
+
+	install := func(t *testing.T, write func(tw *tar.Writer)) error {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		write(tw)
+		// Headers are written as they come; a truncated last entry is fine.
+		_ = tw.Flush()
+		require.NoError(t, gz.Close())
+		archive := filepath.Join(t.TempDir(), "bomb.tgz")
+		require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o644))
+		_, err := NewInstaller(t.TempDir()).Install(t.Context(), archive, InstallOptions{})
+		return err
+	}
+
+	t.Run("size", func(t *testing.T) {
+		t.Parallel()
+		err := install(t, func(tw *tar.Writer) {
+			// The header alone is enough to be refused.
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: "big", Mode: 0o644, Size: maxArchiveSize + 1, Typeflag: tar.TypeReg}))
+		})
+		require.ErrorContains(t, err, "archive extracts to more than 100 MB")
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		t.Parallel()
+		err := install(t, func(tw *tar.Writer) {
+			for i := range maxArchiveEntries + 1 {
+				require.NoError(t, tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("d%d/", i), Mode: 0o755, Typeflag: tar.TypeDir}))
+			}
+		})
+		require.ErrorContains(t, err, "archive has more than 10000 entries")
+	})
+}
+
+func TestInstaller_GitOptionInjection(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := fetchGit(t.Context(), ".", "--upload-pack=touch "+filepath.Join(dir, "pwned"), dir)
+	require.EqualError(t, err, `invalid ref "--upload-pack=touch `+filepath.Join(dir, "pwned")+`"`)
+	_, err = fetchGit(t.Context(), "--upload-pack=touch pwned", "", dir)
+	require.EqualError(t, err, `invalid repository "--upload-pack=touch pwned"`)
+	require.NoFileExists(t, filepath.Join(dir, "pwned"))
+}
+
+func TestInstaller_Git(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(bytes.TrimSpace(out))
+	}
+	git("init", "--quiet")
+	writeSkill(t, filepath.Join(repo, "review"), "review", "Review code.")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Add review skill")
+	first := git("rev-parse", "HEAD")
+
+	dir := t.TempDir()
+	inst := NewInstaller(dir)
+	results, err := inst.Install(t.Context(), "file://"+repo, InstallOptions{})
+	require.NoError(t, err)
+	require.Equal(t, first, results[0].Revision)
+
+	writeSkill(t, filepath.Join(repo, "review"), "review", "Review code carefully.")
+	git("commit", "--quiet", "-am", "Update review skill")
+	second := git("rev-parse", "HEAD")
+
+	results, err = inst.Update(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, first, results[0].PreviousRevision)
+	require.Equal(t, second, results[0].Revision)
+	require.Equal(t, second, readLockFile(t, dir).Skills["review"].Revision)
+}
+
+func TestManager_WatchInstalls(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mgr := NewManager(nil, nil, nil, WithDiscoveryConfig(DiscoveryConfig{SkillsPaths: []string{dir}}))
+	events := mgr.SubscribeEvents(t.Context())
+	go mgr.WatchInstalls(t.Context(), 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	src := t.TempDir()
+	writeSkill(t, filepath.Join(src, "deploy"), "deploy", "Deploy the app.")
+	_, err := NewInstaller(dir).Install(t.Context(), src, InstallOptions{})
+	require.NoError(t, err)
+
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the skills to reload")
+	}
+	require.Contains(t, skillNames(mgr.ActiveSkills()), "deploy")
+}
+
+func skillNames(skills []*Skill) []string {
+	names := make([]string, 0, len(skills))
+	for _, s := range skills {
+		names = append(names, s.Name)
+	}
+	return names
+}
@@ -2,9 +2,14 @@ package skills
 
 import (
 	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/pubsub"
@@ -33,6 +38,10 @@ type Manager struct {
 	resolvedPaths []string
 	workingDir    string
 
+	// discovery is the configuration Reload discovers skills with; nil
+	// when the manager was given its skills without one.
+	discovery *DiscoveryConfig
+
 	broker       *pubsub.Broker[Event]
 	globalMirror bool
 }
@@ -67,6 +76,17 @@ func WithWorkingDir(dir string) ManagerOption {
 	}
 }
 
+// WithDiscoveryConfig stores the configuration the skills were discovered
+// with, so that Reload can discover them again. It also sets the resolved
+// paths and working directory.
+func WithDiscoveryConfig(cfg DiscoveryConfig) ManagerOption {
+	return func(m *Manager) {
+		m.discovery = &cfg
+		m.resolvedPaths = cfg.ResolvePaths()
+		m.workingDir = cfg.WorkingDir
+	}
+}
+
 // NewManager constructs a workspace-scoped Manager with the given
 // pre-computed discovery results. The slices are stored as-is; callers
 // should not mutate them afterwards.
@@ -155,6 +175,58 @@ func (m *Manager) SubscribeEvents(ctx context.Context) <-chan pubsub.Event[Event
 	return m.broker.Subscribe(ctx)
 }
 
+// Reload discovers the skills again with the configuration given by
+// WithDiscoveryConfig, and publishes the new discovery states. It does
+// nothing for managers constructed without one.
+func (m *Manager) Reload() {
+	if m.discovery == nil {
+		return
+	}
+	allSkills, activeSkills, states := DiscoverFromConfig(*m.discovery)
+	m.mu.Lock()
+	m.allSkills = allSkills
+	m.activeSkills = activeSkills
+	m.mu.Unlock()
+	m.PublishStates(states)
+}
+
+// WatchInstalls reloads the skills whenever the lock file of one of the
+// skills directories changes, as when `crush skills` installs, updates or
+// removes skills, until ctx is done. The lock files are polled every
+// interval.
+func (m *Manager) WatchInstalls(ctx context.Context, interval time.Duration) {
+	if m.discovery == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	stamps := m.lockFileStamps()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if current := m.lockFileStamps(); !maps.Equal(current, stamps) {
+			stamps = current
+			slog.Info("Installed skills changed, reloading", "component", "skills")
+			m.Reload()
+		}
+	}
+}
+
+// lockFileStamps returns the modification times of the lock files of the
+// skills directories that have one.
+func (m *Manager) lockFileStamps() map[string]int64 {
+	stamps := make(map[string]int64)
+	for _, dir := range m.resolvedPaths {
+		if info, err := os.Stat(filepath.Join(dir, LockFileName)); err == nil {
+			stamps[dir] = info.ModTime().UnixNano()
+		}
+	}
+	return stamps
+}
+
 // Shutdown releases broker resources.
 func (m *Manager) Shutdown() {
 	if m.broker != nil {
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/charlievieth/fastwalk"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"gopkg.in/yaml.v3"
@@ -43,6 +44,10 @@ type Skill struct {
 	License                string            `yaml:"license,omitempty" json:"license,omitempty"`
 	Compatibility          string            `yaml:"compatibility,omitempty" json:"compatibility,omitempty"`
 	Metadata               map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	AllowedTools           AllowedTools      `yaml:"allowed-tools,omitempty" json:"allowed_tools,omitempty"`
+	Strict                 bool              `yaml:"strict,omitempty" json:"strict,omitempty"`
+	Paths                  PathGlobs         `yaml:"paths,omitempty" json:"paths,omitempty"`
+	Globs                  PathGlobs         `yaml:"globs,omitempty" json:"globs,omitempty"`
 	Instructions           string            `yaml:"-" json:"instructions"`
 	Path                   string            `yaml:"-" json:"path"`
 	SkillFilePath          string            `yaml:"-" json:"skill_file_path"`
@@ -142,6 +147,12 @@ func (s *Skill) Validate() error {
 		errs = append(errs, fmt.Errorf("compatibility exceeds %d characters", MaxCompatibilityLength))
 	}
 
+	for _, pattern := range s.PathPatterns() {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, fmt.Errorf("invalid path pattern %q", pattern))
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
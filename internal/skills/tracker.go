@@ -1,6 +1,7 @@
 package skills
 
 import (
+	"slices"
 	"sort"
 	"sync"
 )
@@ -13,21 +14,63 @@ import (
 // can be marked as loaded. This prevents misattribution when reading builtin
 // files that have been overridden.
 type Tracker struct {
-	mu          sync.RWMutex
-	loaded      map[string]bool
-	activeNames map[string]bool // Set of active skill names (post-dedup, post-filter)
+	mu     sync.RWMutex
+	loaded map[string]bool
+	active map[string]*Skill // Active skills by name (post-dedup, post-filter)
+	// sessions holds the names of the skills loaded in each session, whose
+	// allowed tools apply to the tool calls of that session.
+	sessions map[string]map[string]bool
+	// activations holds the skills activated in each session by the files
+	// matching their paths.
+	activations map[string][]Activation
 }
 
 // NewTracker creates a new skill tracker with the given active skill names.
 // Only skills in activeSkills can be marked as loaded.
 func NewTracker(activeSkills []*Skill) *Tracker {
-	activeNames := make(map[string]bool, len(activeSkills))
-	for _, s := range activeSkills {
-		activeNames[s.Name] = true
-	}
 	return &Tracker{
 		loaded:      make(map[string]bool),
-		activeNames: activeNames,
+		active:      activeByName(activeSkills),
+		sessions:    make(map[string]map[string]bool),
+		activations: make(map[string][]Activation),
+	}
+}
+
+func activeByName(activeSkills []*Skill) map[string]*Skill {
+	active := make(map[string]*Skill, len(activeSkills))
+	for _, s := range activeSkills {
+		active[s.Name] = s
+	}
+	return active
+}
+
+// SetActive replaces the active skills, as when skills are installed or
+// removed while a session is running. Skills that are no longer active are
+// no longer reported as loaded.
+func (t *Tracker) SetActive(activeSkills []*Skill) {
+	if t == nil {
+		return
+	}
+	active := activeByName(activeSkills)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active = active
+	for name := range t.loaded {
+		if active[name] == nil {
+			delete(t.loaded, name)
+		}
+	}
+	for _, names := range t.sessions {
+		for name := range names {
+			if active[name] == nil {
+				delete(names, name)
+			}
+		}
+	}
+	for sessionID, activations := range t.activations {
+		t.activations[sessionID] = slices.DeleteFunc(activations, func(a Activation) bool {
+			return active[a.Name] == nil
+		})
 	}
 }
 
@@ -40,11 +83,96 @@ func (t *Tracker) MarkLoaded(name string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	// Only track if this skill is actually active (not overridden by user skill).
-	if t.activeNames[name] {
+	if t.active[name] != nil {
 		t.loaded[name] = true
 	}
 }
 
+// MarkLoadedInSession marks a skill as having been loaded in the session,
+// so that its allowed tools apply to the session's tool calls.
+func (t *Tracker) MarkLoadedInSession(sessionID, name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active[name] == nil {
+		return
+	}
+	t.loaded[name] = true
+	if sessionID == "" {
+		return
+	}
+	if t.sessions[sessionID] == nil {
+		t.sessions[sessionID] = make(map[string]bool)
+	}
+	t.sessions[sessionID][name] = true
+}
+
+// ActivateForPath activates in the session the skills whose paths match
+// path and that are neither loaded nor activated in it yet, and returns them
+// sorted by name. Skills that disable model invocation are never activated
+// this way. Activated skills only bring their instructions: since any file
+// of the project can activate them, their allowed tools apply only once the
+// skill is loaded explicitly.
+func (t *Tracker) ActivateForPath(sessionID, path, workingDir string) []*Skill {
+	if t == nil || sessionID == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var activated []*Skill
+	for name, skill := range t.active {
+		if skill.DisableModelInvocation || t.sessions[sessionID][name] || !skill.MatchesPath(path, workingDir) {
+			continue
+		}
+		if slices.ContainsFunc(t.activations[sessionID], func(a Activation) bool { return a.Name == name }) {
+			continue
+		}
+		activated = append(activated, skill)
+	}
+	sort.Slice(activated, func(i, j int) bool {
+		return activated[i].Name < activated[j].Name
+	})
+	for _, skill := range activated {
+		t.loaded[skill.Name] = true
+		t.activations[sessionID] = append(t.activations[sessionID], NewActivation(skill, path))
+	}
+	return activated
+}
+
+// Activations returns the skills activated in the session by files
+// matching their paths, in order of activation. Safe to call on a nil
+// Tracker (returns nil).
+func (t *Tracker) Activations(sessionID string) []Activation {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Clone(t.activations[sessionID])
+}
+
+// SessionSkills returns the active skills loaded explicitly in the session,
+// sorted by name. Skills activated by a path are not included. Safe to call on a nil Tracker (returns nil).
+func (t *Tracker) SessionSkills(sessionID string) []*Skill {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.sessions[sessionID]))
+	for name := range t.sessions[sessionID] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	loaded := make([]*Skill, 0, len(names))
+	for _, name := range names {
+		loaded = append(loaded, t.active[name])
+	}
+	return loaded
+}
+
 // IsLoaded returns true if the skill has been loaded.
 func (t *Tracker) IsLoaded(name string) bool {
 	if t == nil {
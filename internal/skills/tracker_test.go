@@ -47,6 +47,20 @@ func TestTracker_NonActiveSkillCannotBeMarkedLoaded(t *testing.T) {
 	require.True(t, tracker.IsLoaded("go-doc"))
 }
 
+func TestTracker_SetActive(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker([]*Skill{{Name: "go-doc"}, {Name: "bash"}})
+	tracker.MarkLoaded("go-doc")
+	tracker.MarkLoaded("bash")
+
+	// Removed skills are dropped, installed ones can be loaded.
+	tracker.SetActive([]*Skill{{Name: "go-doc"}, {Name: "deploy"}})
+	require.Equal(t, []string{"go-doc"}, tracker.LoadedNames())
+	tracker.MarkLoaded("deploy")
+	require.True(t, tracker.IsLoaded("deploy"))
+}
+
 func TestTracker_NilSafety(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,86 @@
+package skills
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// PathGlobs is the paths (or globs) field of a skill: the files whose
+// reading or editing activates the skill automatically. It is written as
+// a list of globs, or as a string of globs separated by commas or spaces.
+type PathGlobs []string
+
+// UnmarshalYAML implements [yaml.Unmarshaler].
+func (p *PathGlobs) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*p = splitOutside(node.Value, '{', '}')
+	case yaml.SequenceNode:
+		var globs []string
+		if err := node.Decode(&globs); err != nil {
+			return err
+		}
+		*p = nil
+		for _, glob := range globs {
+			if glob = strings.TrimSpace(glob); glob != "" {
+				*p = append(*p, glob)
+			}
+		}
+	default:
+		return errors.New("paths must be a string or a list")
+	}
+	return nil
+}
+
+// PathPatterns returns the globs of the paths and globs fields of the
+// skill.
+func (s *Skill) PathPatterns() []string {
+	return slices.Concat(s.Paths, s.Globs)
+}
+
+// MatchesPath reports whether path matches one of the path patterns of the
+// skill. Patterns are relative to workingDir; those without a slash match
+// the base name of files in any directory, as in .gitignore.
+func (s *Skill) MatchesPath(path, workingDir string) bool {
+	if path == "" {
+		return false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	path = filepath.Clean(path)
+	if rel, err := filepath.Rel(workingDir, path); err == nil && filepath.IsLocal(rel) {
+		path = rel
+	}
+	path = filepath.ToSlash(path)
+	base := filepath.Base(path)
+	for _, pattern := range s.PathPatterns() {
+		target := path
+		if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+			target = base
+		}
+		if ok, _ := doublestar.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Activation records a skill activated automatically by a file of the
+// session matching its paths. An activated skill grants no tools until it is
+// loaded explicitly.
+type Activation struct {
+	Name string `json:"name"`
+	// Path is the file that activated the skill.
+	Path string `json:"path"`
+}
+
+// NewActivation returns the activation of skill by path.
+func NewActivation(skill *Skill, path string) Activation {
+	return Activation{Name: skill.Name, Path: path}
+}
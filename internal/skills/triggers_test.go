@@ -0,0 +1,104 @@
+package skills
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathGlobs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"paths list", "paths:\n  - \"**/*.proto\"\n  - buf.yaml", []string{"**/*.proto", "buf.yaml"}},
+		{"globs string", "globs: \"*.{proto,pb}, api/**\"", []string{"*.{proto,pb}", "api/**"}},
+		{"both", "paths: a.go\nglobs: b.go", []string{"a.go", "b.go"}},
+		{"missing", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			skill, err := ParseContent([]byte("---\nname: s\ndescription: d\n" + tt.content + "\n---\n"))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, skill.PathPatterns())
+		})
+	}
+
+	_, err := ParseContent([]byte("---\nname: s\ndescription: d\npaths:\n  proto: true\n---\n"))
+	require.ErrorContains(t, err, "paths must be a string or a list")
+
+	skill := &Skill{Name: "s", Description: "d", Globs: PathGlobs{"[a-"}}
+	require.ErrorContains(t, skill.Validate(), `invalid path pattern "[a-"`)
+}
+
+func TestSkill_MatchesPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	skill := &Skill{Paths: PathGlobs{"**/*.proto", "api/*.yaml"}, Globs: PathGlobs{"Makefile"}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(dir, "svc", "v1", "user.proto"), true},
+		{"user.proto", true},
+		{filepath.Join(dir, "api", "openapi.yaml"), true},
+		{filepath.Join(dir, "svc", "api", "openapi.yaml"), false},
+		{filepath.Join(dir, "tools", "Makefile"), true},
+		{filepath.Join(dir, "main.go"), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, skill.MatchesPath(tt.path, dir))
+		})
+	}
+}
+
+func TestTracker_ActivateForPath(t *testing.T) {
+	t.Parallel()
+
+	protobuf := &Skill{Name: "protobuf", Paths: PathGlobs{"**/*.proto"}, AllowedTools: AllowedTools{"Bash(buf:*)"}}
+	grpc := &Skill{Name: "grpc", Globs: PathGlobs{"*.proto"}}
+	manual := &Skill{Name: "manual", Paths: PathGlobs{"*.proto"}, DisableModelInvocation: true}
+	other := &Skill{Name: "other"}
+	tracker := NewTracker([]*Skill{protobuf, grpc, manual, other})
+
+	require.Empty(t, tracker.ActivateForPath("s1", "/repo/main.go", "/repo"))
+	require.Equal(t, []*Skill{grpc, protobuf}, tracker.ActivateForPath("s1", "/repo/api/user.proto", "/repo"))
+	require.Empty(t, tracker.ActivateForPath("s1", "/repo/api/order.proto", "/repo"))
+	require.Equal(t, []Activation{
+		{Name: "grpc", Path: "/repo/api/user.proto"},
+		{Name: "protobuf", Path: "/repo/api/user.proto"},
+	}, tracker.Activations("s1"))
+	require.True(t, tracker.IsLoaded("protobuf"))
+
+	// Activated skills grant their allowed tools only once loaded explicitly.
+	require.Empty(t, tracker.SessionSkills("s1"))
+	call := ToolCall{Name: "bash", Input: `{"command":"buf lint"}`, WorkingDir: "/repo"}
+	decision, _ := CheckTool(tracker.SessionSkills("s1"), call)
+	require.Equal(t, ToolUnaffected, decision)
+	tracker.MarkLoadedInSession("s1", "protobuf")
+	require.Equal(t, []*Skill{protobuf}, tracker.SessionSkills("s1"))
+	decision, _ = CheckTool(tracker.SessionSkills("s1"), call)
+	require.Equal(t, ToolGranted, decision)
+
+	tracker.SetActive([]*Skill{protobuf})
+	require.Equal(t, []Activation{
+		{Name: "protobuf", Path: "/repo/api/user.proto"},
+	}, tracker.Activations("s1"))
+
+	require.Len(t, tracker.ActivateForPath("s2", "/repo/api/user.proto", "/repo"), 1)
+	require.Empty(t, tracker.ActivateForPath("", "/repo/api/user.proto", "/repo"))
+
+	var nilTracker *Tracker
+	require.Nil(t, nilTracker.ActivateForPath("s1", "/repo/api/user.proto", "/repo"))
+	require.Nil(t, nilTracker.Activations("s1"))
+}
@@ -2587,6 +2587,92 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/workspaces/{id}/sessions/{sid}/shellstate": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shellstate"
+                ],
+                "summary": "Get shell state for session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/proto.ShellState"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/proto.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/proto.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "shellstate"
+                ],
+                "summary": "Reset shell state for session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/proto.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/proto.Error"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -2920,6 +3006,30 @@ const docTemplate = `{
                 }
             }
         },
+        "config.TestAdapter": {
+            "type": "string",
+            "enum": [
+                "go",
+                "junit",
+                "generic"
+            ],
+            "x-enum-varnames": [
+                "TestAdapterGo",
+                "TestAdapterJUnit",
+                "TestAdapterGeneric"
+            ]
+        },
+        "config.ToolFetch": {
+            "type": "object",
+            "properties": {
+                "disable_cache": {
+                    "type": "boolean"
+                },
+                "max_cache_size": {
+                    "type": "integer"
+                }
+            }
+        },
         "config.ToolGrep": {
             "type": "object",
             "properties": {
@@ -2928,6 +3038,17 @@ const docTemplate = `{
                 }
             }
         },
+        "config.ToolHTTPRequest": {
+            "type": "object",
+            "properties": {
+                "allowed_hosts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "config.ToolLs": {
             "type": "object",
             "properties": {
@@ -2939,14 +3060,60 @@ const docTemplate = `{
                 }
             }
         },
+        "config.ToolRunTests": {
+            "type": "object",
+            "properties": {
+                "adapter": {
+                    "$ref": "#/definitions/config.TestAdapter"
+                },
+                "command": {
+                    "type": "string"
+                },
+                "report": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.ToolWebSearch": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "string"
+                },
+                "base_url": {
+                    "type": "string"
+                },
+                "max_results": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "safe_search": {
+                    "type": "string"
+                }
+            }
+        },
         "config.Tools": {
             "type": "object",
             "properties": {
+                "fetch": {
+                    "$ref": "#/definitions/config.ToolFetch"
+                },
                 "grep": {
                     "$ref": "#/definitions/config.ToolGrep"
                 },
+                "http_request": {
+                    "$ref": "#/definitions/config.ToolHTTPRequest"
+                },
                 "ls": {
                     "$ref": "#/definitions/config.ToolLs"
+                },
+                "run_tests": {
+                    "$ref": "#/definitions/config.ToolRunTests"
+                },
+                "web_search": {
+                    "$ref": "#/definitions/config.ToolWebSearch"
                 }
             }
         },
@@ -3619,6 +3786,26 @@ const docTemplate = `{
                 }
             }
         },
+        "proto.ShellState": {
+            "type": "object",
+            "properties": {
+                "env": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "integer"
+                },
+                "working_dir": {
+                    "type": "string"
+                }
+            }
+        },
         "proto.VersionInfo": {
             "type": "object",
             "properties": {
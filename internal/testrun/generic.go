@@ -0,0 +1,54 @@
+package testrun
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// genericTailLines is how much of the output of a generic test command is
+// kept in the report.
+const genericTailLines = 40
+
+// summaryCountPattern matches the counts in summary lines printed by common
+// runners, e.g. pytest's "3 failed, 10 passed", jest's "Tests: 1 failed, 4
+// passed" or cargo's "2 passed; 1 failed; 0 ignored".
+var summaryCountPattern = regexp.MustCompile(`(?i)\b(\d+) (passed|passing|failed|failing|skipped|ignored|pending)\b`)
+
+// ParseGeneric builds a report for a test command whose output format is
+// unknown. Counts are taken from the last summary-looking line, and the
+// tail of the output is kept so the model can see what went wrong.
+func ParseGeneric(output string, exitCode int) Report {
+	var report Report
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		matches := summaryCountPattern.FindAllStringSubmatch(lines[i], -1)
+		if len(matches) == 0 {
+			continue
+		}
+		for _, m := range matches {
+			n, _ := strconv.Atoi(m[1])
+			switch strings.ToLower(m[2]) {
+			case "passed", "passing":
+				report.Passed = n
+			case "failed", "failing":
+				report.Failed = n
+			default:
+				report.Skipped += n
+			}
+		}
+		break
+	}
+
+	if exitCode != 0 {
+		f := Failure{Name: "test command", Message: fmt.Sprintf("exited with code %d", exitCode)}
+		f.File, f.Line = findLocation(output)
+		report.Failures = append(report.Failures, f)
+	}
+	if len(lines) > genericTailLines {
+		lines = append([]string{fmt.Sprintf("... (%d earlier lines omitted)", len(lines)-genericTailLines)}, lines[len(lines)-genericTailLines:]...)
+	}
+	report.Output = strings.Join(lines, "\n")
+	return report
+}
@@ -0,0 +1,137 @@
+package testrun
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// goTestEvent is a single line of `go test -json` output, as documented in
+// `go doc test2json`.
+type goTestEvent struct {
+	Action      string
+	Package     string
+	ImportPath  string
+	Test        string
+	Output      string
+	FailedBuild string
+}
+
+type goTestKey struct {
+	pkg  string
+	test string
+}
+
+// ParseGoTestJSON parses the output of `go test -json`. Lines that are not
+// JSON events, such as build errors printed by older Go versions, end up in
+// Report.Output.
+func ParseGoTestJSON(r io.Reader) (Report, error) {
+	var (
+		report      Report
+		testOutput  = make(map[goTestKey][]string)
+		buildOutput = make(map[string][]string)
+		failedPkgs  = make(map[string]bool)
+		other       []string
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var ev goTestEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &ev) != nil {
+			if strings.TrimSpace(line) != "" {
+				other = append(other, line)
+			}
+			continue
+		}
+
+		key := goTestKey{pkg: ev.Package, test: ev.Test}
+		switch ev.Action {
+		case "output":
+			testOutput[key] = append(testOutput[key], strings.TrimSuffix(ev.Output, "\n"))
+		case "build-output":
+			buildOutput[ev.ImportPath] = append(buildOutput[ev.ImportPath], strings.TrimSuffix(ev.Output, "\n"))
+		case "pass":
+			if ev.Test != "" {
+				report.Passed++
+			}
+		case "skip":
+			if ev.Test != "" {
+				report.Skipped++
+			}
+		case "fail":
+			if ev.Test == "" {
+				if !failedPkgs[ev.Package] {
+					report.Failed++
+					report.Failures = append(report.Failures, packageFailure(ev, testOutput[key], buildOutput[ev.FailedBuild]))
+				}
+				continue
+			}
+			failedPkgs[ev.Package] = true
+			if hasFailedSubtest(report.Failures, ev.Package, ev.Test) {
+				// The subtests already explain why the parent failed.
+				continue
+			}
+			report.Failed++
+			report.Failures = append(report.Failures, testFailure(ev.Package, ev.Test, testOutput[key]))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	report.Output = trimMessage(other)
+	return report, nil
+}
+
+func hasFailedSubtest(failures []Failure, pkg, test string) bool {
+	for _, f := range failures {
+		if f.Package == pkg && strings.HasPrefix(f.Name, test+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func testFailure(pkg, test string, output []string) Failure {
+	var lines []string
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if isGoTestFramingLine(trimmed) {
+			continue
+		}
+		lines = append(lines, trimmed)
+	}
+	f := Failure{Name: test, Package: pkg, Message: trimMessage(lines)}
+	f.File, f.Line = findLocation(f.Message)
+	return f
+}
+
+// packageFailure reports a package that failed without a failing test,
+// which usually means it did not build or a TestMain exited early.
+func packageFailure(ev goTestEvent, output, build []string) Failure {
+	var lines []string
+	for _, line := range append(build, output...) {
+		trimmed := strings.TrimRight(line, " \t")
+		if isGoTestFramingLine(strings.TrimSpace(trimmed)) {
+			continue
+		}
+		lines = append(lines, trimmed)
+	}
+	f := Failure{Name: ev.Package, Package: ev.Package, Message: trimMessage(lines)}
+	f.File, f.Line = findLocation(f.Message)
+	return f
+}
+
+// isGoTestFramingLine reports whether line is one of the status lines
+// go test prints around test output rather than output from the test.
+func isGoTestFramingLine(line string) bool {
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- FAIL", "--- PASS", "--- SKIP", "FAIL\t", "ok  \t", "?   \t"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return line == "FAIL" || line == "PASS"
+}
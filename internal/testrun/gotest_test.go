@@ -0,0 +1,82 @@
+package testrun
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoTestJSON(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/gotest.jsonl")
+	require.NoError(t, err)
+	defer f.Close()
+
+	report, err := ParseGoTestJSON(f)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Passed)
+	require.Equal(t, 3, report.Failed)
+	require.Equal(t, 1, report.Skipped)
+	require.False(t, report.OK())
+
+	require.Equal(t, []Failure{
+		{
+			Name:    "TestAdd",
+			Package: "example.com/fix/bad",
+			Message: "bad_test.go:7: got 2, want 3",
+			File:    "bad_test.go",
+			Line:    7,
+		},
+		{
+			Name:    "TestTable/two",
+			Package: "example.com/fix/bad",
+			Message: "bad_test.go:13: boom",
+			File:    "bad_test.go",
+			Line:    13,
+		},
+		{
+			Name:    "example.com/fix/broken",
+			Package: "example.com/fix/broken",
+			Message: "# example.com/fix/broken [example.com/fix/broken.test]\nbroken/broken_test.go:5:28: undefined: undefined",
+			File:    "broken/broken_test.go",
+			Line:    5,
+		},
+	}, report.Failures)
+}
+
+func TestParseGoTestJSONNonJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		"go: cannot find main module",
+		`{"Action":"pass","Package":"p","Test":"TestA"}`,
+		"",
+	}, "\n")
+
+	report, err := ParseGoTestJSON(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Passed)
+	require.Equal(t, "go: cannot find main module", report.Output)
+}
+
+func TestReportString(t *testing.T) {
+	t.Parallel()
+
+	report := Report{
+		Passed: 2,
+		Failed: 1,
+		Failures: []Failure{
+			{Name: "TestAdd", Package: "pkg", Message: "got 2\nwant 3", File: "add_test.go", Line: 7},
+		},
+	}
+	require.Equal(t, `FAIL: 2 passed, 1 failed, 0 skipped
+
+--- FAIL: TestAdd (pkg) at add_test.go:7
+    got 2
+    want 3`, report.String())
+
+	require.Equal(t, "PASS: 4 passed, 0 failed, 1 skipped", Report{Passed: 4, Skipped: 1}.String())
+}
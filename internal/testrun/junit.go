@@ -0,0 +1,110 @@
+package testrun
+
+import (
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// junitSuite matches both <testsuites> and <testsuite>, which may nest.
+type junitSuite struct {
+	File   string       `xml:"file,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitProblem `xml:"failure"`
+	Errors    []junitProblem `xml:"error"`
+	Skipped   *struct{}      `xml:"skipped"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnit parses a JUnit XML report, as written by most non-Go test
+// runners (pytest --junitxml, jest-junit, Maven Surefire, cargo2junit...).
+func ParseJUnit(data []byte) (Report, error) {
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return Report{}, err
+	}
+	var report Report
+	collectJUnit(&report, root, "")
+	return report, nil
+}
+
+func collectJUnit(report *Report, suite junitSuite, file string) {
+	if suite.File != "" {
+		file = suite.File
+	}
+	for _, s := range suite.Suites {
+		collectJUnit(report, s, file)
+	}
+	for _, c := range suite.Cases {
+		problems := slices.Concat(c.Failures, c.Errors)
+		switch {
+		case len(problems) > 0:
+			report.Failed++
+			report.Failures = append(report.Failures, junitFailure(c, problems, file))
+		case c.Skipped != nil:
+			report.Skipped++
+		default:
+			report.Passed++
+		}
+	}
+}
+
+func junitFailure(c junitCase, problems []junitProblem, suiteFile string) Failure {
+	var lines []string
+	for _, p := range problems {
+		text := strings.TrimSpace(p.Text)
+		msg := strings.TrimSpace(p.Message)
+		if msg == "" {
+			msg = p.Type
+		}
+		if msg != "" && !strings.HasPrefix(text, msg) {
+			lines = append(lines, msg)
+		}
+		if text != "" {
+			lines = append(lines, strings.Split(text, "\n")...)
+		}
+	}
+
+	f := Failure{
+		Name:    c.Name,
+		Package: c.Classname,
+		Message: trimMessage(lines),
+		File:    c.File,
+	}
+	f.Line, _ = strconv.Atoi(c.Line)
+	if f.File == "" || f.Line == 0 {
+		if file, line := findLocation(f.Message); file != "" {
+			f.File, f.Line = file, line
+		}
+	}
+	if f.File == "" {
+		f.File = suiteFile
+	}
+	return f
+}
+
+// Merge adds the counts and failures of other to r, for runners that
+// write one report file per suite.
+func (r *Report) Merge(other Report) {
+	r.Passed += other.Passed
+	r.Failed += other.Failed
+	r.Skipped += other.Skipped
+	r.Failures = append(r.Failures, other.Failures...)
+	if other.Output != "" {
+		r.Output = strings.TrimPrefix(r.Output+"\n"+other.Output, "\n")
+	}
+}
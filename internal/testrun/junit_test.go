@@ -0,0 +1,73 @@
+package testrun
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJUnit(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<testsuites>
+  <testsuite name="pytest" tests="4" failures="1" errors="1" skipped="1">
+    <testcase classname="tests.test_math" name="test_add" file="tests/test_math.py" line="3"/>
+    <testcase classname="tests.test_math" name="test_sub">
+      <failure message="AssertionError: assert 1 == 2">def test_sub():
+&gt;       assert 1 == 2
+E       assert 1 == 2
+
+tests/test_math.py:8: AssertionError</failure>
+    </testcase>
+    <testcase classname="tests.test_io" name="test_read" file="tests/test_io.py" line="12">
+      <error message="FileNotFoundError: data.txt"/>
+    </testcase>
+    <testcase classname="tests.test_io" name="test_later">
+      <skipped message="todo"/>
+    </testcase>
+  </testsuite>
+</testsuites>`)
+
+	report, err := ParseJUnit(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Passed)
+	require.Equal(t, 2, report.Failed)
+	require.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Failures, 2)
+
+	require.Equal(t, "test_sub", report.Failures[0].Name)
+	require.Equal(t, "tests.test_math", report.Failures[0].Package)
+	require.Equal(t, "tests/test_math.py:8", report.Failures[0].Location())
+	require.Contains(t, report.Failures[0].Message, "AssertionError: assert 1 == 2")
+	require.Contains(t, report.Failures[0].Message, "E       assert 1 == 2")
+
+	require.Equal(t, "test_read", report.Failures[1].Name)
+	require.Equal(t, "tests/test_io.py:12", report.Failures[1].Location())
+	require.Equal(t, "FileNotFoundError: data.txt", report.Failures[1].Message)
+}
+
+func TestParseJUnitSingleSuite(t *testing.T) {
+	t.Parallel()
+
+	report, err := ParseJUnit([]byte(`<testsuite name="jest"><testcase name="renders" classname="App"/></testsuite>`))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Passed)
+	require.True(t, report.OK())
+}
+
+func TestParseGeneric(t *testing.T) {
+	t.Parallel()
+
+	output := "running 3 tests\nsrc/lib.rs:10: assertion failed\ntest result: FAILED. 2 passed; 1 failed; 0 ignored\n"
+	report := ParseGeneric(output, 101)
+	require.Equal(t, 2, report.Passed)
+	require.Equal(t, 1, report.Failed)
+	require.Len(t, report.Failures, 1)
+	require.Equal(t, "src/lib.rs:10", report.Failures[0].Location())
+	require.Contains(t, report.Output, "test result: FAILED")
+
+	report = ParseGeneric("Tests: 5 passed, 5 total\n", 0)
+	require.Equal(t, 5, report.Passed)
+	require.True(t, report.OK())
+}
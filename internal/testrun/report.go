@@ -0,0 +1,120 @@
+// Package testrun turns the output of test runners into compact, structured
+// reports: pass/fail counts plus, for each failure, the test name, its
+// assertion message and the file:line it points at.
+package testrun
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxFailureMessageLines bounds the message kept for a single failure so a
+// panicking test cannot flood the report.
+const maxFailureMessageLines = 30
+
+// Report summarizes a test run.
+type Report struct {
+	Passed   int       `json:"passed"`
+	Failed   int       `json:"failed"`
+	Skipped  int       `json:"skipped"`
+	Failures []Failure `json:"failures,omitempty"`
+	// Output holds raw output that could not be attributed to a test, such
+	// as build errors.
+	Output string `json:"output,omitempty"`
+}
+
+// Failure describes a single failing test, or a package that failed
+// without any failing test (e.g. it did not compile).
+type Failure struct {
+	Name    string `json:"name"`
+	Package string `json:"package,omitempty"`
+	Message string `json:"message,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// Location returns the failure's file:line, or an empty string if unknown.
+func (f Failure) Location() string {
+	switch {
+	case f.File == "":
+		return ""
+	case f.Line > 0:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	default:
+		return f.File
+	}
+}
+
+// OK reports whether the run had no failures.
+func (r Report) OK() bool {
+	return r.Failed == 0 && len(r.Failures) == 0
+}
+
+// String formats the report for the model: a summary line followed by one
+// block per failure.
+func (r Report) String() string {
+	var sb strings.Builder
+	status := "PASS"
+	if !r.OK() {
+		status = "FAIL"
+	}
+	fmt.Fprintf(&sb, "%s: %d passed, %d failed, %d skipped\n", status, r.Passed, r.Failed, r.Skipped)
+
+	for _, f := range r.Failures {
+		sb.WriteString("\n--- FAIL: ")
+		sb.WriteString(f.Name)
+		if f.Package != "" {
+			fmt.Fprintf(&sb, " (%s)", f.Package)
+		}
+		if loc := f.Location(); loc != "" {
+			sb.WriteString(" at ")
+			sb.WriteString(loc)
+		}
+		sb.WriteString("\n")
+		if f.Message != "" {
+			for line := range strings.SplitSeq(f.Message, "\n") {
+				sb.WriteString("    ")
+				sb.WriteString(line)
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	if r.Output != "" {
+		sb.WriteString("\n")
+		sb.WriteString(r.Output)
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// locationPattern matches file:line references such as "foo_test.go:42" or
+// "/abs/path/test_foo.py:7".
+var locationPattern = regexp.MustCompile(`([\w.\-/\\]+\.[A-Za-z]\w*):(\d+)`)
+
+// findLocation returns the first file:line reference in text.
+func findLocation(text string) (string, int) {
+	m := locationPattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", 0
+	}
+	line, _ := strconv.Atoi(m[2])
+	return m[1], line
+}
+
+// trimMessage drops blank lines at both ends and caps the number of lines.
+func trimMessage(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > maxFailureMessageLines {
+		omitted := len(lines) - maxFailureMessageLines
+		lines = append(lines[:maxFailureMessageLines:maxFailureMessageLines], fmt.Sprintf("... (%d more lines)", omitted))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -51,6 +51,9 @@ func (f *FetchToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 	if params.Timeout != 0 {
 		toolParams = append(toolParams, "timeout", formatTimeout(params.Timeout))
 	}
+	if params.NoCache {
+		toolParams = append(toolParams, "no_cache", "true")
+	}
 
 	header := toolHeader(sty, opts.Status, "Fetch", cappedWidth, opts.Compact, toolParams...)
 	if opts.Compact {
@@ -120,6 +123,9 @@ func (w *WebFetchToolRenderContext) RenderTool(sty *styles.Styles, width int, op
 	}
 
 	toolParams := []string{params.URL}
+	if params.NoCache {
+		toolParams = append(toolParams, "no_cache", "true")
+	}
 	header := toolHeader(sty, opts.Status, "Fetch", cappedWidth, opts.Compact, toolParams...)
 	if opts.Compact {
 		return header
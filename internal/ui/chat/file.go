@@ -3,6 +3,7 @@ package chat
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/crush/internal/agent/tools"
@@ -308,6 +309,91 @@ func (m *MultiEditToolRenderContext) RenderTool(sty *styles.Styles, width int, o
 	return joinToolParts(header, diff)
 }
 
+// -----------------------------------------------------------------------------
+// Apply Patch Tool
+// -----------------------------------------------------------------------------
+
+// ApplyPatchToolMessageItem is a message item that represents an apply_patch
+// tool call.
+type ApplyPatchToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*ApplyPatchToolMessageItem)(nil)
+
+// NewApplyPatchToolMessageItem creates a new [ApplyPatchToolMessageItem].
+func NewApplyPatchToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &ApplyPatchToolRenderContext{}, canceled)
+}
+
+// ApplyPatchToolRenderContext renders apply_patch tool messages.
+type ApplyPatchToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (a *ApplyPatchToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	// Apply patch tool uses full width for diffs.
+	if opts.IsPending() {
+		return pendingTool(sty, "Apply Patch", opts.Anim, opts.Compact)
+	}
+
+	var meta tools.ApplyPatchResponseMetadata
+	hasMeta := opts.HasResult() && json.Unmarshal([]byte(opts.Result.Metadata), &meta) == nil && len(meta.Files) > 0
+
+	var toolParams []string
+	if hasMeta {
+		toolParams = append(toolParams, fsext.PrettyPath(meta.Files[0].FilePath))
+		if len(meta.Files) > 1 {
+			toolParams = append(toolParams, "files", fmt.Sprintf("%d", len(meta.Files)))
+		}
+	}
+
+	header := toolHeader(sty, opts.Status, "Apply Patch", width, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if !opts.HasResult() {
+		if earlyState, ok := toolEarlyStateContent(sty, opts, width); ok {
+			return joinToolParts(header, earlyState)
+		}
+		return header
+	}
+
+	if !hasMeta {
+		bodyWidth := width - toolBodyLeftPaddingTotal
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+		return joinToolParts(header, body)
+	}
+
+	// Hunk failures are described in the result content; the diff would
+	// only show a partial application, so show the error on its own.
+	failed := slices.ContainsFunc(meta.Files, func(f tools.ApplyPatchFile) bool { return f.Error != "" })
+	if failed {
+		bodyWidth := width - toolBodyLeftPaddingTotal
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+		return joinToolParts(header, body)
+	}
+
+	diffs := make([]string, 0, len(meta.Files))
+	for _, file := range meta.Files {
+		diffs = append(diffs, toolOutputDiffContent(sty, fsext.PrettyPath(file.FilePath), file.OldContent, file.NewContent, width, opts.ExpandedContent))
+	}
+	diff := strings.Join(diffs, "\n\n")
+
+	// On error (e.g. denied permission), show error above the diff.
+	if opts.Result.IsError {
+		errLine := toolErrorContent(sty, opts.Result, width)
+		return strings.Join([]string{header, "", errLine, "", diff}, "\n")
+	}
+
+	return joinToolParts(header, diff)
+}
+
 // -----------------------------------------------------------------------------
 // Download Tool
 // -----------------------------------------------------------------------------
@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// ToolSearchToolMessageItem is a message item that represents a tool_search
+// tool call.
+type ToolSearchToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*ToolSearchToolMessageItem)(nil)
+
+// NewToolSearchToolMessageItem creates a new [ToolSearchToolMessageItem].
+func NewToolSearchToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &ToolSearchToolRenderContext{}, canceled)
+}
+
+// ToolSearchToolRenderContext renders tool_search tool messages, listing
+// the tools the search activated.
+type ToolSearchToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *ToolSearchToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Tool Search", opts.Anim, opts.Compact)
+	}
+
+	var params tools.ToolSearchParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	header := toolHeader(sty, opts.Status, "Tool Search", cappedWidth, opts.Compact, params.Query)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	content := opts.Result.Content
+	var meta tools.ToolSearchResponseMetadata
+	if err := json.Unmarshal([]byte(opts.Result.Metadata), &meta); err == nil && len(meta.Activated) > 0 {
+		content = "Activated:\n" + strings.Join(meta.Activated, "\n")
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/skills"
 	"github.com/charmbracelet/crush/internal/stringext"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/common"
@@ -171,7 +172,7 @@ func newBaseToolMessageItem(
 	canceled bool,
 ) *baseToolMessageItem {
 	// we only do full width for diffs (as far as I know)
-	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName
+	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName && toolCall.Name != tools.ApplyPatchToolName
 
 	status := ToolStatusRunning
 	if canceled {
@@ -221,6 +222,8 @@ func NewToolMessageItem(
 		item = NewBashToolMessageItem(sty, toolCall, result, canceled)
 	case tools.JobOutputToolName:
 		item = NewJobOutputToolMessageItem(sty, toolCall, result, canceled)
+	case tools.JobInputToolName:
+		item = NewJobInputToolMessageItem(sty, toolCall, result, canceled)
 	case tools.JobKillToolName:
 		item = NewJobKillToolMessageItem(sty, toolCall, result, canceled)
 	case tools.ViewToolName:
@@ -231,6 +234,8 @@ func NewToolMessageItem(
 		item = NewEditToolMessageItem(sty, toolCall, result, canceled)
 	case tools.MultiEditToolName:
 		item = NewMultiEditToolMessageItem(sty, toolCall, result, canceled)
+	case tools.ApplyPatchToolName:
+		item = NewApplyPatchToolMessageItem(sty, toolCall, result, canceled)
 	case tools.GlobToolName:
 		item = NewGlobToolMessageItem(sty, toolCall, result, canceled)
 	case tools.GrepToolName:
@@ -259,6 +264,8 @@ func NewToolMessageItem(
 		item = NewReferencesToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSPRestartToolName:
 		item = NewLSPRestartToolMessageItem(sty, toolCall, result, canceled)
+	case tools.ToolSearchToolName:
+		item = NewToolSearchToolMessageItem(sty, toolCall, result, canceled)
 	default:
 		if IsDockerMCPTool(toolCall.Name) {
 			item = NewDockerMCPToolMessageItem(sty, toolCall, result, canceled)
@@ -340,6 +347,9 @@ func (t *baseToolMessageItem) RawRender(width int) string {
 			if hookLine := toolOutputHookIndicator(t.sty, t.result.Metadata, toolItemWidth); hookLine != "" {
 				content = hookLine + "\n\n" + content
 			}
+			if skillLine := toolOutputSkillActivations(t.sty, t.result.Metadata); skillLine != "" {
+				content += "\n\n" + skillLine
+			}
 		}
 
 		height = lipgloss.Height(content)
@@ -731,6 +741,31 @@ func toolOutputSkillContent(sty *styles.Styles, name, description string) string
 	))
 }
 
+// toolOutputSkillActivations renders a line for each skill activated by
+// the file the tool read or edited. Returns empty string if none was.
+func toolOutputSkillActivations(sty *styles.Styles, metadata string) string {
+	if metadata == "" {
+		return ""
+	}
+	var meta struct {
+		ActivatedSkills []skills.Activation `json:"activated_skills"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &meta); err != nil || len(meta.ActivatedSkills) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(meta.ActivatedSkills))
+	for _, a := range meta.ActivatedSkills {
+		lines = append(lines, sty.Tool.Body.Render(fmt.Sprintf(
+			"%s %s %s %s",
+			sty.Tool.ResourceLoadedText.Render("Activated Skill"),
+			sty.Tool.ResourceLoadedIndicator.Render(styles.ArrowRightIcon),
+			sty.Tool.ResourceName.Render(a.Name),
+			sty.Tool.ResourceSize.Render(fsext.PrettyPath(a.Path)),
+		)))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // toolOutputHookIndicator renders hook indicator lines from tool metadata.
 // Returns empty string if no hook metadata is present. Hook names are
 // sanitized (newlines replaced with ¶) and truncated to fit the available
@@ -1627,6 +1662,8 @@ func prettifyToolName(name string) string {
 		return "Bash"
 	case tools.JobOutputToolName:
 		return "Job: Output"
+	case tools.JobInputToolName:
+		return "Job: Input"
 	case tools.JobKillToolName:
 		return "Job: Kill"
 	case tools.DownloadToolName:
@@ -1635,6 +1672,16 @@ func prettifyToolName(name string) string {
 		return "Edit"
 	case tools.MultiEditToolName:
 		return "Multi-Edit"
+	case tools.ApplyPatchToolName:
+		return "Apply Patch"
+	case tools.NotebookEditToolName:
+		return "Notebook Edit"
+	case tools.GitToolName:
+		return "Git"
+	case tools.RunTestsToolName:
+		return "Run Tests"
+	case tools.HTTPRequestToolName:
+		return "HTTP Request"
 	case tools.FetchToolName:
 		return "Fetch"
 	case tools.AgenticFetchToolName:
@@ -1653,6 +1700,8 @@ func prettifyToolName(name string) string {
 		return "Sourcegraph"
 	case tools.TodosToolName:
 		return "To-Do"
+	case tools.ToolSearchToolName:
+		return "Tool Search"
 	case tools.ViewToolName:
 		return "View"
 	case tools.WriteToolName:
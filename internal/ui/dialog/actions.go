@@ -8,6 +8,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/message"
@@ -61,6 +62,11 @@ type (
 	ActionSummarize                   struct {
 		SessionID string
 	}
+	// ActionResetShellState is a message to forget the shell state the
+	// bash tool saved for a session.
+	ActionResetShellState struct {
+		SessionID string
+	}
 	// ActionSelectReasoningEffort is a message indicating a reasoning effort
 	// has been selected.
 	ActionSelectReasoningEffort struct {
@@ -70,12 +76,20 @@ type (
 		Permission permission.PermissionRequest
 		Action     PermissionAction
 	}
+	// ActionMCPElicitationResponse is a message answering the elicitation
+	// request of an MCP server.
+	ActionMCPElicitationResponse struct {
+		Response mcp.ElicitationResponse
+	}
 	// ActionRunCustomCommand is a message to run a custom command.
 	ActionRunCustomCommand struct {
 		Content   string
 		Arguments []commands.Argument
 		Args      map[string]string // Actual argument values
 		Skill     *skills.Skill     // Set when this is a skill command
+		// Command is set for commands loaded from files, which run with
+		// the overrides of their frontmatter.
+		Command *commands.CustomCommand
 	}
 	// ActionAttachSkill is sent when a skill is selected from the commands
 	// dialog to be attached to the conversation as a markdown attachment.
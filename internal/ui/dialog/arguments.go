@@ -99,6 +99,7 @@ func NewArguments(com *common.Common, title, description string, arguments []com
 		} else {
 			input.Placeholder = arg.Title
 		}
+		input.SetValue(arg.Default)
 
 		if i == 0 {
 			input.Focus()
@@ -2,6 +2,7 @@ package dialog
 
 import (
 	"os"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -11,6 +12,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -389,6 +391,7 @@ func (c *Commands) setCommandItems(commandType CommandType) {
 			commandItems = append(commandItems, cmd)
 		}
 	case UserCommands:
+		conflicts := commands.Conflicts(c.customCommands, c.mcpPrompts)
 		for _, cmd := range c.customCommands {
 			var action Action
 			if cmd.Skill != nil {
@@ -398,15 +401,21 @@ func (c *Commands) setCommandItems(commandType CommandType) {
 					Content:   cmd.Content,
 					Arguments: cmd.Arguments,
 					Skill:     cmd.Skill,
+					Command:   &cmd,
 				}
 			}
 			item := NewCommandItem(c.com.Styles, "custom_"+cmd.ID, cmd.Name, "", action)
+			description := strings.TrimSpace(cmd.ArgumentHint + " " + cmd.Description)
 			if cmd.Skill != nil {
-				item = item.WithDescription(cmd.Skill.Description)
+				description = cmd.Skill.Description
+			}
+			if description := joinNonEmpty(description, commandConflictNote(cmd.Overrides, conflicts[cmd.ID])); description != "" {
+				item = item.WithDescription(description)
 			}
 			commandItems = append(commandItems, item)
 		}
 	case MCPPrompts:
+		conflicts := commands.Conflicts(c.customCommands, c.mcpPrompts)
 		for _, cmd := range c.mcpPrompts {
 			action := ActionRunMCPPrompt{
 				Title:       cmd.Title,
@@ -415,7 +424,11 @@ func (c *Commands) setCommandItems(commandType CommandType) {
 				ClientID:    cmd.ClientID,
 				Arguments:   cmd.Arguments,
 			}
-			commandItems = append(commandItems, NewCommandItem(c.com.Styles, "mcp_"+cmd.ID, cmd.PromptID, "", action))
+			item := NewCommandItem(c.com.Styles, "mcp_"+cmd.ID, cmd.PromptID, "", action)
+			if note := commandConflictNote(nil, conflicts[cmd.ID]); note != "" {
+				item = item.WithDescription(note)
+			}
+			commandItems = append(commandItems, item)
 		}
 	}
 
@@ -426,6 +439,26 @@ func (c *Commands) setCommandItems(commandType CommandType) {
 	c.input.SetValue("")
 }
 
+// commandConflictNote describes how a command relates to the definitions it
+// shadows and to the commands of other sources sharing its name.
+func commandConflictNote(overrides, others []string) string {
+	var notes []string
+	if len(overrides) > 0 {
+		notes = append(notes, "overrides "+home.Short(overrides[len(overrides)-1]))
+	}
+	if len(others) > 0 {
+		notes = append(notes, "also "+strings.Join(others, ", "))
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(notes, "; ") + ")"
+}
+
+func joinNonEmpty(parts ...string) string {
+	return strings.Join(slices.DeleteFunc(parts, func(s string) bool { return s == "" }), " ")
+}
+
 // defaultCommands returns the list of default system commands.
 func (c *Commands) defaultCommands() []*CommandItem {
 	commands := []*CommandItem{
@@ -511,6 +544,10 @@ func (c *Commands) defaultCommands() []*CommandItem {
 		commands = append(commands, NewCommandItem(c.com.Styles, "toggle_pills", label, "ctrl+t", ActionTogglePills{}))
 	}
 
+	if c.hasSession {
+		commands = append(commands, NewCommandItem(c.com.Styles, "shell_state", "Shell State", "", ActionOpenDialog{DialogID: ShellStateID}))
+	}
+
 	// Add a command for selecting notification style via picker dialog.
 	notificationLabel := "Notification Style"
 	commands = append(commands, NewCommandItem(c.com.Styles, "select_notifications", notificationLabel, "", ActionOpenDialog{DialogID: NotificationsID}))
@@ -0,0 +1,318 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// ElicitationID is the identifier for the MCP elicitation dialog.
+const ElicitationID = "elicitation"
+
+// Elicitation is a form collecting the input an MCP server requested from
+// the user.
+type Elicitation struct {
+	com     *common.Common
+	request mcp.ElicitationRequest
+	inputs  []textinput.Model
+	focused int
+
+	help   help.Model
+	keyMap struct {
+		Confirm,
+		Next,
+		Previous,
+		Option,
+		Decline,
+		Close key.Binding
+	}
+
+	viewport viewport.Model
+}
+
+var _ Dialog = (*Elicitation)(nil)
+
+// NewElicitation creates a new elicitation dialog.
+func NewElicitation(com *common.Common, request mcp.ElicitationRequest) *Elicitation {
+	e := &Elicitation{
+		com:     com,
+		request: request,
+	}
+
+	e.help = help.New()
+	e.help.Styles = com.Styles.DialogHelpStyles()
+
+	e.keyMap.Confirm = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "submit"),
+	)
+	e.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "tab"),
+		key.WithHelp("↓/tab", "next"),
+	)
+	e.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "shift+tab"),
+		key.WithHelp("↑/shift+tab", "previous"),
+	)
+	e.keyMap.Option = key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "choose"),
+	)
+	e.keyMap.Decline = key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "decline"),
+	)
+	e.keyMap.Close = key.NewBinding(
+		key.WithKeys("esc", "alt+esc"),
+		key.WithHelp("esc", "cancel"),
+	)
+
+	e.inputs = make([]textinput.Model, len(request.Fields))
+	for i, field := range request.Fields {
+		input := textinput.New()
+		input.SetVirtualCursor(false)
+		input.SetStyles(com.Styles.TextInput)
+		input.Prompt = "> "
+		input.Placeholder = field.Description
+		if options := field.Options(); len(options) > 0 && input.Placeholder == "" {
+			input.Placeholder = "←/→ to choose"
+		}
+		if field.Default != nil {
+			input.SetValue(fmt.Sprint(field.Default))
+		}
+		if i == 0 {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+		e.inputs[i] = input
+	}
+
+	return e
+}
+
+// ID implements Dialog.
+func (e *Elicitation) ID() string {
+	return ElicitationID
+}
+
+// RequestID returns the ID of the elicitation request the dialog answers.
+func (e *Elicitation) RequestID() string {
+	return e.request.ID
+}
+
+// focusInput changes focus to a new input by index with wrap-around.
+func (e *Elicitation) focusInput(newIndex int) {
+	if len(e.inputs) == 0 {
+		return
+	}
+	e.inputs[e.focused].Blur()
+	n := len(e.inputs)
+	e.focused = ((newIndex % n) + n) % n
+	e.inputs[e.focused].Focus()
+
+	fieldStart := e.focused * argumentsFieldHeight
+	fieldEnd := fieldStart + argumentsFieldHeight - 1
+	switch top := e.viewport.YOffset(); {
+	case fieldStart < top:
+		e.viewport.SetYOffset(fieldStart)
+	case fieldEnd > top+e.viewport.Height()-1:
+		e.viewport.SetYOffset(fieldEnd - e.viewport.Height() + 1)
+	}
+}
+
+// cycleOption replaces the value of the focused field with the next or
+// previous of its options. It reports false when the field is free text.
+func (e *Elicitation) cycleOption(forward bool) bool {
+	if len(e.inputs) == 0 {
+		return false
+	}
+	options := e.request.Fields[e.focused].Options()
+	if len(options) == 0 {
+		return false
+	}
+	i := slices.Index(options, e.inputs[e.focused].Value())
+	switch {
+	case i < 0:
+		i = 0
+	case forward:
+		i = (i + 1) % len(options)
+	default:
+		i = (i - 1 + len(options)) % len(options)
+	}
+	e.inputs[e.focused].SetValue(options[i])
+	e.inputs[e.focused].CursorEnd()
+	return true
+}
+
+func (e *Elicitation) respond(action string, content map[string]any) Action {
+	return ActionMCPElicitationResponse{
+		Response: mcp.ElicitationResponse{
+			ID:      e.request.ID,
+			Action:  action,
+			Content: content,
+		},
+	}
+}
+
+// submit accepts the request with the values entered, or warns about the
+// first invalid one.
+func (e *Elicitation) submit() Action {
+	content := make(map[string]any, len(e.inputs))
+	for i, field := range e.request.Fields {
+		v, err := field.Parse(e.inputs[i].Value())
+		if err != nil {
+			e.focusInput(i)
+			return ActionCmd{Cmd: util.ReportWarn(err.Error())}
+		}
+		if v != nil {
+			content[field.Name] = v
+		}
+	}
+	return e.respond(mcp.ElicitationAccept, content)
+}
+
+// HandleMsg implements Dialog.
+func (e *Elicitation) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, e.keyMap.Close):
+			return e.respond(mcp.ElicitationCancel, nil)
+		case key.Matches(msg, e.keyMap.Decline):
+			return e.respond(mcp.ElicitationDecline, nil)
+		case key.Matches(msg, e.keyMap.Confirm):
+			if e.focused >= len(e.inputs)-1 {
+				return e.submit()
+			}
+			e.focusInput(e.focused + 1)
+		case key.Matches(msg, e.keyMap.Next):
+			e.focusInput(e.focused + 1)
+		case key.Matches(msg, e.keyMap.Previous):
+			e.focusInput(e.focused - 1)
+		case key.Matches(msg, e.keyMap.Option) && e.cycleOption(msg.String() == "right"):
+		default:
+			if len(e.inputs) == 0 {
+				return nil
+			}
+			var cmd tea.Cmd
+			e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+			return ActionCmd{Cmd: cmd}
+		}
+	case common.CoalescedWheelMsg:
+		e.viewport, _ = e.viewport.Update(tea.MouseWheelMsg(msg.Mouse))
+	case tea.PasteMsg:
+		if len(e.inputs) == 0 {
+			return nil
+		}
+		var cmd tea.Cmd
+		e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+		return ActionCmd{Cmd: cmd}
+	}
+	return nil
+}
+
+// Draw implements Dialog.
+func (e *Elicitation) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	s := e.com.Styles
+
+	contentStyle := s.Dialog.Arguments.Content
+	possibleWidth := area.Dx() - s.Dialog.View.GetHorizontalFrameSize() - contentStyle.GetHorizontalFrameSize()
+
+	fields := make([]string, 0, len(e.inputs))
+	for i, field := range e.request.Fields {
+		labelStyle := s.Dialog.Arguments.InputLabelBlurred
+		markStyle := s.Dialog.Arguments.InputRequiredMarkBlurred
+		if i == e.focused {
+			labelStyle = s.Dialog.Arguments.InputLabelFocused
+			markStyle = s.Dialog.Arguments.InputRequiredMarkFocused
+		}
+		labelText := field.Label()
+		if field.Required {
+			labelText += markStyle.String()
+		}
+
+		inputWidth := max(lipgloss.Width(e.inputs[i].Placeholder), lipgloss.Width(labelText), minInputWidth)
+		e.inputs[i].SetWidth(min(inputWidth, possibleWidth, maxInputWidth))
+
+		fields = append(fields, lipgloss.JoinVertical(lipgloss.Left, labelStyle.Render(labelText), e.inputs[i].View(), ""))
+	}
+	renderedFields := lipgloss.JoinVertical(lipgloss.Left, fields...)
+
+	width := max(lipgloss.Width(renderedFields), min(possibleWidth, maxInputWidth/2))
+	header := common.DialogTitle(s, e.request.Name+" needs your input", width, s.Dialog.TitleGradFromColor, s.Dialog.TitleGradToColor)
+
+	var description string
+	var descriptionHeight int
+	if e.request.Message != "" {
+		description = s.Dialog.Arguments.Description.Width(width).Render(e.request.Message)
+		descriptionHeight = lipgloss.Height(description)
+	}
+	helpView := s.Dialog.HelpView.Width(width).Render(e.help.View(e))
+
+	availableHeight := area.Dy() - s.Dialog.View.GetVerticalFrameSize() - contentStyle.GetVerticalFrameSize() - lipgloss.Height(header) - descriptionHeight - lipgloss.Height(helpView) - 2
+	viewportHeight := min(lipgloss.Height(renderedFields), maxViewportHeight, availableHeight)
+	e.viewport.SetWidth(width)
+	e.viewport.SetHeight(viewportHeight)
+	e.viewport.SetContent(renderedFields)
+
+	var contentParts []string
+	if description != "" {
+		contentParts = append(contentParts, description)
+	}
+	if len(fields) > 0 {
+		content := e.viewport.View()
+		if scrollbar := common.Scrollbar(s, viewportHeight, e.viewport.TotalLineCount(), viewportHeight, e.viewport.YOffset()); scrollbar != "" {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, content, scrollbar)
+		}
+		contentParts = append(contentParts, content)
+	}
+
+	view := lipgloss.JoinVertical(
+		lipgloss.Left,
+		s.Dialog.Title.Render(header),
+		contentStyle.Render(lipgloss.JoinVertical(lipgloss.Left, contentParts...)),
+		helpView,
+	)
+
+	var cur *tea.Cursor
+	if len(e.inputs) > 0 {
+		cur = InputCursor(s, e.inputs[e.focused].Cursor())
+		if cur != nil {
+			cur.Y += descriptionHeight + e.focused*argumentsFieldHeight - e.viewport.YOffset() + 1
+		}
+	}
+	DrawCenterCursor(scr, area, s.Dialog.View.Render(view), cur)
+	return cur
+}
+
+// ShortHelp implements help.KeyMap.
+func (e *Elicitation) ShortHelp() []key.Binding {
+	bindings := []key.Binding{e.keyMap.Confirm}
+	if len(e.inputs) > 0 {
+		bindings = append(bindings, e.keyMap.Next)
+		if len(e.request.Fields[e.focused].Options()) > 0 {
+			bindings = append(bindings, e.keyMap.Option)
+		}
+	}
+	return append(bindings, e.keyMap.Decline, e.keyMap.Close)
+}
+
+// FullHelp implements help.KeyMap.
+func (e *Elicitation) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{e.keyMap.Confirm, e.keyMap.Next, e.keyMap.Previous, e.keyMap.Option},
+		{e.keyMap.Decline, e.keyMap.Close},
+	}
+}
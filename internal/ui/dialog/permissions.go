@@ -321,7 +321,7 @@ func (p *Permissions) respond(action PermissionAction) tea.Msg {
 
 func (p *Permissions) hasDiffView() bool {
 	switch p.permission.ToolName {
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName:
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ApplyPatchToolName, tools.NotebookEditToolName:
 		return true
 	}
 	return false
@@ -468,7 +468,7 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 			lines = append(lines, p.renderKeyValue("URL", params.URL, contentWidth))
 			lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(params.FilePath), contentWidth))
 		}
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName:
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ApplyPatchToolName, tools.ViewToolName:
 		var filePath string
 		switch params := p.permission.Params.(type) {
 		case tools.EditPermissionsParams:
@@ -477,12 +477,44 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 			filePath = params.FilePath
 		case tools.MultiEditPermissionsParams:
 			filePath = params.FilePath
+		case tools.ApplyPatchPermissionsParams:
+			if params.OldPath != "" {
+				lines = append(lines, p.renderKeyValue("From", fsext.PrettyPath(params.OldPath), contentWidth))
+			}
+			filePath = params.FilePath
 		case tools.ViewPermissionsParams:
 			filePath = params.FilePath
 		}
 		if filePath != "" {
 			lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(filePath), contentWidth))
 		}
+	case tools.NotebookEditToolName:
+		if params, ok := p.permission.Params.(tools.NotebookEditPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(params.NotebookPath), contentWidth))
+			cell := fmt.Sprintf("%d", params.CellIndex)
+			if params.CellID != "" {
+				cell += fmt.Sprintf(" (id %s)", params.CellID)
+			}
+			lines = append(lines, p.renderKeyValue("Cell", cell, contentWidth))
+			lines = append(lines, p.renderKeyValue("Action", params.EditMode+" "+params.CellType, contentWidth))
+		}
+	case tools.HTTPRequestToolName:
+		if params, ok := p.permission.Params.(tools.HTTPRequestPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Method", params.Method, contentWidth))
+			lines = append(lines, p.renderKeyValue("URL", params.URL, contentWidth))
+		}
+	case tools.JobInputToolName:
+		if params, ok := p.permission.Params.(tools.JobInputPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Job", params.ShellID, contentWidth))
+		}
+	case tools.RunTestsToolName:
+		if params, ok := p.permission.Params.(tools.RunTestsPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Directory", fsext.PrettyPath(params.WorkingDir), contentWidth))
+		}
+	case tools.GitToolName:
+		if params, ok := p.permission.Params.(tools.GitPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Operation", params.Operation, contentWidth))
+		}
 	case tools.LSToolName:
 		if params, ok := p.permission.Params.(tools.LSPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("Directory", fsext.PrettyPath(params.Path), contentWidth))
@@ -536,6 +568,18 @@ func (p *Permissions) renderContent(width int) string {
 		return p.renderWriteContent(width)
 	case tools.MultiEditToolName:
 		return p.renderMultiEditContent(width)
+	case tools.ApplyPatchToolName:
+		return p.renderApplyPatchContent(width)
+	case tools.NotebookEditToolName:
+		return p.renderNotebookEditContent(width)
+	case tools.GitToolName:
+		return p.renderGitContent(width)
+	case tools.RunTestsToolName:
+		return p.renderRunTestsContent(width)
+	case tools.JobInputToolName:
+		return p.renderJobInputContent(width)
+	case tools.HTTPRequestToolName:
+		return p.renderHTTPRequestContent(width)
 	case tools.DownloadToolName:
 		return p.renderDownloadContent(width)
 	case tools.FetchToolName:
@@ -560,6 +604,62 @@ func (p *Permissions) renderBashContent(width int) string {
 	return p.renderContentPanel(params.Command, width)
 }
 
+func (p *Permissions) renderHTTPRequestContent(width int) string {
+	params, ok := p.permission.Params.(tools.HTTPRequestPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "%s %s", params.Method, params.URL)
+	for _, header := range params.Headers {
+		content.WriteString("\n" + header)
+	}
+	if params.Body != "" {
+		content.WriteString("\n\n" + params.Body)
+	}
+	return p.renderContentPanel(content.String(), width)
+}
+
+func (p *Permissions) renderRunTestsContent(width int) string {
+	params, ok := p.permission.Params.(tools.RunTestsPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	return p.renderContentPanel(params.Command, width)
+}
+
+func (p *Permissions) renderJobInputContent(width int) string {
+	params, ok := p.permission.Params.(tools.JobInputPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	content := params.Command + "\n\n" + params.Input
+	if params.Key != "" {
+		content += "<" + params.Key + ">"
+	}
+	return p.renderContentPanel(content, width)
+}
+
+func (p *Permissions) renderGitContent(width int) string {
+	params, ok := p.permission.Params.(tools.GitPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	var content strings.Builder
+	switch {
+	case params.All:
+		content.WriteString("Stage: all modified tracked files\n\n")
+	case len(params.Paths) > 0:
+		content.WriteString("Stage: " + strings.Join(params.Paths, ", ") + "\n\n")
+	}
+	content.WriteString(params.Message)
+	return p.renderContentPanel(content.String(), width)
+}
+
 func (p *Permissions) renderEditContent(contentWidth int) string {
 	params, ok := p.permission.Params.(tools.EditPermissionsParams)
 	if !ok {
@@ -584,6 +684,24 @@ func (p *Permissions) renderMultiEditContent(contentWidth int) string {
 	return p.renderDiff(params.FilePath, params.OldContent, params.NewContent, contentWidth)
 }
 
+func (p *Permissions) renderApplyPatchContent(contentWidth int) string {
+	params, ok := p.permission.Params.(tools.ApplyPatchPermissionsParams)
+	if !ok {
+		return ""
+	}
+	return p.renderDiff(params.FilePath, params.OldContent, params.NewContent, contentWidth)
+}
+
+// renderNotebookEditContent renders a diff of the edited cell's source
+// rather than of the notebook JSON.
+func (p *Permissions) renderNotebookEditContent(contentWidth int) string {
+	params, ok := p.permission.Params.(tools.NotebookEditPermissionsParams)
+	if !ok {
+		return ""
+	}
+	return p.renderDiff(params.NotebookPath, params.OldContent, params.NewContent, contentWidth)
+}
+
 func (p *Permissions) renderDiff(filePath, oldContent, newContent string, contentWidth int) string {
 	if !p.viewportDirty {
 		if p.isSplitMode() {
@@ -0,0 +1,130 @@
+package dialog
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/shellstate"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// ShellStateID is the identifier for the shell state dialog.
+	ShellStateID              = "shell_state"
+	shellStateDialogMaxWidth  = 70
+	shellStateDialogMaxHeight = 20
+)
+
+// ShellState shows the working directory and exported variables the bash
+// tool carries over between commands of the current session.
+type ShellState struct {
+	com   *common.Common
+	help  help.Model
+	state shellstate.State
+
+	keyMap struct {
+		Reset key.Binding
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*ShellState)(nil)
+
+// NewShellState creates a new shell state dialog for state.
+func NewShellState(com *common.Common, state shellstate.State) *ShellState {
+	s := &ShellState{com: com, state: state}
+
+	h := help.New()
+	h.Styles = com.Styles.DialogHelpStyles()
+	s.help = h
+
+	s.keyMap.Reset = key.NewBinding(
+		key.WithKeys("r", "ctrl+r"),
+		key.WithHelp("r", "reset"),
+	)
+	s.keyMap.Close = CloseKey
+	return s
+}
+
+// ID implements [Dialog].
+func (*ShellState) ID() string {
+	return ShellStateID
+}
+
+// HandleMsg implements [Dialog].
+func (s *ShellState) HandleMsg(msg tea.Msg) Action {
+	if msg, ok := msg.(tea.KeyPressMsg); ok {
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, s.keyMap.Reset):
+			if s.state.IsZero() {
+				return ActionClose{}
+			}
+			return ActionResetShellState{SessionID: s.state.SessionID}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (s *ShellState) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(shellStateDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	s.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Shell State"
+	rc.Gap = 1
+	if s.state.IsZero() {
+		rc.AddPart(t.Dialog.SecondaryText.Render("Commands in this session start in the working directory with a fresh environment."))
+	} else {
+		rc.AddPart(s.renderKeyValue("Directory", cmp.Or(s.state.WorkingDir, "(working directory)"), innerWidth))
+		rc.AddPart(s.renderEnv(innerWidth, shellStateDialogMaxHeight))
+	}
+	rc.Help = s.help.View(s)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+func (s *ShellState) renderKeyValue(key, value string, width int) string {
+	t := s.com.Styles
+	keyStr := t.Dialog.Permissions.KeyText.Render(key)
+	valueStr := t.Dialog.Permissions.ValueText.Width(width - lipgloss.Width(keyStr) - 1).Render(" " + value)
+	return lipgloss.JoinHorizontal(lipgloss.Left, keyStr, valueStr)
+}
+
+// renderEnv renders the saved variables, at most maxLines of them.
+func (s *ShellState) renderEnv(width, maxLines int) string {
+	t := s.com.Styles
+	if len(s.state.Env) == 0 {
+		return t.Dialog.SecondaryText.Render("No exported variables.")
+	}
+
+	lines := s.state.Env
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines-1:maxLines-1], fmt.Sprintf("… and %d more", len(s.state.Env)-maxLines+1))
+	}
+	return t.Dialog.ContentPanel.Width(width).Render(strings.Join(lines, "\n"))
+}
+
+// ShortHelp implements [help.KeyMap].
+func (s *ShellState) ShortHelp() []key.Binding {
+	if s.state.IsZero() {
+		return []key.Binding{s.keyMap.Close}
+	}
+	return []key.Binding{s.keyMap.Reset, s.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (s *ShellState) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
@@ -29,7 +29,7 @@ func (m *UI) mcpInfo(width, maxItems int, isSection bool) string {
 	}
 	list := t.Resource.AdditionalText.Render("None")
 	if len(mcps) > 0 {
-		list = mcpList(t, mcps, width, maxItems)
+		list = mcpList(t, m.com.Config(), mcps, width, maxItems)
 	}
 
 	return lipgloss.NewStyle().Width(width).Render(fmt.Sprintf("%s\n\n%s", title, list))
@@ -50,9 +50,17 @@ func mcpCounts(t *styles.Styles, counts mcp.Counts) string {
 	return strings.Join(parts, " ")
 }
 
+// mcpRestarts formats the number of automatic restarts of an MCP server.
+func mcpRestarts(n int) string {
+	if n == 1 {
+		return "restarted once"
+	}
+	return fmt.Sprintf("restarted %d times", n)
+}
+
 // mcpList renders a list of MCP clients with their status and counts,
 // truncating to maxItems if needed.
-func mcpList(t *styles.Styles, mcps []mcp.ClientInfo, width, maxItems int) string {
+func mcpList(t *styles.Styles, cfg *config.Config, mcps []mcp.ClientInfo, width, maxItems int) string {
 	if maxItems <= 0 {
 		return ""
 	}
@@ -76,12 +84,28 @@ func mcpList(t *styles.Styles, mcps []mcp.ClientInfo, width, maxItems int) strin
 		case mcp.StateConnected:
 			icon = t.Resource.OnlineIcon.String()
 			extraContent = mcpCounts(t, m.Counts)
+			var status []string
+			// Deferred tools are only sent once tool_search activated them.
+			if cfg.DefersMCPTools(m.Name, m.Counts.Tools) {
+				status = append(status, "tools on demand")
+			}
+			if m.Restarts > 0 {
+				status = append(status, mcpRestarts(m.Restarts))
+			}
+			if len(status) > 0 {
+				description = t.Resource.StatusText.Render(strings.Join(status, ", "))
+			}
 		case mcp.StateError:
 			icon = t.Resource.ErrorIcon.String()
-			description = t.Resource.StatusText.Render("error")
+			status := "error"
 			if m.Error != nil {
-				description = t.Resource.StatusText.Render(fmt.Sprintf("error: %s", m.Error.Error()))
+				status = fmt.Sprintf("error: %s", m.Error.Error())
+			}
+			// The last stderr line usually tells why a stdio server died.
+			if m.Stderr != "" {
+				status += "; stderr: " + m.Stderr
 			}
+			description = t.Resource.StatusText.Render(status)
 		case mcp.StateDisabled:
 			icon = t.Resource.DisabledIcon.String()
 			description = t.Resource.StatusText.Render("disabled")
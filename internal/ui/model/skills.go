@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"slices"
@@ -8,16 +9,79 @@ import (
 	"sync"
 
 	"charm.land/lipgloss/v2"
+	agenttools "github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/skills"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 )
 
+// activeSkill is a skill loaded in the current session, with the tools it
+// grants while loaded.
+type activeSkill struct {
+	allowedTools []string
+	strict       bool
+}
+
+// trackActiveSkills records the skills loaded by the view tool results of
+// msg, and those activated by the files its tool results read or edited.
+func (m *UI) trackActiveSkills(msg message.Message) {
+	for _, tr := range msg.ToolResults() {
+		if tr.IsError || tr.Metadata == "" {
+			continue
+		}
+		var activations struct {
+			ActivatedSkills []skills.Activation `json:"activated_skills"`
+		}
+		if err := json.Unmarshal([]byte(tr.Metadata), &activations); err == nil {
+			// Activated skills grant no tools, so they never replace a skill
+			// loaded explicitly.
+			for _, a := range activations.ActivatedSkills {
+				if _, ok := m.activeSkills[a.Name]; !ok {
+					m.setActiveSkill(a.Name, activeSkill{})
+				}
+			}
+		}
+		if tr.Name != agenttools.ViewToolName {
+			continue
+		}
+		var meta agenttools.ViewResponseMetadata
+		if err := json.Unmarshal([]byte(tr.Metadata), &meta); err != nil || meta.ResourceType != agenttools.ViewResourceSkill {
+			continue
+		}
+		m.setActiveSkill(meta.ResourceName, activeSkill{
+			allowedTools: meta.ResourceAllowedTools,
+			strict:       meta.ResourceStrict,
+		})
+	}
+}
+
+func (m *UI) setActiveSkill(name string, skill activeSkill) {
+	if m.activeSkills == nil {
+		m.activeSkills = make(map[string]activeSkill)
+	}
+	m.activeSkills[name] = skill
+}
+
+// description summarizes the tools the skill grants.
+func (s activeSkill) description() string {
+	var b strings.Builder
+	b.WriteString("active")
+	if s.strict {
+		b.WriteString(", strict")
+	}
+	if len(s.allowedTools) > 0 {
+		b.WriteString(": ")
+		b.WriteString(strings.Join(s.allowedTools, " "))
+	}
+	return b.String()
+}
+
 type skillStatusItem struct {
 	icon  string
 	name  string
 	title string
-	// description is reserved for future use (e.g. showing error details).
+	// description shows the tools granted by skills active in the session.
 	description string
 }
 
@@ -87,11 +151,7 @@ func (m *UI) skillStatusItems() []skillStatusItem {
 		if state.State == skills.StateError {
 			icon = t.Resource.ErrorIcon.String()
 		}
-		items = append(items, skillStatusItem{
-			icon:  icon,
-			name:  name,
-			title: t.Resource.Name.Render(name),
-		})
+		items = append(items, m.skillStatusItem(icon, name))
 	}
 
 	builtin := cachedBuiltinSkills()
@@ -105,20 +165,37 @@ func (m *UI) skillStatusItems() []skillStatusItem {
 		if disabledSet[skill.Name] {
 			continue
 		}
-		items = append(items, skillStatusItem{
-			icon:  t.Resource.OnlineIcon.String(),
-			name:  skill.Name,
-			title: t.Resource.Name.Render(skill.Name),
-		})
+		items = append(items, m.skillStatusItem(t.Resource.OnlineIcon.String(), skill.Name))
 	}
 
+	// Skills active in the session come first, then the others by name.
 	slices.SortStableFunc(items, func(a, b skillStatusItem) int {
+		_, aActive := m.activeSkills[a.name]
+		_, bActive := m.activeSkills[b.name]
+		if aActive != bActive {
+			if aActive {
+				return -1
+			}
+			return 1
+		}
 		return strings.Compare(a.name, b.name)
 	})
 
 	return items
 }
 
+func (m *UI) skillStatusItem(icon, name string) skillStatusItem {
+	item := skillStatusItem{
+		icon:  icon,
+		name:  name,
+		title: m.com.Styles.Resource.Name.Render(name),
+	}
+	if active, ok := m.activeSkills[name]; ok {
+		item.description = active.description()
+	}
+	return item
+}
+
 func skillsList(t *styles.Styles, items []skillStatusItem, width, maxItems int) string {
 	if maxItems <= 0 {
 		return ""
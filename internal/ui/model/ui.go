@@ -117,6 +117,14 @@ type (
 	// userCommandsLoadedMsg is sent when user commands are loaded.
 	userCommandsLoadedMsg struct {
 		Commands []commands.CustomCommand
+		// Stamp identifies the state of the command sources the commands
+		// were loaded from.
+		Stamp uint64
+	}
+	// commandsStampMsg is sent periodically with the current state of the
+	// custom command sources, so changed files can be reloaded.
+	commandsStampMsg struct {
+		stamp uint64
 	}
 	// mcpPromptsLoadedMsg is sent when mcp prompts are loaded.
 	mcpPromptsLoadedMsg struct {
@@ -234,6 +242,8 @@ type UI struct {
 
 	// skills
 	skillStates []*skills.SkillState
+	// activeSkills are the skills loaded in the current session, by name.
+	activeSkills map[string]activeSkill
 
 	// sidebarLogo keeps a cached version of the sidebar sidebarLogo.
 	sidebarLogo string
@@ -243,6 +253,7 @@ type UI struct {
 	notifyWindowFocused bool
 	// custom commands & mcp commands
 	customCommands []commands.CustomCommand
+	commandsStamp  uint64
 	mcpPrompts     []commands.MCPPrompt
 
 	// forceCompactMode tracks whether compact mode is forced by user toggle
@@ -388,8 +399,8 @@ func (m *UI) Init() tea.Cmd {
 			cmds = append(cmds, cmd)
 		}
 	}
-	// load the user commands async
-	cmds = append(cmds, m.loadCustomCommands())
+	// load the user commands async and reload them when their files change
+	cmds = append(cmds, m.loadCustomCommands(), m.pollCustomCommands())
 	// load prompt history async
 	cmds = append(cmds, m.loadPromptHistory())
 	// load initial session if specified
@@ -519,7 +530,8 @@ func (m *UI) setState(state uiState, focus uiFocusState) {
 // loadCustomCommands loads the custom commands asynchronously.
 func (m *UI) loadCustomCommands() tea.Cmd {
 	return func() tea.Msg {
-		customCommands, err := commands.LoadCustomCommands(m.com.Config())
+		stamp := commands.SourcesStamp(m.com.Config(), m.com.Workspace.WorkingDir())
+		customCommands, err := commands.LoadCustomCommands(m.com.Config(), m.com.Workspace.WorkingDir())
 		if err != nil {
 			slog.Error("Failed to load custom commands", "error", err)
 		}
@@ -529,10 +541,22 @@ func (m *UI) loadCustomCommands() tea.Cmd {
 			slog.Error("Failed to load skill commands", "error", err)
 		}
 		customCommands = append(customCommands, commands.FromSkillCatalog(skillEntries)...)
-		return userCommandsLoadedMsg{Commands: customCommands}
+		return userCommandsLoadedMsg{Commands: customCommands, Stamp: stamp}
 	}
 }
 
+// commandsPollInterval is how often the custom command sources are checked
+// for added, removed or modified files.
+const commandsPollInterval = 2 * time.Second
+
+// pollCustomCommands checks the custom command sources for changes after
+// [commandsPollInterval].
+func (m *UI) pollCustomCommands() tea.Cmd {
+	return tea.Tick(commandsPollInterval, func(time.Time) tea.Msg {
+		return commandsStampMsg{stamp: commands.SourcesStamp(m.com.Config(), m.com.Workspace.WorkingDir())}
+	})
+}
+
 // loadMCPrompts loads the MCP prompts asynchronously.
 func (m *UI) loadMCPrompts() tea.Msg {
 	prompts, err := commands.LoadMCPPrompts()
@@ -590,6 +614,10 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, util.ReportError(err))
 			break
 		}
+		m.activeSkills = nil
+		for _, msg := range msgs {
+			m.trackActiveSkills(msg)
+		}
 		if cmd := m.setSessionMessages(msgs); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
@@ -620,8 +648,16 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case sendMessageMsg:
 		cmds = append(cmds, m.sendMessage(msg.Content, msg.Attachments...))
 
+	case commandsStampMsg:
+		if m.commandsStamp != 0 && msg.stamp != m.commandsStamp {
+			m.commandsStamp = msg.stamp
+			cmds = append(cmds, m.loadCustomCommands())
+		}
+		cmds = append(cmds, m.pollCustomCommands())
+
 	case userCommandsLoadedMsg:
 		m.customCommands = msg.Commands
+		m.commandsStamp = msg.Stamp
 		dia := m.dialog.Dialog(dialog.CommandsID)
 		if dia == nil {
 			break
@@ -693,6 +729,9 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case pubsub.DeletedEvent:
 			m.chat.RemoveMessage(msg.Payload.ID)
 		}
+		if msg.Type != pubsub.DeletedEvent {
+			m.trackActiveSkills(msg.Payload)
+		}
 		// start the spinner if there is a new message
 		if hasInProgressTodo(m.session.Todos) && m.isAgentBusy() && !m.todoIsSpinning {
 			m.todoIsSpinning = true
@@ -726,6 +765,27 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case mcp.EventResourcesListChanged:
 			return m, handleMCPResourcesEvent(m.com.Workspace, msg.Payload.Name)
 		}
+	case pubsub.Event[mcp.ElicitationRequest]:
+		if msg.Type == pubsub.DeletedEvent {
+			m.closeElicitationDialog(msg.Payload.ID)
+			break
+		}
+		// A newer request supersedes the one shown, which is cancelled
+		// rather than left waiting.
+		if d, ok := m.dialog.Dialog(dialog.ElicitationID).(*dialog.Elicitation); ok {
+			m.dialog.CloseDialog(dialog.ElicitationID)
+			m.com.Workspace.MCPRespondElicitation(mcp.ElicitationResponse{
+				ID:     d.RequestID(),
+				Action: mcp.ElicitationCancel,
+			})
+		}
+		m.dialog.OpenDialogWithGrace(dialog.NewElicitation(m.com, msg.Payload))
+		if cmd := m.sendNotification(notification.Notification{
+			Title:   "Crush is waiting...",
+			Message: fmt.Sprintf("%s needs your input", msg.Payload.Name),
+		}); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case pubsub.Event[permission.PermissionRequest]:
 		if cmd := m.openPermissionsDialog(msg.Payload); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -1446,6 +1506,13 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			return nil
 		})
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionResetShellState:
+		if err := m.com.Workspace.ShellStateReset(context.Background(), msg.SessionID); err != nil {
+			cmds = append(cmds, util.ReportError(err))
+		} else {
+			cmds = append(cmds, util.ReportInfo("Shell state reset"))
+		}
+		m.dialog.CloseDialog(dialog.ShellStateID)
 	case dialog.ActionToggleHelp:
 		m.status.ToggleHelp()
 		m.dialog.CloseDialog(dialog.CommandsID)
@@ -1571,6 +1638,10 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.Workspace.PermissionDeny(msg.Permission)
 		}
 
+	case dialog.ActionMCPElicitationResponse:
+		m.dialog.CloseDialog(dialog.ElicitationID)
+		m.com.Workspace.MCPRespondElicitation(msg.Response)
+
 	case dialog.ActionFilePickerSelected:
 		cmds = append(cmds, tea.Sequence(
 			msg.Cmd(),
@@ -1599,13 +1670,22 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 		}
 		content := msg.Content
 		if msg.Args != nil {
+			for _, arg := range msg.Arguments {
+				if strings.TrimSpace(msg.Args[arg.ID]) == "" && arg.Default != "" {
+					msg.Args[arg.ID] = arg.Default
+				}
+			}
 			content = substituteArgs(content, msg.Args)
 		}
-		// If this is a skill command, format it using the skill's FormatInvocation method
-		if msg.Skill != nil {
-			content = msg.Skill.FormatInvocation()
+		switch {
+		case msg.Skill != nil:
+			// If this is a skill command, format it using the skill's FormatInvocation method
+			cmds = append(cmds, m.sendMessage(msg.Skill.FormatInvocation()))
+		case msg.Command != nil:
+			cmds = append(cmds, m.sendCommand(*msg.Command, content))
+		default:
+			cmds = append(cmds, m.sendMessage(content))
 		}
-		cmds = append(cmds, m.sendMessage(content))
 		m.dialog.CloseFrontDialog()
 	case dialog.ActionAttachSkill:
 		m.dialog.CloseFrontDialog()
@@ -3101,6 +3181,7 @@ func (m *UI) insertMCPResourceCompletion(item completions.ResourceCompletionValu
 			FileName: displayText,
 			MimeType: mimeType,
 			Content:  data,
+			MCPName:  item.MCPName,
 		}
 	}
 	return tea.Batch(heightCmd, resourceCmd)
@@ -3247,6 +3328,22 @@ func (m *UI) attachSkill(skillID, name string) tea.Cmd {
 
 // sendMessage sends a message with the given content and attachments.
 func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.Cmd {
+	return m.sendPrompt(func(ctx context.Context, sessionID string) error {
+		return m.com.Workspace.AgentRun(ctx, sessionID, content, attachments...)
+	})
+}
+
+// sendCommand sends the prompt of a custom command, with its arguments
+// substituted.
+func (m *UI) sendCommand(cmd commands.CustomCommand, content string) tea.Cmd {
+	return m.sendPrompt(func(ctx context.Context, sessionID string) error {
+		return m.com.Workspace.AgentRunCommand(ctx, sessionID, cmd, content)
+	})
+}
+
+// sendPrompt creates a session if needed and sends a prompt to the agent
+// with run.
+func (m *UI) sendPrompt(run func(ctx context.Context, sessionID string) error) tea.Cmd {
 	if !m.com.Workspace.AgentIsReady() {
 		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
 	}
@@ -3283,7 +3380,7 @@ func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.
 		// been accepted (HTTP 202) or synchronously with a validation
 		// or transport error. Run failures and cancellation surface
 		// through SSE-derived events, not this return value.
-		err := m.com.Workspace.AgentRun(context.Background(), sessionID, content, attachments...)
+		err := run(context.Background(), sessionID)
 		if err != nil {
 			return util.InfoMsg{
 				Type: util.InfoTypeError,
@@ -3369,6 +3466,10 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openQuitDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.ShellStateID:
+		if cmd := m.openShellStateDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	default:
 		// Unknown dialog
 		break
@@ -3462,6 +3563,25 @@ func (m *UI) openNotificationsDialog() tea.Cmd {
 	return nil
 }
 
+// openShellStateDialog opens the dialog showing the shell state saved for
+// the current session.
+func (m *UI) openShellStateDialog() tea.Cmd {
+	if m.session == nil {
+		return nil
+	}
+	if m.dialog.ContainsDialog(dialog.ShellStateID) {
+		m.dialog.BringToFront(dialog.ShellStateID)
+		return nil
+	}
+
+	state, err := m.com.Workspace.ShellStateGet(context.Background(), m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	m.dialog.OpenDialog(dialog.NewShellState(m.com, state))
+	return nil
+}
+
 // openSessionsDialog opens the sessions dialog. If the dialog is already open,
 // it brings it to the front. Otherwise, it will list all the sessions and open
 // the dialog.
@@ -3517,6 +3637,16 @@ func (m *UI) openPermissionsDialog(perm permission.PermissionRequest) tea.Cmd {
 	return nil
 }
 
+// closeElicitationDialog dismisses the elicitation dialog of the request
+// id, which another client answered or its server abandoned.
+func (m *UI) closeElicitationDialog(id string) {
+	if d := m.dialog.Dialog(dialog.ElicitationID); d != nil {
+		if e, ok := d.(*dialog.Elicitation); ok && e.RequestID() == id {
+			m.dialog.CloseDialog(dialog.ElicitationID)
+		}
+	}
+}
+
 // handlePermissionNotification updates tool items when permission state changes.
 func (m *UI) handlePermissionNotification(notification permission.PermissionNotification) {
 	if toolItem := m.chat.MessageItem(notification.ToolCallID); toolItem != nil {
@@ -3590,6 +3720,7 @@ func (m *UI) newSession() tea.Cmd {
 	m.session = nil
 	m.sessionFiles = nil
 	m.sessionFileReads = nil
+	m.activeSkills = nil
 	m.setState(uiLanding, uiFocusEditor)
 	m.textarea.Focus()
 	m.chat.Blur()
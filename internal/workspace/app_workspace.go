@@ -18,6 +18,7 @@ import (
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/charmbracelet/crush/internal/skills"
 )
 
@@ -105,6 +106,20 @@ func (w *AppWorkspace) AgentRun(ctx context.Context, sessionID, prompt string, a
 	return err
 }
 
+func (w *AppWorkspace) AgentRunCommand(ctx context.Context, sessionID string, cmd commands.CustomCommand, prompt string, attachments ...message.Attachment) error {
+	if w.app.AgentCoordinator == nil {
+		return errors.New("agent coordinator not initialized")
+	}
+	ctx = agent.WithCommandRun(ctx, agent.CommandRun{
+		Name:         cmd.ID,
+		Model:        cmd.Model,
+		AllowedTools: cmd.AllowedTools,
+		Agent:        cmd.Agent,
+	})
+	_, err := w.app.AgentCoordinator.Run(ctx, sessionID, prompt, attachments...)
+	return err
+}
+
 func (w *AppWorkspace) AgentCancel(sessionID string) {
 	if w.app.AgentCoordinator != nil {
 		w.app.AgentCoordinator.Cancel(sessionID)
@@ -215,6 +230,16 @@ func (w *AppWorkspace) FileTrackerListReadFiles(ctx context.Context, sessionID s
 	return w.app.FileTracker.ListReadFiles(ctx, sessionID)
 }
 
+// -- ShellState --
+
+func (w *AppWorkspace) ShellStateGet(ctx context.Context, sessionID string) (shellstate.State, error) {
+	return w.app.ShellState.Get(ctx, sessionID)
+}
+
+func (w *AppWorkspace) ShellStateReset(ctx context.Context, sessionID string) error {
+	return w.app.ShellState.Reset(ctx, sessionID)
+}
+
 // -- History --
 
 func (w *AppWorkspace) ListSessionHistory(ctx context.Context, sessionID string) ([]history.File, error) {
@@ -382,6 +407,10 @@ func (w *AppWorkspace) EnableDockerMCP(ctx context.Context) error {
 	return nil
 }
 
+func (w *AppWorkspace) MCPRespondElicitation(resp mcptools.ElicitationResponse) bool {
+	return mcptools.RespondElicitation(resp)
+}
+
 func (w *AppWorkspace) DisableDockerMCP() error {
 	if err := mcptools.DisableSingle(w.store, config.DockerMCPName); err != nil {
 		return fmt.Errorf("failed to disable docker MCP: %w", err)
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/client"
+	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/log"
@@ -23,6 +24,7 @@ import (
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/charmbracelet/crush/internal/skills"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
@@ -186,6 +188,15 @@ func (w *ClientWorkspace) AgentRun(ctx context.Context, sessionID, prompt string
 	return w.client.SendMessage(ctx, w.workspaceID(), sessionID, "", prompt, attachments...)
 }
 
+func (w *ClientWorkspace) AgentRunCommand(ctx context.Context, sessionID string, cmd commands.CustomCommand, prompt string, attachments ...message.Attachment) error {
+	return w.client.SendCommand(ctx, w.workspaceID(), sessionID, proto.CommandRun{
+		Name:         cmd.ID,
+		Model:        cmd.Model,
+		AllowedTools: cmd.AllowedTools,
+		Agent:        cmd.Agent,
+	}, prompt, attachments...)
+}
+
 func (w *ClientWorkspace) AgentCancel(sessionID string) {
 	_ = w.client.CancelAgentSession(context.Background(), w.workspaceID(), sessionID)
 }
@@ -348,6 +359,20 @@ func (w *ClientWorkspace) FileTrackerListReadFiles(ctx context.Context, sessionI
 	return w.client.FileTrackerListReadFiles(ctx, w.workspaceID(), sessionID)
 }
 
+// -- ShellState --
+
+func (w *ClientWorkspace) ShellStateGet(ctx context.Context, sessionID string) (shellstate.State, error) {
+	state, err := w.client.GetShellState(ctx, w.workspaceID(), sessionID)
+	if err != nil {
+		return shellstate.State{}, err
+	}
+	return protoToShellState(*state), nil
+}
+
+func (w *ClientWorkspace) ShellStateReset(ctx context.Context, sessionID string) error {
+	return w.client.ResetShellState(ctx, w.workspaceID(), sessionID)
+}
+
 // -- History --
 
 func (w *ClientWorkspace) ListSessionHistory(ctx context.Context, sessionID string) ([]history.File, error) {
@@ -549,6 +574,8 @@ func (w *ClientWorkspace) MCPGetStates() map[string]mcp.ClientInfo {
 				Resources: v.ResourceCount,
 			},
 			ConnectedAt: v.ConnectedAt,
+			Restarts:    v.Restarts,
+			Stderr:      v.Stderr,
 		}
 	}
 	return result
@@ -591,6 +618,15 @@ func (w *ClientWorkspace) EnableDockerMCP(ctx context.Context) error {
 	return w.client.EnableDockerMCP(ctx, w.workspaceID())
 }
 
+func (w *ClientWorkspace) MCPRespondElicitation(resp mcp.ElicitationResponse) bool {
+	resolved, _ := w.client.RespondMCPElicitation(context.Background(), w.workspaceID(), proto.MCPElicitationResponse{
+		ID:      resp.ID,
+		Action:  resp.Action,
+		Content: resp.Content,
+	})
+	return resolved
+}
+
 func (w *ClientWorkspace) DisableDockerMCP() error {
 	return w.client.DisableDockerMCP(context.Background(), w.workspaceID())
 }
@@ -665,6 +701,11 @@ func (w *ClientWorkspace) translateEvent(ev any) tea.Msg {
 				},
 			},
 		}
+	case pubsub.Event[proto.MCPElicitationRequest]:
+		return pubsub.Event[mcp.ElicitationRequest]{
+			Type:    e.Type,
+			Payload: protoToMCPElicitation(e.Payload),
+		}
 	case pubsub.Event[proto.PermissionRequest]:
 		return pubsub.Event[permission.PermissionRequest]{
 			Type: e.Type,
@@ -765,6 +806,27 @@ func protoToMCPEventType(t proto.MCPEventType) mcp.EventType {
 	}
 }
 
+func protoToMCPElicitation(r proto.MCPElicitationRequest) mcp.ElicitationRequest {
+	out := mcp.ElicitationRequest{
+		ID:        r.ID,
+		Name:      r.Name,
+		SessionID: r.SessionID,
+		Message:   r.Message,
+	}
+	for _, f := range r.Fields {
+		out.Fields = append(out.Fields, mcp.ElicitationField{
+			Name:        f.Name,
+			Title:       f.Title,
+			Description: f.Description,
+			Type:        f.Type,
+			Enum:        f.Enum,
+			Required:    f.Required,
+			Default:     f.Default,
+		})
+	}
+	return out
+}
+
 // protoToSession converts a wire-level proto.Session into the domain
 // session.Session. Fields that exist only on the wire (computed-on-read
 // signals like IsBusy, and any future presence counters) are
@@ -815,6 +877,18 @@ func protoToFile(f proto.File) history.File {
 	}
 }
 
+func protoToShellState(s proto.ShellState) shellstate.State {
+	state := shellstate.State{
+		SessionID:  s.SessionID,
+		WorkingDir: s.WorkingDir,
+		Env:        s.Env,
+	}
+	if s.UpdatedAt != 0 {
+		state.UpdatedAt = time.Unix(s.UpdatedAt, 0)
+	}
+	return state
+}
+
 func protoToMessage(m proto.Message) message.Message {
 	msg := message.Message{
 		ID:        m.ID,
@@ -11,6 +11,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
 	mcptools "github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -18,6 +19,7 @@ import (
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shellstate"
 	"github.com/charmbracelet/crush/internal/skills"
 )
 
@@ -82,6 +84,10 @@ type Workspace interface {
 
 	// Agent
 	AgentRun(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) error
+	// AgentRunCommand runs the prompt of a custom command, with its
+	// arguments substituted. Its !`command` and @path references are
+	// expanded by the agent, which applies the command's overrides.
+	AgentRunCommand(ctx context.Context, sessionID string, cmd commands.CustomCommand, prompt string, attachments ...message.Attachment) error
 	AgentCancel(sessionID string)
 	AgentIsBusy() bool
 	AgentIsSessionBusy(sessionID string) bool
@@ -115,6 +121,10 @@ type Workspace interface {
 	FileTrackerLastReadTime(ctx context.Context, sessionID, path string) time.Time
 	FileTrackerListReadFiles(ctx context.Context, sessionID string) ([]string, error)
 
+	// ShellState
+	ShellStateGet(ctx context.Context, sessionID string) (shellstate.State, error)
+	ShellStateReset(ctx context.Context, sessionID string) error
+
 	// History
 	ListSessionHistory(ctx context.Context, sessionID string) ([]history.File, error)
 
@@ -152,6 +162,10 @@ type Workspace interface {
 	RefreshMCPTools(ctx context.Context, name string)
 	ReadMCPResource(ctx context.Context, name, uri string) ([]MCPResourceContents, error)
 	GetMCPPrompt(clientID, promptID string, args map[string]string) (string, error)
+	// MCPRespondElicitation answers the elicitation request of an MCP
+	// server. Like PermissionGrant, it returns false when another client
+	// already answered it or the server gave up.
+	MCPRespondElicitation(resp mcptools.ElicitationResponse) bool
 	EnableDockerMCP(ctx context.Context) error
 	DisableDockerMCP() error
 